@@ -0,0 +1,134 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+
+	"github.com/alinoer/go-std-api/internal/config"
+	"github.com/alinoer/go-std-api/internal/database"
+	"github.com/alinoer/go-std-api/migrations"
+)
+
+const usage = `Usage: migrate [-dry-run] <command>
+
+Commands:
+  up          Apply every pending migration
+  down [N]    Revert the last N migrations (default 1)
+  goto V      Apply or revert migrations until the database is at version V
+  status      Show the current version and which migrations are applied
+
+Flags:
+`
+
+var commands = map[string]bool{"up": true, "down": true, "goto": true, "status": true}
+
+func main() {
+	dryRun := flag.Bool("dry-run", false, "print the SQL that would run instead of running it")
+	flag.Usage = func() {
+		fmt.Fprint(flag.CommandLine.Output(), usage)
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	args := flag.Args()
+	if len(args) == 0 || !commands[args[0]] {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	// Load configuration
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatal("Failed to load config:", err)
+	}
+
+	// Connect to database
+	db, err := database.NewConnection(cfg.DatabaseURL)
+	if err != nil {
+		log.Fatal("Failed to connect to database:", err)
+	}
+	defer db.Close()
+
+	migrator, err := database.NewMigrator(db, migrations.FS, database.MigratorConfig{
+		DryRun: *dryRun,
+		Output: os.Stdout,
+	})
+	if err != nil {
+		log.Fatal("Failed to load migrations:", err)
+	}
+
+	// Stop waiting for the migration lock on Ctrl-C
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if err := run(ctx, migrator, args, *dryRun); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func run(ctx context.Context, migrator *database.Migrator, args []string, dryRun bool) error {
+	var version uint
+	var err error
+
+	switch command := args[0]; {
+	case command == "up" && len(args) == 1:
+		version, err = migrator.Up(ctx)
+	case command == "down" && len(args) <= 2:
+		steps := 1
+		if len(args) == 2 {
+			if steps, err = strconv.Atoi(args[1]); err != nil || steps < 1 {
+				return fmt.Errorf("invalid number of migrations %q", args[1])
+			}
+		}
+		version, err = migrator.Down(ctx, steps)
+	case command == "goto" && len(args) == 2:
+		target, parseErr := strconv.ParseUint(args[1], 10, 64)
+		if parseErr != nil {
+			return fmt.Errorf("invalid version %q", args[1])
+		}
+		version, err = migrator.Goto(ctx, uint(target))
+	case command == "status" && len(args) == 1:
+		return printStatus(ctx, migrator)
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+	if err != nil {
+		return err
+	}
+
+	if dryRun {
+		fmt.Printf("-- The database would be at version %d\n", version)
+	} else {
+		fmt.Printf("Database is at version %d\n", version)
+	}
+	return nil
+}
+
+func printStatus(ctx context.Context, migrator *database.Migrator) error {
+	status, err := migrator.Status(ctx)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Version: %d", status.Version)
+	if status.Dirty {
+		fmt.Print(" (dirty)")
+	}
+	fmt.Println()
+
+	for _, migration := range status.Migrations {
+		state := "pending"
+		if migration.Applied {
+			state = "applied"
+		}
+		fmt.Printf("  %06d %-8s %s\n", migration.Version, state, migration.Name)
+	}
+	return nil
+}
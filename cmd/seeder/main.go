@@ -28,9 +28,19 @@ func main() {
 	log.Println("Connected to database successfully")
 
 	// Initialize repositories and services
+	passwordHasher, err := service.NewPasswordHasher(service.PasswordHashConfig{
+		Algorithm:         cfg.PasswordHashAlgorithm,
+		Argon2Memory:      uint32(cfg.Argon2Memory),
+		Argon2Iterations:  uint32(cfg.Argon2Iterations),
+		Argon2Parallelism: uint8(cfg.Argon2Parallelism),
+		BcryptCost:        cfg.BcryptCost,
+	})
+	if err != nil {
+		log.Fatal("Failed to configure password hasher:", err)
+	}
 	userRepo := repository.NewUserRepository(db)
 	postRepo := repository.NewPostRepository(db)
-	userService := service.NewUserService(userRepo)
+	userService := service.NewUserService(userRepo, passwordHasher)
 	postService := service.NewPostService(postRepo, userRepo)
 
 	ctx := context.Background()
@@ -41,9 +51,9 @@ func main() {
 	sampleUsers := []models.CreateUserRequest{
 		{Username: "john_doe", Password: "password123"},
 		{Username: "alice_smith", Password: "alice2023"},
-		{Username: "bob_wilson", Password: "bob456"},
+		{Username: "bob_wilson", Password: "bob45678"},
 		{Username: "sarah_jones", Password: "sarah789"},
-		{Username: "mike_brown", Password: "mike321"},
+		{Username: "mike_brown", Password: "mike4321"},
 	}
 
 	var users []*models.User
@@ -65,6 +75,16 @@ func main() {
 		users = append(users, user)
 	}
 
+	// Promote the first sample user so the admin routes can be tried out
+	if len(users) > 0 {
+		admin, err := userService.UpdateUserRole(ctx, users[0].ID, models.RoleAdmin)
+		if err != nil {
+			log.Printf("Failed to promote %s to admin: %v", users[0].Username, err)
+		} else {
+			log.Printf("Promoted %s to admin", admin.Username)
+		}
+	}
+
 	// Create sample posts
 	log.Println("Creating sample posts...")
 	
@@ -2,19 +2,23 @@ package main
 
 import (
 	"context"
-	"log"
 	"net/http"
 	"os"
 	"os/signal"
+	"sync"
 	"syscall"
 	"time"
 
 	"github.com/alinoer/go-std-api/internal/config"
 	"github.com/alinoer/go-std-api/internal/database"
 	"github.com/alinoer/go-std-api/internal/handlers"
+	"github.com/alinoer/go-std-api/internal/logger"
 	"github.com/alinoer/go-std-api/internal/middleware"
+	"github.com/alinoer/go-std-api/internal/models"
 	"github.com/alinoer/go-std-api/internal/repository"
+	"github.com/alinoer/go-std-api/internal/response"
 	"github.com/alinoer/go-std-api/internal/service"
+	"github.com/alinoer/go-std-api/migrations"
 
 	"github.com/go-chi/chi/v5"
 	chimw "github.com/go-chi/chi/v5/middleware"
@@ -24,71 +28,234 @@ func main() {
 	// Load configuration
 	cfg, err := config.Load()
 	if err != nil {
-		log.Fatal("Failed to load config:", err)
+		logger.Fatal("Failed to load config", err)
 	}
 
+	// Configure structured logging
+	if err := logger.InitializeWithConfig(logger.Config{
+		ServiceName: "go-std-api",
+		Version:     "1.0.0",
+		Level:       cfg.LogLevel,
+		Format:      cfg.LogFormat,
+	}); err != nil {
+		logger.Fatal("Failed to configure logger", err)
+	}
+	appLogger := logger.GetLogger()
+
 	// Connect to database
 	db, err := database.NewConnection(cfg.DatabaseURL)
 	if err != nil {
-		log.Fatal("Failed to connect to database:", err)
+		appLogger.Fatal("Failed to connect to database", err)
 	}
 	defer db.Close()
 
-	log.Println("Connected to database successfully")
+	appLogger.Info("Connected to database successfully")
+
+	if cfg.AutoMigrate {
+		migrator, err := database.NewMigrator(db, migrations.FS, database.MigratorConfig{})
+		if err != nil {
+			appLogger.Fatal("Failed to load migrations", err)
+		}
+		version, err := migrator.Up(context.Background())
+		if err != nil {
+			appLogger.Fatal("Failed to migrate database", err)
+		}
+		appLogger.Info("Database schema is up to date", "version", version)
+	}
 
 	// Initialize repositories
 	userRepo := repository.NewUserRepository(db)
 	postRepo := repository.NewPostRepository(db)
+	commentRepo := repository.NewCommentRepository(db)
+	refreshTokenRepo := repository.NewRefreshTokenRepository(db)
+	revocationStore := repository.NewTokenRevocationRepository(db)
+	if cfg.TokenRevocationStore == "memory" {
+		revocationStore = repository.NewMemoryTokenRevocationStore()
+	}
 
 	// Initialize services
-	userService := service.NewUserService(userRepo)
+	passwordHasher, err := service.NewPasswordHasher(service.PasswordHashConfig{
+		Algorithm:         cfg.PasswordHashAlgorithm,
+		Argon2Memory:      uint32(cfg.Argon2Memory),
+		Argon2Iterations:  uint32(cfg.Argon2Iterations),
+		Argon2Parallelism: uint8(cfg.Argon2Parallelism),
+		BcryptCost:        cfg.BcryptCost,
+	})
+	if err != nil {
+		appLogger.Fatal("Failed to configure password hasher", err)
+	}
+	tokenConfig := service.DefaultTokenConfig(cfg.APISecretKey)
+	tokenConfig.ExpiresIn = cfg.AccessTokenTTL
+	tokenConfig.RefreshExpiresIn = cfg.RefreshTokenTTL
+	signingKeys := service.NewHMACKeySet(cfg.APISecretKey)
+	if cfg.JWTSigningKeyFile != "" {
+		signingKeys, err = service.LoadKeySetFromFiles(cfg.JWTSigningKeyFile, cfg.JWTVerificationKeyFiles)
+		if err != nil {
+			appLogger.Fatal("Failed to load JWT signing keys", err)
+		}
+	}
+	authService := service.NewAuthServiceWithConfig(tokenConfig, signingKeys, userRepo, refreshTokenRepo, revocationStore)
+	userServiceConfig := service.DefaultUserServiceConfig()
+	if cfg.UserDeletionPostPolicy != "" {
+		userServiceConfig.PostPolicy = models.UserPostPolicy(cfg.UserDeletionPostPolicy)
+	}
+	var breachedPasswords *service.BreachedPasswords
+	if cfg.BreachedPasswordsFile != "" {
+		breachedPasswords, err = service.LoadBreachedPasswordsFile(cfg.BreachedPasswordsFile)
+		if err != nil {
+			appLogger.Fatal("Failed to load breached passwords", err)
+		}
+		appLogger.Info("Loaded breached passwords", "file", cfg.BreachedPasswordsFile, "hashes", breachedPasswords.Len())
+	}
+	userServiceConfig.PasswordPolicy = service.NewPasswordPolicy(service.PasswordPolicyConfig{
+		MinLength:           cfg.PasswordMinLength,
+		MaxLength:           cfg.PasswordMaxLength,
+		MinCharacterClasses: cfg.PasswordMinCharacterClasses,
+		DisallowUsername:    cfg.PasswordDisallowUsername,
+	}, breachedPasswords)
+	userServiceConfig.Tokens = authService
+	userService := service.NewUserServiceWithConfig(userRepo, passwordHasher, userServiceConfig)
 	postService := service.NewPostService(postRepo, userRepo)
-	authService := service.NewAuthService(cfg.APISecretKey)
+	commentService := service.NewCommentService(commentRepo, postRepo)
+
+	loginThrottle := service.NewLoginThrottle(repository.NewLoginRepository(db), service.LoginThrottleConfig{
+		MaxFailures:      cfg.LoginMaxFailures,
+		MaxFailuresPerIP: cfg.LoginMaxFailuresPerIP,
+		Lockout:          cfg.LoginLockout,
+		MaxLockout:       cfg.LoginMaxLockout,
+		FailureWindow:    cfg.LoginFailureWindow,
+	})
+
+	postScheduler := service.NewPostScheduler(postRepo, cfg.PostSchedulerInterval)
+	trashPurger := service.NewTrashPurger(postRepo, userRepo, time.Duration(cfg.TrashRetentionDays)*24*time.Hour, userServiceConfig.PostPolicy)
 
 	// Initialize handlers
 	userHandler := handlers.NewUserHandler(userService)
-	postHandler := handlers.NewPostHandler(postService, userService)
-	authHandler := handlers.NewAuthHandler(userService, authService)
+	userHandlerV2 := handlers.NewUserHandlerV2(userService)
+	postHandler := handlers.NewPostHandler(postService)
+	commentHandler := handlers.NewCommentHandler(commentService)
+	authHandler := handlers.NewAuthHandlerWithLoginThrottle(userService, authService, loginThrottle)
 
 	// Setup router
 	r := chi.NewRouter()
 
 	// Middleware
 	r.Use(chimw.RequestID)
+	r.Use(middleware.RequestID)
 	r.Use(chimw.RealIP)
-	r.Use(middleware.LoggingMiddleware)
-	r.Use(chimw.Recoverer)
+	r.Use(middleware.RequestLogger(appLogger))
+	r.Use(middleware.ErrorHandler(&middleware.ErrorHandlerConfig{
+		EnableStackTrace:     cfg.DevMode,
+		EnableDetailedErrors: cfg.DevMode,
+		DefaultMessage:       "An error occurred while processing your request",
+		Logger:               appLogger,
+	}))
 	r.Use(chimw.Timeout(60 * time.Second))
 
 	// Health check endpoint
 	r.Get("/health", func(w http.ResponseWriter, r *http.Request) {
-		handlers.WriteMessage(w, "Server is healthy")
+		response.NewResponseWriter(w, r).JSONWithMessage(http.StatusOK, nil, "Server is healthy")
 	})
 
+	// Public keys for verifying access tokens
+	r.Get("/.well-known/jwks.json", authHandler.JWKS)
+
+	// Requests to create things can be retried safely with an Idempotency-Key
+	idempotent := func(next http.Handler) http.Handler { return next }
+	if cfg.IdempotencyKeyTTL > 0 {
+		idempotencyConfig := middleware.DefaultIdempotencyConfig()
+		idempotencyConfig.TTL = cfg.IdempotencyKeyTTL
+		idempotent = middleware.Idempotency(repository.NewIdempotencyRepository(db), idempotencyConfig)
+	}
+
+	// Rate limits per route group, keyed by user for signed in requests and
+	// by IP address otherwise
+	rateLimitStore := repository.NewRateLimitRepository(db)
+	if cfg.RateLimitStore == "memory" {
+		rateLimitStore = repository.NewMemoryRateLimitStore()
+	}
+	rateLimited := func(name string, limit config.RateLimit) func(http.Handler) http.Handler {
+		if !limit.Enabled() {
+			return func(next http.Handler) http.Handler { return next }
+		}
+		return middleware.RateLimit(rateLimitStore, middleware.RateLimitConfig{
+			Name:  name,
+			Limit: models.RateLimit{Requests: limit.Requests, Period: limit.Period},
+		})
+	}
+	authLimit := rateLimited("auth", cfg.RateLimitAuth)
+	readLimit := rateLimited("read", cfg.RateLimitRead)
+	writeLimit := rateLimited("write", cfg.RateLimitWrite)
+
 	// API routes
 	r.Route("/api/v1", func(r chi.Router) {
 		// Authentication routes
-		r.Post("/auth/register", authHandler.Register)
-		r.Post("/auth/login", authHandler.Login)
+		r.With(authLimit, idempotent).Post("/auth/register", authHandler.Register)
+		r.With(authLimit).Post("/auth/login", authHandler.Login)
+		r.With(authLimit).Post("/auth/refresh", authHandler.Refresh)
+		r.With(authLimit).Post("/auth/logout", authHandler.Logout)
 
 		// Public routes
-		r.Post("/users", userHandler.CreateUser) // Duplicate of register for backwards compatibility
-		r.Get("/users", userHandler.ListUsers)
-		r.Get("/users/{id}", userHandler.GetUser)
-		r.Get("/users/{userId}/posts", postHandler.GetPostsByUser)
+		r.With(authLimit, idempotent).Post("/users", userHandler.CreateUser) // Duplicate of register for backwards compatibility
+		r.With(readLimit).Get("/users", userHandler.ListUsers)
+		r.With(readLimit).Get("/users/{id}", userHandler.GetUser)
+		r.With(readLimit).Get("/tags", postHandler.ListTags)
 
-		// Public posts routes (read-only)
-		r.Get("/posts", postHandler.ListPosts)
-		r.Get("/posts/{id}", postHandler.GetPost)
+		// Public post routes (read-only). Signed in users see their own
+		// reactions flagged and their own drafts.
+		r.Group(func(r chi.Router) {
+			r.Use(middleware.OptionalJWTAuthMiddleware(authService))
+			r.Use(readLimit)
+
+			r.Get("/posts/{id}/comments", commentHandler.ListComments)
+			r.Get("/users/{userId}/posts", postHandler.GetPostsByUser)
+			r.Get("/posts", postHandler.ListPosts)
+			r.Get("/posts/search", postHandler.SearchPosts)
+			r.Get("/posts/{id}", postHandler.GetPost)
+		})
 
 		// Protected routes (require JWT authentication)
 		r.Group(func(r chi.Router) {
 			r.Use(middleware.JWTAuthMiddleware(authService))
+			r.Use(writeLimit)
+
+			r.Post("/auth/logout-all", authHandler.LogoutAll)
+
+			// Self-service account routes
+			r.Put("/users/{id}", userHandlerV2.UpdateUser)
+			r.Delete("/users/{id}", userHandlerV2.DeleteUser)
 
 			// Protected post routes
-			r.Post("/posts", postHandler.CreatePost)
+			r.With(idempotent).Post("/posts", postHandler.CreatePost)
+			r.Get("/posts/trash", postHandler.ListDeletedPosts)
+			r.Post("/posts/{id}/restore", postHandler.RestorePost)
 			r.Put("/posts/{id}", postHandler.UpdatePost)
+			r.Patch("/posts/{id}", postHandler.PatchPost)
 			r.Delete("/posts/{id}", postHandler.DeletePost)
+			r.Put("/posts/{id}/reactions/{kind}", postHandler.AddReaction)
+			r.Delete("/posts/{id}/reactions/{kind}", postHandler.RemoveReaction)
+			r.Get("/posts/{id}/revisions", postHandler.ListRevisions)
+			r.Get("/posts/{id}/revisions/diff", postHandler.DiffRevisions)
+			r.Post("/posts/{id}/revisions/{rev}/restore", postHandler.RestoreRevision)
+
+			// Protected comment routes
+			r.With(idempotent).Post("/posts/{id}/comments", commentHandler.CreateComment)
+			r.Put("/comments/{id}", commentHandler.UpdateComment)
+			r.Delete("/comments/{id}", commentHandler.DeleteComment)
+		})
+
+		// Admin routes (require JWT authentication and a role with the permission)
+		r.Route("/admin", func(r chi.Router) {
+			r.Use(middleware.JWTAuthMiddleware(authService))
+			r.Use(writeLimit)
+
+			r.With(middleware.RequirePermission(models.PermissionReadPrivateUserFields)).Get("/users", userHandler.ListPrivateUsers)
+			r.With(middleware.RequirePermission(models.PermissionManageUsers)).Put("/users/{id}/role", authHandler.UpdateUserRole)
+			r.With(middleware.RequirePermission(models.PermissionManageUsers)).Post("/users/{id}/revoke-tokens", authHandler.RevokeUserTokens)
+			r.With(middleware.RequirePermission(models.PermissionManageUsers)).Post("/users/{id}/unlock", authHandler.UnlockUser)
+			r.With(middleware.RequirePermission(models.PermissionManageUsers)).Post("/users/{id}/restore", userHandler.RestoreUser)
+			r.With(middleware.RequirePermission(models.PermissionDeleteAnyPost)).Delete("/posts/{id}", postHandler.DeleteAnyPost)
+			r.With(middleware.RequirePermission(models.PermissionDeleteAnyPost)).Post("/posts/{id}/restore", postHandler.RestoreAnyPost)
 		})
 	})
 
@@ -103,25 +270,47 @@ func main() {
 
 	// Start server in a goroutine
 	go func() {
-		log.Printf("Server starting on port %s", cfg.ServerPort)
+		appLogger.Info("Server starting", "port", cfg.ServerPort, "dev_mode", cfg.DevMode)
 		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			log.Fatal("Failed to start server:", err)
+			appLogger.Fatal("Failed to start server", err)
 		}
 	}()
 
+	// Publish scheduled posts and purge the trash in the background until
+	// shutdown
+	backgroundCtx, stopBackground := context.WithCancel(context.Background())
+	var background sync.WaitGroup
+	background.Add(1)
+	go func() {
+		defer background.Done()
+		postScheduler.Run(backgroundCtx)
+	}()
+	if cfg.TrashRetentionDays > 0 {
+		background.Add(1)
+		go func() {
+			defer background.Done()
+			trashPurger.Run(backgroundCtx)
+		}()
+	}
+
 	// Wait for interrupt signal to gracefully shutdown the server
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
-	log.Println("Shutting down server...")
+	appLogger.Info("Shutting down server...")
 
 	// Graceful shutdown with 30 second timeout
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
 	if err := server.Shutdown(ctx); err != nil {
-		log.Fatal("Server forced to shutdown:", err)
+		appLogger.Fatal("Server forced to shutdown", err)
 	}
 
-	log.Println("Server exited")
+	// Stop the background jobs after the server so that no request races a
+	// half-finished run, and wait for them before the database is closed
+	stopBackground()
+	background.Wait()
+
+	appLogger.Info("Server exited")
 }
@@ -5,6 +5,7 @@ import (
 	"os"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestLoad(t *testing.T) {
@@ -56,6 +57,111 @@ func TestLoad(t *testing.T) {
 				return nil
 			},
 		},
+		{
+			name: "logging settings from environment",
+			envVars: map[string]string{
+				"LOG_LEVEL":  "debug",
+				"LOG_FORMAT": "text",
+				"DEV_MODE":   "true",
+			},
+			expectedError: false,
+			validateFunc: func(c *Config) error {
+				if c.LogLevel != "debug" {
+					return fmt.Errorf("expected LOG_LEVEL debug, got %s", c.LogLevel)
+				}
+				if c.LogFormat != "text" {
+					return fmt.Errorf("expected LOG_FORMAT text, got %s", c.LogFormat)
+				}
+				if !c.DevMode {
+					return fmt.Errorf("expected DEV_MODE to be enabled")
+				}
+				return nil
+			},
+		},
+		{
+			name: "auto migrate from environment",
+			envVars: map[string]string{
+				"AUTO_MIGRATE": "true",
+			},
+			expectedError: false,
+			validateFunc: func(c *Config) error {
+				if !c.AutoMigrate {
+					return fmt.Errorf("expected AUTO_MIGRATE to be enabled")
+				}
+				return nil
+			},
+		},
+		{
+			name: "login lockout from environment",
+			envVars: map[string]string{
+				"LOGIN_MAX_FAILURES":        "3",
+				"LOGIN_MAX_FAILURES_PER_IP": "0",
+				"LOGIN_LOCKOUT":             "30s",
+				"LOGIN_MAX_LOCKOUT":         "10m",
+				"LOGIN_FAILURE_WINDOW":      "2h",
+			},
+			expectedError: false,
+			validateFunc: func(c *Config) error {
+				if c.LoginMaxFailures != 3 || c.LoginMaxFailuresPerIP != 0 {
+					return fmt.Errorf("expected 3 failures per username and none per IP, got %d and %d", c.LoginMaxFailures, c.LoginMaxFailuresPerIP)
+				}
+				if c.LoginLockout != 30*time.Second || c.LoginMaxLockout != 10*time.Minute {
+					return fmt.Errorf("expected lockouts from 30s to 10m, got %v and %v", c.LoginLockout, c.LoginMaxLockout)
+				}
+				if c.LoginFailureWindow != 2*time.Hour {
+					return fmt.Errorf("expected failures to count for 2h, got %v", c.LoginFailureWindow)
+				}
+				return nil
+			},
+		},
+		{
+			name: "password policy from environment",
+			envVars: map[string]string{
+				"PASSWORD_MIN_LENGTH":            "12",
+				"PASSWORD_MAX_LENGTH":            "64",
+				"PASSWORD_MIN_CHARACTER_CLASSES": "3",
+				"PASSWORD_DISALLOW_USERNAME":     "false",
+				"BREACHED_PASSWORDS_FILE":        "/data/pwned-passwords.txt",
+			},
+			expectedError: false,
+			validateFunc: func(c *Config) error {
+				if c.PasswordMinLength != 12 || c.PasswordMaxLength != 64 || c.PasswordMinCharacterClasses != 3 {
+					return fmt.Errorf("expected 12 to 64 characters of 3 classes, got %d to %d of %d", c.PasswordMinLength, c.PasswordMaxLength, c.PasswordMinCharacterClasses)
+				}
+				if c.PasswordDisallowUsername {
+					return fmt.Errorf("expected passwords based on the username to be allowed")
+				}
+				if c.BreachedPasswordsFile != "/data/pwned-passwords.txt" {
+					return fmt.Errorf("expected BREACHED_PASSWORDS_FILE /data/pwned-passwords.txt, got %s", c.BreachedPasswordsFile)
+				}
+				return nil
+			},
+		},
+		{
+			name: "rate limits from environment",
+			envVars: map[string]string{
+				"RATE_LIMIT_STORE": "memory",
+				"RATE_LIMIT_AUTH":  "5/30s",
+				"RATE_LIMIT_READ":  "0",
+				"RATE_LIMIT_WRITE": "not-a-limit",
+			},
+			expectedError: false,
+			validateFunc: func(c *Config) error {
+				if c.RateLimitStore != "memory" {
+					return fmt.Errorf("expected RATE_LIMIT_STORE memory, got %s", c.RateLimitStore)
+				}
+				if c.RateLimitAuth != (RateLimit{Requests: 5, Period: 30 * time.Second}) {
+					return fmt.Errorf("expected RATE_LIMIT_AUTH 5/30s, got %+v", c.RateLimitAuth)
+				}
+				if c.RateLimitRead.Enabled() {
+					return fmt.Errorf("expected RATE_LIMIT_READ to be off, got %+v", c.RateLimitRead)
+				}
+				if c.RateLimitWrite != (RateLimit{Requests: 60, Period: time.Minute}) {
+					return fmt.Errorf("expected the default RATE_LIMIT_WRITE, got %+v", c.RateLimitWrite)
+				}
+				return nil
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -124,9 +230,12 @@ func TestConfig_validate(t *testing.T) {
 		{
 			name: "valid config",
 			config: &Config{
-				DatabaseURL:  "postgres://localhost:5432/test",
-				APISecretKey: "secret",
-				ServerPort:   "8080",
+				DatabaseURL:       "postgres://localhost:5432/test",
+				APISecretKey:      "secret",
+				ServerPort:        "8080",
+				Argon2Memory:      64 * 1024,
+				Argon2Iterations:  3,
+				Argon2Parallelism: 2,
 			},
 			expectedError: false,
 		},
@@ -160,6 +269,226 @@ func TestConfig_validate(t *testing.T) {
 			expectedError: true,
 			errorContains: "SERVER_PORT is required",
 		},
+		{
+			name: "verification keys without signing key",
+			config: &Config{
+				DatabaseURL:             "postgres://localhost:5432/test",
+				APISecretKey:            "secret",
+				ServerPort:              "8080",
+				JWTVerificationKeyFiles: []string{"old.pem"},
+			},
+			expectedError: true,
+			errorContains: "JWT_VERIFICATION_KEY_FILES requires JWT_SIGNING_KEY_FILE",
+		},
+		{
+			name: "unknown token revocation store",
+			config: &Config{
+				DatabaseURL:          "postgres://localhost:5432/test",
+				APISecretKey:         "secret",
+				ServerPort:           "8080",
+				TokenRevocationStore: "redis",
+			},
+			expectedError: true,
+			errorContains: "TOKEN_REVOCATION_STORE must be",
+		},
+		{
+			name: "unknown user deletion post policy",
+			config: &Config{
+				DatabaseURL:            "postgres://localhost:5432/test",
+				APISecretKey:           "secret",
+				ServerPort:             "8080",
+				UserDeletionPostPolicy: "archive",
+			},
+			expectedError: true,
+			errorContains: "USER_DELETION_POST_POLICY must be",
+		},
+		{
+			name: "negative post scheduler interval",
+			config: &Config{
+				DatabaseURL:           "postgres://localhost:5432/test",
+				APISecretKey:          "secret",
+				ServerPort:            "8080",
+				PostSchedulerInterval: -time.Minute,
+			},
+			expectedError: true,
+			errorContains: "POST_SCHEDULER_INTERVAL must not be negative",
+		},
+		{
+			name: "negative trash retention",
+			config: &Config{
+				DatabaseURL:        "postgres://localhost:5432/test",
+				APISecretKey:       "secret",
+				ServerPort:         "8080",
+				TrashRetentionDays: -1,
+			},
+			expectedError: true,
+			errorContains: "TRASH_RETENTION_DAYS must not be negative",
+		},
+		{
+			name: "negative idempotency key ttl",
+			config: &Config{
+				DatabaseURL:       "postgres://localhost:5432/test",
+				APISecretKey:      "secret",
+				ServerPort:        "8080",
+				IdempotencyKeyTTL: -time.Hour,
+			},
+			expectedError: true,
+			errorContains: "IDEMPOTENCY_KEY_TTL must not be negative",
+		},
+		{
+			name: "password max length below min length",
+			config: &Config{
+				DatabaseURL:       "postgres://localhost:5432/test",
+				APISecretKey:      "secret",
+				ServerPort:        "8080",
+				PasswordMinLength: 12,
+				PasswordMaxLength: 8,
+			},
+			expectedError: true,
+			errorContains: "PASSWORD_MAX_LENGTH must not be less than PASSWORD_MIN_LENGTH",
+		},
+		{
+			name: "too many password character classes",
+			config: &Config{
+				DatabaseURL:                 "postgres://localhost:5432/test",
+				APISecretKey:                "secret",
+				ServerPort:                  "8080",
+				PasswordMinCharacterClasses: 5,
+			},
+			expectedError: true,
+			errorContains: "PASSWORD_MIN_CHARACTER_CLASSES must be between 0 and 4",
+		},
+		{
+			name: "negative login max failures",
+			config: &Config{
+				DatabaseURL:      "postgres://localhost:5432/test",
+				APISecretKey:     "secret",
+				ServerPort:       "8080",
+				LoginMaxFailures: -1,
+			},
+			expectedError: true,
+			errorContains: "LOGIN_MAX_FAILURES must not be negative",
+		},
+		{
+			name: "login lockout without a duration",
+			config: &Config{
+				DatabaseURL:      "postgres://localhost:5432/test",
+				APISecretKey:     "secret",
+				ServerPort:       "8080",
+				LoginMaxFailures: 5,
+			},
+			expectedError: true,
+			errorContains: "LOGIN_LOCKOUT must be positive",
+		},
+		{
+			name: "login max lockout below lockout",
+			config: &Config{
+				DatabaseURL:      "postgres://localhost:5432/test",
+				APISecretKey:     "secret",
+				ServerPort:       "8080",
+				LoginMaxFailures: 5,
+				LoginLockout:     time.Hour,
+				LoginMaxLockout:  time.Minute,
+			},
+			expectedError: true,
+			errorContains: "LOGIN_MAX_LOCKOUT must not be less than LOGIN_LOCKOUT",
+		},
+		{
+			name: "login lockout without a failure window",
+			config: &Config{
+				DatabaseURL:      "postgres://localhost:5432/test",
+				APISecretKey:     "secret",
+				ServerPort:       "8080",
+				LoginMaxFailures: 5,
+				LoginLockout:     time.Minute,
+				LoginMaxLockout:  time.Hour,
+			},
+			expectedError: true,
+			errorContains: "LOGIN_FAILURE_WINDOW must be positive",
+		},
+		{
+			name: "unknown rate limit store",
+			config: &Config{
+				DatabaseURL:    "postgres://localhost:5432/test",
+				APISecretKey:   "secret",
+				ServerPort:     "8080",
+				RateLimitStore: "redis",
+			},
+			expectedError: true,
+			errorContains: "RATE_LIMIT_STORE must be",
+		},
+		{
+			name: "rate limit without a period",
+			config: &Config{
+				DatabaseURL:   "postgres://localhost:5432/test",
+				APISecretKey:  "secret",
+				ServerPort:    "8080",
+				RateLimitAuth: RateLimit{Requests: 10},
+			},
+			expectedError: true,
+			errorContains: "RATE_LIMIT_AUTH must be",
+		},
+		{
+			name: "unknown log level",
+			config: &Config{
+				DatabaseURL:  "postgres://localhost:5432/test",
+				APISecretKey: "secret",
+				ServerPort:   "8080",
+				LogLevel:     "verbose",
+			},
+			expectedError: true,
+			errorContains: "LOG_LEVEL must be",
+		},
+		{
+			name: "unknown log format",
+			config: &Config{
+				DatabaseURL:  "postgres://localhost:5432/test",
+				APISecretKey: "secret",
+				ServerPort:   "8080",
+				LogFormat:    "xml",
+			},
+			expectedError: true,
+			errorContains: "LOG_FORMAT must be",
+		},
+		{
+			name: "negative argon2 memory",
+			config: &Config{
+				DatabaseURL:       "postgres://localhost:5432/test",
+				APISecretKey:      "secret",
+				ServerPort:        "8080",
+				Argon2Memory:      -1,
+				Argon2Iterations:  3,
+				Argon2Parallelism: 2,
+			},
+			expectedError: true,
+			errorContains: "ARGON2_MEMORY_KIB must be between 1 and 4294967295",
+		},
+		{
+			name: "argon2 iterations beyond uint32",
+			config: &Config{
+				DatabaseURL:       "postgres://localhost:5432/test",
+				APISecretKey:      "secret",
+				ServerPort:        "8080",
+				Argon2Memory:      64 * 1024,
+				Argon2Iterations:  1 << 32,
+				Argon2Parallelism: 2,
+			},
+			expectedError: true,
+			errorContains: "ARGON2_ITERATIONS must be between 1 and 4294967295",
+		},
+		{
+			name: "argon2 parallelism beyond uint8",
+			config: &Config{
+				DatabaseURL:       "postgres://localhost:5432/test",
+				APISecretKey:      "secret",
+				ServerPort:        "8080",
+				Argon2Memory:      64 * 1024,
+				Argon2Iterations:  3,
+				Argon2Parallelism: 256,
+			},
+			expectedError: true,
+			errorContains: "ARGON2_PARALLELISM must be between 1 and 255",
+		},
 	}
 
 	for _, tt := range tests {
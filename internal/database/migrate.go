@@ -0,0 +1,364 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// migrationLockID is the Postgres advisory lock that keeps migrators from
+// running at the same time, e.g. when several replicas start together
+const migrationLockID int64 = 0x676f2d7374642d61 // "go-std-a"
+
+var migrationFileName = regexp.MustCompile(`^(\d+)_(.+)\.(up|down)\.sql$`)
+
+// Migration is a numbered schema change with the SQL that applies and
+// reverts it
+type Migration struct {
+	Version uint
+	Name    string
+	Up      string
+	Down    string
+}
+
+// LoadMigrations reads the NNNNNN_name.up.sql and NNNNNN_name.down.sql files
+// in the root of fsys and returns the migrations ordered by version. Files
+// that are not SQL are ignored.
+func LoadMigrations(fsys fs.FS) ([]*Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migrations: %w", err)
+	}
+
+	byVersion := make(map[uint]*Migration)
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".sql") {
+			continue
+		}
+		match := migrationFileName.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, fmt.Errorf("invalid migration file name %q", entry.Name())
+		}
+		version, err := strconv.ParseUint(match[1], 10, 64)
+		if err != nil || version == 0 {
+			return nil, fmt.Errorf("invalid migration version in %q", entry.Name())
+		}
+
+		migration, ok := byVersion[uint(version)]
+		if !ok {
+			migration = &Migration{Version: uint(version), Name: match[2]}
+			byVersion[uint(version)] = migration
+		}
+		if migration.Name != match[2] {
+			return nil, fmt.Errorf("migration %d has files named %q and %q", version, migration.Name, match[2])
+		}
+
+		sql, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration %q: %w", entry.Name(), err)
+		}
+		if match[3] == "up" {
+			migration.Up = string(sql)
+		} else {
+			migration.Down = string(sql)
+		}
+	}
+
+	migrations := make([]*Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if migration.Up == "" {
+			return nil, fmt.Errorf("migration %d has no up file", migration.Version)
+		}
+		migrations = append(migrations, migration)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	return migrations, nil
+}
+
+// MigratorConfig holds the settings for a Migrator
+type MigratorConfig struct {
+	// DryRun writes the SQL of the pending migrations to Output instead of
+	// running it
+	DryRun bool
+	// Output receives the name of every migration that is run, if set
+	Output io.Writer
+}
+
+// Migrator applies migrations and records the current version in the
+// schema_migrations table. The table has the layout golang-migrate uses, so
+// databases migrated with its CLI can be taken over.
+type Migrator struct {
+	db         *pgxpool.Pool
+	migrations []*Migration
+	config     MigratorConfig
+}
+
+// NewMigrator creates a migrator for the migrations in fsys
+func NewMigrator(db *pgxpool.Pool, fsys fs.FS, config MigratorConfig) (*Migrator, error) {
+	migrations, err := LoadMigrations(fsys)
+	if err != nil {
+		return nil, err
+	}
+	if config.Output == nil {
+		config.Output = io.Discard
+	}
+
+	return &Migrator{
+		db:         db,
+		migrations: migrations,
+		config:     config,
+	}, nil
+}
+
+// MigrationStatus is the version the database is at and which migrations
+// have been applied
+type MigrationStatus struct {
+	// Version is zero before the first migration
+	Version    uint
+	Dirty      bool
+	Migrations []MigrationState
+}
+
+// MigrationState tells whether a migration has been applied
+type MigrationState struct {
+	Version uint
+	Name    string
+	Applied bool
+}
+
+// Up applies every pending migration and returns the version the database
+// is at afterwards
+func (m *Migrator) Up(ctx context.Context) (uint, error) {
+	return m.migrate(ctx, func(uint) uint {
+		if len(m.migrations) == 0 {
+			return 0
+		}
+		return m.migrations[len(m.migrations)-1].Version
+	})
+}
+
+// Down reverts the last steps migrations and returns the version the
+// database is at afterwards
+func (m *Migrator) Down(ctx context.Context, steps int) (uint, error) {
+	if steps < 1 {
+		return 0, fmt.Errorf("steps must be at least 1")
+	}
+	return m.migrate(ctx, func(current uint) uint {
+		index := m.index(current)
+		if index-steps < 0 {
+			return 0
+		}
+		return m.migrations[index-steps].Version
+	})
+}
+
+// Goto applies or reverts migrations until the database is at version,
+// which must be zero or the version of a migration
+func (m *Migrator) Goto(ctx context.Context, version uint) (uint, error) {
+	if version != 0 && m.index(version) < 0 {
+		return 0, fmt.Errorf("no migration with version %d", version)
+	}
+	return m.migrate(ctx, func(uint) uint {
+		return version
+	})
+}
+
+// Status returns the version the database is at and the state of every
+// migration
+func (m *Migrator) Status(ctx context.Context) (*MigrationStatus, error) {
+	conn, err := m.db.Acquire(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to acquire connection: %w", err)
+	}
+	defer conn.Release()
+
+	version, dirty, err := currentVersion(ctx, conn.Conn())
+	if err != nil {
+		return nil, err
+	}
+
+	status := &MigrationStatus{Version: version, Dirty: dirty}
+	for _, migration := range m.migrations {
+		status.Migrations = append(status.Migrations, MigrationState{
+			Version: migration.Version,
+			Name:    migration.Name,
+			Applied: migration.Version <= version,
+		})
+	}
+
+	return status, nil
+}
+
+// migrationStep is one migration file to run, after which the database is
+// at version
+type migrationStep struct {
+	file    string
+	sql     string
+	version uint
+}
+
+// migrate moves the database from its current version to the one target
+// picks, holding the advisory lock throughout
+func (m *Migrator) migrate(ctx context.Context, target func(current uint) uint) (uint, error) {
+	conn, err := m.db.Acquire(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to acquire connection: %w", err)
+	}
+	defer conn.Release()
+
+	// Advisory locks belong to the session, so every statement below runs
+	// on this connection
+	if _, err := conn.Exec(ctx, "SELECT pg_advisory_lock($1)", migrationLockID); err != nil {
+		return 0, fmt.Errorf("failed to lock migrations: %w", err)
+	}
+	defer conn.Exec(context.Background(), "SELECT pg_advisory_unlock($1)", migrationLockID)
+
+	if !m.config.DryRun {
+		if _, err := conn.Exec(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+			version BIGINT NOT NULL PRIMARY KEY,
+			dirty BOOLEAN NOT NULL
+		)`); err != nil {
+			return 0, fmt.Errorf("failed to create schema_migrations: %w", err)
+		}
+	}
+
+	current, dirty, err := currentVersion(ctx, conn.Conn())
+	if err != nil {
+		return 0, err
+	}
+	if dirty {
+		return current, fmt.Errorf("database is dirty at version %d: repair it by hand, then clear the dirty flag in schema_migrations", current)
+	}
+
+	to := target(current)
+	steps, err := m.plan(current, to)
+	if err != nil {
+		return current, err
+	}
+
+	for _, step := range steps {
+		if m.config.DryRun {
+			fmt.Fprintf(m.config.Output, "-- %s\n%s\n", step.file, step.sql)
+			continue
+		}
+		if err := applyStep(ctx, conn.Conn(), step); err != nil {
+			return current, err
+		}
+		current = step.version
+		fmt.Fprintf(m.config.Output, "Applied %s\n", step.file)
+	}
+
+	return to, nil
+}
+
+// plan lists the migration files that move the database from current to
+// target, in the order they must run
+func (m *Migrator) plan(current, target uint) ([]migrationStep, error) {
+	if current != 0 && m.index(current) < 0 {
+		return nil, fmt.Errorf("database is at version %d, which has no migration", current)
+	}
+
+	var steps []migrationStep
+	if target >= current {
+		for _, migration := range m.migrations {
+			if migration.Version > current && migration.Version <= target {
+				steps = append(steps, migrationStep{
+					file:    fmt.Sprintf("%06d_%s.up.sql", migration.Version, migration.Name),
+					sql:     migration.Up,
+					version: migration.Version,
+				})
+			}
+		}
+		return steps, nil
+	}
+
+	for i := m.index(current); i >= 0 && m.migrations[i].Version > target; i-- {
+		migration := m.migrations[i]
+		if migration.Down == "" {
+			return nil, fmt.Errorf("migration %d has no down file", migration.Version)
+		}
+		var previous uint
+		if i > 0 {
+			previous = m.migrations[i-1].Version
+		}
+		steps = append(steps, migrationStep{
+			file:    fmt.Sprintf("%06d_%s.down.sql", migration.Version, migration.Name),
+			sql:     migration.Down,
+			version: previous,
+		})
+	}
+	return steps, nil
+}
+
+// index returns the position of the migration with version, or -1
+func (m *Migrator) index(version uint) int {
+	for i, migration := range m.migrations {
+		if migration.Version == version {
+			return i
+		}
+	}
+	return -1
+}
+
+// currentVersion reads the version recorded in schema_migrations, which is
+// zero if the table is missing or empty
+func currentVersion(ctx context.Context, conn *pgx.Conn) (uint, bool, error) {
+	var exists bool
+	if err := conn.QueryRow(ctx, "SELECT to_regclass('schema_migrations') IS NOT NULL").Scan(&exists); err != nil {
+		return 0, false, fmt.Errorf("failed to read schema version: %w", err)
+	}
+	if !exists {
+		return 0, false, nil
+	}
+
+	var version int64
+	var dirty bool
+	err := conn.QueryRow(ctx, "SELECT version, dirty FROM schema_migrations LIMIT 1").Scan(&version, &dirty)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to read schema version: %w", err)
+	}
+
+	return uint(version), dirty, nil
+}
+
+// applyStep runs a migration file and records the new version in the same
+// transaction, so a failed migration leaves the database as it was
+func applyStep(ctx context.Context, conn *pgx.Conn, step migrationStep) error {
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, step.sql); err != nil {
+		return fmt.Errorf("migration %s failed: %w", step.file, err)
+	}
+	if _, err := tx.Exec(ctx, "DELETE FROM schema_migrations"); err != nil {
+		return fmt.Errorf("failed to record schema version: %w", err)
+	}
+	if step.version > 0 {
+		if _, err := tx.Exec(ctx, "INSERT INTO schema_migrations (version, dirty) VALUES ($1, FALSE)", int64(step.version)); err != nil {
+			return fmt.Errorf("failed to record schema version: %w", err)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit migration %s: %w", step.file, err)
+	}
+	return nil
+}
@@ -0,0 +1,198 @@
+package database
+
+import (
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/alinoer/go-std-api/migrations"
+)
+
+func TestLoadMigrations(t *testing.T) {
+	tests := []struct {
+		name             string
+		files            fstest.MapFS
+		expectedVersions []uint
+		errorContains    string
+	}{
+		{
+			name: "ordered by version",
+			files: fstest.MapFS{
+				"000002_add_posts.up.sql":     {Data: []byte("CREATE TABLE posts ();")},
+				"000002_add_posts.down.sql":   {Data: []byte("DROP TABLE posts;")},
+				"000001_add_users.up.sql":     {Data: []byte("CREATE TABLE users ();")},
+				"000001_add_users.down.sql":   {Data: []byte("DROP TABLE users;")},
+				"000010_add_index.up.sql":     {Data: []byte("CREATE INDEX idx ON posts (id);")},
+				"migrations.go":               {Data: []byte("package migrations")},
+				"README.md":                   {Data: []byte("notes")},
+				"subdirectory/ignored.up.sql": {Data: []byte("SELECT 1;")},
+			},
+			expectedVersions: []uint{1, 2, 10},
+		},
+		{
+			name:          "invalid file name",
+			files:         fstest.MapFS{"add_users.up.sql": {Data: []byte("SELECT 1;")}},
+			errorContains: "invalid migration file name",
+		},
+		{
+			name:          "version zero",
+			files:         fstest.MapFS{"000000_init.up.sql": {Data: []byte("SELECT 1;")}},
+			errorContains: "invalid migration version",
+		},
+		{
+			name: "mismatched names",
+			files: fstest.MapFS{
+				"000001_add_users.up.sql":    {Data: []byte("SELECT 1;")},
+				"000001_add_people.down.sql": {Data: []byte("SELECT 1;")},
+			},
+			errorContains: "has files named",
+		},
+		{
+			name:          "missing up file",
+			files:         fstest.MapFS{"000001_add_users.down.sql": {Data: []byte("SELECT 1;")}},
+			errorContains: "has no up file",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			loaded, err := LoadMigrations(tt.files)
+			if tt.errorContains != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.errorContains) {
+					t.Errorf("expected an error containing %q, got %v", tt.errorContains, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			var versions []uint
+			for _, migration := range loaded {
+				versions = append(versions, migration.Version)
+			}
+			if len(versions) != len(tt.expectedVersions) {
+				t.Fatalf("expected versions %v, got %v", tt.expectedVersions, versions)
+			}
+			for i := range versions {
+				if versions[i] != tt.expectedVersions[i] {
+					t.Fatalf("expected versions %v, got %v", tt.expectedVersions, versions)
+				}
+			}
+			if loaded[0].Name != "add_users" || loaded[0].Up != "CREATE TABLE users ();" || loaded[0].Down != "DROP TABLE users;" {
+				t.Errorf("unexpected first migration %+v", loaded[0])
+			}
+		})
+	}
+}
+
+func TestLoadMigrations_Embedded(t *testing.T) {
+	loaded, err := LoadMigrations(migrations.FS)
+	if err != nil {
+		t.Fatalf("failed to load the embedded migrations: %v", err)
+	}
+	if len(loaded) == 0 {
+		t.Fatal("expected embedded migrations")
+	}
+
+	for i, migration := range loaded {
+		if migration.Version != uint(i+1) {
+			t.Errorf("expected migration %d to have version %d, got %d", i, i+1, migration.Version)
+		}
+		if strings.TrimSpace(migration.Down) == "" {
+			t.Errorf("migration %d has no down SQL", migration.Version)
+		}
+	}
+}
+
+func TestMigrator_Plan(t *testing.T) {
+	migrator := &Migrator{migrations: []*Migration{
+		{Version: 1, Name: "one", Up: "up 1", Down: "down 1"},
+		{Version: 2, Name: "two", Up: "up 2", Down: "down 2"},
+		{Version: 5, Name: "five", Up: "up 5", Down: "down 5"},
+	}}
+
+	tests := []struct {
+		name          string
+		current       uint
+		target        uint
+		expected      []migrationStep
+		errorContains string
+	}{
+		{
+			name:    "all the way up",
+			current: 0,
+			target:  5,
+			expected: []migrationStep{
+				{file: "000001_one.up.sql", sql: "up 1", version: 1},
+				{file: "000002_two.up.sql", sql: "up 2", version: 2},
+				{file: "000005_five.up.sql", sql: "up 5", version: 5},
+			},
+		},
+		{
+			name:     "part of the way up",
+			current:  1,
+			target:   2,
+			expected: []migrationStep{{file: "000002_two.up.sql", sql: "up 2", version: 2}},
+		},
+		{
+			name:    "up to date",
+			current: 5,
+			target:  5,
+		},
+		{
+			name:    "down",
+			current: 5,
+			target:  1,
+			expected: []migrationStep{
+				{file: "000005_five.down.sql", sql: "down 5", version: 2},
+				{file: "000002_two.down.sql", sql: "down 2", version: 1},
+			},
+		},
+		{
+			name:    "all the way down",
+			current: 2,
+			target:  0,
+			expected: []migrationStep{
+				{file: "000002_two.down.sql", sql: "down 2", version: 1},
+				{file: "000001_one.down.sql", sql: "down 1", version: 0},
+			},
+		},
+		{
+			name:          "unknown current version",
+			current:       3,
+			target:        5,
+			errorContains: "which has no migration",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			steps, err := migrator.plan(tt.current, tt.target)
+			if tt.errorContains != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.errorContains) {
+					t.Errorf("expected an error containing %q, got %v", tt.errorContains, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(steps) != len(tt.expected) {
+				t.Fatalf("expected steps %+v, got %+v", tt.expected, steps)
+			}
+			for i := range steps {
+				if steps[i] != tt.expected[i] {
+					t.Errorf("expected step %d to be %+v, got %+v", i, tt.expected[i], steps[i])
+				}
+			}
+		})
+	}
+
+	t.Run("missing down file", func(t *testing.T) {
+		migrator := &Migrator{migrations: []*Migration{{Version: 1, Name: "one", Up: "up 1"}}}
+		if _, err := migrator.plan(1, 0); err == nil || !strings.Contains(err.Error(), "has no down file") {
+			t.Errorf("expected a missing down file error, got %v", err)
+		}
+	})
+}
@@ -17,9 +17,13 @@ const (
 	ErrCodeUnauthorized ErrorCode = "UNAUTHORIZED"
 	ErrCodeForbidden    ErrorCode = "FORBIDDEN"
 	ErrCodeConflict     ErrorCode = "CONFLICT"
+	ErrCodePrecondition ErrorCode = "PRECONDITION_FAILED"
 	ErrCodeValidation   ErrorCode = "VALIDATION_ERROR"
 	ErrCodeRateLimit    ErrorCode = "RATE_LIMIT_EXCEEDED"
 
+	ErrCodeUnsupportedMediaType ErrorCode = "UNSUPPORTED_MEDIA_TYPE"
+	ErrCodePayloadTooLarge      ErrorCode = "PAYLOAD_TOO_LARGE"
+
 	// Server Errors (5xx)
 	ErrCodeInternal           ErrorCode = "INTERNAL_ERROR"
 	ErrCodeServiceUnavailable ErrorCode = "SERVICE_UNAVAILABLE"
@@ -117,8 +121,14 @@ func getHTTPStatusForCode(code ErrorCode) int {
 		return http.StatusForbidden
 	case ErrCodeConflict:
 		return http.StatusConflict
+	case ErrCodePrecondition:
+		return http.StatusPreconditionFailed
 	case ErrCodeRateLimit:
 		return http.StatusTooManyRequests
+	case ErrCodeUnsupportedMediaType:
+		return http.StatusUnsupportedMediaType
+	case ErrCodePayloadTooLarge:
+		return http.StatusRequestEntityTooLarge
 	case ErrCodeServiceUnavailable:
 		return http.StatusServiceUnavailable
 	case ErrCodeInternal, ErrCodeDatabaseError, ErrCodeExternalService:
@@ -162,6 +172,28 @@ func Conflict(resource string, details string) *AppError {
 		WithDetails(details)
 }
 
+// StaleVersion reports a change based on an outdated version of a resource
+func StaleVersion(resource string, version int) *AppError {
+	return NewAppError(ErrCodeConflict, fmt.Sprintf("%s has been changed since version %d", resource, version))
+}
+
+// PreconditionFailed reports a change whose precondition, such as an
+// If-Match header, no longer holds for the resource
+func PreconditionFailed(resource string) *AppError {
+	return NewAppError(ErrCodePrecondition, fmt.Sprintf("%s does not match the precondition", resource))
+}
+
+// UnsupportedMediaType reports a request body in a format the endpoint does
+// not accept
+func UnsupportedMediaType(mediaType string) *AppError {
+	return NewAppError(ErrCodeUnsupportedMediaType, fmt.Sprintf("Unsupported media type '%s'", mediaType))
+}
+
+// PayloadTooLarge reports a request body over the limit in bytes
+func PayloadTooLarge(limit int64) *AppError {
+	return NewAppError(ErrCodePayloadTooLarge, fmt.Sprintf("Request body must be at most %d bytes", limit))
+}
+
 func InternalError(message string) *AppError {
 	return NewAppError(ErrCodeInternal, message)
 }
@@ -176,6 +208,20 @@ func ExternalServiceError(service string, err error) *AppError {
 		WithInternal(err)
 }
 
+// New, Is and As mirror the standard library so callers importing this
+// package as "errors" keep access to them
+func New(text string) error {
+	return errors.New(text)
+}
+
+func Is(err, target error) bool {
+	return errors.Is(err, target)
+}
+
+func As(err error, target interface{}) bool {
+	return errors.As(err, target)
+}
+
 // IsAppError checks if an error is an AppError
 func IsAppError(err error) bool {
 	var appErr *AppError
@@ -147,6 +147,30 @@ func TestPredefinedErrorConstructors(t *testing.T) {
 			expectedCode:   ErrCodeConflict,
 			expectedStatus: http.StatusConflict,
 		},
+		{
+			name:           "StaleVersion",
+			constructor:    func() *AppError { return StaleVersion("Post", 3) },
+			expectedCode:   ErrCodeConflict,
+			expectedStatus: http.StatusConflict,
+		},
+		{
+			name:           "PreconditionFailed",
+			constructor:    func() *AppError { return PreconditionFailed("Post") },
+			expectedCode:   ErrCodePrecondition,
+			expectedStatus: http.StatusPreconditionFailed,
+		},
+		{
+			name:           "UnsupportedMediaType",
+			constructor:    func() *AppError { return UnsupportedMediaType("text/plain") },
+			expectedCode:   ErrCodeUnsupportedMediaType,
+			expectedStatus: http.StatusUnsupportedMediaType,
+		},
+		{
+			name:           "PayloadTooLarge",
+			constructor:    func() *AppError { return PayloadTooLarge(1024) },
+			expectedCode:   ErrCodePayloadTooLarge,
+			expectedStatus: http.StatusRequestEntityTooLarge,
+		},
 		{
 			name:           "InternalError",
 			constructor:    func() *AppError { return InternalError("server error") },
@@ -338,7 +362,10 @@ func TestGetHTTPStatusForCode(t *testing.T) {
 		{ErrCodeUnauthorized, http.StatusUnauthorized},
 		{ErrCodeForbidden, http.StatusForbidden},
 		{ErrCodeConflict, http.StatusConflict},
+		{ErrCodePrecondition, http.StatusPreconditionFailed},
 		{ErrCodeRateLimit, http.StatusTooManyRequests},
+		{ErrCodeUnsupportedMediaType, http.StatusUnsupportedMediaType},
+		{ErrCodePayloadTooLarge, http.StatusRequestEntityTooLarge},
 		{ErrCodeServiceUnavailable, http.StatusServiceUnavailable},
 		{ErrCodeInternal, http.StatusInternalServerError},
 		{ErrCodeDatabaseError, http.StatusInternalServerError},
@@ -2,42 +2,56 @@ package handlers
 
 import (
 	"encoding/json"
+	"io"
+	"net"
 	"net/http"
 
+	"github.com/alinoer/go-std-api/internal/errors"
+	"github.com/alinoer/go-std-api/internal/middleware"
 	"github.com/alinoer/go-std-api/internal/models"
+	"github.com/alinoer/go-std-api/internal/response"
 	"github.com/alinoer/go-std-api/internal/service"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
 )
 
 type AuthHandler struct {
-	userService service.UserService
-	authService *service.AuthService
+	userService   service.UserService
+	authService   *service.AuthService
+	loginThrottle *service.LoginThrottle
 }
 
 func NewAuthHandler(userService service.UserService, authService *service.AuthService) *AuthHandler {
+	return NewAuthHandlerWithLoginThrottle(userService, authService, nil)
+}
+
+// NewAuthHandlerWithLoginThrottle creates a handler whose logins are
+// protected against password guessing by loginThrottle, if it is not nil
+func NewAuthHandlerWithLoginThrottle(userService service.UserService, authService *service.AuthService, loginThrottle *service.LoginThrottle) *AuthHandler {
 	return &AuthHandler{
-		userService: userService,
-		authService: authService,
+		userService:   userService,
+		authService:   authService,
+		loginThrottle: loginThrottle,
 	}
 }
 
 func (h *AuthHandler) Register(w http.ResponseWriter, r *http.Request) {
+	resp := response.NewResponseWriter(w, r)
+
 	var req models.RegisterRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		WriteError(w, http.StatusBadRequest, "Invalid JSON payload")
+		resp.BadRequest("Invalid JSON payload")
 		return
 	}
 
 	// Validate input
 	if req.Username == "" {
-		WriteError(w, http.StatusBadRequest, "Username is required")
+		resp.Error(errors.ValidationError("username", "Username is required"))
 		return
 	}
 	if req.Password == "" {
-		WriteError(w, http.StatusBadRequest, "Password is required")
-		return
-	}
-	if len(req.Password) < 6 {
-		WriteError(w, http.StatusBadRequest, "Password must be at least 6 characters long")
+		resp.Error(errors.ValidationError("password", "Password is required"))
 		return
 	}
 
@@ -49,55 +63,258 @@ func (h *AuthHandler) Register(w http.ResponseWriter, r *http.Request) {
 
 	user, err := h.userService.CreateUser(r.Context(), createReq)
 	if err != nil {
-		WriteError(w, http.StatusBadRequest, err.Error())
+		resp.Error(err)
 		return
 	}
 
-	response := models.RegisterResponse{
-		User:    user,
-		Message: "User registered successfully",
-	}
-
-	WriteJSON(w, http.StatusCreated, response)
+	resp.JSONWithMessage(http.StatusCreated, user, "User registered successfully")
 }
 
 func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
+	resp := response.NewResponseWriter(w, r)
+
 	var req models.LoginRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		WriteError(w, http.StatusBadRequest, "Invalid JSON payload")
+		resp.BadRequest("Invalid JSON payload")
 		return
 	}
 
 	// Validate input
 	if req.Username == "" {
-		WriteError(w, http.StatusBadRequest, "Username is required")
+		resp.Error(errors.ValidationError("username", "Username is required"))
 		return
 	}
 	if req.Password == "" {
-		WriteError(w, http.StatusBadRequest, "Password is required")
+		resp.Error(errors.ValidationError("password", "Password is required"))
 		return
 	}
 
+	// Count the attempt before checking the password, so that locked out
+	// usernames and addresses get no guesses, not even concurrent ones
+	var attempt *service.LoginAttempt
+	if h.loginThrottle != nil {
+		var err error
+		attempt, err = h.loginThrottle.Reserve(r.Context(), req.Username, middleware.ClientIP(r))
+		if err != nil {
+			setRetryAfter(w, err)
+			resp.Error(err)
+			return
+		}
+	}
+
 	// Validate credentials
 	user, err := h.userService.ValidateCredentials(r.Context(), req.Username, req.Password)
 	if err != nil {
-		WriteError(w, http.StatusUnauthorized, "Invalid username or password")
+		if attempt != nil {
+			if errors.AsAppError(err).Code == errors.ErrCodeUnauthorized {
+				h.loginThrottle.RecordFailure(r.Context(), attempt)
+			} else {
+				h.loginThrottle.Release(r.Context(), attempt)
+			}
+		}
+		resp.Error(err)
 		return
 	}
 
-	// Generate JWT token
-	token, expiresIn, err := h.authService.GenerateToken(user.ID, user.Username)
+	if attempt != nil {
+		if err := h.loginThrottle.RecordSuccess(r.Context(), attempt, user); err != nil {
+			resp.Error(err)
+			return
+		}
+	}
+
+	// Generate access and refresh tokens
+	tokens, err := h.authService.IssueTokens(r.Context(), user)
 	if err != nil {
-		WriteError(w, http.StatusInternalServerError, "Failed to generate token")
+		resp.Error(err)
 		return
 	}
 
-	response := models.LoginResponse{
-		User:        user,
-		AccessToken: token,
-		TokenType:   "Bearer",
-		ExpiresIn:   expiresIn,
+	resp.Success(models.LoginResponse{
+		User:             user,
+		AccessToken:      tokens.AccessToken,
+		TokenType:        "Bearer",
+		ExpiresIn:        tokens.ExpiresIn,
+		RefreshToken:     tokens.RefreshToken,
+		RefreshExpiresIn: tokens.RefreshExpiresIn,
+	})
+}
+
+func (h *AuthHandler) Refresh(w http.ResponseWriter, r *http.Request) {
+	resp := response.NewResponseWriter(w, r)
+
+	var req models.RefreshTokenRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		resp.BadRequest("Invalid JSON payload")
+		return
+	}
+
+	if req.RefreshToken == "" {
+		resp.Error(errors.ValidationError("refresh_token", "Refresh token is required"))
+		return
+	}
+
+	tokens, err := h.authService.RefreshToken(r.Context(), req.RefreshToken)
+	if err != nil {
+		resp.Error(err)
+		return
+	}
+
+	resp.Success(models.TokenResponse{
+		AccessToken:      tokens.AccessToken,
+		TokenType:        "Bearer",
+		ExpiresIn:        tokens.ExpiresIn,
+		RefreshToken:     tokens.RefreshToken,
+		RefreshExpiresIn: tokens.RefreshExpiresIn,
+	})
+}
+
+func (h *AuthHandler) Logout(w http.ResponseWriter, r *http.Request) {
+	resp := response.NewResponseWriter(w, r)
+
+	var req models.RefreshTokenRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		resp.BadRequest("Invalid JSON payload")
+		return
+	}
+
+	if req.RefreshToken == "" {
+		resp.Error(errors.ValidationError("refresh_token", "Refresh token is required"))
+		return
+	}
+
+	if err := h.authService.RevokeRefreshToken(r.Context(), req.RefreshToken); err != nil {
+		resp.Error(err)
+		return
+	}
+
+	// Revoke the access token as well when the client sends it; one that is
+	// already invalid or expired needs no revoking
+	if token, ok := getBearerToken(r); ok {
+		err := h.authService.RevokeAccessToken(r.Context(), token)
+		if err != nil && errors.AsAppError(err).Code != errors.ErrCodeUnauthorized {
+			resp.Error(err)
+			return
+		}
+	}
+
+	resp.JSONWithMessage(http.StatusOK, nil, "Logged out successfully")
+}
+
+// LogoutAll revokes every access and refresh token of the authenticated user
+func (h *AuthHandler) LogoutAll(w http.ResponseWriter, r *http.Request) {
+	resp := response.NewResponseWriter(w, r)
+
+	userID, ok := GetAuthenticatedUserID(r)
+	if !ok {
+		resp.Unauthorized("Authentication required")
+		return
+	}
+
+	if err := h.authService.RevokeUserTokens(r.Context(), userID); err != nil {
+		resp.Error(err)
+		return
 	}
 
-	WriteJSON(w, http.StatusOK, response)
+	resp.JSONWithMessage(http.StatusOK, nil, "Logged out of all sessions")
+}
+
+// RevokeUserTokens is the admin operation that revokes every access and
+// refresh token of the user in the URL
+func (h *AuthHandler) RevokeUserTokens(w http.ResponseWriter, r *http.Request) {
+	resp := response.NewResponseWriter(w, r)
+
+	idStr := chi.URLParam(r, "id")
+	userID, err := uuid.Parse(idStr)
+	if err != nil {
+		resp.BadRequest("Invalid user ID")
+		return
+	}
+
+	if err := h.authService.RevokeUserTokens(r.Context(), userID); err != nil {
+		resp.Error(err)
+		return
+	}
+
+	resp.JSONWithMessage(http.StatusOK, nil, "User tokens revoked successfully")
+}
+
+// UpdateUserRole is the admin operation that changes a user's role. The
+// user's tokens are revoked so the old role stops being honoured at once.
+func (h *AuthHandler) UpdateUserRole(w http.ResponseWriter, r *http.Request) {
+	resp := response.NewResponseWriter(w, r)
+
+	idStr := chi.URLParam(r, "id")
+	userID, err := uuid.Parse(idStr)
+	if err != nil {
+		resp.BadRequest("Invalid user ID")
+		return
+	}
+
+	var req models.UpdateRoleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		resp.BadRequest("Invalid JSON payload")
+		return
+	}
+
+	user, err := h.userService.UpdateUserRole(r.Context(), userID, req.Role)
+	if err != nil {
+		resp.Error(err)
+		return
+	}
+
+	if err := h.authService.RevokeUserTokens(r.Context(), userID); err != nil {
+		resp.Error(err)
+		return
+	}
+
+	resp.Success(models.NewPrivateUser(user))
+}
+
+// UnlockUser is the admin operation that lifts the login lockout of the user
+// in the URL, and of the IP address in the body if there is one
+func (h *AuthHandler) UnlockUser(w http.ResponseWriter, r *http.Request) {
+	resp := response.NewResponseWriter(w, r)
+
+	idStr := chi.URLParam(r, "id")
+	userID, err := uuid.Parse(idStr)
+	if err != nil {
+		resp.BadRequest("Invalid user ID")
+		return
+	}
+
+	// The body is optional
+	var req models.UnlockUserRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err != io.EOF {
+		resp.BadRequest("Invalid JSON payload")
+		return
+	}
+	if req.IPAddress != "" && net.ParseIP(req.IPAddress) == nil {
+		resp.Error(errors.ValidationError("ip_address", "IP address is invalid"))
+		return
+	}
+
+	user, err := h.userService.GetUser(r.Context(), userID)
+	if err != nil {
+		resp.Error(err)
+		return
+	}
+
+	if h.loginThrottle != nil {
+		if err := h.loginThrottle.Unlock(r.Context(), user, req.IPAddress); err != nil {
+			resp.Error(err)
+			return
+		}
+	}
+
+	resp.JSONWithMessage(http.StatusOK, nil, "User unlocked successfully")
+}
+
+// JWKS publishes the public keys that verify access tokens so other services
+// can validate them without the signing secret. The key set is written bare,
+// without the response envelope, as JWT libraries expect.
+func (h *AuthHandler) JWKS(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	json.NewEncoder(w).Encode(h.authService.JWKS())
 }
\ No newline at end of file
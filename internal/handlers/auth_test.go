@@ -7,10 +7,17 @@ import (
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
+	"github.com/alinoer/go-std-api/internal/errors"
 	"github.com/alinoer/go-std-api/internal/models"
+	"github.com/alinoer/go-std-api/internal/response"
+	"github.com/alinoer/go-std-api/internal/repository"
 	"github.com/alinoer/go-std-api/internal/service"
+	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
 )
 
@@ -19,6 +26,8 @@ type MockAuthUserService struct {
 	validateCredentialsUser *models.User
 	validateCredentialsError error
 	createdUser             *models.User
+	updateUserRoleError     error
+	getUserError            error
 }
 
 
@@ -37,24 +46,249 @@ func (m *MockAuthUserService) ValidateCredentials(ctx context.Context, username,
 }
 
 func (m *MockAuthUserService) GetUser(ctx context.Context, id uuid.UUID) (*models.User, error) {
-	return nil, nil
+	if m.getUserError != nil {
+		return nil, m.getUserError
+	}
+	return &models.User{ID: id, Username: "testuser"}, nil
 }
 
 func (m *MockAuthUserService) GetUserByUsername(ctx context.Context, username string) (*models.User, error) {
 	return nil, nil
 }
 
-func (m *MockAuthUserService) ListUsers(ctx context.Context) ([]*models.User, error) {
+func (m *MockAuthUserService) ListUsers(ctx context.Context, opts *models.ListOptions) ([]*models.User, error) {
+	return nil, nil
+}
+
+func (m *MockAuthUserService) ListUsersPaginated(ctx context.Context, pagination *models.PaginationParams, opts *models.ListOptions) (*models.PaginatedResponse, error) {
+	return nil, nil
+}
+
+func (m *MockAuthUserService) ListUsersByCursor(ctx context.Context, params *models.CursorParams, opts *models.ListOptions) (*models.PaginatedResponse, error) {
+	return nil, nil
+}
+
+func (m *MockAuthUserService) ListPrivateUsers(ctx context.Context, pagination *models.PaginationParams) (*models.PaginatedResponse, error) {
 	return nil, nil
 }
 
-func (m *MockAuthUserService) ListUsersPaginated(ctx context.Context, pagination *models.PaginationParams) (*models.PaginatedResponse, error) {
+func (m *MockAuthUserService) UpdateUserRole(ctx context.Context, id uuid.UUID, role models.Role) (*models.User, error) {
+	if m.updateUserRoleError != nil {
+		return nil, m.updateUserRoleError
+	}
+	return &models.User{ID: id, Role: role}, nil
+}
+
+func (m *MockAuthUserService) UpdateUser(ctx context.Context, userID, id uuid.UUID, req *models.UpdateUserRequest) (*models.User, error) {
+	return nil, nil
+}
+
+func (m *MockAuthUserService) DeleteUser(ctx context.Context, userID, id uuid.UUID) error {
+	return nil
+}
+
+func (m *MockAuthUserService) RestoreUser(ctx context.Context, id uuid.UUID) (*models.User, error) {
+	return nil, nil
+}
+
+// MockAuthUserRepository is a minimal in-memory UserRepository used by the
+// AuthService when refreshing tokens
+type MockAuthUserRepository struct {
+	users map[uuid.UUID]*models.User
+}
+
+func (m *MockAuthUserRepository) Create(ctx context.Context, user *models.User) error {
+	m.users[user.ID] = user
+	return nil
+}
+
+func (m *MockAuthUserRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.User, error) {
+	if user, exists := m.users[id]; exists {
+		return user, nil
+	}
+	return nil, errors.NotFound("User")
+}
+
+func (m *MockAuthUserRepository) GetByUsername(ctx context.Context, username string) (*models.User, error) {
+	for _, user := range m.users {
+		if user.Username == username {
+			return user, nil
+		}
+	}
+	return nil, errors.NotFound("User")
+}
+
+func (m *MockAuthUserRepository) List(ctx context.Context, opts *models.ListOptions) ([]*models.User, error) {
 	return nil, nil
 }
 
+func (m *MockAuthUserRepository) ListPaginated(ctx context.Context, pagination *models.PaginationParams, opts *models.ListOptions) ([]*models.User, int64, error) {
+	return nil, 0, nil
+}
+
+func (m *MockAuthUserRepository) ListByCursor(ctx context.Context, params *models.CursorParams, opts *models.ListOptions) ([]*models.User, bool, error) {
+	return nil, false, nil
+}
+
+func (m *MockAuthUserRepository) Count(ctx context.Context, opts *models.ListOptions) (int64, error) {
+	return int64(len(m.users)), nil
+}
+
+func (m *MockAuthUserRepository) UpdatePasswordHash(ctx context.Context, id uuid.UUID, passwordHash string) error {
+	return nil
+}
+
+func (m *MockAuthUserRepository) UpdateRole(ctx context.Context, id uuid.UUID, role models.Role) error {
+	user, exists := m.users[id]
+	if !exists {
+		return errors.NotFound("User")
+	}
+	user.Role = role
+	return nil
+}
+
+func (m *MockAuthUserRepository) Update(ctx context.Context, user *models.User) error {
+	m.users[user.ID] = user
+	return nil
+}
+
+func (m *MockAuthUserRepository) Delete(ctx context.Context, id uuid.UUID, policy models.UserPostPolicy) error {
+	delete(m.users, id)
+	return nil
+}
+
+func (m *MockAuthUserRepository) Restore(ctx context.Context, id uuid.UUID) error {
+	return errors.NotFound("User")
+}
+
+func (m *MockAuthUserRepository) Purge(ctx context.Context, before time.Time, policy models.UserPostPolicy) (int64, error) {
+	return 0, nil
+}
+
+// MockRefreshTokenRepository is an in-memory RefreshTokenRepository
+type MockRefreshTokenRepository struct {
+	tokens map[uuid.UUID]*models.RefreshToken
+}
+
+func (m *MockRefreshTokenRepository) Create(ctx context.Context, token *models.RefreshToken) error {
+	m.tokens[token.ID] = token
+	return nil
+}
+
+func (m *MockRefreshTokenRepository) GetByTokenHash(ctx context.Context, tokenHash string) (*models.RefreshToken, error) {
+	for _, token := range m.tokens {
+		if token.TokenHash == tokenHash {
+			copied := *token
+			return &copied, nil
+		}
+	}
+	return nil, errors.NotFound("Refresh token")
+}
+
+func (m *MockRefreshTokenRepository) Rotate(ctx context.Context, oldID uuid.UUID, next *models.RefreshToken) (bool, error) {
+	old, exists := m.tokens[oldID]
+	if !exists || old.RevokedAt != nil {
+		return false, nil
+	}
+	now := time.Now()
+	old.RevokedAt = &now
+	old.ReplacedBy = &next.ID
+	m.tokens[next.ID] = next
+	return true, nil
+}
+
+func (m *MockRefreshTokenRepository) RevokeFamily(ctx context.Context, familyID uuid.UUID) error {
+	now := time.Now()
+	for _, token := range m.tokens {
+		if token.FamilyID == familyID && token.RevokedAt == nil {
+			token.RevokedAt = &now
+		}
+	}
+	return nil
+}
+
+func (m *MockRefreshTokenRepository) RevokeAllForUser(ctx context.Context, userID uuid.UUID) error {
+	now := time.Now()
+	for _, token := range m.tokens {
+		if token.UserID == userID && token.RevokedAt == nil {
+			token.RevokedAt = &now
+		}
+	}
+	return nil
+}
+
+// MockLoginRepository keeps failed logins in memory
+type MockLoginRepository struct {
+	mu          sync.Mutex
+	failures    map[string]int
+	lockedUntil map[string]time.Time
+	events      []*models.LoginEvent
+}
+
+func NewMockLoginRepository() *MockLoginRepository {
+	return &MockLoginRepository{
+		failures:    make(map[string]int),
+		lockedUntil: make(map[string]time.Time),
+	}
+}
+
+func (m *MockLoginRepository) ReserveAttempt(ctx context.Context, key string, limit models.LoginLimit) (bool, time.Time, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if until := m.lockedUntil[key]; until.After(time.Now()) {
+		return false, until, nil
+	}
+	m.failures[key]++
+	delete(m.lockedUntil, key)
+	if lockout := limit.LockoutAfter(m.failures[key]); lockout > 0 {
+		m.lockedUntil[key] = time.Now().Add(lockout)
+	}
+	return true, m.lockedUntil[key], nil
+}
+
+func (m *MockLoginRepository) Release(ctx context.Context, key string, lockedUntil time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.failures[key] > 0 {
+		m.failures[key]--
+	}
+	if until, ok := m.lockedUntil[key]; ok && until.Equal(lockedUntil) {
+		delete(m.lockedUntil, key)
+	}
+	return nil
+}
+
+func (m *MockLoginRepository) Reset(ctx context.Context, keys []string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, key := range keys {
+		delete(m.failures, key)
+		delete(m.lockedUntil, key)
+	}
+	return nil
+}
+
+func (m *MockLoginRepository) CreateEvent(ctx context.Context, event *models.LoginEvent) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.events = append(m.events, event)
+	return nil
+}
+
+// newTestAuthService returns an AuthService backed by in-memory repositories
+// that know about the given users
+func newTestAuthService(users ...*models.User) *service.AuthService {
+	userRepo := &MockAuthUserRepository{users: make(map[uuid.UUID]*models.User)}
+	for _, user := range users {
+		userRepo.users[user.ID] = user
+	}
+	refreshRepo := &MockRefreshTokenRepository{tokens: make(map[uuid.UUID]*models.RefreshToken)}
+	return service.NewAuthService("test-secret-key", userRepo, refreshRepo, repository.NewMemoryTokenRevocationStore())
+}
+
 func TestNewAuthHandler(t *testing.T) {
 	mockUserService := &MockAuthUserService{}
-	authService := service.NewAuthService("test-secret-key")
+	authService := newTestAuthService()
 	handler := NewAuthHandler(mockUserService, authService)
 
 	if handler == nil {
@@ -102,7 +336,7 @@ func TestAuthHandler_Register(t *testing.T) {
 			},
 			setupMock:          func(mockUser *MockAuthUserService) {},
 			expectedStatusCode: http.StatusBadRequest,
-			expectedError:      "Username is required",
+			expectedError:      "Validation failed for field 'username'",
 		},
 		{
 			name: "empty password",
@@ -112,17 +346,21 @@ func TestAuthHandler_Register(t *testing.T) {
 			},
 			setupMock:          func(mockUser *MockAuthUserService) {},
 			expectedStatusCode: http.StatusBadRequest,
-			expectedError:      "Password is required",
+			expectedError:      "Validation failed for field 'password'",
 		},
 		{
-			name: "password too short",
+			name: "password rejected by the policy",
 			requestBody: models.RegisterRequest{
 				Username: "testuser",
 				Password: "12345",
 			},
-			setupMock:          func(mockUser *MockAuthUserService) {},
+			setupMock: func(mockUser *MockAuthUserService) {
+				validationErrors := &errors.ValidationErrors{}
+				validationErrors.Add("password", "Password must be at least 8 characters long")
+				mockUser.createUserError = validationErrors.ToAppError()
+			},
 			expectedStatusCode: http.StatusBadRequest,
-			expectedError:      "Password must be at least 6 characters long",
+			expectedError:      "Multiple validation errors",
 		},
 		{
 			name: "user service error",
@@ -131,17 +369,17 @@ func TestAuthHandler_Register(t *testing.T) {
 				Password: "password123",
 			},
 			setupMock: func(mockUser *MockAuthUserService) {
-				mockUser.createUserError = fmt.Errorf("username already exists")
+				mockUser.createUserError = errors.Conflict("Username", "choose a different username")
 			},
-			expectedStatusCode: http.StatusBadRequest,
-			expectedError:      "username already exists",
+			expectedStatusCode: http.StatusConflict,
+			expectedError:      "Username already exists",
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			mockUserService := &MockAuthUserService{}
-			authService := service.NewAuthService("test-secret-key")
+			authService := newTestAuthService()
 			tt.setupMock(mockUserService)
 			handler := NewAuthHandler(mockUserService, authService)
 
@@ -167,7 +405,7 @@ func TestAuthHandler_Register(t *testing.T) {
 			}
 
 			if tt.expectedError != "" {
-				var errorResp ErrorResponse
+				var errorResp response.ErrorResponse
 				if err := json.Unmarshal(w.Body.Bytes(), &errorResp); err != nil {
 					t.Errorf("failed to unmarshal error response: %v", err)
 				}
@@ -175,14 +413,14 @@ func TestAuthHandler_Register(t *testing.T) {
 					t.Errorf("expected error %q, got %q", tt.expectedError, errorResp.Error)
 				}
 			} else {
-				var response models.RegisterResponse
-				if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+				var successResp response.StandardResponse
+				if err := json.Unmarshal(w.Body.Bytes(), &successResp); err != nil {
 					t.Errorf("failed to unmarshal success response: %v", err)
 				}
-				if response.User == nil {
+				if successResp.Data == nil {
 					t.Error("expected non-nil user in response")
 				}
-				if response.Message == "" {
+				if successResp.Message == "" {
 					t.Error("expected non-empty message in response")
 				}
 			}
@@ -227,7 +465,7 @@ func TestAuthHandler_Login(t *testing.T) {
 			},
 			setupMock:          func(mockUser *MockAuthUserService) {},
 			expectedStatusCode: http.StatusBadRequest,
-			expectedError:      "Username is required",
+			expectedError:      "Validation failed for field 'username'",
 		},
 		{
 			name: "empty password",
@@ -237,7 +475,7 @@ func TestAuthHandler_Login(t *testing.T) {
 			},
 			setupMock:          func(mockUser *MockAuthUserService) {},
 			expectedStatusCode: http.StatusBadRequest,
-			expectedError:      "Password is required",
+			expectedError:      "Validation failed for field 'password'",
 		},
 		{
 			name: "invalid credentials",
@@ -246,7 +484,7 @@ func TestAuthHandler_Login(t *testing.T) {
 				Password: "wrongpassword",
 			},
 			setupMock: func(mockUser *MockAuthUserService) {
-				mockUser.validateCredentialsError = fmt.Errorf("invalid credentials")
+				mockUser.validateCredentialsError = errors.Unauthorized("Invalid username or password")
 			},
 			expectedStatusCode: http.StatusUnauthorized,
 			expectedError:      "Invalid username or password",
@@ -256,7 +494,7 @@ func TestAuthHandler_Login(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			mockUserService := &MockAuthUserService{}
-			authService := service.NewAuthService("test-secret-key")
+			authService := newTestAuthService()
 			tt.setupMock(mockUserService)
 			handler := NewAuthHandler(mockUserService, authService)
 
@@ -282,7 +520,7 @@ func TestAuthHandler_Login(t *testing.T) {
 			}
 
 			if tt.expectedError != "" {
-				var errorResp ErrorResponse
+				var errorResp response.ErrorResponse
 				if err := json.Unmarshal(w.Body.Bytes(), &errorResp); err != nil {
 					t.Errorf("failed to unmarshal error response: %v", err)
 				}
@@ -290,17 +528,596 @@ func TestAuthHandler_Login(t *testing.T) {
 					t.Errorf("expected error %q, got %q", tt.expectedError, errorResp.Error)
 				}
 			} else {
-				var response models.LoginResponse
-				if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
-					t.Errorf("failed to unmarshal success response: %v", err)
-				}
-				if response.User == nil {
+				var login models.LoginResponse
+				decodeResponseData(t, w, &login)
+				if login.User == nil {
 					t.Error("expected non-nil user in response")
 				}
-				if response.AccessToken == "" {
+				if login.AccessToken == "" {
 					t.Error("expected non-empty access token in response")
 				}
+				if login.RefreshToken == "" {
+					t.Error("expected non-empty refresh token in response")
+				}
 			}
 		})
 	}
-}
\ No newline at end of file
+}
+
+func TestAuthHandler_LoginLockout(t *testing.T) {
+	user := &models.User{ID: uuid.New(), Username: "testuser"}
+	mockUserService := &MockAuthUserService{}
+	loginRepo := NewMockLoginRepository()
+	config := service.DefaultLoginThrottleConfig()
+	config.MaxFailures = 2
+	handler := NewAuthHandlerWithLoginThrottle(mockUserService, newTestAuthService(user), service.NewLoginThrottle(loginRepo, config))
+
+	login := func(password string) *httptest.ResponseRecorder {
+		body := fmt.Sprintf(`{"username":"testuser","password":%q}`, password)
+		req := httptest.NewRequest(http.MethodPost, "/auth/login", bytes.NewBufferString(body))
+		req.RemoteAddr = "192.0.2.1:1234"
+		w := httptest.NewRecorder()
+		handler.Login(w, req)
+		return w
+	}
+
+	mockUserService.validateCredentialsError = errors.Unauthorized("Invalid username or password")
+	for i := 0; i < 2; i++ {
+		if w := login("wrongpassword"); w.Code != http.StatusUnauthorized {
+			t.Fatalf("expected failed login %d to be unauthorized, got %d", i+1, w.Code)
+		}
+	}
+
+	// Even the right password is rejected during the lockout
+	mockUserService.validateCredentialsError = nil
+	mockUserService.validateCredentialsUser = user
+	w := login("password123")
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected a locked out login to get 429, got %d", w.Code)
+	}
+	if w.Header().Get("Retry-After") != "60" {
+		t.Errorf("expected Retry-After 60, got %q", w.Header().Get("Retry-After"))
+	}
+
+	// Once unlocked, the user can log in and the failures are forgotten
+	loginRepo.Reset(context.Background(), []string{"username:testuser"})
+	if w := login("password123"); w.Code != http.StatusOK {
+		t.Fatalf("expected the login to succeed, got %d", w.Code)
+	}
+	if loginRepo.failures["ip:192.0.2.1"] != 2 {
+		t.Errorf("expected the address to keep its 2 failures, got %d", loginRepo.failures["ip:192.0.2.1"])
+	}
+
+	var outcomes []models.LoginOutcome
+	for _, event := range loginRepo.events {
+		outcomes = append(outcomes, event.Outcome)
+	}
+	expected := []models.LoginOutcome{models.LoginOutcomeFailure, models.LoginOutcomeFailure, models.LoginOutcomeLocked, models.LoginOutcomeSuccess}
+	if fmt.Sprint(outcomes) != fmt.Sprint(expected) {
+		t.Errorf("expected events %v, got %v", expected, outcomes)
+	}
+	if loginRepo.events[0].IPAddress != "192.0.2.1" {
+		t.Errorf("expected the client address to be recorded, got %q", loginRepo.events[0].IPAddress)
+	}
+}
+
+// GuessingUserService rejects every password and counts how many it checked
+type GuessingUserService struct {
+	MockAuthUserService
+	checks int32
+}
+
+func (m *GuessingUserService) ValidateCredentials(ctx context.Context, username, password string) (*models.User, error) {
+	atomic.AddInt32(&m.checks, 1)
+	return nil, errors.Unauthorized("Invalid username or password")
+}
+
+func TestAuthHandler_ConcurrentLogins(t *testing.T) {
+	userService := &GuessingUserService{}
+	config := service.DefaultLoginThrottleConfig()
+	handler := NewAuthHandlerWithLoginThrottle(userService, newTestAuthService(), service.NewLoginThrottle(NewMockLoginRepository(), config))
+
+	login := func() int {
+		req := httptest.NewRequest(http.MethodPost, "/auth/login", bytes.NewBufferString(`{"username":"testuser","password":"guess"}`))
+		req.RemoteAddr = "192.0.2.1:1234"
+		w := httptest.NewRecorder()
+		handler.Login(w, req)
+		return w.Code
+	}
+
+	if code := login(); code != http.StatusUnauthorized {
+		t.Fatalf("expected the first guess to be unauthorized, got %d", code)
+	}
+
+	// A burst of guesses gets no more than guesses one after another
+	const burst = 50
+	var wg sync.WaitGroup
+	var unauthorized, locked int32
+	for i := 0; i < burst; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			switch login() {
+			case http.StatusUnauthorized:
+				atomic.AddInt32(&unauthorized, 1)
+			case http.StatusTooManyRequests:
+				atomic.AddInt32(&locked, 1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if checks := atomic.LoadInt32(&userService.checks); checks != int32(config.MaxFailures) {
+		t.Errorf("expected %d passwords to be checked, got %d", config.MaxFailures, checks)
+	}
+	if unauthorized != int32(config.MaxFailures-1) || locked != int32(burst-config.MaxFailures+1) {
+		t.Errorf("expected %d failed and %d locked out logins, got %d and %d", config.MaxFailures-1, burst-config.MaxFailures+1, unauthorized, locked)
+	}
+}
+
+func TestAuthHandler_UnlockUser(t *testing.T) {
+	userID := uuid.New()
+
+	tests := []struct {
+		name               string
+		userID             string
+		body               string
+		setupMock          func(*MockAuthUserService)
+		expectedStatusCode int
+		expectedError      string
+		expectedIPUnlocked bool
+	}{
+		{
+			name:               "successful unlock",
+			userID:             userID.String(),
+			setupMock:          func(mock *MockAuthUserService) {},
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:               "unlock with the user's address",
+			userID:             userID.String(),
+			body:               `{"ip_address":"192.0.2.1"}`,
+			setupMock:          func(mock *MockAuthUserService) {},
+			expectedStatusCode: http.StatusOK,
+			expectedIPUnlocked: true,
+		},
+		{
+			name:               "invalid address",
+			userID:             userID.String(),
+			body:               `{"ip_address":"not an address"}`,
+			setupMock:          func(mock *MockAuthUserService) {},
+			expectedStatusCode: http.StatusBadRequest,
+			expectedError:      "Validation failed for field 'ip_address'",
+		},
+		{
+			name:               "invalid JSON",
+			userID:             userID.String(),
+			body:               `{"ip_address":`,
+			setupMock:          func(mock *MockAuthUserService) {},
+			expectedStatusCode: http.StatusBadRequest,
+			expectedError:      "Invalid JSON payload",
+		},
+		{
+			name:               "invalid user ID",
+			userID:             "invalid-uuid",
+			setupMock:          func(mock *MockAuthUserService) {},
+			expectedStatusCode: http.StatusBadRequest,
+			expectedError:      "Invalid user ID",
+		},
+		{
+			name:   "user not found",
+			userID: userID.String(),
+			setupMock: func(mock *MockAuthUserService) {
+				mock.getUserError = errors.NotFound("User")
+			},
+			expectedStatusCode: http.StatusNotFound,
+			expectedError:      "User not found",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockUserService := &MockAuthUserService{}
+			tt.setupMock(mockUserService)
+			loginRepo := NewMockLoginRepository()
+			loginRepo.failures["username:testuser"] = 5
+			loginRepo.lockedUntil["username:testuser"] = time.Now().Add(time.Hour)
+			loginRepo.lockedUntil["ip:192.0.2.1"] = time.Now().Add(time.Hour)
+			handler := NewAuthHandlerWithLoginThrottle(mockUserService, newTestAuthService(), service.NewLoginThrottle(loginRepo, service.DefaultLoginThrottleConfig()))
+
+			req := httptest.NewRequest(http.MethodPost, "/admin/users/"+tt.userID+"/unlock", bytes.NewBufferString(tt.body))
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("id", tt.userID)
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+			w := httptest.NewRecorder()
+
+			handler.UnlockUser(w, req)
+
+			if w.Code != tt.expectedStatusCode {
+				t.Errorf("expected status code %d, got %d", tt.expectedStatusCode, w.Code)
+			}
+
+			if tt.expectedError != "" {
+				var errorResp response.ErrorResponse
+				if err := json.Unmarshal(w.Body.Bytes(), &errorResp); err != nil {
+					t.Errorf("failed to unmarshal error response: %v", err)
+				}
+				if errorResp.Error != tt.expectedError {
+					t.Errorf("expected error %q, got %q", tt.expectedError, errorResp.Error)
+				}
+				if _, locked := loginRepo.lockedUntil["username:testuser"]; !locked {
+					t.Error("expected the lockout to stay")
+				}
+				return
+			}
+
+			if _, locked := loginRepo.lockedUntil["username:testuser"]; locked {
+				t.Error("expected the lockout to be lifted")
+			}
+			if _, locked := loginRepo.lockedUntil["ip:192.0.2.1"]; locked == tt.expectedIPUnlocked {
+				t.Errorf("expected the address to be unlocked: %t", tt.expectedIPUnlocked)
+			}
+			if len(loginRepo.events) != 1 || loginRepo.events[0].Outcome != models.LoginOutcomeUnlocked {
+				t.Fatalf("expected an unlock event, got %+v", loginRepo.events)
+			}
+			if tt.expectedIPUnlocked && loginRepo.events[0].IPAddress != "192.0.2.1" {
+				t.Errorf("expected the event to record the unlocked address, got %q", loginRepo.events[0].IPAddress)
+			}
+		})
+	}
+}
+
+func TestAuthHandler_RefreshAndLogout(t *testing.T) {
+	user := &models.User{
+		ID:       uuid.New(),
+		Username: "testuser",
+	}
+	mockUserService := &MockAuthUserService{validateCredentialsUser: user}
+	handler := NewAuthHandler(mockUserService, newTestAuthService(user))
+
+	post := func(handlerFunc http.HandlerFunc, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, path, bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		handlerFunc(w, req)
+		return w
+	}
+
+	w := post(handler.Login, "/auth/login", `{"username":"testuser","password":"password123"}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected login to succeed, got %d", w.Code)
+	}
+	var login models.LoginResponse
+	decodeResponseData(t, w, &login)
+
+	refreshBody := func(token string) string {
+		return fmt.Sprintf(`{"refresh_token":%q}`, token)
+	}
+
+	tests := []struct {
+		name               string
+		handler            http.HandlerFunc
+		body               string
+		expectedStatusCode int
+		expectedError      string
+	}{
+		{
+			name:               "refresh with invalid JSON",
+			handler:            handler.Refresh,
+			body:               "invalid json",
+			expectedStatusCode: http.StatusBadRequest,
+			expectedError:      "Invalid JSON payload",
+		},
+		{
+			name:               "refresh without token",
+			handler:            handler.Refresh,
+			body:               `{}`,
+			expectedStatusCode: http.StatusBadRequest,
+			expectedError:      "Validation failed for field 'refresh_token'",
+		},
+		{
+			name:               "refresh with unknown token",
+			handler:            handler.Refresh,
+			body:               refreshBody("unknown"),
+			expectedStatusCode: http.StatusUnauthorized,
+			expectedError:      "Invalid refresh token",
+		},
+		{
+			name:               "refresh with issued token",
+			handler:            handler.Refresh,
+			body:               refreshBody(login.RefreshToken),
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:               "refresh with already rotated token",
+			handler:            handler.Refresh,
+			body:               refreshBody(login.RefreshToken),
+			expectedStatusCode: http.StatusUnauthorized,
+			expectedError:      "Refresh token has been revoked",
+		},
+		{
+			name:               "logout without token",
+			handler:            handler.Logout,
+			body:               `{}`,
+			expectedStatusCode: http.StatusBadRequest,
+			expectedError:      "Validation failed for field 'refresh_token'",
+		},
+		{
+			name:               "logout with unknown token",
+			handler:            handler.Logout,
+			body:               refreshBody("unknown"),
+			expectedStatusCode: http.StatusUnauthorized,
+			expectedError:      "Invalid refresh token",
+		},
+		{
+			name:               "logout with issued token",
+			handler:            handler.Logout,
+			body:               refreshBody(login.RefreshToken),
+			expectedStatusCode: http.StatusOK,
+		},
+	}
+
+	// Cases run in order: the rotation and logout cases depend on the earlier ones
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := post(tt.handler, "/auth/refresh", tt.body)
+
+			if w.Code != tt.expectedStatusCode {
+				t.Errorf("expected status code %d, got %d", tt.expectedStatusCode, w.Code)
+			}
+
+			if tt.expectedError != "" {
+				var errorResp response.ErrorResponse
+				if err := json.Unmarshal(w.Body.Bytes(), &errorResp); err != nil {
+					t.Errorf("failed to unmarshal error response: %v", err)
+				}
+				if errorResp.Error != tt.expectedError {
+					t.Errorf("expected error %q, got %q", tt.expectedError, errorResp.Error)
+				}
+			}
+		})
+	}
+}
+
+func TestAuthHandler_RevokeAccessTokens(t *testing.T) {
+	user := &models.User{
+		ID:       uuid.New(),
+		Username: "testuser",
+	}
+	authService := newTestAuthService(user)
+	handler := NewAuthHandler(&MockAuthUserService{}, authService)
+	ctx := context.Background()
+
+	isRevoked := func(accessToken string) bool {
+		claims, err := authService.ValidateToken(accessToken)
+		if err != nil {
+			t.Fatalf("failed to validate token: %v", err)
+		}
+		return authService.CheckRevocation(ctx, claims) != nil
+	}
+
+	t.Run("logout revokes the presented access token", func(t *testing.T) {
+		tokens, err := authService.IssueTokens(ctx, user)
+		if err != nil {
+			t.Fatalf("failed to issue tokens: %v", err)
+		}
+
+		body := fmt.Sprintf(`{"refresh_token":%q}`, tokens.RefreshToken)
+		req := httptest.NewRequest(http.MethodPost, "/auth/logout", bytes.NewBufferString(body))
+		req.Header.Set("Authorization", "Bearer "+tokens.AccessToken)
+		w := httptest.NewRecorder()
+
+		handler.Logout(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Errorf("expected status code %d, got %d", http.StatusOK, w.Code)
+		}
+		if !isRevoked(tokens.AccessToken) {
+			t.Error("expected access token to be revoked")
+		}
+	})
+
+	t.Run("logout all requires authentication", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/auth/logout-all", nil)
+		w := httptest.NewRecorder()
+
+		handler.LogoutAll(w, req)
+
+		if w.Code != http.StatusUnauthorized {
+			t.Errorf("expected status code %d, got %d", http.StatusUnauthorized, w.Code)
+		}
+	})
+
+	t.Run("logout all revokes every token", func(t *testing.T) {
+		first, _ := authService.IssueTokens(ctx, user)
+		second, _ := authService.IssueTokens(ctx, user)
+
+		req := withAuthenticatedUser(httptest.NewRequest(http.MethodPost, "/auth/logout-all", nil), user.ID)
+		w := httptest.NewRecorder()
+
+		handler.LogoutAll(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Errorf("expected status code %d, got %d", http.StatusOK, w.Code)
+		}
+		if !isRevoked(first.AccessToken) || !isRevoked(second.AccessToken) {
+			t.Error("expected all access tokens to be revoked")
+		}
+		if _, err := authService.RefreshToken(ctx, second.RefreshToken); err == nil {
+			t.Error("expected refresh tokens to be revoked")
+		}
+	})
+
+	tests := []struct {
+		name               string
+		userID             string
+		expectedStatusCode int
+		expectedError      string
+	}{
+		{
+			name:               "admin revokes user tokens",
+			userID:             user.ID.String(),
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:               "invalid user ID",
+			userID:             "invalid-uuid",
+			expectedStatusCode: http.StatusBadRequest,
+			expectedError:      "Invalid user ID",
+		},
+		{
+			name:               "unknown user",
+			userID:             uuid.New().String(),
+			expectedStatusCode: http.StatusNotFound,
+			expectedError:      "User not found",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tokens, _ := authService.IssueTokens(ctx, user)
+
+			req := httptest.NewRequest(http.MethodPost, "/admin/users/"+tt.userID+"/revoke-tokens", nil)
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("id", tt.userID)
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+			w := httptest.NewRecorder()
+
+			handler.RevokeUserTokens(w, req)
+
+			if w.Code != tt.expectedStatusCode {
+				t.Errorf("expected status code %d, got %d", tt.expectedStatusCode, w.Code)
+			}
+
+			if tt.expectedError != "" {
+				var errorResp response.ErrorResponse
+				if err := json.Unmarshal(w.Body.Bytes(), &errorResp); err != nil {
+					t.Errorf("failed to unmarshal error response: %v", err)
+				}
+				if errorResp.Error != tt.expectedError {
+					t.Errorf("expected error %q, got %q", tt.expectedError, errorResp.Error)
+				}
+			} else if !isRevoked(tokens.AccessToken) {
+				t.Error("expected user's access token to be revoked")
+			}
+		})
+	}
+}
+
+func TestAuthHandler_JWKS(t *testing.T) {
+	handler := NewAuthHandler(&MockAuthUserService{}, newTestAuthService())
+
+	req := httptest.NewRequest(http.MethodGet, "/.well-known/jwks.json", nil)
+	w := httptest.NewRecorder()
+
+	handler.JWKS(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("expected status code %d, got %d", http.StatusOK, w.Code)
+	}
+	if w.Header().Get("Cache-Control") == "" {
+		t.Error("expected Cache-Control header")
+	}
+
+	var jwks models.JWKS
+	if err := json.Unmarshal(w.Body.Bytes(), &jwks); err != nil {
+		t.Fatalf("failed to unmarshal JWKS: %v", err)
+	}
+	if jwks.Keys == nil || len(jwks.Keys) != 0 {
+		t.Errorf("expected an empty key list for an HMAC key set, got %v", jwks.Keys)
+	}
+}
+
+func TestAuthHandler_UpdateUserRole(t *testing.T) {
+	user := &models.User{
+		ID:       uuid.New(),
+		Username: "testuser",
+		Role:     models.RoleUser,
+	}
+
+	tests := []struct {
+		name               string
+		userID             string
+		body               string
+		setupMock          func(*MockAuthUserService)
+		expectedStatusCode int
+		expectedError      string
+	}{
+		{
+			name:               "successful role change",
+			userID:             user.ID.String(),
+			body:               `{"role":"moderator"}`,
+			setupMock:          func(mock *MockAuthUserService) {},
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:               "invalid user ID",
+			userID:             "invalid-uuid",
+			body:               `{"role":"moderator"}`,
+			setupMock:          func(mock *MockAuthUserService) {},
+			expectedStatusCode: http.StatusBadRequest,
+			expectedError:      "Invalid user ID",
+		},
+		{
+			name:               "invalid JSON",
+			userID:             user.ID.String(),
+			body:               "invalid json",
+			setupMock:          func(mock *MockAuthUserService) {},
+			expectedStatusCode: http.StatusBadRequest,
+			expectedError:      "Invalid JSON payload",
+		},
+		{
+			name:   "invalid role",
+			userID: user.ID.String(),
+			body:   `{"role":"superuser"}`,
+			setupMock: func(mock *MockAuthUserService) {
+				mock.updateUserRoleError = errors.ValidationError("role", "Role must be one of user, moderator or admin")
+			},
+			expectedStatusCode: http.StatusBadRequest,
+			expectedError:      "Validation failed for field 'role'",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockUserService := &MockAuthUserService{}
+			tt.setupMock(mockUserService)
+			authService := newTestAuthService(user)
+			handler := NewAuthHandler(mockUserService, authService)
+
+			tokens, err := authService.IssueTokens(context.Background(), user)
+			if err != nil {
+				t.Fatalf("failed to issue tokens: %v", err)
+			}
+
+			req := httptest.NewRequest(http.MethodPut, "/admin/users/"+tt.userID+"/role", bytes.NewBufferString(tt.body))
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("id", tt.userID)
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+			w := httptest.NewRecorder()
+
+			handler.UpdateUserRole(w, req)
+
+			if w.Code != tt.expectedStatusCode {
+				t.Errorf("expected status code %d, got %d", tt.expectedStatusCode, w.Code)
+			}
+
+			if tt.expectedError != "" {
+				var errorResp response.ErrorResponse
+				if err := json.Unmarshal(w.Body.Bytes(), &errorResp); err != nil {
+					t.Errorf("failed to unmarshal error response: %v", err)
+				}
+				if errorResp.Error != tt.expectedError {
+					t.Errorf("expected error %q, got %q", tt.expectedError, errorResp.Error)
+				}
+				return
+			}
+
+			// Tokens carrying the old role must stop working
+			claims, _ := authService.ValidateToken(tokens.AccessToken)
+			if err := authService.CheckRevocation(context.Background(), claims); err == nil {
+				t.Error("expected tokens issued with the old role to be revoked")
+			}
+		})
+	}
+}
@@ -0,0 +1,127 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/alinoer/go-std-api/internal/models"
+	"github.com/alinoer/go-std-api/internal/response"
+	"github.com/alinoer/go-std-api/internal/service"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+type CommentHandler struct {
+	commentService service.CommentService
+}
+
+func NewCommentHandler(commentService service.CommentService) *CommentHandler {
+	return &CommentHandler{
+		commentService: commentService,
+	}
+}
+
+// ListComments lists a post's top-level comments with their replies
+func (h *CommentHandler) ListComments(w http.ResponseWriter, r *http.Request) {
+	resp := response.NewResponseWriter(w, r)
+
+	postID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		resp.BadRequest("Invalid post ID")
+		return
+	}
+
+	pagination := ParsePaginationParams(r)
+
+	result, err := h.commentService.ListComments(r.Context(), postID, pagination)
+	if err != nil {
+		resp.Error(err)
+		return
+	}
+
+	resp.JSONWithMeta(http.StatusOK, result.Data, "", result.Pagination)
+}
+
+func (h *CommentHandler) CreateComment(w http.ResponseWriter, r *http.Request) {
+	resp := response.NewResponseWriter(w, r)
+
+	userID, ok := GetAuthenticatedUserID(r)
+	if !ok {
+		resp.Unauthorized("Authentication required")
+		return
+	}
+
+	postID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		resp.BadRequest("Invalid post ID")
+		return
+	}
+
+	var req models.CreateCommentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		resp.BadRequest("Invalid JSON payload")
+		return
+	}
+
+	comment, err := h.commentService.CreateComment(r.Context(), userID, postID, &req)
+	if err != nil {
+		resp.Error(err)
+		return
+	}
+
+	resp.Created(comment)
+}
+
+func (h *CommentHandler) UpdateComment(w http.ResponseWriter, r *http.Request) {
+	resp := response.NewResponseWriter(w, r)
+
+	userID, ok := GetAuthenticatedUserID(r)
+	if !ok {
+		resp.Unauthorized("Authentication required")
+		return
+	}
+
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		resp.BadRequest("Invalid comment ID")
+		return
+	}
+
+	var req models.UpdateCommentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		resp.BadRequest("Invalid JSON payload")
+		return
+	}
+
+	comment, err := h.commentService.UpdateComment(r.Context(), userID, id, &req)
+	if err != nil {
+		resp.Error(err)
+		return
+	}
+
+	resp.Success(comment)
+}
+
+func (h *CommentHandler) DeleteComment(w http.ResponseWriter, r *http.Request) {
+	resp := response.NewResponseWriter(w, r)
+
+	userID, ok := GetAuthenticatedUserID(r)
+	if !ok {
+		resp.Unauthorized("Authentication required")
+		return
+	}
+
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		resp.BadRequest("Invalid comment ID")
+		return
+	}
+
+	if err := h.commentService.DeleteComment(r.Context(), userID, id); err != nil {
+		resp.Error(err)
+		return
+	}
+
+	resp.NoContent()
+}
@@ -0,0 +1,331 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/alinoer/go-std-api/internal/errors"
+	"github.com/alinoer/go-std-api/internal/models"
+	"github.com/alinoer/go-std-api/internal/response"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+type MockCommentService struct {
+	createError       error
+	listError         error
+	updateError       error
+	deleteError       error
+	createdForUserID  uuid.UUID
+	createdForPostID  uuid.UUID
+	paginatedResponse *models.PaginatedResponse
+}
+
+func (m *MockCommentService) CreateComment(ctx context.Context, userID, postID uuid.UUID, req *models.CreateCommentRequest) (*models.Comment, error) {
+	m.createdForUserID = userID
+	m.createdForPostID = postID
+	if m.createError != nil {
+		return nil, m.createError
+	}
+	return &models.Comment{ID: uuid.New(), PostID: postID, UserID: userID, ParentID: req.ParentID, Content: req.Content}, nil
+}
+
+func (m *MockCommentService) ListComments(ctx context.Context, postID uuid.UUID, pagination *models.PaginationParams) (*models.PaginatedResponse, error) {
+	if m.listError != nil {
+		return nil, m.listError
+	}
+	return m.paginatedResponse, nil
+}
+
+func (m *MockCommentService) UpdateComment(ctx context.Context, userID, id uuid.UUID, req *models.UpdateCommentRequest) (*models.Comment, error) {
+	if m.updateError != nil {
+		return nil, m.updateError
+	}
+	return &models.Comment{ID: id, UserID: userID, Content: req.Content}, nil
+}
+
+func (m *MockCommentService) DeleteComment(ctx context.Context, userID, id uuid.UUID) error {
+	return m.deleteError
+}
+
+func newCommentRequest(method, target, id, body string) *http.Request {
+	req := httptest.NewRequest(method, target, bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("id", id)
+	return req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+}
+
+func TestCommentHandler_ListComments(t *testing.T) {
+	postID := uuid.New()
+
+	tests := []struct {
+		name           string
+		postID         string
+		mockService    *MockCommentService
+		expectedStatus int
+	}{
+		{
+			name:   "list comments",
+			postID: postID.String(),
+			mockService: &MockCommentService{paginatedResponse: &models.PaginatedResponse{
+				Data:       []*models.Comment{{ID: uuid.New(), PostID: postID, Content: "First"}},
+				Pagination: models.NewPaginationMeta(1, 10, 1),
+			}},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "post not found",
+			postID:         postID.String(),
+			mockService:    &MockCommentService{listError: errors.NotFound("Post")},
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "invalid post ID",
+			postID:         "invalid-uuid",
+			mockService:    &MockCommentService{},
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := NewCommentHandler(tt.mockService)
+
+			req := newCommentRequest(http.MethodGet, "/posts/"+tt.postID+"/comments?page=1", tt.postID, "")
+			w := httptest.NewRecorder()
+
+			handler.ListComments(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+			if tt.expectedStatus == http.StatusOK {
+				var successResp response.StandardResponse
+				if err := json.Unmarshal(w.Body.Bytes(), &successResp); err != nil {
+					t.Fatalf("failed to unmarshal response: %v", err)
+				}
+				if successResp.Meta == nil {
+					t.Error("expected non-nil pagination in response")
+				}
+			}
+		})
+	}
+}
+
+func TestCommentHandler_CreateComment(t *testing.T) {
+	userID := uuid.New()
+	postID := uuid.New()
+
+	tests := []struct {
+		name           string
+		postID         string
+		authenticated  bool
+		requestBody    string
+		mockService    *MockCommentService
+		expectedStatus int
+		expectedCode   string
+	}{
+		{
+			name:           "create comment",
+			postID:         postID.String(),
+			authenticated:  true,
+			requestBody:    `{"content": "Nice post"}`,
+			mockService:    &MockCommentService{},
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name:           "reply to a reply",
+			postID:         postID.String(),
+			authenticated:  true,
+			requestBody:    `{"content": "Nice post", "parent_id": "` + uuid.New().String() + `"}`,
+			mockService:    &MockCommentService{createError: errors.ValidationError("parent_id", "Replies cannot be replied to")},
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   string(errors.ErrCodeValidation),
+		},
+		{
+			name:           "invalid JSON",
+			postID:         postID.String(),
+			authenticated:  true,
+			requestBody:    `{"content":`,
+			mockService:    &MockCommentService{},
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   string(errors.ErrCodeBadRequest),
+		},
+		{
+			name:           "not authenticated",
+			postID:         postID.String(),
+			requestBody:    `{"content": "Nice post"}`,
+			mockService:    &MockCommentService{},
+			expectedStatus: http.StatusUnauthorized,
+			expectedCode:   string(errors.ErrCodeUnauthorized),
+		},
+		{
+			name:           "invalid post ID",
+			postID:         "invalid-uuid",
+			authenticated:  true,
+			requestBody:    `{"content": "Nice post"}`,
+			mockService:    &MockCommentService{},
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   string(errors.ErrCodeBadRequest),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := NewCommentHandler(tt.mockService)
+
+			req := newCommentRequest(http.MethodPost, "/posts/"+tt.postID+"/comments", tt.postID, tt.requestBody)
+			if tt.authenticated {
+				req = withAuthenticatedUser(req, userID)
+			}
+			w := httptest.NewRecorder()
+
+			handler.CreateComment(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+			if tt.expectedCode != "" {
+				var errResp response.ErrorResponse
+				if err := json.NewDecoder(w.Body).Decode(&errResp); err != nil {
+					t.Fatalf("failed to decode error response: %v", err)
+				}
+				if errResp.Code != tt.expectedCode {
+					t.Errorf("expected code %q, got %q", tt.expectedCode, errResp.Code)
+				}
+				return
+			}
+			if tt.mockService.createdForUserID != userID || tt.mockService.createdForPostID != postID {
+				t.Errorf("expected comment by %s on %s, got %s on %s", userID, postID, tt.mockService.createdForUserID, tt.mockService.createdForPostID)
+			}
+		})
+	}
+}
+
+func TestCommentHandler_UpdateComment(t *testing.T) {
+	userID := uuid.New()
+	commentID := uuid.New()
+
+	tests := []struct {
+		name           string
+		commentID      string
+		authenticated  bool
+		requestBody    string
+		mockService    *MockCommentService
+		expectedStatus int
+	}{
+		{
+			name:           "author edits",
+			commentID:      commentID.String(),
+			authenticated:  true,
+			requestBody:    `{"content": "Edited"}`,
+			mockService:    &MockCommentService{},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "another user's comment",
+			commentID:      commentID.String(),
+			authenticated:  true,
+			requestBody:    `{"content": "Edited"}`,
+			mockService:    &MockCommentService{updateError: errors.Forbidden("You can only update your own comments")},
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:           "not authenticated",
+			commentID:      commentID.String(),
+			requestBody:    `{"content": "Edited"}`,
+			mockService:    &MockCommentService{},
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "invalid comment ID",
+			commentID:      "invalid-uuid",
+			authenticated:  true,
+			requestBody:    `{"content": "Edited"}`,
+			mockService:    &MockCommentService{},
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := NewCommentHandler(tt.mockService)
+
+			req := newCommentRequest(http.MethodPut, "/comments/"+tt.commentID, tt.commentID, tt.requestBody)
+			if tt.authenticated {
+				req = withAuthenticatedUser(req, userID)
+			}
+			w := httptest.NewRecorder()
+
+			handler.UpdateComment(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestCommentHandler_DeleteComment(t *testing.T) {
+	userID := uuid.New()
+	commentID := uuid.New()
+
+	tests := []struct {
+		name           string
+		commentID      string
+		authenticated  bool
+		mockService    *MockCommentService
+		expectedStatus int
+	}{
+		{
+			name:           "author deletes",
+			commentID:      commentID.String(),
+			authenticated:  true,
+			mockService:    &MockCommentService{},
+			expectedStatus: http.StatusNoContent,
+		},
+		{
+			name:           "another user's comment",
+			commentID:      commentID.String(),
+			authenticated:  true,
+			mockService:    &MockCommentService{deleteError: errors.Forbidden("You can only delete your own comments")},
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:           "comment not found",
+			commentID:      commentID.String(),
+			authenticated:  true,
+			mockService:    &MockCommentService{deleteError: errors.NotFound("Comment")},
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "not authenticated",
+			commentID:      commentID.String(),
+			mockService:    &MockCommentService{},
+			expectedStatus: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := NewCommentHandler(tt.mockService)
+
+			req := newCommentRequest(http.MethodDelete, "/comments/"+tt.commentID, tt.commentID, "")
+			if tt.authenticated {
+				req = withAuthenticatedUser(req, userID)
+			}
+			w := httptest.NewRecorder()
+
+			handler.DeleteComment(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+		})
+	}
+}
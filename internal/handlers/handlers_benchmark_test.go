@@ -10,7 +10,6 @@ import (
 	"testing"
 
 	"github.com/alinoer/go-std-api/internal/models"
-	"github.com/alinoer/go-std-api/internal/service"
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
 )
@@ -96,7 +95,7 @@ func BenchmarkAuthHandler_Register(b *testing.B) {
 			Username: "benchuser",
 		},
 	}
-	authService := service.NewAuthService("test-secret-key")
+	authService := newTestAuthService()
 	handler := NewAuthHandler(mockUserService, authService)
 
 	requestBody := models.RegisterRequest{
@@ -122,7 +121,7 @@ func BenchmarkAuthHandler_Login(b *testing.B) {
 			Username: "benchuser",
 		},
 	}
-	authService := service.NewAuthService("test-secret-key")
+	authService := newTestAuthService()
 	handler := NewAuthHandler(mockUserService, authService)
 
 	requestBody := models.LoginRequest{
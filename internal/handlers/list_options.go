@@ -0,0 +1,195 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/alinoer/go-std-api/internal/errors"
+	"github.com/alinoer/go-std-api/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// FilterValueType is the type a filter query parameter is parsed as
+type FilterValueType int
+
+const (
+	FilterString FilterValueType = iota
+	FilterUUID
+	FilterTime
+	FilterPostStatus
+)
+
+// FilterParam whitelists a query parameter that filters a list
+type FilterParam struct {
+	Name  string
+	Field string
+	Op    models.FilterOp
+	Type  FilterValueType
+}
+
+// ListQuerySpec whitelists the filters and sort fields a list endpoint accepts
+type ListQuerySpec struct {
+	Filters    []FilterParam
+	SortFields []string
+	// Tags enables the repeatable tag parameter, matching items carrying
+	// every given tag
+	Tags bool
+}
+
+var postListSpec = ListQuerySpec{
+	Filters: []FilterParam{
+		{Name: "user_id", Field: "user_id", Op: models.FilterEquals, Type: FilterUUID},
+		{Name: "title_contains", Field: "title", Op: models.FilterContains, Type: FilterString},
+		{Name: "created_after", Field: "created_at", Op: models.FilterAfter, Type: FilterTime},
+		{Name: "created_before", Field: "created_at", Op: models.FilterBefore, Type: FilterTime},
+		{Name: "status", Field: "status", Op: models.FilterEquals, Type: FilterPostStatus},
+	},
+	SortFields: []string{"created_at", "title", "reaction_count"},
+	Tags:       true,
+}
+
+var userListSpec = ListQuerySpec{
+	Filters: []FilterParam{
+		{Name: "username_contains", Field: "username", Op: models.FilterContains, Type: FilterString},
+		{Name: "created_after", Field: "created_at", Op: models.FilterAfter, Type: FilterTime},
+		{Name: "created_before", Field: "created_at", Op: models.FilterBefore, Type: FilterTime},
+	},
+	SortFields: []string{"created_at", "username"},
+}
+
+// filterSuffixes mark query parameters as filters, so that filters on fields
+// outside the whitelist are rejected instead of silently ignored
+var filterSuffixes = []string{"_contains", "_after", "_before"}
+
+const maxFilterValueLength = 200
+
+// ParseListOptions reads the filter parameters and the sort parameter allowed
+// by spec. sort is a comma separated list of fields, each optionally prefixed
+// with "-" for descending order, e.g. "-created_at,title".
+func ParseListOptions(r *http.Request, spec ListQuerySpec) (*models.ListOptions, *errors.ValidationErrors) {
+	query := r.URL.Query()
+	validationErrors := &errors.ValidationErrors{}
+	opts := &models.ListOptions{}
+
+	params := make(map[string]FilterParam, len(spec.Filters))
+	for _, param := range spec.Filters {
+		params[param.Name] = param
+	}
+
+	names := make([]string, 0, len(query))
+	for name := range query {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		param, ok := params[name]
+		if !ok {
+			if hasFilterSuffix(name) {
+				validationErrors.Add(name, "Unknown filter")
+			}
+			continue
+		}
+
+		raw := query.Get(name)
+		if raw == "" {
+			continue
+		}
+		value, err := parseFilterValue(raw, param.Type)
+		if err != nil {
+			validationErrors.Add(name, err.Error())
+			continue
+		}
+		opts.Filters = append(opts.Filters, models.Filter{Field: param.Field, Op: param.Op, Value: value})
+	}
+
+	if spec.Tags {
+		for _, tag := range query["tag"] {
+			switch {
+			case tag == "":
+				continue
+			case len(tag) > maxFilterValueLength:
+				validationErrors.Add("tag", fmt.Sprintf("Must be at most %d characters", maxFilterValueLength))
+			default:
+				opts.Tags = append(opts.Tags, tag)
+			}
+		}
+	}
+
+	if sortParam := query.Get("sort"); sortParam != "" {
+		seen := make(map[string]bool)
+		for _, item := range strings.Split(sortParam, ",") {
+			item = strings.TrimSpace(item)
+			field := strings.TrimPrefix(item, "-")
+			switch {
+			case field == "":
+				validationErrors.Add("sort", "Sort fields must not be empty")
+			case !containsString(spec.SortFields, field):
+				validationErrors.Add("sort", fmt.Sprintf("Cannot sort by %s; allowed fields are %s", field, strings.Join(spec.SortFields, ", ")))
+			case seen[field]:
+				validationErrors.Add("sort", fmt.Sprintf("Field %s is sorted more than once", field))
+			default:
+				seen[field] = true
+				opts.Sort = append(opts.Sort, models.SortField{Field: field, Descending: strings.HasPrefix(item, "-")})
+			}
+		}
+	}
+
+	if validationErrors.HasErrors() {
+		return nil, validationErrors
+	}
+
+	return opts, nil
+}
+
+func parseFilterValue(raw string, valueType FilterValueType) (interface{}, error) {
+	switch valueType {
+	case FilterUUID:
+		id, err := uuid.Parse(raw)
+		if err != nil {
+			return nil, fmt.Errorf("Must be a valid UUID")
+		}
+		return id, nil
+	case FilterTime:
+		if t, err := time.Parse(time.RFC3339, raw); err == nil {
+			return t, nil
+		}
+		if t, err := time.Parse("2006-01-02", raw); err == nil {
+			return t, nil
+		}
+		return nil, fmt.Errorf("Must be an RFC 3339 timestamp or a YYYY-MM-DD date")
+	case FilterPostStatus:
+		status := models.PostStatus(raw)
+		if !status.IsValid() {
+			return nil, fmt.Errorf("Must be one of draft, published, archived")
+		}
+		return string(status), nil
+	default:
+		if len(raw) > maxFilterValueLength {
+			return nil, fmt.Errorf("Must be at most %d characters", maxFilterValueLength)
+		}
+		return raw, nil
+	}
+}
+
+func hasFilterSuffix(name string) bool {
+	for _, suffix := range filterSuffixes {
+		if strings.HasSuffix(name, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
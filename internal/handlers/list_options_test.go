@@ -0,0 +1,163 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/alinoer/go-std-api/internal/models"
+	"github.com/google/uuid"
+)
+
+func TestParseListOptions(t *testing.T) {
+	userID := uuid.New()
+
+	tests := []struct {
+		name            string
+		query           string
+		spec            ListQuerySpec
+		expectedFilters []models.Filter
+		expectedSort    []models.SortField
+		expectedFields  []string
+	}{
+		{
+			name:  "no options",
+			query: "",
+			spec:  postListSpec,
+		},
+		{
+			name:  "post filters",
+			query: "user_id=" + userID.String() + "&title_contains=go&created_after=2024-01-01T00:00:00Z",
+			spec:  postListSpec,
+			expectedFilters: []models.Filter{
+				{Field: "created_at", Op: models.FilterAfter, Value: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)},
+				{Field: "title", Op: models.FilterContains, Value: "go"},
+				{Field: "user_id", Op: models.FilterEquals, Value: userID},
+			},
+		},
+		{
+			name:  "date only",
+			query: "created_before=2024-02-01",
+			spec:  userListSpec,
+			expectedFilters: []models.Filter{
+				{Field: "created_at", Op: models.FilterBefore, Value: time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)},
+			},
+		},
+		{
+			name:  "sort",
+			query: "sort=-created_at,title",
+			spec:  postListSpec,
+			expectedSort: []models.SortField{
+				{Field: "created_at", Descending: true},
+				{Field: "title"},
+			},
+		},
+		{
+			name:  "pagination parameters are ignored",
+			query: "page=2&page_size=5&cursor=abc&limit=10",
+			spec:  postListSpec,
+		},
+		{
+			name:           "invalid user id",
+			query:          "user_id=not-a-uuid",
+			spec:           postListSpec,
+			expectedFields: []string{"user_id"},
+		},
+		{
+			name:           "invalid timestamp",
+			query:          "created_after=yesterday",
+			spec:           postListSpec,
+			expectedFields: []string{"created_after"},
+		},
+		{
+			name:  "status",
+			query: "status=archived",
+			spec:  postListSpec,
+			expectedFilters: []models.Filter{
+				{Field: "status", Op: models.FilterEquals, Value: "archived"},
+			},
+		},
+		{
+			name:           "unknown status",
+			query:          "status=deleted",
+			spec:           postListSpec,
+			expectedFields: []string{"status"},
+		},
+		{
+			name:           "filter outside the whitelist",
+			query:          "content_contains=secret",
+			spec:           postListSpec,
+			expectedFields: []string{"content_contains"},
+		},
+		{
+			name:           "post filter on users",
+			query:          "title_contains=go",
+			spec:           userListSpec,
+			expectedFields: []string{"title_contains"},
+		},
+		{
+			name:           "unknown and duplicate sort fields",
+			query:          "sort=password_hash,title,-title,",
+			spec:           postListSpec,
+			expectedFields: []string{"sort", "sort", "sort"},
+		},
+		{
+			name:           "every error is reported",
+			query:          "user_id=bad&created_before=bad&sort=content",
+			spec:           postListSpec,
+			expectedFields: []string{"created_before", "user_id", "sort"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/posts?"+tt.query, nil)
+
+			opts, validationErrors := ParseListOptions(req, tt.spec)
+
+			if len(tt.expectedFields) > 0 {
+				if validationErrors == nil {
+					t.Fatal("expected validation errors but got none")
+				}
+				if len(validationErrors.Errors) != len(tt.expectedFields) {
+					t.Fatalf("expected %d errors, got %d", len(tt.expectedFields), len(validationErrors.Errors))
+				}
+				for i, field := range tt.expectedFields {
+					if got := validationErrors.Errors[i].Context["field"]; got != field {
+						t.Errorf("expected error %d for field %q, got %v", i, field, got)
+					}
+				}
+				return
+			}
+
+			if validationErrors != nil {
+				t.Fatalf("unexpected validation errors: %v", validationErrors.Errors)
+			}
+			if len(opts.Filters) != len(tt.expectedFilters) {
+				t.Fatalf("expected filters %v, got %v", tt.expectedFilters, opts.Filters)
+			}
+			for i, filter := range opts.Filters {
+				expected := tt.expectedFilters[i]
+				if filter.Field != expected.Field || filter.Op != expected.Op {
+					t.Errorf("expected filter %v, got %v", expected, filter)
+				}
+				if expectedTime, ok := expected.Value.(time.Time); ok {
+					if !filter.Value.(time.Time).Equal(expectedTime) {
+						t.Errorf("expected value %v, got %v", expected.Value, filter.Value)
+					}
+				} else if filter.Value != expected.Value {
+					t.Errorf("expected value %v, got %v", expected.Value, filter.Value)
+				}
+			}
+			if len(opts.Sort) != len(tt.expectedSort) {
+				t.Fatalf("expected sort %v, got %v", tt.expectedSort, opts.Sort)
+			}
+			for i := range opts.Sort {
+				if opts.Sort[i] != tt.expectedSort[i] {
+					t.Errorf("expected sort %v, got %v", tt.expectedSort[i], opts.Sort[i])
+				}
+			}
+		})
+	}
+}
@@ -1,10 +1,17 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
+	"io"
+	"mime"
 	"net/http"
+	"strconv"
+	"strings"
 
+	"github.com/alinoer/go-std-api/internal/errors"
 	"github.com/alinoer/go-std-api/internal/models"
+	"github.com/alinoer/go-std-api/internal/response"
 	"github.com/alinoer/go-std-api/internal/service"
 
 	"github.com/go-chi/chi/v5"
@@ -13,97 +20,189 @@ import (
 
 type PostHandler struct {
 	postService service.PostService
-	userService service.UserService
 }
 
-func NewPostHandler(postService service.PostService, userService service.UserService) *PostHandler {
+func NewPostHandler(postService service.PostService) *PostHandler {
 	return &PostHandler{
 		postService: postService,
-		userService: userService,
 	}
 }
 
 func (h *PostHandler) CreatePost(w http.ResponseWriter, r *http.Request) {
+	resp := response.NewResponseWriter(w, r)
+
 	var req models.CreatePostRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		WriteError(w, http.StatusBadRequest, "Invalid JSON payload")
+		resp.BadRequest("Invalid JSON payload")
 		return
 	}
 
-	// For this example, we'll use a hardcoded user ID since we don't have proper JWT auth
-	// In a real application, you would extract the user ID from the JWT token
-	// For now, let's get the first user from the database as an example
-	users, err := h.userService.ListUsers(r.Context())
-	if err != nil || len(users) == 0 {
-		WriteError(w, http.StatusBadRequest, "No users found. Please create a user first")
+	userID, ok := GetAuthenticatedUserID(r)
+	if !ok {
+		resp.Unauthorized("Authentication required")
 		return
 	}
-	userID := users[0].ID
 
 	post, err := h.postService.CreatePost(r.Context(), userID, &req)
 	if err != nil {
-		WriteError(w, http.StatusBadRequest, err.Error())
+		resp.Error(err)
 		return
 	}
 
-	WriteJSON(w, http.StatusCreated, SuccessResponse{Data: post})
+	w.Header().Set("ETag", post.ETag())
+	resp.Created(post)
 }
 
 func (h *PostHandler) GetPost(w http.ResponseWriter, r *http.Request) {
+	resp := response.NewResponseWriter(w, r)
+
 	idStr := chi.URLParam(r, "id")
 	id, err := uuid.Parse(idStr)
 	if err != nil {
-		WriteError(w, http.StatusBadRequest, "Invalid post ID")
+		resp.BadRequest("Invalid post ID")
 		return
 	}
 
 	post, err := h.postService.GetPost(r.Context(), id)
 	if err != nil {
-		WriteError(w, http.StatusNotFound, "Post not found")
+		resp.Error(err)
 		return
 	}
 
-	WriteSuccess(w, post)
+	// The ETag follows the version, which reactions and comments do not
+	// change, so Last-Modified covers them. A request with both conditions
+	// only gets a 304 when both hold.
+	ifNoneMatch := r.Header.Get("If-None-Match") != ""
+	ifModifiedSince := r.Header.Get("If-Modified-Since") != ""
+	w.Header().Set("ETag", post.ETag())
+	w.Header().Set("Last-Modified", post.LastModified().UTC().Format(http.TimeFormat))
+	w.Header().Add("Vary", "Authorization")
+	if (ifNoneMatch || ifModifiedSince) &&
+		(!ifNoneMatch || MatchesIfNoneMatch(r, post.ETag())) &&
+		(!ifModifiedSince || NotModifiedSince(r, post.LastModified())) {
+		resp.NotModified()
+		return
+	}
+
+	resp.Success(post)
 }
 
 func (h *PostHandler) ListPosts(w http.ResponseWriter, r *http.Request) {
+	resp := response.NewResponseWriter(w, r)
+
 	// Check if pagination parameters are provided
+	if IsCursorPagination(r) {
+		h.ListPostsByCursor(w, r)
+		return
+	}
 	if r.URL.Query().Get("page") != "" || r.URL.Query().Get("page_size") != "" {
 		h.ListPostsPaginated(w, r)
 		return
 	}
 
+	opts, validationErrors := ParseListOptions(r, postListSpec)
+	if validationErrors != nil {
+		resp.ValidationError(validationErrors)
+		return
+	}
+
 	// Default non-paginated response
-	posts, err := h.postService.ListPosts(r.Context())
+	posts, err := h.postService.ListPosts(r.Context(), opts)
 	if err != nil {
-		WriteError(w, http.StatusInternalServerError, "Failed to retrieve posts")
+		resp.Error(err)
 		return
 	}
 
-	WriteSuccess(w, posts)
+	resp.Success(posts)
 }
 
 func (h *PostHandler) ListPostsPaginated(w http.ResponseWriter, r *http.Request) {
+	resp := response.NewResponseWriter(w, r)
+
 	pagination := ParsePaginationParams(r)
 
-	result, err := h.postService.ListPostsPaginated(r.Context(), pagination)
+	opts, validationErrors := ParseListOptions(r, postListSpec)
+	if validationErrors != nil {
+		resp.ValidationError(validationErrors)
+		return
+	}
+
+	result, err := h.postService.ListPostsPaginated(r.Context(), pagination, opts)
 	if err != nil {
-		WriteError(w, http.StatusInternalServerError, "Failed to retrieve posts")
+		resp.Error(err)
 		return
 	}
 
-	WriteJSON(w, http.StatusOK, result)
+	resp.JSONWithMeta(http.StatusOK, result.Data, "", result.Pagination)
+}
+
+func (h *PostHandler) ListPostsByCursor(w http.ResponseWriter, r *http.Request) {
+	resp := response.NewResponseWriter(w, r)
+
+	params, err := ParseCursorParams(r)
+	if err != nil {
+		resp.Error(err)
+		return
+	}
+
+	opts, validationErrors := ParseListOptions(r, postListSpec)
+	if validationErrors != nil {
+		resp.ValidationError(validationErrors)
+		return
+	}
+
+	result, err := h.postService.ListPostsByCursor(r.Context(), params, opts)
+	if err != nil {
+		resp.Error(err)
+		return
+	}
+
+	resp.JSONWithMeta(http.StatusOK, result.Data, "", result.Pagination)
+}
+
+// SearchPosts runs a full-text search given by the q query parameter
+func (h *PostHandler) SearchPosts(w http.ResponseWriter, r *http.Request) {
+	resp := response.NewResponseWriter(w, r)
+
+	pagination := ParsePaginationParams(r)
+
+	result, err := h.postService.SearchPosts(r.Context(), r.URL.Query().Get("q"), pagination)
+	if err != nil {
+		resp.Error(err)
+		return
+	}
+
+	resp.JSONWithMeta(http.StatusOK, result.Data, "", result.Pagination)
+}
+
+// ListTags lists the tags in use with their post counts
+func (h *PostHandler) ListTags(w http.ResponseWriter, r *http.Request) {
+	resp := response.NewResponseWriter(w, r)
+
+	tags, err := h.postService.ListTags(r.Context())
+	if err != nil {
+		resp.Error(err)
+		return
+	}
+
+	resp.Success(tags)
 }
 
 func (h *PostHandler) GetPostsByUser(w http.ResponseWriter, r *http.Request) {
+	resp := response.NewResponseWriter(w, r)
+
 	idStr := chi.URLParam(r, "userId")
 	userID, err := uuid.Parse(idStr)
 	if err != nil {
-		WriteError(w, http.StatusBadRequest, "Invalid user ID")
+		resp.BadRequest("Invalid user ID")
 		return
 	}
 
 	// Check if pagination parameters are provided
+	if IsCursorPagination(r) {
+		h.GetPostsByUserByCursor(w, r, userID)
+		return
+	}
 	if r.URL.Query().Get("page") != "" || r.URL.Query().Get("page_size") != "" {
 		h.GetPostsByUserPaginated(w, r, userID)
 		return
@@ -112,60 +211,366 @@ func (h *PostHandler) GetPostsByUser(w http.ResponseWriter, r *http.Request) {
 	// Default non-paginated response
 	posts, err := h.postService.GetPostsByUser(r.Context(), userID)
 	if err != nil {
-		WriteError(w, http.StatusInternalServerError, "Failed to retrieve posts")
+		resp.Error(err)
 		return
 	}
 
-	WriteSuccess(w, posts)
+	resp.Success(posts)
 }
 
 func (h *PostHandler) GetPostsByUserPaginated(w http.ResponseWriter, r *http.Request, userID uuid.UUID) {
+	resp := response.NewResponseWriter(w, r)
+
 	pagination := ParsePaginationParams(r)
 
 	result, err := h.postService.GetPostsByUserPaginated(r.Context(), userID, pagination)
 	if err != nil {
-		WriteError(w, http.StatusInternalServerError, "Failed to retrieve posts")
+		resp.Error(err)
 		return
 	}
 
-	WriteJSON(w, http.StatusOK, result)
+	resp.JSONWithMeta(http.StatusOK, result.Data, "", result.Pagination)
 }
 
+func (h *PostHandler) GetPostsByUserByCursor(w http.ResponseWriter, r *http.Request, userID uuid.UUID) {
+	resp := response.NewResponseWriter(w, r)
+
+	params, err := ParseCursorParams(r)
+	if err != nil {
+		resp.Error(err)
+		return
+	}
+
+	result, err := h.postService.GetPostsByUserByCursor(r.Context(), userID, params)
+	if err != nil {
+		resp.Error(err)
+		return
+	}
+
+	resp.JSONWithMeta(http.StatusOK, result.Data, "", result.Pagination)
+}
+
+// UpdatePost replaces the editable fields of a post with the request body
 func (h *PostHandler) UpdatePost(w http.ResponseWriter, r *http.Request) {
+	resp := response.NewResponseWriter(w, r)
+
+	userID, ok := GetAuthenticatedUserID(r)
+	if !ok {
+		resp.Unauthorized("Authentication required")
+		return
+	}
+
 	idStr := chi.URLParam(r, "id")
 	id, err := uuid.Parse(idStr)
 	if err != nil {
-		WriteError(w, http.StatusBadRequest, "Invalid post ID")
+		resp.BadRequest("Invalid post ID")
 		return
 	}
 
-	var req models.UpdatePostRequest
+	var req models.ReplacePostRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		WriteError(w, http.StatusBadRequest, "Invalid JSON payload")
+		resp.BadRequest("Invalid JSON payload")
+		return
+	}
+
+	post, err := h.postService.ReplacePost(r.Context(), userID, id, &req, ParseIfMatch(r, id))
+	if err != nil {
+		resp.Error(err)
+		return
+	}
+
+	w.Header().Set("ETag", post.ETag())
+	resp.Success(post)
+}
+
+// PatchPost applies the JSON merge patch or JSON patch in the request body
+// to a post, depending on the request's content type
+func (h *PostHandler) PatchPost(w http.ResponseWriter, r *http.Request) {
+	resp := response.NewResponseWriter(w, r)
+
+	userID, ok := GetAuthenticatedUserID(r)
+	if !ok {
+		resp.Unauthorized("Authentication required")
+		return
+	}
+
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		resp.BadRequest("Invalid post ID")
+		return
+	}
+
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	format := models.PatchFormat(mediaType)
+	if err != nil || !format.IsValid() {
+		formats := make([]string, len(models.PatchFormats))
+		for i, format := range models.PatchFormats {
+			formats[i] = string(format)
+		}
+		w.Header().Set("Accept-Patch", strings.Join(formats, ", "))
+		resp.Error(errors.UnsupportedMediaType(r.Header.Get("Content-Type")))
+		return
+	}
+
+	document, err := io.ReadAll(r.Body)
+	if err != nil {
+		resp.BadRequest("Invalid request body")
+		return
+	}
+
+	post, err := h.postService.PatchPost(r.Context(), userID, id, &models.PostPatch{Format: format, Document: document}, ParseIfMatch(r, id))
+	if err != nil {
+		resp.Error(err)
+		return
+	}
+
+	w.Header().Set("ETag", post.ETag())
+	resp.Success(post)
+}
+
+// ListRevisions lists the revisions of one of the user's posts, newest first
+func (h *PostHandler) ListRevisions(w http.ResponseWriter, r *http.Request) {
+	resp := response.NewResponseWriter(w, r)
+
+	userID, ok := GetAuthenticatedUserID(r)
+	if !ok {
+		resp.Unauthorized("Authentication required")
 		return
 	}
 
-	post, err := h.postService.UpdatePost(r.Context(), id, &req)
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
 	if err != nil {
-		WriteError(w, http.StatusNotFound, err.Error())
+		resp.BadRequest("Invalid post ID")
 		return
 	}
 
-	WriteSuccess(w, post)
+	pagination := ParsePaginationParams(r)
+
+	result, err := h.postService.ListRevisions(r.Context(), userID, id, pagination)
+	if err != nil {
+		resp.Error(err)
+		return
+	}
+
+	resp.JSONWithMeta(http.StatusOK, result.Data, "", result.Pagination)
+}
+
+// DiffRevisions returns a unified diff between the revisions given by the
+// from and to query parameters, which default to the previous and the current
+// version
+func (h *PostHandler) DiffRevisions(w http.ResponseWriter, r *http.Request) {
+	resp := response.NewResponseWriter(w, r)
+
+	userID, ok := GetAuthenticatedUserID(r)
+	if !ok {
+		resp.Unauthorized("Authentication required")
+		return
+	}
+
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		resp.BadRequest("Invalid post ID")
+		return
+	}
+
+	var versions [2]int
+	for i, name := range []string{"from", "to"} {
+		value := r.URL.Query().Get(name)
+		if value == "" {
+			continue
+		}
+		if versions[i], err = strconv.Atoi(value); err != nil || versions[i] < 1 {
+			resp.BadRequest("Invalid " + name + " revision")
+			return
+		}
+	}
+
+	diff, err := h.postService.DiffRevisions(r.Context(), userID, id, versions[0], versions[1])
+	if err != nil {
+		resp.Error(err)
+		return
+	}
+
+	resp.Success(diff)
+}
+
+// RestoreRevision makes an earlier revision of the post current again
+func (h *PostHandler) RestoreRevision(w http.ResponseWriter, r *http.Request) {
+	resp := response.NewResponseWriter(w, r)
+
+	userID, ok := GetAuthenticatedUserID(r)
+	if !ok {
+		resp.Unauthorized("Authentication required")
+		return
+	}
+
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		resp.BadRequest("Invalid post ID")
+		return
+	}
+
+	version, err := strconv.Atoi(chi.URLParam(r, "rev"))
+	if err != nil || version < 1 {
+		resp.BadRequest("Invalid revision")
+		return
+	}
+
+	post, err := h.postService.RestoreRevision(r.Context(), userID, id, version)
+	if err != nil {
+		resp.Error(err)
+		return
+	}
+
+	w.Header().Set("ETag", post.ETag())
+	resp.Success(post)
 }
 
 func (h *PostHandler) DeletePost(w http.ResponseWriter, r *http.Request) {
+	resp := response.NewResponseWriter(w, r)
+
+	userID, ok := GetAuthenticatedUserID(r)
+	if !ok {
+		resp.Unauthorized("Authentication required")
+		return
+	}
+
+	idStr := chi.URLParam(r, "id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		resp.BadRequest("Invalid post ID")
+		return
+	}
+
+	if err := h.postService.DeletePost(r.Context(), userID, id, ParseIfMatch(r, id)); err != nil {
+		resp.Error(err)
+		return
+	}
+
+	resp.JSONWithMessage(http.StatusOK, nil, "Post deleted successfully")
+}
+
+// ListDeletedPosts lists the authenticated user's posts in the trash
+func (h *PostHandler) ListDeletedPosts(w http.ResponseWriter, r *http.Request) {
+	resp := response.NewResponseWriter(w, r)
+
+	userID, ok := GetAuthenticatedUserID(r)
+	if !ok {
+		resp.Unauthorized("Authentication required")
+		return
+	}
+
+	pagination := ParsePaginationParams(r)
+
+	result, err := h.postService.ListDeletedPosts(r.Context(), userID, pagination)
+	if err != nil {
+		resp.Error(err)
+		return
+	}
+
+	resp.JSONWithMeta(http.StatusOK, result.Data, "", result.Pagination)
+}
+
+// RestorePost takes one of the authenticated user's posts out of the trash
+func (h *PostHandler) RestorePost(w http.ResponseWriter, r *http.Request) {
+	resp := response.NewResponseWriter(w, r)
+
+	userID, ok := GetAuthenticatedUserID(r)
+	if !ok {
+		resp.Unauthorized("Authentication required")
+		return
+	}
+
+	idStr := chi.URLParam(r, "id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		resp.BadRequest("Invalid post ID")
+		return
+	}
+
+	post, err := h.postService.RestorePost(r.Context(), userID, id)
+	if err != nil {
+		resp.Error(err)
+		return
+	}
+
+	w.Header().Set("ETag", post.ETag())
+	resp.Success(post)
+}
+
+// RestoreAnyPost takes a post out of the trash regardless of its author. The
+// route must be protected by RequirePermission(models.PermissionDeleteAnyPost).
+func (h *PostHandler) RestoreAnyPost(w http.ResponseWriter, r *http.Request) {
+	resp := response.NewResponseWriter(w, r)
+
+	idStr := chi.URLParam(r, "id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		resp.BadRequest("Invalid post ID")
+		return
+	}
+
+	post, err := h.postService.RestoreAnyPost(r.Context(), id)
+	if err != nil {
+		resp.Error(err)
+		return
+	}
+
+	w.Header().Set("ETag", post.ETag())
+	resp.Success(post)
+}
+
+// DeleteAnyPost deletes a post regardless of its author. The route must be
+// protected by RequirePermission(models.PermissionDeleteAnyPost).
+func (h *PostHandler) DeleteAnyPost(w http.ResponseWriter, r *http.Request) {
+	resp := response.NewResponseWriter(w, r)
+
 	idStr := chi.URLParam(r, "id")
 	id, err := uuid.Parse(idStr)
 	if err != nil {
-		WriteError(w, http.StatusBadRequest, "Invalid post ID")
+		resp.BadRequest("Invalid post ID")
+		return
+	}
+
+	if err := h.postService.DeleteAnyPost(r.Context(), id); err != nil {
+		resp.Error(err)
+		return
+	}
+
+	resp.JSONWithMessage(http.StatusOK, nil, "Post deleted successfully")
+}
+// AddReaction adds the authenticated user's reaction of the kind in the URL.
+// Repeating the request leaves the single reaction in place.
+func (h *PostHandler) AddReaction(w http.ResponseWriter, r *http.Request) {
+	h.updateReaction(w, r, h.postService.AddReaction)
+}
+
+// RemoveReaction removes the authenticated user's reaction of the kind in the
+// URL, if present
+func (h *PostHandler) RemoveReaction(w http.ResponseWriter, r *http.Request) {
+	h.updateReaction(w, r, h.postService.RemoveReaction)
+}
+
+func (h *PostHandler) updateReaction(w http.ResponseWriter, r *http.Request, update func(ctx context.Context, userID, postID uuid.UUID, kind models.ReactionKind) (*models.Post, error)) {
+	resp := response.NewResponseWriter(w, r)
+
+	userID, ok := GetAuthenticatedUserID(r)
+	if !ok {
+		resp.Unauthorized("Authentication required")
+		return
+	}
+
+	postID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		resp.BadRequest("Invalid post ID")
 		return
 	}
 
-	if err := h.postService.DeletePost(r.Context(), id); err != nil {
-		WriteError(w, http.StatusNotFound, err.Error())
+	post, err := update(r.Context(), userID, postID, models.ReactionKind(chi.URLParam(r, "kind")))
+	if err != nil {
+		resp.Error(err)
 		return
 	}
 
-	WriteMessage(w, "Post deleted successfully")
-}
\ No newline at end of file
+	resp.Success(post)
+}
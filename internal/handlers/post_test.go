@@ -8,9 +8,14 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/alinoer/go-std-api/internal/errors"
+	"github.com/alinoer/go-std-api/internal/middleware"
 	"github.com/alinoer/go-std-api/internal/models"
+	"github.com/alinoer/go-std-api/internal/response"
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
 )
@@ -24,14 +29,30 @@ type MockPostService struct {
 	getPostsByUserPaginatedError  error
 	updatePostError               error
 	deletePostError               error
+	deleteAnyPostError            error
 	createdPost                   *models.Post
 	retrievedPost                 *models.Post
 	posts                         []*models.Post
 	paginatedResponse             *models.PaginatedResponse
 	updatedPost                   *models.Post
+	createdForUserID              uuid.UUID
+	cursorParams                  *models.CursorParams
+	listOptions                   *models.ListOptions
+	reactionError                 error
+	reactionKind                  models.ReactionKind
+	tags                          []*models.Tag
+	revisionError                 error
+	diffFrom                      int
+	diffTo                        int
+	restoredVersion               int
+	ifMatch                       *models.Precondition
+	restorePostError              error
+	replaceRequest                *models.ReplacePostRequest
+	patch                         *models.PostPatch
 }
 
 func (m *MockPostService) CreatePost(ctx context.Context, userID uuid.UUID, req *models.CreatePostRequest) (*models.Post, error) {
+	m.createdForUserID = userID
 	if m.createPostError != nil {
 		return nil, m.createPostError
 	}
@@ -45,14 +66,16 @@ func (m *MockPostService) GetPost(ctx context.Context, id uuid.UUID) (*models.Po
 	return m.retrievedPost, nil
 }
 
-func (m *MockPostService) ListPosts(ctx context.Context) ([]*models.Post, error) {
+func (m *MockPostService) ListPosts(ctx context.Context, opts *models.ListOptions) ([]*models.Post, error) {
+	m.listOptions = opts
 	if m.listPostsError != nil {
 		return nil, m.listPostsError
 	}
 	return m.posts, nil
 }
 
-func (m *MockPostService) ListPostsPaginated(ctx context.Context, pagination *models.PaginationParams) (*models.PaginatedResponse, error) {
+func (m *MockPostService) ListPostsPaginated(ctx context.Context, pagination *models.PaginationParams, opts *models.ListOptions) (*models.PaginatedResponse, error) {
+	m.listOptions = opts
 	if m.listPostsPaginatedError != nil {
 		return nil, m.listPostsPaginatedError
 	}
@@ -73,53 +96,158 @@ func (m *MockPostService) GetPostsByUserPaginated(ctx context.Context, userID uu
 	return m.paginatedResponse, nil
 }
 
-func (m *MockPostService) UpdatePost(ctx context.Context, id uuid.UUID, req *models.UpdatePostRequest) (*models.Post, error) {
+func (m *MockPostService) ListPostsByCursor(ctx context.Context, params *models.CursorParams, opts *models.ListOptions) (*models.PaginatedResponse, error) {
+	m.cursorParams = params
+	m.listOptions = opts
+	if m.listPostsPaginatedError != nil {
+		return nil, m.listPostsPaginatedError
+	}
+	return m.paginatedResponse, nil
+}
+
+func (m *MockPostService) GetPostsByUserByCursor(ctx context.Context, userID uuid.UUID, params *models.CursorParams) (*models.PaginatedResponse, error) {
+	m.cursorParams = params
+	if m.getPostsByUserPaginatedError != nil {
+		return nil, m.getPostsByUserPaginatedError
+	}
+	return m.paginatedResponse, nil
+}
+
+func (m *MockPostService) SearchPosts(ctx context.Context, query string, pagination *models.PaginationParams) (*models.PaginatedResponse, error) {
+	if m.listPostsPaginatedError != nil {
+		return nil, m.listPostsPaginatedError
+	}
+	return m.paginatedResponse, nil
+}
+
+func (m *MockPostService) UpdatePost(ctx context.Context, userID, id uuid.UUID, req *models.UpdatePostRequest, ifMatch *models.Precondition) (*models.Post, error) {
+	m.ifMatch = ifMatch
+	if m.updatePostError != nil {
+		return nil, m.updatePostError
+	}
+	return m.updatedPost, nil
+}
+
+func (m *MockPostService) ReplacePost(ctx context.Context, userID, id uuid.UUID, req *models.ReplacePostRequest, ifMatch *models.Precondition) (*models.Post, error) {
+	m.replaceRequest = req
+	m.ifMatch = ifMatch
+	if m.updatePostError != nil {
+		return nil, m.updatePostError
+	}
+	return m.updatedPost, nil
+}
+
+func (m *MockPostService) PatchPost(ctx context.Context, userID, id uuid.UUID, patch *models.PostPatch, ifMatch *models.Precondition) (*models.Post, error) {
+	m.patch = patch
+	m.ifMatch = ifMatch
 	if m.updatePostError != nil {
 		return nil, m.updatePostError
 	}
 	return m.updatedPost, nil
 }
 
-func (m *MockPostService) DeletePost(ctx context.Context, id uuid.UUID) error {
+func (m *MockPostService) DeletePost(ctx context.Context, userID, id uuid.UUID, ifMatch *models.Precondition) error {
+	m.ifMatch = ifMatch
 	return m.deletePostError
 }
 
-type MockPostUserService struct {
-	listUsersError error
-	users          []*models.User
+func (m *MockPostService) DeleteAnyPost(ctx context.Context, id uuid.UUID) error {
+	return m.deleteAnyPostError
 }
 
-func (m *MockPostUserService) ListUsers(ctx context.Context) ([]*models.User, error) {
-	if m.listUsersError != nil {
-		return nil, m.listUsersError
+func (m *MockPostService) ListDeletedPosts(ctx context.Context, userID uuid.UUID, pagination *models.PaginationParams) (*models.PaginatedResponse, error) {
+	if m.listPostsPaginatedError != nil {
+		return nil, m.listPostsPaginatedError
 	}
-	return m.users, nil
+	return m.paginatedResponse, nil
 }
 
-func (m *MockPostUserService) CreateUser(ctx context.Context, req *models.CreateUserRequest) (*models.User, error) {
-	return nil, nil
+func (m *MockPostService) RestorePost(ctx context.Context, userID, id uuid.UUID) (*models.Post, error) {
+	if m.restorePostError != nil {
+		return nil, m.restorePostError
+	}
+	return m.retrievedPost, nil
 }
 
-func (m *MockPostUserService) GetUser(ctx context.Context, id uuid.UUID) (*models.User, error) {
-	return nil, nil
+func (m *MockPostService) RestoreAnyPost(ctx context.Context, id uuid.UUID) (*models.Post, error) {
+	if m.restorePostError != nil {
+		return nil, m.restorePostError
+	}
+	return m.retrievedPost, nil
 }
 
-func (m *MockPostUserService) ListUsersPaginated(ctx context.Context, pagination *models.PaginationParams) (*models.PaginatedResponse, error) {
-	return nil, nil
+func (m *MockPostService) AddReaction(ctx context.Context, userID, postID uuid.UUID, kind models.ReactionKind) (*models.Post, error) {
+	m.reactionKind = kind
+	if m.reactionError != nil {
+		return nil, m.reactionError
+	}
+	return &models.Post{ID: postID, ReactionCount: 1, Reactions: map[models.ReactionKind]int64{kind: 1}, MyReactions: []models.ReactionKind{kind}}, nil
+}
+
+func (m *MockPostService) RemoveReaction(ctx context.Context, userID, postID uuid.UUID, kind models.ReactionKind) (*models.Post, error) {
+	m.reactionKind = kind
+	if m.reactionError != nil {
+		return nil, m.reactionError
+	}
+	return &models.Post{ID: postID, Reactions: map[models.ReactionKind]int64{}, MyReactions: []models.ReactionKind{}}, nil
 }
 
-func (m *MockPostUserService) ValidateCredentials(ctx context.Context, username, password string) (*models.User, error) {
-	return nil, nil
+func (m *MockPostService) ListTags(ctx context.Context) ([]*models.Tag, error) {
+	if m.listPostsError != nil {
+		return nil, m.listPostsError
+	}
+	return m.tags, nil
 }
 
-func (m *MockPostUserService) GetUserByUsername(ctx context.Context, username string) (*models.User, error) {
-	return nil, nil
+func (m *MockPostService) ListRevisions(ctx context.Context, userID, postID uuid.UUID, pagination *models.PaginationParams) (*models.PaginatedResponse, error) {
+	if m.revisionError != nil {
+		return nil, m.revisionError
+	}
+	return &models.PaginatedResponse{
+		Data:       []*models.PostRevision{{PostID: postID, Version: 2}, {PostID: postID, Version: 1}},
+		Pagination: models.NewPaginationMeta(pagination.Page, pagination.PageSize, 2),
+	}, nil
+}
+
+func (m *MockPostService) DiffRevisions(ctx context.Context, userID, postID uuid.UUID, from, to int) (*models.PostRevisionDiff, error) {
+	m.diffFrom, m.diffTo = from, to
+	if m.revisionError != nil {
+		return nil, m.revisionError
+	}
+	return &models.PostRevisionDiff{PostID: postID, From: from, To: to}, nil
+}
+
+func (m *MockPostService) RestoreRevision(ctx context.Context, userID, postID uuid.UUID, version int) (*models.Post, error) {
+	m.restoredVersion = version
+	if m.revisionError != nil {
+		return nil, m.revisionError
+	}
+	return &models.Post{ID: postID, UserID: userID, Version: 3}, nil
+}
+
+// withAuthenticatedUser mimics JWTAuthMiddleware by storing userID in the request context
+func withAuthenticatedUser(req *http.Request, userID uuid.UUID) *http.Request {
+	ctx := context.WithValue(req.Context(), middleware.UserIDKey, userID.String())
+	return req.WithContext(ctx)
+}
+
+// decodeResponseData decodes the data field of a response envelope into v
+func decodeResponseData(t *testing.T, w *httptest.ResponseRecorder, v interface{}) {
+	t.Helper()
+	var envelope struct {
+		Data json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &envelope); err != nil {
+		t.Fatalf("failed to unmarshal response envelope: %v", err)
+	}
+	if err := json.Unmarshal(envelope.Data, v); err != nil {
+		t.Fatalf("failed to unmarshal response data: %v", err)
+	}
 }
 
 func TestNewPostHandler(t *testing.T) {
 	mockPostService := &MockPostService{}
-	mockUserService := &MockPostUserService{}
-	handler := NewPostHandler(mockPostService, mockUserService)
+	handler := NewPostHandler(mockPostService)
 
 	if handler == nil {
 		t.Error("expected non-nil post handler")
@@ -127,16 +255,16 @@ func TestNewPostHandler(t *testing.T) {
 	if handler.postService == nil {
 		t.Error("expected non-nil post service")
 	}
-	if handler.userService == nil {
-		t.Error("expected non-nil user service")
-	}
 }
 
 func TestPostHandler_CreatePost(t *testing.T) {
+	authorID := uuid.New()
+
 	tests := []struct {
 		name               string
 		requestBody        interface{}
-		setupMocks         func(*MockPostService, *MockPostUserService)
+		authenticated      bool
+		setupMocks         func(*MockPostService)
 		expectedStatusCode int
 		expectedError      string
 	}{
@@ -146,12 +274,11 @@ func TestPostHandler_CreatePost(t *testing.T) {
 				Title:   "Test Post",
 				Content: "This is a test post",
 			},
-			setupMocks: func(postMock *MockPostService, userMock *MockPostUserService) {
-				userMock.users = []*models.User{
-					{ID: uuid.New(), Username: "testuser"},
-				}
+			authenticated: true,
+			setupMocks: func(postMock *MockPostService) {
 				postMock.createdPost = &models.Post{
 					ID:      uuid.New(),
+					UserID:  authorID,
 					Title:   "Test Post",
 					Content: "This is a test post",
 				}
@@ -161,33 +288,21 @@ func TestPostHandler_CreatePost(t *testing.T) {
 		{
 			name:               "invalid JSON",
 			requestBody:        "invalid json",
-			setupMocks:         func(postMock *MockPostService, userMock *MockPostUserService) {},
+			authenticated:      true,
+			setupMocks:         func(postMock *MockPostService) {},
 			expectedStatusCode: http.StatusBadRequest,
 			expectedError:      "Invalid JSON payload",
 		},
 		{
-			name: "no users found",
+			name: "unauthenticated request",
 			requestBody: models.CreatePostRequest{
 				Title:   "Test Post",
 				Content: "This is a test post",
 			},
-			setupMocks: func(postMock *MockPostService, userMock *MockPostUserService) {
-				userMock.users = []*models.User{}
-			},
-			expectedStatusCode: http.StatusBadRequest,
-			expectedError:      "No users found. Please create a user first",
-		},
-		{
-			name: "user service error",
-			requestBody: models.CreatePostRequest{
-				Title:   "Test Post",
-				Content: "This is a test post",
-			},
-			setupMocks: func(postMock *MockPostService, userMock *MockPostUserService) {
-				userMock.listUsersError = fmt.Errorf("database error")
-			},
-			expectedStatusCode: http.StatusBadRequest,
-			expectedError:      "No users found. Please create a user first",
+			authenticated:      false,
+			setupMocks:         func(postMock *MockPostService) {},
+			expectedStatusCode: http.StatusUnauthorized,
+			expectedError:      "Authentication required",
 		},
 		{
 			name: "post service error",
@@ -195,23 +310,20 @@ func TestPostHandler_CreatePost(t *testing.T) {
 				Title:   "Test Post",
 				Content: "This is a test post",
 			},
-			setupMocks: func(postMock *MockPostService, userMock *MockPostUserService) {
-				userMock.users = []*models.User{
-					{ID: uuid.New(), Username: "testuser"},
-				}
-				postMock.createPostError = fmt.Errorf("validation error")
+			authenticated: true,
+			setupMocks: func(postMock *MockPostService) {
+				postMock.createPostError = errors.ValidationError("title", "Title is required")
 			},
 			expectedStatusCode: http.StatusBadRequest,
-			expectedError:      "validation error",
+			expectedError:      "Validation failed for field 'title'",
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			mockPostService := &MockPostService{}
-			mockUserService := &MockPostUserService{}
-			tt.setupMocks(mockPostService, mockUserService)
-			handler := NewPostHandler(mockPostService, mockUserService)
+			tt.setupMocks(mockPostService)
+			handler := NewPostHandler(mockPostService)
 
 			var body []byte
 			var err error
@@ -226,6 +338,9 @@ func TestPostHandler_CreatePost(t *testing.T) {
 
 			req := httptest.NewRequest(http.MethodPost, "/posts", bytes.NewBuffer(body))
 			req.Header.Set("Content-Type", "application/json")
+			if tt.authenticated {
+				req = withAuthenticatedUser(req, authorID)
+			}
 			w := httptest.NewRecorder()
 
 			handler.CreatePost(w, req)
@@ -235,7 +350,7 @@ func TestPostHandler_CreatePost(t *testing.T) {
 			}
 
 			if tt.expectedError != "" {
-				var errorResp ErrorResponse
+				var errorResp response.ErrorResponse
 				if err := json.Unmarshal(w.Body.Bytes(), &errorResp); err != nil {
 					t.Errorf("failed to unmarshal error response: %v", err)
 				}
@@ -243,13 +358,16 @@ func TestPostHandler_CreatePost(t *testing.T) {
 					t.Errorf("expected error %q, got %q", tt.expectedError, errorResp.Error)
 				}
 			} else {
-				var response SuccessResponse
-				if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+				var successResp response.StandardResponse
+				if err := json.Unmarshal(w.Body.Bytes(), &successResp); err != nil {
 					t.Errorf("failed to unmarshal success response: %v", err)
 				}
-				if response.Data == nil {
+				if successResp.Data == nil {
 					t.Error("expected non-nil data in response")
 				}
+				if mockPostService.createdForUserID != authorID {
+					t.Errorf("expected post to be created for user %s, got %s", authorID, mockPostService.createdForUserID)
+				}
 			}
 		})
 	}
@@ -288,7 +406,7 @@ func TestPostHandler_GetPost(t *testing.T) {
 			name:   "post not found",
 			postID: validPostID.String(),
 			setupMock: func(mock *MockPostService) {
-				mock.getPostError = fmt.Errorf("post not found")
+				mock.getPostError = errors.NotFound("Post")
 			},
 			expectedStatusCode: http.StatusNotFound,
 			expectedError:      "Post not found",
@@ -298,9 +416,8 @@ func TestPostHandler_GetPost(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			mockPostService := &MockPostService{}
-			mockUserService := &MockPostUserService{}
 			tt.setupMock(mockPostService)
-			handler := NewPostHandler(mockPostService, mockUserService)
+			handler := NewPostHandler(mockPostService)
 
 			req := httptest.NewRequest(http.MethodGet, "/posts/"+tt.postID, nil)
 			w := httptest.NewRecorder()
@@ -316,7 +433,7 @@ func TestPostHandler_GetPost(t *testing.T) {
 			}
 
 			if tt.expectedError != "" {
-				var errorResp ErrorResponse
+				var errorResp response.ErrorResponse
 				if err := json.Unmarshal(w.Body.Bytes(), &errorResp); err != nil {
 					t.Errorf("failed to unmarshal error response: %v", err)
 				}
@@ -324,11 +441,11 @@ func TestPostHandler_GetPost(t *testing.T) {
 					t.Errorf("expected error %q, got %q", tt.expectedError, errorResp.Error)
 				}
 			} else {
-				var response SuccessResponse
-				if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+				var successResp response.StandardResponse
+				if err := json.Unmarshal(w.Body.Bytes(), &successResp); err != nil {
 					t.Errorf("failed to unmarshal success response: %v", err)
 				}
-				if response.Data == nil {
+				if successResp.Data == nil {
 					t.Error("expected non-nil data in response")
 				}
 			}
@@ -352,9 +469,9 @@ func TestPostHandler_UpdatePost(t *testing.T) {
 		{
 			name:   "successful update post",
 			postID: validPostID.String(),
-			requestBody: models.UpdatePostRequest{
-				Title:   &title,
-				Content: &content,
+			requestBody: models.ReplacePostRequest{
+				Title:   title,
+				Content: content,
 			},
 			setupMock: func(mock *MockPostService) {
 				mock.updatedPost = &models.Post{
@@ -368,7 +485,7 @@ func TestPostHandler_UpdatePost(t *testing.T) {
 		{
 			name:               "invalid post ID",
 			postID:             "invalid-uuid",
-			requestBody:        models.UpdatePostRequest{},
+			requestBody:        models.ReplacePostRequest{},
 			setupMock:          func(mock *MockPostService) {},
 			expectedStatusCode: http.StatusBadRequest,
 			expectedError:      "Invalid post ID",
@@ -384,23 +501,36 @@ func TestPostHandler_UpdatePost(t *testing.T) {
 		{
 			name:   "post not found",
 			postID: validPostID.String(),
-			requestBody: models.UpdatePostRequest{
-				Title: &title,
+			requestBody: models.ReplacePostRequest{
+				Title:   title,
+				Content: content,
 			},
 			setupMock: func(mock *MockPostService) {
-				mock.updatePostError = fmt.Errorf("post not found")
+				mock.updatePostError = errors.NotFound("Post")
 			},
 			expectedStatusCode: http.StatusNotFound,
-			expectedError:      "post not found",
+			expectedError:      "Post not found",
+		},
+		{
+			name:   "post owned by another user",
+			postID: validPostID.String(),
+			requestBody: models.ReplacePostRequest{
+				Title:   title,
+				Content: content,
+			},
+			setupMock: func(mock *MockPostService) {
+				mock.updatePostError = errors.Forbidden("You can only update your own posts")
+			},
+			expectedStatusCode: http.StatusForbidden,
+			expectedError:      "You can only update your own posts",
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			mockPostService := &MockPostService{}
-			mockUserService := &MockPostUserService{}
 			tt.setupMock(mockPostService)
-			handler := NewPostHandler(mockPostService, mockUserService)
+			handler := NewPostHandler(mockPostService)
 
 			var body []byte
 			var err error
@@ -415,6 +545,7 @@ func TestPostHandler_UpdatePost(t *testing.T) {
 
 			req := httptest.NewRequest(http.MethodPut, "/posts/"+tt.postID, bytes.NewBuffer(body))
 			req.Header.Set("Content-Type", "application/json")
+			req = withAuthenticatedUser(req, uuid.New())
 			w := httptest.NewRecorder()
 
 			rctx := chi.NewRouteContext()
@@ -426,9 +557,14 @@ func TestPostHandler_UpdatePost(t *testing.T) {
 			if w.Code != tt.expectedStatusCode {
 				t.Errorf("expected status code %d, got %d", tt.expectedStatusCode, w.Code)
 			}
+			if want, ok := tt.requestBody.(models.ReplacePostRequest); ok && w.Code == http.StatusOK {
+				if mockPostService.replaceRequest == nil || mockPostService.replaceRequest.Title != want.Title || mockPostService.replaceRequest.Content != want.Content {
+					t.Errorf("expected the replacement %+v, got %+v", want, mockPostService.replaceRequest)
+				}
+			}
 
 			if tt.expectedError != "" {
-				var errorResp ErrorResponse
+				var errorResp response.ErrorResponse
 				if err := json.Unmarshal(w.Body.Bytes(), &errorResp); err != nil {
 					t.Errorf("failed to unmarshal error response: %v", err)
 				}
@@ -436,11 +572,11 @@ func TestPostHandler_UpdatePost(t *testing.T) {
 					t.Errorf("expected error %q, got %q", tt.expectedError, errorResp.Error)
 				}
 			} else {
-				var response SuccessResponse
-				if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+				var successResp response.StandardResponse
+				if err := json.Unmarshal(w.Body.Bytes(), &successResp); err != nil {
 					t.Errorf("failed to unmarshal success response: %v", err)
 				}
-				if response.Data == nil {
+				if successResp.Data == nil {
 					t.Error("expected non-nil data in response")
 				}
 			}
@@ -448,6 +584,92 @@ func TestPostHandler_UpdatePost(t *testing.T) {
 	}
 }
 
+func TestPostHandler_PatchPost(t *testing.T) {
+	postID := uuid.New()
+	etag := `"` + postID.String() + `.2"`
+
+	tests := []struct {
+		name           string
+		contentType    string
+		serviceError   error
+		expectedStatus int
+		expectedFormat models.PatchFormat
+	}{
+		{
+			name:           "merge patch",
+			contentType:    "application/merge-patch+json; charset=utf-8",
+			expectedStatus: http.StatusOK,
+			expectedFormat: models.PatchFormatMergePatch,
+		},
+		{
+			name:           "json patch",
+			contentType:    "application/json-patch+json",
+			expectedStatus: http.StatusOK,
+			expectedFormat: models.PatchFormatJSONPatch,
+		},
+		{
+			name:           "plain json",
+			contentType:    "application/json",
+			expectedStatus: http.StatusUnsupportedMediaType,
+		},
+		{
+			name:           "no content type",
+			expectedStatus: http.StatusUnsupportedMediaType,
+		},
+		{
+			name:           "patch does not apply",
+			contentType:    "application/json-patch+json",
+			serviceError:   errors.NewAppError(errors.ErrCodeConflict, "JSON patch test failed for '/title'"),
+			expectedStatus: http.StatusConflict,
+			expectedFormat: models.PatchFormatJSONPatch,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := &MockPostService{
+				updatedPost:     &models.Post{ID: postID, Title: "Patched", Version: 2},
+				updatePostError: tt.serviceError,
+			}
+			body := `{"title":"Patched"}`
+			req := httptest.NewRequest(http.MethodPatch, "/posts/"+postID.String(), bytes.NewBufferString(body))
+			if tt.contentType != "" {
+				req.Header.Set("Content-Type", tt.contentType)
+			}
+			req.Header.Set("If-Match", `"`+postID.String()+`.1"`)
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("id", postID.String())
+			req = withAuthenticatedUser(req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx)), uuid.New())
+			w := httptest.NewRecorder()
+
+			NewPostHandler(mockService).PatchPost(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+			switch {
+			case tt.expectedStatus == http.StatusUnsupportedMediaType:
+				if mockService.patch != nil {
+					t.Error("expected the patch not to be applied")
+				}
+				if accept := w.Header().Get("Accept-Patch"); accept != "application/merge-patch+json, application/json-patch+json" {
+					t.Errorf("expected the supported formats in Accept-Patch, got %q", accept)
+				}
+			default:
+				if mockService.patch.Format != tt.expectedFormat || string(mockService.patch.Document) != body {
+					t.Errorf("expected a %s patch with the request body, got %+v", tt.expectedFormat, mockService.patch)
+				}
+				if !mockService.ifMatch.Allows(1) || mockService.ifMatch.Allows(2) {
+					t.Errorf("expected a precondition on version 1, got %+v", mockService.ifMatch)
+				}
+			}
+			if tt.expectedStatus == http.StatusOK && w.Header().Get("ETag") != etag {
+				t.Errorf("expected ETag %s, got %q", etag, w.Header().Get("ETag"))
+			}
+		})
+	}
+}
+
 func TestPostHandler_DeletePost(t *testing.T) {
 	validPostID := uuid.New()
 
@@ -475,21 +697,30 @@ func TestPostHandler_DeletePost(t *testing.T) {
 			name:   "post not found",
 			postID: validPostID.String(),
 			setupMock: func(mock *MockPostService) {
-				mock.deletePostError = fmt.Errorf("post not found")
+				mock.deletePostError = errors.NotFound("Post")
 			},
 			expectedStatusCode: http.StatusNotFound,
-			expectedError:      "post not found",
+			expectedError:      "Post not found",
+		},
+		{
+			name:   "post owned by another user",
+			postID: validPostID.String(),
+			setupMock: func(mock *MockPostService) {
+				mock.deletePostError = errors.Forbidden("You can only delete your own posts")
+			},
+			expectedStatusCode: http.StatusForbidden,
+			expectedError:      "You can only delete your own posts",
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			mockPostService := &MockPostService{}
-			mockUserService := &MockPostUserService{}
 			tt.setupMock(mockPostService)
-			handler := NewPostHandler(mockPostService, mockUserService)
+			handler := NewPostHandler(mockPostService)
 
 			req := httptest.NewRequest(http.MethodDelete, "/posts/"+tt.postID, nil)
+			req = withAuthenticatedUser(req, uuid.New())
 			w := httptest.NewRecorder()
 
 			rctx := chi.NewRouteContext()
@@ -503,7 +734,7 @@ func TestPostHandler_DeletePost(t *testing.T) {
 			}
 
 			if tt.expectedError != "" {
-				var errorResp ErrorResponse
+				var errorResp response.ErrorResponse
 				if err := json.Unmarshal(w.Body.Bytes(), &errorResp); err != nil {
 					t.Errorf("failed to unmarshal error response: %v", err)
 				}
@@ -511,11 +742,11 @@ func TestPostHandler_DeletePost(t *testing.T) {
 					t.Errorf("expected error %q, got %q", tt.expectedError, errorResp.Error)
 				}
 			} else {
-				var response SuccessResponse
-				if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+				var successResp response.StandardResponse
+				if err := json.Unmarshal(w.Body.Bytes(), &successResp); err != nil {
 					t.Errorf("failed to unmarshal success response: %v", err)
 				}
-				if response.Message == "" {
+				if successResp.Message == "" {
 					t.Error("expected non-empty message in response")
 				}
 			}
@@ -559,16 +790,39 @@ func TestPostHandler_GetPostsByUser(t *testing.T) {
 					Data: []*models.Post{
 						{ID: uuid.New(), Title: "Post 1"},
 					},
-					Pagination: &models.PaginationMeta{
-						Page:     1,
-						PageSize: 10,
-						Total:    1,
+					Pagination: models.NewPaginationMeta(1, 10, 1),
+				}
+			},
+			expectedStatusCode: http.StatusOK,
+			expectPaginated:    true,
+		},
+		{
+			name:   "successful get posts by user with cursor",
+			userID: validUserID.String(),
+			queryParams: map[string]string{
+				"limit": "5",
+			},
+			setupMock: func(mock *MockPostService) {
+				mock.paginatedResponse = &models.PaginatedResponse{
+					Data: []*models.Post{
+						{ID: uuid.New(), Title: "Post 1"},
 					},
+					Pagination: &models.PaginationMeta{Limit: 5},
 				}
 			},
 			expectedStatusCode: http.StatusOK,
 			expectPaginated:    true,
 		},
+		{
+			name:   "invalid cursor",
+			userID: validUserID.String(),
+			queryParams: map[string]string{
+				"cursor": "not-a-cursor",
+			},
+			setupMock:          func(mock *MockPostService) {},
+			expectedStatusCode: http.StatusBadRequest,
+			expectedError:      "Validation failed for field 'cursor'",
+		},
 		{
 			name:               "invalid user ID",
 			userID:             "invalid-uuid",
@@ -580,19 +834,18 @@ func TestPostHandler_GetPostsByUser(t *testing.T) {
 			name:   "service error",
 			userID: validUserID.String(),
 			setupMock: func(mock *MockPostService) {
-				mock.getPostsByUserError = fmt.Errorf("database error")
+				mock.getPostsByUserError = errors.DatabaseError("list posts", fmt.Errorf("connection refused"))
 			},
 			expectedStatusCode: http.StatusInternalServerError,
-			expectedError:      "Failed to retrieve posts",
+			expectedError:      "Database operation failed: list posts",
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			mockPostService := &MockPostService{}
-			mockUserService := &MockPostUserService{}
 			tt.setupMock(mockPostService)
-			handler := NewPostHandler(mockPostService, mockUserService)
+			handler := NewPostHandler(mockPostService)
 
 			reqURL := "/users/" + tt.userID + "/posts"
 			if len(tt.queryParams) > 0 {
@@ -617,7 +870,7 @@ func TestPostHandler_GetPostsByUser(t *testing.T) {
 			}
 
 			if tt.expectedError != "" {
-				var errorResp ErrorResponse
+				var errorResp response.ErrorResponse
 				if err := json.Unmarshal(w.Body.Bytes(), &errorResp); err != nil {
 					t.Errorf("failed to unmarshal error response: %v", err)
 				}
@@ -626,26 +879,741 @@ func TestPostHandler_GetPostsByUser(t *testing.T) {
 				}
 			} else {
 				if tt.expectPaginated {
-					var response models.PaginatedResponse
-					if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+					var successResp response.StandardResponse
+					if err := json.Unmarshal(w.Body.Bytes(), &successResp); err != nil {
 						t.Errorf("failed to unmarshal paginated response: %v", err)
 					}
-					if response.Data == nil {
+					if successResp.Data == nil {
 						t.Error("expected non-nil data in response")
 					}
-					if response.Pagination == nil {
+					if successResp.Meta == nil {
 						t.Error("expected non-nil pagination in response")
 					}
 				} else {
-					var response SuccessResponse
-					if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+					var successResp response.StandardResponse
+					if err := json.Unmarshal(w.Body.Bytes(), &successResp); err != nil {
 						t.Errorf("failed to unmarshal success response: %v", err)
 					}
-					if response.Data == nil {
+					if successResp.Data == nil {
 						t.Error("expected non-nil data in response")
 					}
 				}
 			}
 		})
 	}
-}
\ No newline at end of file
+}
+func TestPostHandler_DeleteAnyPost(t *testing.T) {
+	validPostID := uuid.New()
+
+	tests := []struct {
+		name               string
+		postID             string
+		setupMock          func(*MockPostService)
+		expectedStatusCode int
+		expectedError      string
+	}{
+		{
+			name:               "successful delete",
+			postID:             validPostID.String(),
+			setupMock:          func(mock *MockPostService) {},
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:               "invalid post ID",
+			postID:             "invalid-uuid",
+			setupMock:          func(mock *MockPostService) {},
+			expectedStatusCode: http.StatusBadRequest,
+			expectedError:      "Invalid post ID",
+		},
+		{
+			name:   "post not found",
+			postID: validPostID.String(),
+			setupMock: func(mock *MockPostService) {
+				mock.deleteAnyPostError = errors.NotFound("Post")
+			},
+			expectedStatusCode: http.StatusNotFound,
+			expectedError:      "Post not found",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockPostService := &MockPostService{}
+			tt.setupMock(mockPostService)
+			handler := NewPostHandler(mockPostService)
+
+			req := httptest.NewRequest(http.MethodDelete, "/admin/posts/"+tt.postID, nil)
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("id", tt.postID)
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+			w := httptest.NewRecorder()
+
+			handler.DeleteAnyPost(w, req)
+
+			if w.Code != tt.expectedStatusCode {
+				t.Errorf("expected status code %d, got %d", tt.expectedStatusCode, w.Code)
+			}
+
+			if tt.expectedError != "" {
+				var errorResp response.ErrorResponse
+				if err := json.Unmarshal(w.Body.Bytes(), &errorResp); err != nil {
+					t.Errorf("failed to unmarshal error response: %v", err)
+				}
+				if errorResp.Error != tt.expectedError {
+					t.Errorf("expected error %q, got %q", tt.expectedError, errorResp.Error)
+				}
+			}
+		})
+	}
+}
+
+func TestPostHandler_SearchPosts(t *testing.T) {
+	tests := []struct {
+		name               string
+		query              string
+		setupMock          func(*MockPostService)
+		expectedStatusCode int
+		expectedCode       string
+	}{
+		{
+			name:  "successful search",
+			query: "q=golang&page=1",
+			setupMock: func(mock *MockPostService) {
+				mock.paginatedResponse = &models.PaginatedResponse{
+					Data: []*models.PostSearchResult{
+						{Post: models.Post{ID: uuid.New(), Title: "Golang"}, Rank: 0.5, TitleHeadline: "<mark>Golang</mark>"},
+					},
+					Pagination: models.NewPaginationMeta(1, 10, 1),
+				}
+			},
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:  "missing query",
+			query: "",
+			setupMock: func(mock *MockPostService) {
+				mock.listPostsPaginatedError = errors.ValidationError("q", "Search query is required")
+			},
+			expectedStatusCode: http.StatusBadRequest,
+			expectedCode:       string(errors.ErrCodeValidation),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := &MockPostService{}
+			tt.setupMock(mockService)
+			handler := NewPostHandler(mockService)
+
+			req := httptest.NewRequest(http.MethodGet, "/posts/search?"+tt.query, nil)
+			w := httptest.NewRecorder()
+
+			handler.SearchPosts(w, req)
+
+			if w.Code != tt.expectedStatusCode {
+				t.Fatalf("expected status code %d, got %d: %s", tt.expectedStatusCode, w.Code, w.Body.String())
+			}
+
+			if tt.expectedCode != "" {
+				var errorResp response.ErrorResponse
+				if err := json.Unmarshal(w.Body.Bytes(), &errorResp); err != nil {
+					t.Fatalf("failed to unmarshal error response: %v", err)
+				}
+				if errorResp.Code != tt.expectedCode {
+					t.Errorf("expected code %q, got %q", tt.expectedCode, errorResp.Code)
+				}
+				return
+			}
+
+			var successResp response.StandardResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &successResp); err != nil {
+				t.Fatalf("failed to unmarshal response: %v", err)
+			}
+			if successResp.Meta == nil {
+				t.Error("expected non-nil pagination in response")
+			}
+		})
+	}
+}
+
+func TestPostHandler_ListPosts(t *testing.T) {
+	tests := []struct {
+		name               string
+		query              string
+		expectedStatusCode int
+		expectedFilters    int
+		expectedSort       int
+		expectedTags       []string
+	}{
+		{
+			name:               "no options",
+			query:              "",
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:               "filters and sort",
+			query:              "title_contains=go&created_after=2024-01-01&sort=-created_at,title",
+			expectedStatusCode: http.StatusOK,
+			expectedFilters:    2,
+			expectedSort:       2,
+		},
+		{
+			name:               "paginated with filters",
+			query:              "page=1&user_id=" + uuid.New().String(),
+			expectedStatusCode: http.StatusOK,
+			expectedFilters:    1,
+		},
+		{
+			name:               "tags",
+			query:              "tag=go&tag=api&tag=",
+			expectedStatusCode: http.StatusOK,
+			expectedTags:       []string{"go", "api"},
+		},
+		{
+			name:               "tags with cursor pagination",
+			query:              "limit=5&tag=go",
+			expectedStatusCode: http.StatusOK,
+			expectedTags:       []string{"go"},
+		},
+		{
+			name:               "unknown filter",
+			query:              "content_contains=go",
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:               "unknown sort field",
+			query:              "page=1&sort=password_hash",
+			expectedStatusCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := &MockPostService{
+				posts:             []*models.Post{},
+				paginatedResponse: &models.PaginatedResponse{Data: []*models.Post{}, Pagination: models.NewPaginationMeta(1, 10, 0)},
+			}
+			handler := NewPostHandler(mockService)
+
+			req := httptest.NewRequest(http.MethodGet, "/posts?"+tt.query, nil)
+			w := httptest.NewRecorder()
+
+			handler.ListPosts(w, req)
+
+			if w.Code != tt.expectedStatusCode {
+				t.Fatalf("expected status code %d, got %d: %s", tt.expectedStatusCode, w.Code, w.Body.String())
+			}
+			if tt.expectedStatusCode != http.StatusOK {
+				var errorResp response.ErrorResponse
+				if err := json.Unmarshal(w.Body.Bytes(), &errorResp); err != nil {
+					t.Fatalf("failed to unmarshal error response: %v", err)
+				}
+				if errorResp.Code != string(errors.ErrCodeValidation) {
+					t.Errorf("expected code %q, got %q", errors.ErrCodeValidation, errorResp.Code)
+				}
+				return
+			}
+
+			if mockService.listOptions == nil {
+				t.Fatal("expected list options to be passed to the service")
+			}
+			if len(mockService.listOptions.Filters) != tt.expectedFilters {
+				t.Errorf("expected %d filters, got %d", tt.expectedFilters, len(mockService.listOptions.Filters))
+			}
+			if len(mockService.listOptions.Sort) != tt.expectedSort {
+				t.Errorf("expected %d sort fields, got %d", tt.expectedSort, len(mockService.listOptions.Sort))
+			}
+			if strings.Join(mockService.listOptions.Tags, ",") != strings.Join(tt.expectedTags, ",") {
+				t.Errorf("expected tags %v, got %v", tt.expectedTags, mockService.listOptions.Tags)
+			}
+		})
+	}
+}
+
+func TestPostHandler_ListTags(t *testing.T) {
+	tests := []struct {
+		name               string
+		mockService        *MockPostService
+		expectedStatusCode int
+		expectedTags       int
+	}{
+		{
+			name: "tags with counts",
+			mockService: &MockPostService{tags: []*models.Tag{
+				{Name: "go", PostCount: 3},
+				{Name: "api", PostCount: 1},
+			}},
+			expectedStatusCode: http.StatusOK,
+			expectedTags:       2,
+		},
+		{
+			name:               "service error",
+			mockService:        &MockPostService{listPostsError: errors.DatabaseError("list tags", fmt.Errorf("connection refused"))},
+			expectedStatusCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := NewPostHandler(tt.mockService)
+
+			req := httptest.NewRequest(http.MethodGet, "/tags", nil)
+			w := httptest.NewRecorder()
+
+			handler.ListTags(w, req)
+
+			if w.Code != tt.expectedStatusCode {
+				t.Fatalf("expected status code %d, got %d: %s", tt.expectedStatusCode, w.Code, w.Body.String())
+			}
+			if tt.expectedStatusCode != http.StatusOK {
+				return
+			}
+
+			var successResp struct {
+				Data []*models.Tag `json:"data"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &successResp); err != nil {
+				t.Fatalf("failed to unmarshal response: %v", err)
+			}
+			if len(successResp.Data) != tt.expectedTags {
+				t.Errorf("expected %d tags, got %d", tt.expectedTags, len(successResp.Data))
+			}
+		})
+	}
+}
+
+func TestPostHandler_Reactions(t *testing.T) {
+	userID := uuid.New()
+	postID := uuid.New()
+
+	tests := []struct {
+		name               string
+		method             string
+		postID             string
+		kind               string
+		authenticated      bool
+		reactionError      error
+		expectedStatusCode int
+	}{
+		{
+			name:               "add reaction",
+			method:             http.MethodPut,
+			postID:             postID.String(),
+			kind:               "like",
+			authenticated:      true,
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:               "remove reaction",
+			method:             http.MethodDelete,
+			postID:             postID.String(),
+			kind:               "like",
+			authenticated:      true,
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:               "unknown kind",
+			method:             http.MethodPut,
+			postID:             postID.String(),
+			kind:               "dislike",
+			authenticated:      true,
+			reactionError:      errors.ValidationError("kind", "Reaction must be one of like, love, laugh, wow, sad"),
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:               "post not found",
+			method:             http.MethodPut,
+			postID:             postID.String(),
+			kind:               "like",
+			authenticated:      true,
+			reactionError:      errors.NotFound("Post"),
+			expectedStatusCode: http.StatusNotFound,
+		},
+		{
+			name:               "invalid post ID",
+			method:             http.MethodPut,
+			postID:             "invalid-uuid",
+			kind:               "like",
+			authenticated:      true,
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:               "not authenticated",
+			method:             http.MethodPut,
+			postID:             postID.String(),
+			kind:               "like",
+			expectedStatusCode: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := &MockPostService{reactionError: tt.reactionError}
+			handler := NewPostHandler(mockService)
+
+			req := httptest.NewRequest(tt.method, "/posts/"+tt.postID+"/reactions/"+tt.kind, nil)
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("id", tt.postID)
+			rctx.URLParams.Add("kind", tt.kind)
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+			if tt.authenticated {
+				req = withAuthenticatedUser(req, userID)
+			}
+			w := httptest.NewRecorder()
+
+			if tt.method == http.MethodPut {
+				handler.AddReaction(w, req)
+			} else {
+				handler.RemoveReaction(w, req)
+			}
+
+			if w.Code != tt.expectedStatusCode {
+				t.Fatalf("expected status code %d, got %d: %s", tt.expectedStatusCode, w.Code, w.Body.String())
+			}
+			if tt.expectedStatusCode == http.StatusOK && mockService.reactionKind != models.ReactionKind(tt.kind) {
+				t.Errorf("expected kind %q, got %q", tt.kind, mockService.reactionKind)
+			}
+		})
+	}
+}
+
+func TestPostHandler_Revisions(t *testing.T) {
+	userID := uuid.New()
+	postID := uuid.New()
+
+	tests := []struct {
+		name            string
+		handler         func(*PostHandler) http.HandlerFunc
+		target          string
+		rev             string
+		authenticated   bool
+		mockService     *MockPostService
+		expectedStatus  int
+		expectedFrom    int
+		expectedTo      int
+		expectedVersion int
+	}{
+		{
+			name:           "list revisions",
+			handler:        func(h *PostHandler) http.HandlerFunc { return h.ListRevisions },
+			target:         "/revisions?page=1",
+			authenticated:  true,
+			mockService:    &MockPostService{},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "list another user's revisions",
+			handler:        func(h *PostHandler) http.HandlerFunc { return h.ListRevisions },
+			target:         "/revisions",
+			authenticated:  true,
+			mockService:    &MockPostService{revisionError: errors.Forbidden("You can only manage the revisions of your own posts")},
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:           "list revisions unauthenticated",
+			handler:        func(h *PostHandler) http.HandlerFunc { return h.ListRevisions },
+			target:         "/revisions",
+			mockService:    &MockPostService{},
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "diff with defaults",
+			handler:        func(h *PostHandler) http.HandlerFunc { return h.DiffRevisions },
+			target:         "/revisions/diff",
+			authenticated:  true,
+			mockService:    &MockPostService{},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "diff between revisions",
+			handler:        func(h *PostHandler) http.HandlerFunc { return h.DiffRevisions },
+			target:         "/revisions/diff?from=1&to=3",
+			authenticated:  true,
+			mockService:    &MockPostService{},
+			expectedStatus: http.StatusOK,
+			expectedFrom:   1,
+			expectedTo:     3,
+		},
+		{
+			name:           "diff with an invalid revision",
+			handler:        func(h *PostHandler) http.HandlerFunc { return h.DiffRevisions },
+			target:         "/revisions/diff?from=first",
+			authenticated:  true,
+			mockService:    &MockPostService{},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:            "restore",
+			handler:         func(h *PostHandler) http.HandlerFunc { return h.RestoreRevision },
+			target:          "/revisions/2/restore",
+			rev:             "2",
+			authenticated:   true,
+			mockService:     &MockPostService{},
+			expectedStatus:  http.StatusOK,
+			expectedVersion: 2,
+		},
+		{
+			name:            "restore a missing revision",
+			handler:         func(h *PostHandler) http.HandlerFunc { return h.RestoreRevision },
+			target:          "/revisions/9/restore",
+			rev:             "9",
+			authenticated:   true,
+			mockService:     &MockPostService{revisionError: errors.NotFound("Revision")},
+			expectedStatus:  http.StatusNotFound,
+			expectedVersion: 9,
+		},
+		{
+			name:           "restore an invalid revision",
+			handler:        func(h *PostHandler) http.HandlerFunc { return h.RestoreRevision },
+			target:         "/revisions/0/restore",
+			rev:            "0",
+			authenticated:  true,
+			mockService:    &MockPostService{},
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := NewPostHandler(tt.mockService)
+
+			req := httptest.NewRequest(http.MethodGet, "/posts/"+postID.String()+tt.target, nil)
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("id", postID.String())
+			rctx.URLParams.Add("rev", tt.rev)
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+			if tt.authenticated {
+				req = withAuthenticatedUser(req, userID)
+			}
+			w := httptest.NewRecorder()
+
+			tt.handler(handler)(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+			if tt.mockService.diffFrom != tt.expectedFrom || tt.mockService.diffTo != tt.expectedTo {
+				t.Errorf("expected diff from %d to %d, got %d to %d", tt.expectedFrom, tt.expectedTo, tt.mockService.diffFrom, tt.mockService.diffTo)
+			}
+			if tt.mockService.restoredVersion != tt.expectedVersion {
+				t.Errorf("expected revision %d restored, got %d", tt.expectedVersion, tt.mockService.restoredVersion)
+			}
+		})
+	}
+}
+
+func TestPostHandler_ConditionalRequests(t *testing.T) {
+	userID := uuid.New()
+	post := &models.Post{
+		ID:         uuid.New(),
+		UserID:     userID,
+		Title:      "Title",
+		Content:    "Content",
+		Version:    3,
+		UpdatedAt:  time.Date(2026, 10, 14, 12, 0, 0, 0, time.UTC),
+		ActivityAt: time.Date(2026, 10, 14, 12, 30, 0, 0, time.UTC),
+	}
+	etag := `"` + post.ID.String() + `.3"`
+
+	newRequest := func(method, header, value string) *http.Request {
+		req := httptest.NewRequest(method, "/posts/"+post.ID.String(), bytes.NewBufferString(`{"title":"Changed"}`))
+		if header != "" {
+			req.Header.Set(header, value)
+		}
+		rctx := chi.NewRouteContext()
+		rctx.URLParams.Add("id", post.ID.String())
+		req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+		return withAuthenticatedUser(req, userID)
+	}
+
+	t.Run("get returns the ETag and the last modification", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		NewPostHandler(&MockPostService{retrievedPost: post}).GetPost(w, newRequest(http.MethodGet, "", ""))
+
+		if w.Code != http.StatusOK || w.Header().Get("ETag") != etag {
+			t.Errorf("expected 200 with ETag %s, got %d with %q", etag, w.Code, w.Header().Get("ETag"))
+		}
+		if w.Header().Get("Last-Modified") != "Wed, 14 Oct 2026 12:30:00 GMT" {
+			t.Errorf("expected the time of the last reaction, got %q", w.Header().Get("Last-Modified"))
+		}
+		if w.Header().Get("Vary") != "Authorization" {
+			t.Errorf("expected Vary: Authorization, got %q", w.Header().Get("Vary"))
+		}
+	})
+
+	t.Run("get ETag works with If-Match", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		NewPostHandler(&MockPostService{retrievedPost: post}).GetPost(w, newRequest(http.MethodGet, "", ""))
+
+		req := newRequest(http.MethodPut, "If-Match", w.Header().Get("ETag"))
+		if precondition := ParseIfMatch(req, post.ID); !precondition.Allows(3) {
+			t.Errorf("expected the ETag from GET to allow version 3, got %+v", precondition)
+		}
+	})
+
+	for _, tt := range []struct {
+		name            string
+		ifNoneMatch     string
+		ifModifiedSince string
+		expectedStatus  int
+	}{
+		{"matching tag", etag, "", http.StatusNotModified},
+		{"weak matching tag", "W/" + etag, "", http.StatusNotModified},
+		{"one of several tags", `"other", ` + etag, "", http.StatusNotModified},
+		{"wildcard", "*", "", http.StatusNotModified},
+		{"older version", `"` + post.ID.String() + `.2"`, "", http.StatusOK},
+		{"not modified since", "", "Wed, 14 Oct 2026 12:30:00 GMT", http.StatusNotModified},
+		{"reacted to since", "", "Wed, 14 Oct 2026 12:00:00 GMT", http.StatusOK},
+		{"matching tag but reacted to since", etag, "Wed, 14 Oct 2026 12:00:00 GMT", http.StatusOK},
+		{"matching tag and not modified since", etag, "Wed, 14 Oct 2026 12:30:00 GMT", http.StatusNotModified},
+		{"invalid date", "", "yesterday", http.StatusOK},
+	} {
+		t.Run("conditional get with "+tt.name, func(t *testing.T) {
+			req := newRequest(http.MethodGet, "If-None-Match", tt.ifNoneMatch)
+			if tt.ifModifiedSince != "" {
+				req.Header.Set("If-Modified-Since", tt.ifModifiedSince)
+			}
+			w := httptest.NewRecorder()
+			NewPostHandler(&MockPostService{retrievedPost: post}).GetPost(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+			if tt.expectedStatus == http.StatusNotModified && (w.Body.Len() != 0 || w.Header().Get("ETag") != etag) {
+				t.Errorf("expected an empty 304 with the ETag, got %q with %q", w.Body.String(), w.Header().Get("ETag"))
+			}
+		})
+	}
+
+	t.Run("update passes If-Match on", func(t *testing.T) {
+		mockService := &MockPostService{updatedPost: &models.Post{ID: post.ID, Version: 4}}
+		w := httptest.NewRecorder()
+		NewPostHandler(mockService).UpdatePost(w, newRequest(http.MethodPut, "If-Match", etag))
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+		}
+		if !mockService.ifMatch.Allows(3) || mockService.ifMatch.Allows(4) {
+			t.Errorf("expected a precondition on version 3, got %+v", mockService.ifMatch)
+		}
+		if w.Header().Get("ETag") != `"`+post.ID.String()+`.4"` {
+			t.Errorf("expected the ETag of the new version, got %q", w.Header().Get("ETag"))
+		}
+	})
+
+	t.Run("failed precondition", func(t *testing.T) {
+		mockService := &MockPostService{updatePostError: errors.PreconditionFailed("Post"), deletePostError: errors.PreconditionFailed("Post")}
+		handler := NewPostHandler(mockService)
+
+		w := httptest.NewRecorder()
+		handler.UpdatePost(w, newRequest(http.MethodPut, "If-Match", `"stale"`))
+		if w.Code != http.StatusPreconditionFailed {
+			t.Errorf("expected update status 412, got %d", w.Code)
+		}
+
+		w = httptest.NewRecorder()
+		handler.DeletePost(w, newRequest(http.MethodDelete, "If-Match", `"stale"`))
+		if w.Code != http.StatusPreconditionFailed {
+			t.Errorf("expected delete status 412, got %d", w.Code)
+		}
+	})
+
+	t.Run("delete without If-Match", func(t *testing.T) {
+		mockService := &MockPostService{}
+		w := httptest.NewRecorder()
+		NewPostHandler(mockService).DeletePost(w, newRequest(http.MethodDelete, "", ""))
+
+		if w.Code != http.StatusOK || mockService.ifMatch != nil {
+			t.Errorf("expected an unconditional delete, got %d with %+v", w.Code, mockService.ifMatch)
+		}
+	})
+}
+
+func TestPostHandler_Trash(t *testing.T) {
+	userID := uuid.New()
+	postID := uuid.New()
+
+	newRequest := func(method, target, id string, authenticated bool) *http.Request {
+		req := httptest.NewRequest(method, target, nil)
+		rctx := chi.NewRouteContext()
+		rctx.URLParams.Add("id", id)
+		req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+		if authenticated {
+			req = withAuthenticatedUser(req, userID)
+		}
+		return req
+	}
+
+	tests := []struct {
+		name           string
+		handle         func(*PostHandler, http.ResponseWriter, *http.Request)
+		request        *http.Request
+		mockService    *MockPostService
+		expectedStatus int
+	}{
+		{
+			name:    "list trash",
+			handle:  (*PostHandler).ListDeletedPosts,
+			request: newRequest(http.MethodGet, "/posts/trash", "", true),
+			mockService: &MockPostService{paginatedResponse: &models.PaginatedResponse{
+				Data:       []*models.Post{{ID: postID, UserID: userID}},
+				Pagination: models.NewPaginationMeta(1, 10, 1),
+			}},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "list trash unauthenticated",
+			handle:         (*PostHandler).ListDeletedPosts,
+			request:        newRequest(http.MethodGet, "/posts/trash", "", false),
+			mockService:    &MockPostService{},
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "restore own post",
+			handle:         (*PostHandler).RestorePost,
+			request:        newRequest(http.MethodPost, "/posts/"+postID.String()+"/restore", postID.String(), true),
+			mockService:    &MockPostService{retrievedPost: &models.Post{ID: postID, UserID: userID, Version: 1}},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "restore another user's post",
+			handle:         (*PostHandler).RestorePost,
+			request:        newRequest(http.MethodPost, "/posts/"+postID.String()+"/restore", postID.String(), true),
+			mockService:    &MockPostService{restorePostError: errors.Forbidden("You can only restore your own posts")},
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:           "restore with invalid post ID",
+			handle:         (*PostHandler).RestorePost,
+			request:        newRequest(http.MethodPost, "/posts/invalid-uuid/restore", "invalid-uuid", true),
+			mockService:    &MockPostService{},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "admin restores any post",
+			handle:         (*PostHandler).RestoreAnyPost,
+			request:        newRequest(http.MethodPost, "/admin/posts/"+postID.String()+"/restore", postID.String(), false),
+			mockService:    &MockPostService{retrievedPost: &models.Post{ID: postID, Version: 1}},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "admin restores a post outside the trash",
+			handle:         (*PostHandler).RestoreAnyPost,
+			request:        newRequest(http.MethodPost, "/admin/posts/"+postID.String()+"/restore", postID.String(), false),
+			mockService:    &MockPostService{restorePostError: errors.NotFound("Post")},
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+
+			tt.handle(NewPostHandler(tt.mockService), w, tt.request)
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+		})
+	}
+}
@@ -12,6 +12,7 @@ import (
 	"github.com/alinoer/go-std-api/internal/models"
 	"github.com/alinoer/go-std-api/internal/service"
 	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
 )
 
 func TestUserHandler_SecurityInputValidation(t *testing.T) {
@@ -146,37 +147,55 @@ func TestUserHandler_SecurityHeaders(t *testing.T) {
 }
 
 func TestAuthHandler_SecurityPasswordHandling(t *testing.T) {
-	mockUserService := &MockAuthUserService{}
-	authService := service.NewAuthService("test-secret-key")
-	handler := NewAuthHandler(mockUserService, authService)
+	hasher, err := service.NewBcryptHasher(bcrypt.MinCost)
+	if err != nil {
+		t.Fatalf("failed to create hasher: %v", err)
+	}
+	breached, err := service.LoadBreachedPasswords(strings.NewReader("5CEC175B165E3D5E62C9E13CE848EF6FEAC81BFF:4679\n"))
+	if err != nil {
+		t.Fatalf("failed to load breached passwords: %v", err)
+	}
+	userRepo := &MockAuthUserRepository{users: make(map[uuid.UUID]*models.User)}
+	userService := service.NewUserServiceWithConfig(userRepo, hasher, service.UserServiceConfig{
+		PostPolicy:     models.UserPostPolicyAnonymize,
+		PasswordPolicy: service.NewPasswordPolicy(service.DefaultPasswordPolicyConfig(), breached),
+	})
+	handler := NewAuthHandler(userService, newTestAuthService())
 
 	tests := []struct {
 		name               string
 		password           string
 		expectedStatusCode int
-		note               string
 	}{
 		{
 			name:               "weak password",
 			password:           "123",
-			expectedStatusCode: http.StatusBadRequest, // This actually works due to length validation
+			expectedStatusCode: http.StatusBadRequest,
 		},
 		{
-			name:               "common password", 
-			password:           "password",
-			expectedStatusCode: http.StatusCreated, // Currently passes - should be 400
-			note:               "TODO: Add common password validation",
+			name:               "common password",
+			password:           "qwerty123",
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:               "password based on the username",
+			password:           "TestUser2024",
+			expectedStatusCode: http.StatusBadRequest,
 		},
 		{
 			name:               "empty password",
 			password:           "",
-			expectedStatusCode: http.StatusBadRequest, // This actually works
+			expectedStatusCode: http.StatusBadRequest,
 		},
 		{
 			name:               "very long password",
 			password:           strings.Repeat("a", 1000),
-			expectedStatusCode: http.StatusCreated, // Currently passes - should be 400
-			note:               "TODO: Add max length validation",
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:               "strong password",
+			password:           "correct horse battery staple",
+			expectedStatusCode: http.StatusCreated,
 		},
 	}
 
@@ -195,11 +214,7 @@ func TestAuthHandler_SecurityPasswordHandling(t *testing.T) {
 			handler.Register(w, req)
 
 			if w.Code != tt.expectedStatusCode {
-				if tt.note != "" {
-					t.Logf("Security Issue: %s - %s (got %d)", tt.name, tt.note, w.Code)
-				} else {
-					t.Errorf("expected status code %d, got %d", tt.expectedStatusCode, w.Code)
-				}
+				t.Errorf("expected status code %d, got %d: %s", tt.expectedStatusCode, w.Code, w.Body.String())
 			}
 
 			// Ensure password is not echoed back in any response
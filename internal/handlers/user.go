@@ -5,6 +5,7 @@ import (
 	"net/http"
 
 	"github.com/alinoer/go-std-api/internal/models"
+	"github.com/alinoer/go-std-api/internal/response"
 	"github.com/alinoer/go-std-api/internal/service"
 
 	"github.com/go-chi/chi/v5"
@@ -22,63 +23,148 @@ func NewUserHandler(userService service.UserService) *UserHandler {
 }
 
 func (h *UserHandler) CreateUser(w http.ResponseWriter, r *http.Request) {
+	resp := response.NewResponseWriter(w, r)
+
 	var req models.CreateUserRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		WriteError(w, http.StatusBadRequest, "Invalid JSON payload")
+		resp.BadRequest("Invalid JSON payload")
 		return
 	}
 
 	user, err := h.userService.CreateUser(r.Context(), &req)
 	if err != nil {
-		WriteError(w, http.StatusBadRequest, err.Error())
+		resp.Error(err)
 		return
 	}
 
-	WriteJSON(w, http.StatusCreated, SuccessResponse{Data: user})
+	resp.Created(user)
 }
 
 func (h *UserHandler) GetUser(w http.ResponseWriter, r *http.Request) {
+	resp := response.NewResponseWriter(w, r)
+
 	idStr := chi.URLParam(r, "id")
 	id, err := uuid.Parse(idStr)
 	if err != nil {
-		WriteError(w, http.StatusBadRequest, "Invalid user ID")
+		resp.BadRequest("Invalid user ID")
 		return
 	}
 
 	user, err := h.userService.GetUser(r.Context(), id)
 	if err != nil {
-		WriteError(w, http.StatusNotFound, "User not found")
+		resp.Error(err)
 		return
 	}
 
-	WriteSuccess(w, user)
+	resp.Success(user)
 }
 
 func (h *UserHandler) ListUsers(w http.ResponseWriter, r *http.Request) {
+	resp := response.NewResponseWriter(w, r)
+
 	// Check if pagination parameters are provided
+	if IsCursorPagination(r) {
+		h.ListUsersByCursor(w, r)
+		return
+	}
 	if r.URL.Query().Get("page") != "" || r.URL.Query().Get("page_size") != "" {
 		h.ListUsersPaginated(w, r)
 		return
 	}
 
+	opts, validationErrors := ParseListOptions(r, userListSpec)
+	if validationErrors != nil {
+		resp.ValidationError(validationErrors)
+		return
+	}
+
 	// Default non-paginated response
-	users, err := h.userService.ListUsers(r.Context())
+	users, err := h.userService.ListUsers(r.Context(), opts)
 	if err != nil {
-		WriteError(w, http.StatusInternalServerError, "Failed to retrieve users")
+		resp.Error(err)
 		return
 	}
 
-	WriteSuccess(w, users)
+	resp.Success(users)
 }
 
 func (h *UserHandler) ListUsersPaginated(w http.ResponseWriter, r *http.Request) {
+	resp := response.NewResponseWriter(w, r)
+
 	pagination := ParsePaginationParams(r)
 
-	result, err := h.userService.ListUsersPaginated(r.Context(), pagination)
+	opts, validationErrors := ParseListOptions(r, userListSpec)
+	if validationErrors != nil {
+		resp.ValidationError(validationErrors)
+		return
+	}
+
+	result, err := h.userService.ListUsersPaginated(r.Context(), pagination, opts)
 	if err != nil {
-		WriteError(w, http.StatusInternalServerError, "Failed to retrieve users")
+		resp.Error(err)
 		return
 	}
 
-	WriteJSON(w, http.StatusOK, result)
-}
\ No newline at end of file
+	resp.JSONWithMeta(http.StatusOK, result.Data, "", result.Pagination)
+}
+
+func (h *UserHandler) ListUsersByCursor(w http.ResponseWriter, r *http.Request) {
+	resp := response.NewResponseWriter(w, r)
+
+	params, err := ParseCursorParams(r)
+	if err != nil {
+		resp.Error(err)
+		return
+	}
+
+	opts, validationErrors := ParseListOptions(r, userListSpec)
+	if validationErrors != nil {
+		resp.ValidationError(validationErrors)
+		return
+	}
+
+	result, err := h.userService.ListUsersByCursor(r.Context(), params, opts)
+	if err != nil {
+		resp.Error(err)
+		return
+	}
+
+	resp.JSONWithMeta(http.StatusOK, result.Data, "", result.Pagination)
+}
+
+// ListPrivateUsers lists users with their private fields. The route must be
+// protected by RequirePermission(models.PermissionReadPrivateUserFields).
+func (h *UserHandler) ListPrivateUsers(w http.ResponseWriter, r *http.Request) {
+	resp := response.NewResponseWriter(w, r)
+
+	pagination := ParsePaginationParams(r)
+
+	result, err := h.userService.ListPrivateUsers(r.Context(), pagination)
+	if err != nil {
+		resp.Error(err)
+		return
+	}
+
+	resp.JSONWithMeta(http.StatusOK, result.Data, "", result.Pagination)
+}
+
+// RestoreUser takes a deleted user out of the trash. The route must be
+// protected by RequirePermission(models.PermissionManageUsers).
+func (h *UserHandler) RestoreUser(w http.ResponseWriter, r *http.Request) {
+	resp := response.NewResponseWriter(w, r)
+
+	idStr := chi.URLParam(r, "id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		resp.BadRequest("Invalid user ID")
+		return
+	}
+
+	user, err := h.userService.RestoreUser(r.Context(), id)
+	if err != nil {
+		resp.Error(err)
+		return
+	}
+
+	resp.Success(models.NewPrivateUser(user))
+}
@@ -26,7 +26,7 @@ func TestUserHandler_Integration(t *testing.T) {
 
 	// Setup real dependencies
 	userRepo := repository.NewUserRepository(testDB.DB)
-	userService := service.NewUserService(userRepo)
+	userService := service.NewUserService(userRepo, newIntegrationPasswordHasher(t))
 	handler := NewUserHandler(userService)
 
 	t.Run("complete user workflow", func(t *testing.T) {
@@ -126,7 +126,7 @@ func TestUserHandler_ConcurrentCreation(t *testing.T) {
 	defer testDB.Cleanup(t)
 
 	userRepo := repository.NewUserRepository(testDB.DB)
-	userService := service.NewUserService(userRepo)
+	userService := service.NewUserService(userRepo, newIntegrationPasswordHasher(t))
 	handler := NewUserHandler(userService)
 
 	t.Run("concurrent user creation", func(t *testing.T) {
@@ -162,4 +162,19 @@ func TestUserHandler_ConcurrentCreation(t *testing.T) {
 			}
 		}
 	})
-}
\ No newline at end of file
+}
+
+// newIntegrationPasswordHasher returns a cheap argon2id hasher for tests
+func newIntegrationPasswordHasher(t *testing.T) service.PasswordHasher {
+	hasher, err := service.NewPasswordHasher(service.PasswordHashConfig{
+		Algorithm:         service.PasswordAlgorithmArgon2id,
+		Argon2Memory:      1024,
+		Argon2Iterations:  1,
+		Argon2Parallelism: 1,
+		BcryptCost:        4,
+	})
+	if err != nil {
+		t.Fatalf("failed to create password hasher: %v", err)
+	}
+	return hasher
+}
@@ -8,9 +8,12 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"strings"
 	"testing"
 
+	"github.com/alinoer/go-std-api/internal/errors"
 	"github.com/alinoer/go-std-api/internal/models"
+	"github.com/alinoer/go-std-api/internal/response"
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
 )
@@ -24,6 +27,10 @@ type MockUserHandlerService struct {
 	retrievedUser             *models.User
 	users                     []*models.User
 	paginatedResponse         *models.PaginatedResponse
+	updateUserError           error
+	deleteUserError           error
+	restoreUserError          error
+	updatedUser               *models.User
 }
 
 func (m *MockUserHandlerService) CreateUser(ctx context.Context, req *models.CreateUserRequest) (*models.User, error) {
@@ -40,14 +47,21 @@ func (m *MockUserHandlerService) GetUser(ctx context.Context, id uuid.UUID) (*mo
 	return m.retrievedUser, nil
 }
 
-func (m *MockUserHandlerService) ListUsers(ctx context.Context) ([]*models.User, error) {
+func (m *MockUserHandlerService) ListUsers(ctx context.Context, opts *models.ListOptions) ([]*models.User, error) {
 	if m.listUsersError != nil {
 		return nil, m.listUsersError
 	}
 	return m.users, nil
 }
 
-func (m *MockUserHandlerService) ListUsersPaginated(ctx context.Context, pagination *models.PaginationParams) (*models.PaginatedResponse, error) {
+func (m *MockUserHandlerService) ListUsersPaginated(ctx context.Context, pagination *models.PaginationParams, opts *models.ListOptions) (*models.PaginatedResponse, error) {
+	if m.listUsersPaginatedError != nil {
+		return nil, m.listUsersPaginatedError
+	}
+	return m.paginatedResponse, nil
+}
+
+func (m *MockUserHandlerService) ListUsersByCursor(ctx context.Context, params *models.CursorParams, opts *models.ListOptions) (*models.PaginatedResponse, error) {
 	if m.listUsersPaginatedError != nil {
 		return nil, m.listUsersPaginatedError
 	}
@@ -62,6 +76,35 @@ func (m *MockUserHandlerService) GetUserByUsername(ctx context.Context, username
 	return nil, nil
 }
 
+func (m *MockUserHandlerService) ListPrivateUsers(ctx context.Context, pagination *models.PaginationParams) (*models.PaginatedResponse, error) {
+	if m.listUsersPaginatedError != nil {
+		return nil, m.listUsersPaginatedError
+	}
+	return m.paginatedResponse, nil
+}
+
+func (m *MockUserHandlerService) UpdateUserRole(ctx context.Context, id uuid.UUID, role models.Role) (*models.User, error) {
+	return nil, nil
+}
+
+func (m *MockUserHandlerService) UpdateUser(ctx context.Context, userID, id uuid.UUID, req *models.UpdateUserRequest) (*models.User, error) {
+	if m.updateUserError != nil {
+		return nil, m.updateUserError
+	}
+	return m.updatedUser, nil
+}
+
+func (m *MockUserHandlerService) DeleteUser(ctx context.Context, userID, id uuid.UUID) error {
+	return m.deleteUserError
+}
+
+func (m *MockUserHandlerService) RestoreUser(ctx context.Context, id uuid.UUID) (*models.User, error) {
+	if m.restoreUserError != nil {
+		return nil, m.restoreUserError
+	}
+	return m.retrievedUser, nil
+}
+
 func TestNewUserHandler(t *testing.T) {
 	mockService := &MockUserHandlerService{}
 	handler := NewUserHandler(mockService)
@@ -110,10 +153,10 @@ func TestUserHandler_CreateUser(t *testing.T) {
 				Password: "password123",
 			},
 			setupMock: func(mock *MockUserHandlerService) {
-				mock.createUserError = fmt.Errorf("username already exists")
+				mock.createUserError = errors.Conflict("Username", "choose a different username")
 			},
-			expectedStatusCode: http.StatusBadRequest,
-			expectedError:      "username already exists",
+			expectedStatusCode: http.StatusConflict,
+			expectedError:      "Username already exists",
 		},
 	}
 
@@ -145,7 +188,7 @@ func TestUserHandler_CreateUser(t *testing.T) {
 			}
 
 			if tt.expectedError != "" {
-				var errorResp ErrorResponse
+				var errorResp response.ErrorResponse
 				if err := json.Unmarshal(w.Body.Bytes(), &errorResp); err != nil {
 					t.Errorf("failed to unmarshal error response: %v", err)
 				}
@@ -153,11 +196,11 @@ func TestUserHandler_CreateUser(t *testing.T) {
 					t.Errorf("expected error %q, got %q", tt.expectedError, errorResp.Error)
 				}
 			} else {
-				var response SuccessResponse
-				if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+				var successResp response.StandardResponse
+				if err := json.Unmarshal(w.Body.Bytes(), &successResp); err != nil {
 					t.Errorf("failed to unmarshal success response: %v", err)
 				}
-				if response.Data == nil {
+				if successResp.Data == nil {
 					t.Error("expected non-nil data in response")
 				}
 			}
@@ -197,7 +240,7 @@ func TestUserHandler_GetUser(t *testing.T) {
 			name:   "user not found",
 			userID: validUserID.String(),
 			setupMock: func(mock *MockUserHandlerService) {
-				mock.getUserError = fmt.Errorf("user not found")
+				mock.getUserError = errors.NotFound("User")
 			},
 			expectedStatusCode: http.StatusNotFound,
 			expectedError:      "User not found",
@@ -224,7 +267,7 @@ func TestUserHandler_GetUser(t *testing.T) {
 			}
 
 			if tt.expectedError != "" {
-				var errorResp ErrorResponse
+				var errorResp response.ErrorResponse
 				if err := json.Unmarshal(w.Body.Bytes(), &errorResp); err != nil {
 					t.Errorf("failed to unmarshal error response: %v", err)
 				}
@@ -232,11 +275,11 @@ func TestUserHandler_GetUser(t *testing.T) {
 					t.Errorf("expected error %q, got %q", tt.expectedError, errorResp.Error)
 				}
 			} else {
-				var response SuccessResponse
-				if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+				var successResp response.StandardResponse
+				if err := json.Unmarshal(w.Body.Bytes(), &successResp); err != nil {
 					t.Errorf("failed to unmarshal success response: %v", err)
 				}
-				if response.Data == nil {
+				if successResp.Data == nil {
 					t.Error("expected non-nil data in response")
 				}
 			}
@@ -275,11 +318,7 @@ func TestUserHandler_ListUsers(t *testing.T) {
 					Data: []*models.User{
 						{ID: uuid.New(), Username: "user1"},
 					},
-					Pagination: &models.PaginationMeta{
-						Page:      1,
-						PageSize:  10,
-						Total:     1,
-					},
+					Pagination: models.NewPaginationMeta(1, 10, 1),
 				}
 			},
 			expectedStatusCode: http.StatusOK,
@@ -288,10 +327,10 @@ func TestUserHandler_ListUsers(t *testing.T) {
 		{
 			name: "service error",
 			setupMock: func(mock *MockUserHandlerService) {
-				mock.listUsersError = fmt.Errorf("database error")
+				mock.listUsersError = errors.DatabaseError("list users", fmt.Errorf("connection refused"))
 			},
 			expectedStatusCode: http.StatusInternalServerError,
-			expectedError:      "Failed to retrieve users",
+			expectedError:      "Database operation failed: list users",
 		},
 		{
 			name: "paginated service error",
@@ -299,10 +338,10 @@ func TestUserHandler_ListUsers(t *testing.T) {
 				"page": "1",
 			},
 			setupMock: func(mock *MockUserHandlerService) {
-				mock.listUsersPaginatedError = fmt.Errorf("database error")
+				mock.listUsersPaginatedError = errors.DatabaseError("list users", fmt.Errorf("connection refused"))
 			},
 			expectedStatusCode: http.StatusInternalServerError,
-			expectedError:      "Failed to retrieve users",
+			expectedError:      "Database operation failed: list users",
 		},
 	}
 
@@ -331,7 +370,7 @@ func TestUserHandler_ListUsers(t *testing.T) {
 			}
 
 			if tt.expectedError != "" {
-				var errorResp ErrorResponse
+				var errorResp response.ErrorResponse
 				if err := json.Unmarshal(w.Body.Bytes(), &errorResp); err != nil {
 					t.Errorf("failed to unmarshal error response: %v", err)
 				}
@@ -340,26 +379,122 @@ func TestUserHandler_ListUsers(t *testing.T) {
 				}
 			} else {
 				if tt.expectPaginated {
-					var response models.PaginatedResponse
-					if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+					var successResp response.StandardResponse
+					if err := json.Unmarshal(w.Body.Bytes(), &successResp); err != nil {
 						t.Errorf("failed to unmarshal paginated response: %v", err)
 					}
-					if response.Data == nil {
+					if successResp.Data == nil {
 						t.Error("expected non-nil data in response")
 					}
-					if response.Pagination == nil {
+					if successResp.Meta == nil {
 						t.Error("expected non-nil pagination in response")
 					}
 				} else {
-					var response SuccessResponse
-					if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+					var successResp response.StandardResponse
+					if err := json.Unmarshal(w.Body.Bytes(), &successResp); err != nil {
 						t.Errorf("failed to unmarshal success response: %v", err)
 					}
-					if response.Data == nil {
+					if successResp.Data == nil {
 						t.Error("expected non-nil data in response")
 					}
 				}
 			}
 		})
 	}
-}
\ No newline at end of file
+}
+func TestUserHandler_ListPrivateUsers(t *testing.T) {
+	tests := []struct {
+		name               string
+		setupMock          func(*MockUserHandlerService)
+		expectedStatusCode int
+	}{
+		{
+			name: "successful list",
+			setupMock: func(mock *MockUserHandlerService) {
+				mock.paginatedResponse = &models.PaginatedResponse{
+					Data: []*models.PrivateUser{
+						{ID: uuid.New(), Username: "admin", Role: models.RoleAdmin},
+					},
+					Pagination: models.NewPaginationMeta(1, 10, 1),
+				}
+			},
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name: "service error",
+			setupMock: func(mock *MockUserHandlerService) {
+				mock.listUsersPaginatedError = errors.DatabaseError("list users", fmt.Errorf("connection refused"))
+			},
+			expectedStatusCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := &MockUserHandlerService{}
+			tt.setupMock(mockService)
+			handler := NewUserHandler(mockService)
+
+			req := httptest.NewRequest(http.MethodGet, "/admin/users", nil)
+			w := httptest.NewRecorder()
+
+			handler.ListPrivateUsers(w, req)
+
+			if w.Code != tt.expectedStatusCode {
+				t.Errorf("expected status code %d, got %d", tt.expectedStatusCode, w.Code)
+			}
+
+			if tt.expectedStatusCode == http.StatusOK && !strings.Contains(w.Body.String(), `"role":"admin"`) {
+				t.Errorf("expected private role field in response, got %s", w.Body.String())
+			}
+		})
+	}
+}
+
+func TestUserHandler_RestoreUser(t *testing.T) {
+	userID := uuid.New()
+
+	tests := []struct {
+		name               string
+		userID             string
+		mockService        *MockUserHandlerService
+		expectedStatusCode int
+	}{
+		{
+			name:               "successful restore",
+			userID:             userID.String(),
+			mockService:        &MockUserHandlerService{retrievedUser: &models.User{ID: userID, Username: "returning"}},
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:               "user not in the trash",
+			userID:             userID.String(),
+			mockService:        &MockUserHandlerService{restoreUserError: errors.NotFound("User")},
+			expectedStatusCode: http.StatusNotFound,
+		},
+		{
+			name:               "invalid user ID",
+			userID:             "invalid-uuid",
+			mockService:        &MockUserHandlerService{},
+			expectedStatusCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := NewUserHandler(tt.mockService)
+
+			req := httptest.NewRequest(http.MethodPost, "/admin/users/"+tt.userID+"/restore", nil)
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("id", tt.userID)
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+			w := httptest.NewRecorder()
+
+			handler.RestoreUser(w, req)
+
+			if w.Code != tt.expectedStatusCode {
+				t.Errorf("expected status code %d, got %d: %s", tt.expectedStatusCode, w.Code, w.Body.String())
+			}
+		})
+	}
+}
@@ -120,7 +120,7 @@ func (h *UserHandlerV2) ListUsers(w http.ResponseWriter, r *http.Request) {
 	// Check if pagination is requested
 	if pagination != nil {
 		// Handle paginated response
-		result, err := h.userService.ListUsersPaginated(ctx, pagination)
+		result, err := h.userService.ListUsersPaginated(ctx, pagination, nil)
 		if err != nil {
 			resp.Error(errors.DatabaseError("list users", err))
 			return
@@ -129,7 +129,7 @@ func (h *UserHandlerV2) ListUsers(w http.ResponseWriter, r *http.Request) {
 		resp.JSONWithMeta(http.StatusOK, result.Data, "Users retrieved successfully", result.Pagination)
 	} else {
 		// Handle simple list
-		users, err := h.userService.ListUsers(ctx)
+		users, err := h.userService.ListUsers(ctx, nil)
 		if err != nil {
 			resp.Error(errors.DatabaseError("list users", err))
 			return
@@ -142,13 +142,20 @@ func (h *UserHandlerV2) ListUsers(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-// UpdateUser updates user information
+// UpdateUser changes the authenticated user's username and/or password
 func (h *UserHandlerV2) UpdateUser(w http.ResponseWriter, r *http.Request) {
 	resp := response.NewResponseWriter(w, r)
+	ctx := r.Context()
+
+	userID, ok := GetAuthenticatedUserID(r)
+	if !ok {
+		resp.Unauthorized("Authentication required")
+		return
+	}
 
 	// Parse user ID
 	idStr := chi.URLParam(r, "id")
-	_, err := uuid.Parse(idStr)
+	id, err := uuid.Parse(idStr)
 	if err != nil {
 		resp.BadRequest("Invalid user ID format")
 		return
@@ -167,17 +174,38 @@ func (h *UserHandlerV2) UpdateUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Update user (this would require implementing UpdateUser in service)
-	// For now, we'll return a not implemented error
-	resp.Error(errors.NewAppError(errors.ErrCodeInternal, "Update functionality not yet implemented").
-		WithHTTPStatus(http.StatusNotImplemented).
-		WithContext("feature", "user_update"))
+	user, err := h.userService.UpdateUser(ctx, userID, id, &req)
+	if err != nil {
+		if appErr := errors.AsAppError(err); appErr != nil {
+			resp.Error(appErr)
+		} else {
+			resp.Error(errors.InternalError("Failed to update user").WithInternal(err))
+		}
+		return
+	}
+
+	h.logger.WithContext(ctx).Info("User updated successfully",
+		"user_id", user.ID,
+		"username_changed", req.Username != nil,
+		"password_changed", req.Password != nil,
+	)
+
+	resp.Success(map[string]interface{}{
+		"user": user,
+	})
 }
 
-// DeleteUser deletes a user
+// DeleteUser deletes the authenticated user's account
 func (h *UserHandlerV2) DeleteUser(w http.ResponseWriter, r *http.Request) {
 	resp := response.NewResponseWriter(w, r)
-	
+	ctx := r.Context()
+
+	userID, ok := GetAuthenticatedUserID(r)
+	if !ok {
+		resp.Unauthorized("Authentication required")
+		return
+	}
+
 	// Parse user ID
 	idStr := chi.URLParam(r, "id")
 	id, err := uuid.Parse(idStr)
@@ -186,10 +214,18 @@ func (h *UserHandlerV2) DeleteUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// For now, return not implemented
-	resp.Error(errors.NewAppError(errors.ErrCodeInternal, "Delete functionality not yet implemented").
-		WithHTTPStatus(http.StatusNotImplemented).
-		WithContext("user_id", id.String()))
+	if err := h.userService.DeleteUser(ctx, userID, id); err != nil {
+		if appErr := errors.AsAppError(err); appErr != nil {
+			resp.Error(appErr)
+		} else {
+			resp.Error(errors.InternalError("Failed to delete user").WithInternal(err))
+		}
+		return
+	}
+
+	h.logger.WithContext(ctx).Info("User deleted successfully", "user_id", id)
+
+	resp.NoContent()
 }
 
 // validateCreateUserRequest validates the create user request
@@ -205,13 +241,9 @@ func (h *UserHandlerV2) validateCreateUserRequest(req *models.CreateUserRequest)
 		validationErrors.Add("username", "Username must be less than 50 characters")
 	}
 
-	// Password validation
+	// Password validation; the password policy is applied by the service
 	if req.Password == "" {
 		validationErrors.Add("password", "Password is required")
-	} else if len(req.Password) < 6 {
-		validationErrors.Add("password", "Password must be at least 6 characters long")
-	} else if len(req.Password) > 100 {
-		validationErrors.Add("password", "Password must be less than 100 characters")
 	}
 
 	// Security validations
@@ -234,8 +266,34 @@ func (h *UserHandlerV2) validateCreateUserRequest(req *models.CreateUserRequest)
 func (h *UserHandlerV2) validateUpdateUserRequest(req *models.UpdateUserRequest) *errors.ValidationErrors {
 	validationErrors := &errors.ValidationErrors{}
 
-	// Add validation logic for update request
-	// This is a placeholder for when UpdateUserRequest is defined
+	if req.Username == nil && req.Password == nil {
+		validationErrors.Add("body", "At least one of username or password is required")
+	}
+
+	// Username validation
+	if req.Username != nil {
+		username := *req.Username
+		if len(username) < 3 {
+			validationErrors.Add("username", "Username must be at least 3 characters long")
+		} else if len(username) > 50 {
+			validationErrors.Add("username", "Username must be less than 50 characters")
+		}
+
+		if h.containsSQLInjection(username) {
+			validationErrors.Add("username", "Username contains invalid characters")
+		}
+
+		if h.containsXSS(username) {
+			validationErrors.Add("username", "Username contains potentially dangerous content")
+		}
+	}
+
+	// Password validation; the password policy is applied by the service
+	if req.Password != nil {
+		if req.CurrentPassword == nil || *req.CurrentPassword == "" {
+			validationErrors.Add("current_password", "Current password is required to change the password")
+		}
+	}
 
 	if !validationErrors.HasErrors() {
 		return nil
@@ -0,0 +1,211 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/alinoer/go-std-api/internal/errors"
+	"github.com/alinoer/go-std-api/internal/models"
+	"github.com/alinoer/go-std-api/internal/response"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+func TestUserHandlerV2_UpdateUser(t *testing.T) {
+	userID := uuid.New()
+
+	tests := []struct {
+		name           string
+		userID         string
+		authenticated  bool
+		requestBody    string
+		mockService    *MockUserHandlerService
+		expectedStatus int
+		expectedCode   string
+	}{
+		{
+			name:           "change username",
+			userID:         userID.String(),
+			authenticated:  true,
+			requestBody:    `{"username": "renamed"}`,
+			mockService:    &MockUserHandlerService{updatedUser: &models.User{ID: userID, Username: "renamed"}},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "change password",
+			userID:         userID.String(),
+			authenticated:  true,
+			requestBody:    `{"password": "newpassword", "current_password": "oldpassword"}`,
+			mockService:    &MockUserHandlerService{updatedUser: &models.User{ID: userID, Username: "testuser"}},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "password without current password",
+			userID:         userID.String(),
+			authenticated:  true,
+			requestBody:    `{"password": "newpassword"}`,
+			mockService:    &MockUserHandlerService{},
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   string(errors.ErrCodeValidation),
+		},
+		{
+			name:           "username too short",
+			userID:         userID.String(),
+			authenticated:  true,
+			requestBody:    `{"username": "ab"}`,
+			mockService:    &MockUserHandlerService{},
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   string(errors.ErrCodeValidation),
+		},
+		{
+			name:           "empty update",
+			userID:         userID.String(),
+			authenticated:  true,
+			requestBody:    `{}`,
+			mockService:    &MockUserHandlerService{},
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   string(errors.ErrCodeValidation),
+		},
+		{
+			name:           "username taken",
+			userID:         userID.String(),
+			authenticated:  true,
+			requestBody:    `{"username": "taken"}`,
+			mockService:    &MockUserHandlerService{updateUserError: errors.Conflict("Username", "choose a different username")},
+			expectedStatus: http.StatusConflict,
+			expectedCode:   string(errors.ErrCodeConflict),
+		},
+		{
+			name:           "another user's account",
+			userID:         uuid.New().String(),
+			authenticated:  true,
+			requestBody:    `{"username": "renamed"}`,
+			mockService:    &MockUserHandlerService{updateUserError: errors.Forbidden("You can only update your own account")},
+			expectedStatus: http.StatusForbidden,
+			expectedCode:   string(errors.ErrCodeForbidden),
+		},
+		{
+			name:           "not authenticated",
+			userID:         userID.String(),
+			requestBody:    `{"username": "renamed"}`,
+			mockService:    &MockUserHandlerService{},
+			expectedStatus: http.StatusUnauthorized,
+			expectedCode:   string(errors.ErrCodeUnauthorized),
+		},
+		{
+			name:           "invalid user ID",
+			userID:         "invalid-uuid",
+			authenticated:  true,
+			requestBody:    `{"username": "renamed"}`,
+			mockService:    &MockUserHandlerService{},
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   string(errors.ErrCodeBadRequest),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := NewUserHandlerV2(tt.mockService)
+
+			req := httptest.NewRequest(http.MethodPut, "/users/"+tt.userID, bytes.NewBufferString(tt.requestBody))
+			req.Header.Set("Content-Type", "application/json")
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("id", tt.userID)
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+			if tt.authenticated {
+				req = withAuthenticatedUser(req, userID)
+			}
+			w := httptest.NewRecorder()
+
+			handler.UpdateUser(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+
+			if tt.expectedCode != "" {
+				var errResp response.ErrorResponse
+				if err := json.NewDecoder(w.Body).Decode(&errResp); err != nil {
+					t.Fatalf("failed to decode error response: %v", err)
+				}
+				if errResp.Code != tt.expectedCode {
+					t.Errorf("expected code %q, got %q", tt.expectedCode, errResp.Code)
+				}
+			}
+		})
+	}
+}
+
+func TestUserHandlerV2_DeleteUser(t *testing.T) {
+	userID := uuid.New()
+
+	tests := []struct {
+		name           string
+		userID         string
+		authenticated  bool
+		mockService    *MockUserHandlerService
+		expectedStatus int
+	}{
+		{
+			name:           "delete own account",
+			userID:         userID.String(),
+			authenticated:  true,
+			mockService:    &MockUserHandlerService{},
+			expectedStatus: http.StatusNoContent,
+		},
+		{
+			name:          "blocked by remaining posts",
+			userID:        userID.String(),
+			authenticated: true,
+			mockService: &MockUserHandlerService{
+				deleteUserError: errors.NewAppError(errors.ErrCodeConflict, "User still has posts"),
+			},
+			expectedStatus: http.StatusConflict,
+		},
+		{
+			name:           "another user's account",
+			userID:         uuid.New().String(),
+			authenticated:  true,
+			mockService:    &MockUserHandlerService{deleteUserError: errors.Forbidden("You can only delete your own account")},
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:           "not authenticated",
+			userID:         userID.String(),
+			mockService:    &MockUserHandlerService{},
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "invalid user ID",
+			userID:         "invalid-uuid",
+			authenticated:  true,
+			mockService:    &MockUserHandlerService{},
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := NewUserHandlerV2(tt.mockService)
+
+			req := httptest.NewRequest(http.MethodDelete, "/users/"+tt.userID, nil)
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("id", tt.userID)
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+			if tt.authenticated {
+				req = withAuthenticatedUser(req, userID)
+			}
+			w := httptest.NewRecorder()
+
+			handler.DeleteUser(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+		})
+	}
+}
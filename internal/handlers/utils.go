@@ -1,10 +1,17 @@
 package handlers
 
 import (
+	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
+	"github.com/alinoer/go-std-api/internal/errors"
+	"github.com/alinoer/go-std-api/internal/middleware"
 	"github.com/alinoer/go-std-api/internal/models"
+
+	"github.com/google/uuid"
 )
 
 func ParsePaginationParams(r *http.Request) *models.PaginationParams {
@@ -27,4 +34,126 @@ func ParsePaginationParams(r *http.Request) *models.PaginationParams {
 	}
 
 	return models.NewPaginationParams(page, pageSize)
-}
\ No newline at end of file
+}
+
+// IsCursorPagination reports whether the request asks for cursor pagination
+// rather than page numbers
+func IsCursorPagination(r *http.Request) bool {
+	query := r.URL.Query()
+	return query.Has("cursor") || query.Has("limit")
+}
+
+// ParseCursorParams reads the cursor, limit and include_total query parameters
+func ParseCursorParams(r *http.Request) (*models.CursorParams, error) {
+	query := r.URL.Query()
+
+	var cursor *models.Cursor
+	if token := query.Get("cursor"); token != "" {
+		decoded, err := models.DecodeCursor(token)
+		if err != nil {
+			return nil, errors.ValidationError("cursor", "Cursor is invalid")
+		}
+		cursor = decoded
+	}
+
+	limit := models.DefaultPageSize
+	if limitStr := query.Get("limit"); limitStr != "" {
+		parsed, err := strconv.Atoi(limitStr)
+		if err != nil || parsed < 1 || parsed > models.MaxPageSize {
+			return nil, errors.ValidationError("limit", fmt.Sprintf("Limit must be between 1 and %d", models.MaxPageSize))
+		}
+		limit = parsed
+	}
+
+	includeTotal, _ := strconv.ParseBool(query.Get("include_total"))
+
+	return models.NewCursorParams(cursor, limit, includeTotal), nil
+}
+
+// GetAuthenticatedUserID returns the ID of the user authenticated by JWTAuthMiddleware
+func GetAuthenticatedUserID(r *http.Request) (uuid.UUID, bool) {
+	userIDStr, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		return uuid.Nil, false
+	}
+
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		return uuid.Nil, false
+	}
+
+	return userID, true
+}
+
+// setRetryAfter sets the Retry-After header for a rate limit error that
+// carries the seconds to wait in its "retry_after" context
+func setRetryAfter(w http.ResponseWriter, err error) {
+	appErr := errors.AsAppError(err)
+	if appErr.Code != errors.ErrCodeRateLimit {
+		return
+	}
+	if seconds, ok := appErr.Context["retry_after"].(int); ok {
+		w.Header().Set(middleware.RetryAfterHeader, strconv.Itoa(seconds))
+	}
+}
+
+// getBearerToken returns the token from an "Authorization: Bearer" header
+func getBearerToken(r *http.Request) (string, bool) {
+	authHeader := r.Header.Get("Authorization")
+	if !strings.HasPrefix(authHeader, "Bearer ") {
+		return "", false
+	}
+
+	token := strings.TrimPrefix(authHeader, "Bearer ")
+	return token, token != ""
+}
+// ParseIfMatch turns the If-Match header into a precondition on the versions
+// of the post with the given ID, or nil when the header is missing. Weak and
+// foreign entity tags never match, as If-Match uses strong comparison.
+func ParseIfMatch(r *http.Request, postID uuid.UUID) *models.Precondition {
+	header := strings.TrimSpace(r.Header.Get("If-Match"))
+	if header == "" {
+		return nil
+	}
+	if header == "*" {
+		return &models.Precondition{Any: true}
+	}
+
+	precondition := &models.Precondition{}
+	prefix := postID.String() + "."
+	for _, tag := range strings.Split(header, ",") {
+		opaque, ok := strings.CutPrefix(strings.TrimSpace(tag), `"`+prefix)
+		if !ok {
+			continue
+		}
+		if version, err := strconv.Atoi(strings.TrimSuffix(opaque, `"`)); err == nil && strings.HasSuffix(opaque, `"`) {
+			precondition.Versions = append(precondition.Versions, version)
+		}
+	}
+	return precondition
+}
+
+// MatchesIfNoneMatch reports whether etag is listed in the If-None-Match
+// header, using weak comparison
+func MatchesIfNoneMatch(r *http.Request, etag string) bool {
+	header := strings.TrimSpace(r.Header.Get("If-None-Match"))
+	if header == "*" {
+		return true
+	}
+	for _, tag := range strings.Split(header, ",") {
+		if strings.TrimPrefix(strings.TrimSpace(tag), "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
+
+// NotModifiedSince reports whether the If-Modified-Since header is a time no
+// earlier than lastModified. HTTP dates have whole seconds.
+func NotModifiedSince(r *http.Request, lastModified time.Time) bool {
+	since, err := http.ParseTime(r.Header.Get("If-Modified-Since"))
+	if err != nil {
+		return false
+	}
+	return !lastModified.Truncate(time.Second).After(since)
+}
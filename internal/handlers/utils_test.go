@@ -4,9 +4,14 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"reflect"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/alinoer/go-std-api/internal/errors"
 	"github.com/alinoer/go-std-api/internal/models"
+	"github.com/google/uuid"
 )
 
 func TestParsePaginationParams(t *testing.T) {
@@ -328,4 +333,121 @@ func TestParsePaginationParams_BoundaryValues(t *testing.T) {
 			}
 		})
 	}
-}
\ No newline at end of file
+}
+func TestParseCursorParams(t *testing.T) {
+	cursor := models.Cursor{CreatedAt: time.Now().UTC(), ID: uuid.New(), Direction: models.CursorNext}
+
+	tests := []struct {
+		name                 string
+		queryParams          map[string]string
+		expectedLimit        int
+		expectCursor         bool
+		expectedIncludeTotal bool
+		expectedErrorField   string
+	}{
+		{
+			name:          "defaults",
+			queryParams:   map[string]string{},
+			expectedLimit: 10,
+		},
+		{
+			name: "cursor, limit and total",
+			queryParams: map[string]string{
+				"cursor":        cursor.Encode(),
+				"limit":         "25",
+				"include_total": "true",
+			},
+			expectedLimit:        25,
+			expectCursor:         true,
+			expectedIncludeTotal: true,
+		},
+		{
+			name:               "malformed cursor",
+			queryParams:        map[string]string{"cursor": "not-a-cursor"},
+			expectedErrorField: "cursor",
+		},
+		{
+			name:               "limit over maximum",
+			queryParams:        map[string]string{"limit": "101"},
+			expectedErrorField: "limit",
+		},
+		{
+			name:               "limit not a number",
+			queryParams:        map[string]string{"limit": "ten"},
+			expectedErrorField: "limit",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := url.Values{}
+			for key, value := range tt.queryParams {
+				q.Set(key, value)
+			}
+			req := httptest.NewRequest(http.MethodGet, "/posts?"+q.Encode(), nil)
+
+			params, err := ParseCursorParams(req)
+
+			if tt.expectedErrorField != "" {
+				appErr := errors.AsAppError(err)
+				if err == nil || appErr.Code != errors.ErrCodeValidation {
+					t.Fatalf("expected validation error, got %v", err)
+				}
+				if !strings.Contains(appErr.Message, tt.expectedErrorField) {
+					t.Errorf("expected error for field %q, got %q", tt.expectedErrorField, appErr.Message)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if params.Limit != tt.expectedLimit {
+				t.Errorf("expected limit %d, got %d", tt.expectedLimit, params.Limit)
+			}
+			if (params.Cursor != nil) != tt.expectCursor {
+				t.Errorf("expected cursor present to be %t, got %+v", tt.expectCursor, params.Cursor)
+			}
+			if tt.expectCursor && params.Cursor.ID != cursor.ID {
+				t.Errorf("expected cursor ID %s, got %s", cursor.ID, params.Cursor.ID)
+			}
+			if params.IncludeTotal != tt.expectedIncludeTotal {
+				t.Errorf("expected include total %t, got %t", tt.expectedIncludeTotal, params.IncludeTotal)
+			}
+		})
+	}
+}
+
+func TestParseIfMatch(t *testing.T) {
+	postID := uuid.New()
+	tag := func(id uuid.UUID, version string) string { return `"` + id.String() + "." + version + `"` }
+
+	tests := []struct {
+		name     string
+		header   string
+		expected *models.Precondition
+	}{
+		{"missing header", "", nil},
+		{"wildcard", "*", &models.Precondition{Any: true}},
+		{"single tag", tag(postID, "3"), &models.Precondition{Versions: []int{3}}},
+		{"several tags", tag(postID, "3") + ", " + tag(postID, "5"), &models.Precondition{Versions: []int{3, 5}}},
+		{"weak tag", "W/" + tag(postID, "3"), &models.Precondition{}},
+		{"another post's tag", tag(uuid.New(), "3"), &models.Precondition{}},
+		{"malformed tags", `"garbage", ` + tag(postID, "x") + ", " + postID.String() + ".3", &models.Precondition{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPut, "/", nil)
+			if tt.header != "" {
+				req.Header.Set("If-Match", tt.header)
+			}
+
+			precondition := ParseIfMatch(req, postID)
+
+			if !reflect.DeepEqual(precondition, tt.expected) {
+				t.Errorf("expected %+v, got %+v", tt.expected, precondition)
+			}
+		})
+	}
+}
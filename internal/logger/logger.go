@@ -3,6 +3,7 @@ package logger
 import (
 	"context"
 	"fmt"
+	"io"
 	"log/slog"
 	"os"
 	"runtime"
@@ -76,11 +77,32 @@ const (
 
 var globalLogger *Logger
 
-// Initialize sets up the global logger
-func Initialize(serviceName, version string) {
-	// Create structured JSON handler
+// Config configures a Logger
+type Config struct {
+	ServiceName string
+	Version     string
+	// Level is the minimum level written: "debug", "info", "warn" or "error"
+	Level string
+	// Format is the output encoding: "json" or "text"
+	Format string
+	// Output defaults to stdout
+	Output io.Writer
+}
+
+// New creates a logger from the given configuration
+func New(config Config) (*Logger, error) {
+	level, err := ParseLevel(config.Level)
+	if err != nil {
+		return nil, err
+	}
+
+	output := config.Output
+	if output == nil {
+		output = os.Stdout
+	}
+
 	opts := &slog.HandlerOptions{
-		Level: slog.LevelDebug,
+		Level: level,
 		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
 			// Customize timestamp format
 			if a.Key == slog.TimeKey {
@@ -93,14 +115,57 @@ func Initialize(serviceName, version string) {
 		},
 	}
 
-	handler := slog.NewJSONHandler(os.Stdout, opts)
-	slogger := slog.New(handler)
+	var handler slog.Handler
+	switch strings.ToLower(config.Format) {
+	case "", "json":
+		handler = slog.NewJSONHandler(output, opts)
+	case "text":
+		handler = slog.NewTextHandler(output, opts)
+	default:
+		return nil, fmt.Errorf("unsupported log format %q", config.Format)
+	}
 
-	globalLogger = &Logger{
-		Logger:      slogger,
-		serviceName: serviceName,
-		version:     version,
+	return &Logger{
+		Logger:      slog.New(handler),
+		serviceName: config.ServiceName,
+		version:     config.Version,
+	}, nil
+}
+
+// ParseLevel converts a level name to a slog.Level. An empty name means info.
+func ParseLevel(level string) (slog.Level, error) {
+	switch strings.ToLower(level) {
+	case "debug":
+		return slog.LevelDebug, nil
+	case "", "info":
+		return slog.LevelInfo, nil
+	case "warn", "warning":
+		return slog.LevelWarn, nil
+	case "error":
+		return slog.LevelError, nil
+	default:
+		return slog.LevelInfo, fmt.Errorf("unsupported log level %q", level)
+	}
+}
+
+// Initialize sets up the global logger
+func Initialize(serviceName, version string) {
+	globalLogger, _ = New(Config{
+		ServiceName: serviceName,
+		Version:     version,
+		Level:       "debug",
+		Format:      "json",
+	})
+}
+
+// InitializeWithConfig sets up the global logger from the given configuration
+func InitializeWithConfig(config Config) error {
+	logger, err := New(config)
+	if err != nil {
+		return err
 	}
+	globalLogger = logger
+	return nil
 }
 
 // GetLogger returns the global logger instance
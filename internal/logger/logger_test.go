@@ -0,0 +1,131 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestParseLevel(t *testing.T) {
+	tests := []struct {
+		name          string
+		level         string
+		expected      slog.Level
+		expectedError bool
+	}{
+		{name: "empty defaults to info", level: "", expected: slog.LevelInfo},
+		{name: "debug", level: "debug", expected: slog.LevelDebug},
+		{name: "upper case", level: "WARN", expected: slog.LevelWarn},
+		{name: "error", level: "error", expected: slog.LevelError},
+		{name: "unknown", level: "verbose", expectedError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			level, err := ParseLevel(tt.level)
+			if tt.expectedError {
+				if err == nil {
+					t.Error("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if level != tt.expected {
+				t.Errorf("expected level %v, got %v", tt.expected, level)
+			}
+		})
+	}
+}
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name          string
+		config        Config
+		expectJSON    bool
+		expectDebug   bool
+		expectedError bool
+	}{
+		{
+			name:       "json at info level",
+			config:     Config{Level: "info", Format: "json"},
+			expectJSON: true,
+		},
+		{
+			name:        "text at debug level",
+			config:      Config{Level: "debug", Format: "text"},
+			expectDebug: true,
+		},
+		{
+			name:          "unknown format",
+			config:        Config{Format: "xml"},
+			expectedError: true,
+		},
+		{
+			name:          "unknown level",
+			config:        Config{Level: "verbose"},
+			expectedError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			tt.config.ServiceName = "test-service"
+			tt.config.Output = &buf
+
+			log, err := New(tt.config)
+			if tt.expectedError {
+				if err == nil {
+					t.Error("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			log.Debug("debug message")
+			log.Info("info message", "key", "value")
+
+			output := buf.String()
+			if strings.Contains(output, "debug message") != tt.expectDebug {
+				t.Errorf("expected debug message logged to be %v, got output: %s", tt.expectDebug, output)
+			}
+
+			lines := strings.Split(strings.TrimSpace(output), "\n")
+			last := lines[len(lines)-1]
+			var entry map[string]interface{}
+			isJSON := json.Unmarshal([]byte(last), &entry) == nil
+			if isJSON != tt.expectJSON {
+				t.Errorf("expected JSON output to be %v, got: %s", tt.expectJSON, last)
+			}
+			if !strings.Contains(last, "service=test-service") && entry["service"] != "test-service" {
+				t.Errorf("expected service attribute, got: %s", last)
+			}
+		})
+	}
+}
+
+func TestLogger_WithContext(t *testing.T) {
+	var buf bytes.Buffer
+	log, err := New(Config{Format: "json", Output: &buf})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	ctx := context.WithValue(context.Background(), RequestIDKey, "req-123")
+	log.WithContext(ctx).Info("message")
+
+	var entry map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("failed to decode log entry: %v", err)
+	}
+	if entry["request_id"] != "req-123" {
+		t.Errorf("expected request_id %q, got %v", "req-123", entry["request_id"])
+	}
+}
@@ -5,6 +5,9 @@ import (
 	"net/http"
 	"strings"
 
+	"github.com/alinoer/go-std-api/internal/logger"
+	"github.com/alinoer/go-std-api/internal/models"
+	"github.com/alinoer/go-std-api/internal/response"
 	"github.com/alinoer/go-std-api/internal/service"
 )
 
@@ -13,6 +16,7 @@ type contextKey string
 const (
 	UserIDKey   contextKey = "userID"
 	UsernameKey contextKey = "username"
+	RoleKey     contextKey = "role"
 )
 
 func JWTAuthMiddleware(authService *service.AuthService) func(http.Handler) http.Handler {
@@ -20,52 +24,93 @@ func JWTAuthMiddleware(authService *service.AuthService) func(http.Handler) http
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			authHeader := r.Header.Get("Authorization")
 			if authHeader == "" {
-				http.Error(w, "Authorization header required", http.StatusUnauthorized)
+				response.NewResponseWriter(w, r).Unauthorized("Authorization header required")
 				return
 			}
 
-			// Check if it's a Bearer token
-			if !strings.HasPrefix(authHeader, "Bearer ") {
-				http.Error(w, "Invalid authorization format. Use 'Bearer <token>'", http.StatusUnauthorized)
+			ctx, ok := authenticate(w, r, authService, authHeader)
+			if !ok {
 				return
 			}
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
 
-			token := strings.TrimPrefix(authHeader, "Bearer ")
-			
-			// Validate JWT token
-			claims, err := authService.ValidateToken(token)
-			if err != nil {
-				http.Error(w, "Invalid or expired token", http.StatusUnauthorized)
+// OptionalJWTAuthMiddleware authenticates requests that carry a token and
+// lets anonymous requests through, for public routes whose responses differ
+// for the signed in user. Invalid tokens are still rejected.
+func OptionalJWTAuthMiddleware(authService *service.AuthService) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			authHeader := r.Header.Get("Authorization")
+			if authHeader == "" {
+				next.ServeHTTP(w, r)
 				return
 			}
 
-			// Set user information in context
-			ctx := context.WithValue(r.Context(), UserIDKey, claims.UserID.String())
-			ctx = context.WithValue(ctx, UsernameKey, claims.Username)
+			ctx, ok := authenticate(w, r, authService, authHeader)
+			if !ok {
+				return
+			}
 			next.ServeHTTP(w, r.WithContext(ctx))
 		})
 	}
 }
 
+// authenticate validates the bearer token in authHeader and returns the
+// request context with the user's information. It writes the error response
+// and returns false when the token is not accepted.
+func authenticate(w http.ResponseWriter, r *http.Request, authService *service.AuthService, authHeader string) (context.Context, bool) {
+	// Check if it's a Bearer token
+	if !strings.HasPrefix(authHeader, "Bearer ") {
+		response.NewResponseWriter(w, r).Unauthorized("Invalid authorization format. Use 'Bearer <token>'")
+		return nil, false
+	}
+
+	token := strings.TrimPrefix(authHeader, "Bearer ")
+
+	// Validate JWT token
+	claims, err := authService.ValidateToken(token)
+	if err != nil {
+		response.NewResponseWriter(w, r).Unauthorized("Invalid or expired token")
+		return nil, false
+	}
+
+	// Reject tokens that were revoked before they expired
+	if err := authService.CheckRevocation(r.Context(), claims); err != nil {
+		response.NewResponseWriter(w, r).Error(err)
+		return nil, false
+	}
+
+	// Set user information in context
+	ctx := context.WithValue(r.Context(), UserIDKey, claims.UserID.String())
+	ctx = context.WithValue(ctx, UsernameKey, claims.Username)
+	ctx = context.WithValue(ctx, RoleKey, claims.Role)
+	ctx = context.WithValue(ctx, logger.UserIDKey, claims.UserID.String())
+	ctx = models.ContextWithViewer(ctx, claims.UserID)
+	return ctx, true
+}
+
 // Legacy middleware for backward compatibility (if needed)
 func AuthMiddleware(apiKey string) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			authHeader := r.Header.Get("Authorization")
 			if authHeader == "" {
-				http.Error(w, "Authorization header required", http.StatusUnauthorized)
+				response.NewResponseWriter(w, r).Unauthorized("Authorization header required")
 				return
 			}
 
 			// Check if it's a Bearer token
 			if !strings.HasPrefix(authHeader, "Bearer ") {
-				http.Error(w, "Invalid authorization format. Use 'Bearer <token>'", http.StatusUnauthorized)
+				response.NewResponseWriter(w, r).Unauthorized("Invalid authorization format. Use 'Bearer <token>'")
 				return
 			}
 
 			token := strings.TrimPrefix(authHeader, "Bearer ")
 			if token != apiKey {
-				http.Error(w, "Invalid API key", http.StatusUnauthorized)
+				response.NewResponseWriter(w, r).Unauthorized("Invalid API key")
 				return
 			}
 
@@ -89,3 +134,9 @@ func GetUsernameFromContext(ctx context.Context) (string, bool) {
 	username, ok := ctx.Value(UsernameKey).(string)
 	return username, ok
 }
+
+// GetRoleFromContext extracts the role of the authenticated user from the request context
+func GetRoleFromContext(ctx context.Context) (models.Role, bool) {
+	role, ok := ctx.Value(RoleKey).(models.Role)
+	return role, ok
+}
\ No newline at end of file
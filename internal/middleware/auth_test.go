@@ -2,9 +2,17 @@ package middleware
 
 import (
 	"context"
+	"encoding/json"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+
+	"github.com/alinoer/go-std-api/internal/errors"
+	"github.com/alinoer/go-std-api/internal/models"
+	"github.com/alinoer/go-std-api/internal/repository"
+	"github.com/alinoer/go-std-api/internal/response"
+	"github.com/alinoer/go-std-api/internal/service"
+	"github.com/google/uuid"
 )
 
 func TestAuthMiddleware(t *testing.T) {
@@ -15,6 +23,7 @@ func TestAuthMiddleware(t *testing.T) {
 		authHeader         string
 		expectedStatusCode int
 		expectedBody       string
+		expectedError      string
 		expectContextValue bool
 	}{
 		{
@@ -28,35 +37,35 @@ func TestAuthMiddleware(t *testing.T) {
 			name:               "missing authorization header",
 			authHeader:         "",
 			expectedStatusCode: http.StatusUnauthorized,
-			expectedBody:       "Authorization header required\n",
+			expectedError:      "Authorization header required",
 			expectContextValue: false,
 		},
 		{
 			name:               "invalid authorization format",
 			authHeader:         "Basic " + apiKey,
 			expectedStatusCode: http.StatusUnauthorized,
-			expectedBody:       "Invalid authorization format. Use 'Bearer <token>'\n",
+			expectedError:      "Invalid authorization format. Use 'Bearer <token>'",
 			expectContextValue: false,
 		},
 		{
 			name:               "invalid api key",
 			authHeader:         "Bearer wrong-key",
 			expectedStatusCode: http.StatusUnauthorized,
-			expectedBody:       "Invalid API key\n",
+			expectedError:      "Invalid API key",
 			expectContextValue: false,
 		},
 		{
 			name:               "empty bearer token",
 			authHeader:         "Bearer ",
 			expectedStatusCode: http.StatusUnauthorized,
-			expectedBody:       "Invalid API key\n",
+			expectedError:      "Invalid API key",
 			expectContextValue: false,
 		},
 		{
 			name:               "bearer with space only",
 			authHeader:         "Bearer",
 			expectedStatusCode: http.StatusUnauthorized,
-			expectedBody:       "Invalid authorization format. Use 'Bearer <token>'\n",
+			expectedError:      "Invalid authorization format. Use 'Bearer <token>'",
 			expectContextValue: false,
 		},
 	}
@@ -93,7 +102,15 @@ func TestAuthMiddleware(t *testing.T) {
 			}
 
 			// Check response body
-			if w.Body.String() != tt.expectedBody {
+			if tt.expectedError != "" {
+				var errResp response.ErrorResponse
+				if err := json.Unmarshal(w.Body.Bytes(), &errResp); err != nil {
+					t.Fatalf("failed to decode error response %q: %v", w.Body.String(), err)
+				}
+				if errResp.Error != tt.expectedError || errResp.Code != string(errors.ErrCodeUnauthorized) {
+					t.Errorf("expected %s error %q, got %s %q", errors.ErrCodeUnauthorized, tt.expectedError, errResp.Code, errResp.Error)
+				}
+			} else if w.Body.String() != tt.expectedBody {
 				t.Errorf("expected body %q, got %q", tt.expectedBody, w.Body.String())
 			}
 
@@ -199,4 +216,165 @@ func TestAuthMiddleware_Integration(t *testing.T) {
 	if w.Body.String() != "success" {
 		t.Errorf("expected body 'success', got %q", w.Body.String())
 	}
-}
\ No newline at end of file
+}
+func TestJWTAuthMiddleware_Revocation(t *testing.T) {
+	ctx := context.Background()
+	revocationStore := repository.NewMemoryTokenRevocationStore()
+	authService := service.NewAuthService("test-secret-key", nil, nil, revocationStore)
+	userID := uuid.New()
+
+	newToken := func() string {
+		token, _, err := authService.GenerateToken(ctx, &models.User{ID: userID, Username: "testuser"})
+		if err != nil {
+			t.Fatalf("failed to generate token: %v", err)
+		}
+		return token
+	}
+
+	validToken := newToken()
+	revokedToken := newToken()
+	if err := authService.RevokeAccessToken(ctx, revokedToken); err != nil {
+		t.Fatalf("failed to revoke token: %v", err)
+	}
+
+	otherUserID := uuid.New()
+	staleToken, _, _ := authService.GenerateToken(ctx, &models.User{ID: otherUserID, Username: "otheruser"})
+	if _, err := revocationStore.IncrementTokenGeneration(ctx, otherUserID); err != nil {
+		t.Fatalf("failed to increment generation: %v", err)
+	}
+
+	tests := []struct {
+		name               string
+		token              string
+		expectedStatusCode int
+		expectedBody       string
+		expectedError      string
+	}{
+		{
+			name:               "valid token",
+			token:              validToken,
+			expectedStatusCode: http.StatusOK,
+			expectedBody:       "OK",
+		},
+		{
+			name:               "revoked token",
+			token:              revokedToken,
+			expectedStatusCode: http.StatusUnauthorized,
+			expectedError:      "Token has been revoked",
+		},
+		{
+			name:               "token from previous generation",
+			token:              staleToken,
+			expectedStatusCode: http.StatusUnauthorized,
+			expectedError:      "Token has been revoked",
+		},
+		{
+			name:               "malformed token",
+			token:              "not-a-token",
+			expectedStatusCode: http.StatusUnauthorized,
+			expectedError:      "Invalid or expired token",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+				w.Write([]byte("OK"))
+			})
+			handler := JWTAuthMiddleware(authService)(testHandler)
+
+			req := httptest.NewRequest(http.MethodGet, "/test", nil)
+			req.Header.Set("Authorization", "Bearer "+tt.token)
+			w := httptest.NewRecorder()
+
+			handler.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedStatusCode {
+				t.Errorf("expected status code %d, got %d", tt.expectedStatusCode, w.Code)
+			}
+			if tt.expectedError != "" {
+				var errResp response.ErrorResponse
+				if err := json.Unmarshal(w.Body.Bytes(), &errResp); err != nil {
+					t.Fatalf("failed to decode error response %q: %v", w.Body.String(), err)
+				}
+				if errResp.Error != tt.expectedError || errResp.Code != string(errors.ErrCodeUnauthorized) {
+					t.Errorf("expected %s error %q, got %s %q", errors.ErrCodeUnauthorized, tt.expectedError, errResp.Code, errResp.Error)
+				}
+				return
+			}
+			if w.Body.String() != tt.expectedBody {
+				t.Errorf("expected body %q, got %q", tt.expectedBody, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestOptionalJWTAuthMiddleware(t *testing.T) {
+	ctx := context.Background()
+	authService := service.NewAuthService("test-secret-key", nil, nil, repository.NewMemoryTokenRevocationStore())
+	userID := uuid.New()
+
+	validToken, _, err := authService.GenerateToken(ctx, &models.User{ID: userID, Username: "testuser"})
+	if err != nil {
+		t.Fatalf("failed to generate token: %v", err)
+	}
+
+	tests := []struct {
+		name               string
+		authHeader         string
+		expectedStatusCode int
+		expectViewer       bool
+	}{
+		{
+			name:               "anonymous",
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:               "valid token",
+			authHeader:         "Bearer " + validToken,
+			expectedStatusCode: http.StatusOK,
+			expectViewer:       true,
+		},
+		{
+			name:               "invalid token",
+			authHeader:         "Bearer not-a-token",
+			expectedStatusCode: http.StatusUnauthorized,
+		},
+		{
+			name:               "wrong scheme",
+			authHeader:         "Basic dXNlcjpwYXNz",
+			expectedStatusCode: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var viewerID uuid.UUID
+			var hasViewer bool
+			testHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				viewerID, hasViewer = models.ViewerFromContext(r.Context())
+				w.WriteHeader(http.StatusOK)
+			})
+			handler := OptionalJWTAuthMiddleware(authService)(testHandler)
+
+			req := httptest.NewRequest(http.MethodGet, "/test", nil)
+			if tt.authHeader != "" {
+				req.Header.Set("Authorization", tt.authHeader)
+			}
+			w := httptest.NewRecorder()
+
+			handler.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedStatusCode {
+				t.Errorf("expected status code %d, got %d", tt.expectedStatusCode, w.Code)
+			}
+			if hasViewer != tt.expectViewer {
+				t.Errorf("expected viewer present to be %t, got %t", tt.expectViewer, hasViewer)
+			}
+			if tt.expectViewer && viewerID != userID {
+				t.Errorf("expected viewer %s, got %s", userID, viewerID)
+			}
+		})
+	}
+}
@@ -69,9 +69,12 @@ func ErrorHandler(config *ErrorHandlerConfig) func(http.Handler) http.Handler {
 					
 					appErr := errors.InternalError("Internal server error").
 						WithInternal(err).
-						WithContext("panic", true).
-						WithContext("stack_trace", stackTrace)
-					
+						WithContext("panic", true)
+
+					if config.EnableStackTrace {
+						appErr = appErr.WithContext("stack_trace", stackTrace)
+					}
+
 					ew.WriteError(appErr)
 				}
 			}()
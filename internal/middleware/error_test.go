@@ -380,4 +380,42 @@ func TestGetRequestID(t *testing.T) {
 			}
 		})
 	}
-}
\ No newline at end of file
+}
+func TestErrorHandler_StackTrace(t *testing.T) {
+	tests := []struct {
+		name             string
+		enableStackTrace bool
+	}{
+		{name: "dev mode", enableStackTrace: true},
+		{name: "stack traces disabled", enableStackTrace: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := &ErrorHandlerConfig{
+				EnableStackTrace:     tt.enableStackTrace,
+				EnableDetailedErrors: true,
+				DefaultMessage:       "An error occurred",
+				Logger:               logger.GetLogger(),
+			}
+			handler := ErrorHandler(config)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				panic("test panic")
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, "/test", nil)
+			w := httptest.NewRecorder()
+
+			handler.ServeHTTP(w, req)
+
+			var response ErrorResponse
+			if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+
+			_, hasStackTrace := response.Context["stack_trace"]
+			if hasStackTrace != tt.enableStackTrace {
+				t.Errorf("expected stack trace present to be %v, got %v", tt.enableStackTrace, hasStackTrace)
+			}
+		})
+	}
+}
@@ -0,0 +1,192 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/alinoer/go-std-api/internal/errors"
+	"github.com/alinoer/go-std-api/internal/logger"
+	"github.com/alinoer/go-std-api/internal/models"
+	"github.com/alinoer/go-std-api/internal/repository"
+	"github.com/alinoer/go-std-api/internal/response"
+)
+
+const (
+	IdempotencyKeyHeader     = "Idempotency-Key"
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+	maxIdempotencyKeyLength  = 255
+)
+
+// replayedHeaders are the response headers stored with a response and sent
+// again when it is replayed
+var replayedHeaders = []string{"Content-Type", "ETag", "Location"}
+
+// IdempotencyConfig holds the settings for the Idempotency middleware
+type IdempotencyConfig struct {
+	// TTL is how long a response is kept for replay
+	TTL time.Duration
+	// LockTimeout is how long a request holds its key. A retry after that
+	// runs the request again even if the first one never finished.
+	LockTimeout time.Duration
+	// MaxBodySize is the largest request body, in bytes, that is read to
+	// fingerprint a request with a key
+	MaxBodySize int64
+}
+
+// DefaultIdempotencyConfig keeps responses for a day
+func DefaultIdempotencyConfig() IdempotencyConfig {
+	return IdempotencyConfig{
+		TTL:         24 * time.Hour,
+		LockTimeout: time.Minute,
+		MaxBodySize: 1 << 20,
+	}
+}
+
+// Idempotency makes requests with an Idempotency-Key header safe to retry.
+// The first request with a key runs as usual and its response is stored;
+// retries with the same key and request get the stored response back with an
+// Idempotent-Replayed header instead of running again. Reusing a key for a
+// different request, or while the first one is still running, is a conflict.
+// Keys belong to the user set by JWTAuthMiddleware, so it must be mounted
+// after it on protected routes; keys of anonymous requests belong to the
+// client's IP address. Server errors are not stored, so that the request can
+// be retried.
+func Idempotency(store repository.IdempotencyStore, config IdempotencyConfig) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get(IdempotencyKeyHeader)
+			if key == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			resp := response.NewResponseWriter(w, r)
+			if len(key) > maxIdempotencyKeyLength {
+				resp.Error(errors.ValidationError(IdempotencyKeyHeader, "Idempotency-Key must be at most 255 characters"))
+				return
+			}
+
+			body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, config.MaxBodySize))
+			if err != nil {
+				var maxBytesErr *http.MaxBytesError
+				if errors.As(err, &maxBytesErr) {
+					resp.Error(errors.PayloadTooLarge(maxBytesErr.Limit))
+					return
+				}
+				resp.BadRequest("Invalid request body")
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+
+			scope := "ip:" + ClientIP(r)
+			if userID, ok := GetUserIDFromContext(r.Context()); ok {
+				scope = "user:" + userID
+			}
+			fingerprint := requestFingerprint(r, body)
+
+			record, err := store.Claim(r.Context(), scope, key, fingerprint, config.LockTimeout)
+			if err != nil {
+				resp.Error(err)
+				return
+			}
+			if record != nil {
+				switch {
+				case record.Fingerprint != fingerprint:
+					resp.Error(errors.NewAppError(errors.ErrCodeConflict, "Idempotency-Key has already been used for a different request"))
+				case record.InFlight():
+					resp.Error(errors.NewAppError(errors.ErrCodeConflict, "A request with this Idempotency-Key is still in progress"))
+				default:
+					replay(w, record)
+				}
+				return
+			}
+
+			// Store the outcome even if the client has gone away
+			storeCtx := context.WithoutCancel(r.Context())
+			recorder := &recordingResponseWriter{ResponseWriter: w}
+			completed := false
+			defer func() {
+				if !completed {
+					if err := store.Release(storeCtx, scope, key); err != nil {
+						logger.GetLogger().WithContext(storeCtx).Error("Failed to release idempotency key", err)
+					}
+				}
+			}()
+
+			next.ServeHTTP(recorder, r)
+
+			statusCode := recorder.statusCode
+			if statusCode == 0 {
+				statusCode = http.StatusOK
+			}
+			if statusCode >= http.StatusInternalServerError {
+				return
+			}
+
+			header := make(map[string][]string)
+			for _, name := range replayedHeaders {
+				if values := w.Header().Values(name); len(values) > 0 {
+					header[name] = values
+				}
+			}
+			completed = true
+			err = store.Complete(storeCtx, scope, key, &models.IdempotencyRecord{
+				Fingerprint: fingerprint,
+				StatusCode:  statusCode,
+				Header:      header,
+				Body:        recorder.body.Bytes(),
+			}, config.TTL)
+			if err != nil {
+				logger.GetLogger().WithContext(storeCtx).Error("Failed to store idempotent response", err)
+			}
+		})
+	}
+}
+
+// requestFingerprint identifies a request by its method, URL and body
+func requestFingerprint(r *http.Request, body []byte) string {
+	hash := sha256.New()
+	io.WriteString(hash, r.Method+" "+r.URL.RequestURI()+"\n")
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// replay writes a stored response
+func replay(w http.ResponseWriter, record *models.IdempotencyRecord) {
+	for name, values := range record.Header {
+		for _, value := range values {
+			w.Header().Add(name, value)
+		}
+	}
+	w.Header().Set(IdempotentReplayedHeader, "true")
+	w.WriteHeader(record.StatusCode)
+	w.Write(record.Body)
+}
+
+// recordingResponseWriter keeps a copy of the status code and body written
+// through it
+type recordingResponseWriter struct {
+	http.ResponseWriter
+	statusCode int
+	body       bytes.Buffer
+}
+
+func (rw *recordingResponseWriter) WriteHeader(code int) {
+	if rw.statusCode == 0 {
+		rw.statusCode = code
+	}
+	rw.ResponseWriter.WriteHeader(code)
+}
+
+func (rw *recordingResponseWriter) Write(data []byte) (int, error) {
+	if rw.statusCode == 0 {
+		rw.statusCode = http.StatusOK
+	}
+	rw.body.Write(data)
+	return rw.ResponseWriter.Write(data)
+}
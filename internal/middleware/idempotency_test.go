@@ -0,0 +1,259 @@
+package middleware
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alinoer/go-std-api/internal/models"
+)
+
+// MockIdempotencyStore keeps records in memory and ignores expiry
+type MockIdempotencyStore struct {
+	records  map[string]*models.IdempotencyRecord
+	ttl      time.Duration
+	released int
+}
+
+func NewMockIdempotencyStore() *MockIdempotencyStore {
+	return &MockIdempotencyStore{records: make(map[string]*models.IdempotencyRecord)}
+}
+
+func (m *MockIdempotencyStore) Claim(ctx context.Context, scope, key, fingerprint string, lockTimeout time.Duration) (*models.IdempotencyRecord, error) {
+	if record, exists := m.records[scope+"/"+key]; exists {
+		return record, nil
+	}
+	m.records[scope+"/"+key] = &models.IdempotencyRecord{Fingerprint: fingerprint}
+	return nil, nil
+}
+
+func (m *MockIdempotencyStore) Complete(ctx context.Context, scope, key string, record *models.IdempotencyRecord, ttl time.Duration) error {
+	m.records[scope+"/"+key] = record
+	m.ttl = ttl
+	return nil
+}
+
+func (m *MockIdempotencyStore) Release(ctx context.Context, scope, key string) error {
+	delete(m.records, scope+"/"+key)
+	m.released++
+	return nil
+}
+
+func TestIdempotency(t *testing.T) {
+	config := IdempotencyConfig{TTL: time.Hour, LockTimeout: time.Minute, MaxBodySize: 64}
+
+	// newHandler counts the requests that reach it and answers with the
+	// status code and a body that tells the calls apart
+	newHandler := func(statusCode int, calls *int) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			*calls++
+			body, _ := io.ReadAll(r.Body)
+			w.Header().Set("Content-Type", "application/json")
+			w.Header().Set("Location", "/posts/1")
+			w.Header().Set("X-Other", "not replayed")
+			w.WriteHeader(statusCode)
+			w.Write([]byte(`{"call":` + strconv.Itoa(*calls) + `,"body":` + string(body) + `}`))
+		})
+	}
+
+	newRequest := func(key, body, userID string) *http.Request {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/posts", strings.NewReader(body))
+		if key != "" {
+			req.Header.Set(IdempotencyKeyHeader, key)
+		}
+		if userID != "" {
+			req = req.WithContext(context.WithValue(req.Context(), UserIDKey, userID))
+		}
+		return req
+	}
+
+	t.Run("retry gets the stored response", func(t *testing.T) {
+		store := NewMockIdempotencyStore()
+		calls := 0
+		handler := Idempotency(store, config)(newHandler(http.StatusCreated, &calls))
+
+		first := httptest.NewRecorder()
+		handler.ServeHTTP(first, newRequest("key-1", `{"title":"a"}`, "user-1"))
+		retry := httptest.NewRecorder()
+		handler.ServeHTTP(retry, newRequest("key-1", `{"title":"a"}`, "user-1"))
+
+		if calls != 1 {
+			t.Fatalf("expected the handler to run once, ran %d times", calls)
+		}
+		if retry.Code != http.StatusCreated || retry.Body.String() != first.Body.String() {
+			t.Errorf("expected the first response %d %s, got %d %s", first.Code, first.Body.String(), retry.Code, retry.Body.String())
+		}
+		if retry.Header().Get("Location") != "/posts/1" || retry.Header().Get("Content-Type") != "application/json" {
+			t.Errorf("expected the stored headers to be replayed, got %v", retry.Header())
+		}
+		if retry.Header().Get("X-Other") != "" {
+			t.Errorf("expected other headers not to be replayed, got %v", retry.Header())
+		}
+		if retry.Header().Get(IdempotentReplayedHeader) != "true" || first.Header().Get(IdempotentReplayedHeader) != "" {
+			t.Errorf("expected only the retry to be marked as replayed")
+		}
+		if store.ttl != time.Hour {
+			t.Errorf("expected the response to be kept for an hour, got %v", store.ttl)
+		}
+	})
+
+	t.Run("same key with a different body", func(t *testing.T) {
+		store := NewMockIdempotencyStore()
+		calls := 0
+		handler := Idempotency(store, config)(newHandler(http.StatusCreated, &calls))
+
+		handler.ServeHTTP(httptest.NewRecorder(), newRequest("key-1", `{"title":"a"}`, "user-1"))
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, newRequest("key-1", `{"title":"b"}`, "user-1"))
+
+		if w.Code != http.StatusConflict || calls != 1 {
+			t.Errorf("expected 409 without running the handler again, got %d after %d calls", w.Code, calls)
+		}
+	})
+
+	t.Run("request still in flight", func(t *testing.T) {
+		store := NewMockIdempotencyStore()
+		calls := 0
+		var retry *httptest.ResponseRecorder
+		var handler http.Handler
+		handler = Idempotency(store, config)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			calls++
+			if calls == 1 {
+				retry = httptest.NewRecorder()
+				handler.ServeHTTP(retry, newRequest("key-1", `{}`, "user-1"))
+			}
+			w.WriteHeader(http.StatusCreated)
+		}))
+
+		handler.ServeHTTP(httptest.NewRecorder(), newRequest("key-1", `{}`, "user-1"))
+
+		if retry.Code != http.StatusConflict || calls != 1 {
+			t.Errorf("expected 409 for the concurrent retry, got %d after %d calls", retry.Code, calls)
+		}
+	})
+
+	t.Run("keys belong to a user", func(t *testing.T) {
+		store := NewMockIdempotencyStore()
+		calls := 0
+		handler := Idempotency(store, config)(newHandler(http.StatusCreated, &calls))
+
+		handler.ServeHTTP(httptest.NewRecorder(), newRequest("key-1", `{}`, "user-1"))
+		handler.ServeHTTP(httptest.NewRecorder(), newRequest("key-1", `{}`, "user-2"))
+		handler.ServeHTTP(httptest.NewRecorder(), newRequest("key-1", `{}`, ""))
+
+		if calls != 3 {
+			t.Errorf("expected every user's request to run, ran %d times", calls)
+		}
+	})
+
+	t.Run("anonymous keys belong to a client address", func(t *testing.T) {
+		store := NewMockIdempotencyStore()
+		calls := 0
+		handler := Idempotency(store, config)(newHandler(http.StatusCreated, &calls))
+
+		newAnonymousRequest := func(remoteAddr, body string) *http.Request {
+			req := newRequest("key-1", body, "")
+			req.RemoteAddr = remoteAddr
+			return req
+		}
+
+		handler.ServeHTTP(httptest.NewRecorder(), newAnonymousRequest("192.0.2.1:1234", `{"username":"a"}`))
+		other := httptest.NewRecorder()
+		handler.ServeHTTP(other, newAnonymousRequest("192.0.2.2:1234", `{"username":"b"}`))
+		retry := httptest.NewRecorder()
+		handler.ServeHTTP(retry, newAnonymousRequest("192.0.2.1:5678", `{"username":"a"}`))
+
+		if other.Code != http.StatusCreated || other.Header().Get(IdempotentReplayedHeader) != "" {
+			t.Errorf("expected another client's key to be independent, got %d", other.Code)
+		}
+		if calls != 2 || retry.Header().Get(IdempotentReplayedHeader) != "true" {
+			t.Errorf("expected the retry from the same address to be replayed, got %d calls", calls)
+		}
+	})
+
+	t.Run("body too large", func(t *testing.T) {
+		store := NewMockIdempotencyStore()
+		calls := 0
+		handler := Idempotency(store, config)(newHandler(http.StatusCreated, &calls))
+
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, newRequest("key-1", `{"content":"`+strings.Repeat("a", 100)+`"}`, "user-1"))
+
+		if w.Code != http.StatusRequestEntityTooLarge || calls != 0 || len(store.records) != 0 {
+			t.Errorf("expected 413 without claiming the key, got %d after %d calls", w.Code, calls)
+		}
+	})
+
+	t.Run("server errors are not stored", func(t *testing.T) {
+		store := NewMockIdempotencyStore()
+		calls := 0
+		handler := Idempotency(store, config)(newHandler(http.StatusInternalServerError, &calls))
+
+		handler.ServeHTTP(httptest.NewRecorder(), newRequest("key-1", `{}`, "user-1"))
+		handler.ServeHTTP(httptest.NewRecorder(), newRequest("key-1", `{}`, "user-1"))
+
+		if calls != 2 || store.released != 2 {
+			t.Errorf("expected both requests to run and release the key, got %d calls and %d releases", calls, store.released)
+		}
+	})
+
+	t.Run("client errors are stored", func(t *testing.T) {
+		store := NewMockIdempotencyStore()
+		calls := 0
+		handler := Idempotency(store, config)(newHandler(http.StatusBadRequest, &calls))
+
+		handler.ServeHTTP(httptest.NewRecorder(), newRequest("key-1", `{}`, "user-1"))
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, newRequest("key-1", `{}`, "user-1"))
+
+		if calls != 1 || w.Code != http.StatusBadRequest {
+			t.Errorf("expected the 400 to be replayed, got %d after %d calls", w.Code, calls)
+		}
+	})
+
+	t.Run("panicking handler releases the key", func(t *testing.T) {
+		store := NewMockIdempotencyStore()
+		handler := Idempotency(store, config)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			panic("boom")
+		}))
+
+		func() {
+			defer func() { recover() }()
+			handler.ServeHTTP(httptest.NewRecorder(), newRequest("key-1", `{}`, "user-1"))
+		}()
+
+		if store.released != 1 || len(store.records) != 0 {
+			t.Errorf("expected the key to be released, got %d releases", store.released)
+		}
+	})
+
+	t.Run("without a key", func(t *testing.T) {
+		store := NewMockIdempotencyStore()
+		calls := 0
+		handler := Idempotency(store, config)(newHandler(http.StatusCreated, &calls))
+
+		handler.ServeHTTP(httptest.NewRecorder(), newRequest("", `{}`, "user-1"))
+		handler.ServeHTTP(httptest.NewRecorder(), newRequest("", `{}`, "user-1"))
+
+		if calls != 2 || len(store.records) != 0 {
+			t.Errorf("expected both requests to run untracked, got %d calls", calls)
+		}
+	})
+
+	t.Run("key too long", func(t *testing.T) {
+		calls := 0
+		handler := Idempotency(NewMockIdempotencyStore(), config)(newHandler(http.StatusCreated, &calls))
+
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, newRequest(strings.Repeat("k", 256), `{}`, "user-1"))
+
+		if w.Code != http.StatusBadRequest || calls != 0 {
+			t.Errorf("expected 400 without running the handler, got %d after %d calls", w.Code, calls)
+		}
+	})
+}
@@ -4,6 +4,8 @@ import (
 	"log"
 	"net/http"
 	"time"
+
+	"github.com/alinoer/go-std-api/internal/logger"
 )
 
 type responseWriter struct {
@@ -38,4 +40,23 @@ func LoggingMiddleware(next http.Handler) http.Handler {
 			duration,
 		)
 	})
-}
\ No newline at end of file
+}
+
+// RequestLogger logs every request through the structured logger, including
+// the request ID set by RequestID
+func RequestLogger(log *logger.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+
+			wrapped := &responseWriter{
+				ResponseWriter: w,
+				statusCode:     http.StatusOK,
+			}
+
+			next.ServeHTTP(wrapped, r)
+
+			log.LogHTTPRequest(r.Context(), r.Method, r.URL.Path, wrapped.statusCode, time.Since(start), nil)
+		})
+	}
+}
@@ -2,6 +2,8 @@ package middleware
 
 import (
 	"bytes"
+	"context"
+	"encoding/json"
 	"log"
 	"net/http"
 	"net/http/httptest"
@@ -9,6 +11,8 @@ import (
 	"strings"
 	"testing"
 	"time"
+
+	"github.com/alinoer/go-std-api/internal/logger"
 )
 
 func TestLoggingMiddleware(t *testing.T) {
@@ -231,4 +235,40 @@ func TestLoggingMiddleware_Integration(t *testing.T) {
 			t.Errorf("expected log to contain %q, got: %s", element, logOutput)
 		}
 	}
-}
\ No newline at end of file
+}
+
+func TestRequestLogger(t *testing.T) {
+	var logBuffer bytes.Buffer
+	log, err := logger.New(logger.Config{ServiceName: "test-service", Format: "json", Output: &logBuffer})
+	if err != nil {
+		t.Fatalf("failed to create logger: %v", err)
+	}
+
+	handler := RequestLogger(log)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+	}))
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/posts", nil)
+	req = req.WithContext(context.WithValue(req.Context(), logger.RequestIDKey, "req-123"))
+	w := httptest.NewRecorder()
+
+	handler.ServeHTTP(w, req)
+
+	var entry map[string]interface{}
+	if err := json.Unmarshal(logBuffer.Bytes(), &entry); err != nil {
+		t.Fatalf("failed to decode log entry %q: %v", logBuffer.String(), err)
+	}
+
+	expected := map[string]interface{}{
+		"method":      http.MethodPost,
+		"path":        "/api/v1/posts",
+		"status_code": float64(http.StatusCreated),
+		"request_id":  "req-123",
+		"service":     "test-service",
+	}
+	for key, value := range expected {
+		if entry[key] != value {
+			t.Errorf("expected log %s %v, got %v", key, value, entry[key])
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package middleware
+
+import (
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/alinoer/go-std-api/internal/errors"
+	"github.com/alinoer/go-std-api/internal/logger"
+	"github.com/alinoer/go-std-api/internal/models"
+	"github.com/alinoer/go-std-api/internal/repository"
+	"github.com/alinoer/go-std-api/internal/response"
+)
+
+const (
+	RateLimitLimitHeader     = "RateLimit-Limit"
+	RateLimitRemainingHeader = "RateLimit-Remaining"
+	RateLimitResetHeader     = "RateLimit-Reset"
+	RateLimitPolicyHeader    = "RateLimit-Policy"
+	RetryAfterHeader         = "Retry-After"
+)
+
+// RateLimitConfig holds the settings for a RateLimit middleware
+type RateLimitConfig struct {
+	// Name identifies the route group. Every group has its own buckets, so
+	// reading posts does not use up a client's logins.
+	Name  string
+	Limit models.RateLimit
+}
+
+// RateLimit limits how often a client can call the routes it is mounted on.
+// Signed in users are limited by their user ID, so it must be mounted after
+// JWTAuthMiddleware to tell them apart; anonymous clients are limited by IP
+// address, which chimw.RealIP takes from the proxy headers. Every response
+// carries RateLimit-* headers, and rejected requests get a 429 with a
+// Retry-After header. If the store fails, requests are let through.
+func RateLimit(store repository.RateLimitStore, config RateLimitConfig) func(http.Handler) http.Handler {
+	policy := strconv.Itoa(config.Limit.Requests) + ";w=" + strconv.Itoa(seconds(config.Limit.Period))
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			result, err := store.Take(r.Context(), config.Name+":"+rateLimitKey(r), config.Limit)
+			if err != nil {
+				logger.GetLogger().WithContext(r.Context()).Error("Failed to check rate limit", err)
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			header := w.Header()
+			header.Set(RateLimitLimitHeader, strconv.Itoa(config.Limit.Requests))
+			header.Set(RateLimitRemainingHeader, strconv.Itoa(result.Remaining))
+			header.Set(RateLimitResetHeader, strconv.Itoa(seconds(result.ResetAfter)))
+			header.Set(RateLimitPolicyHeader, policy)
+
+			if !result.Allowed {
+				// Clients that retry right away would only be rejected again
+				retryAfter := seconds(result.RetryAfter)
+				if retryAfter < 1 {
+					retryAfter = 1
+				}
+				header.Set(RetryAfterHeader, strconv.Itoa(retryAfter))
+				response.NewResponseWriter(w, r).Error(errors.NewAppError(errors.ErrCodeRateLimit, "Too many requests").
+					WithDetails("Retry in " + strconv.Itoa(retryAfter) + " seconds"))
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// rateLimitKey identifies the client making a request
+func rateLimitKey(r *http.Request) string {
+	if userID, ok := GetUserIDFromContext(r.Context()); ok {
+		return "user:" + userID
+	}
+
+	return "ip:" + ClientIP(r)
+}
+
+// ClientIP returns the IP address of the client making a request, which
+// chimw.RealIP takes from the proxy headers
+func ClientIP(r *http.Request) string {
+	// RemoteAddr is host:port unless chimw.RealIP replaced it
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		return host
+	}
+	return r.RemoteAddr
+}
+
+// seconds rounds a duration up to whole seconds
+func seconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
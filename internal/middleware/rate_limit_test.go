@@ -0,0 +1,111 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/alinoer/go-std-api/internal/errors"
+	"github.com/alinoer/go-std-api/internal/models"
+	"github.com/alinoer/go-std-api/internal/repository"
+)
+
+// MockRateLimitStore returns a fixed result or error and records the keys
+// it was asked about
+type MockRateLimitStore struct {
+	result *models.RateLimitResult
+	err    error
+	keys   []string
+}
+
+func (m *MockRateLimitStore) Take(ctx context.Context, key string, limit models.RateLimit) (*models.RateLimitResult, error) {
+	m.keys = append(m.keys, key)
+	return m.result, m.err
+}
+
+func TestRateLimit(t *testing.T) {
+	config := RateLimitConfig{Name: "auth", Limit: models.RateLimit{Requests: 2, Period: time.Minute}}
+	okHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	newRequest := func(remoteAddr, userID string) *http.Request {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/auth/login", nil)
+		req.RemoteAddr = remoteAddr
+		if userID != "" {
+			req = req.WithContext(context.WithValue(req.Context(), UserIDKey, userID))
+		}
+		return req
+	}
+
+	t.Run("rejects requests over the limit", func(t *testing.T) {
+		handler := RateLimit(repository.NewMemoryRateLimitStore(), config)(okHandler)
+
+		for i, want := range []string{"1", "0"} {
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, newRequest("192.0.2.1:1234", ""))
+			if w.Code != http.StatusOK {
+				t.Fatalf("expected request %d to pass, got %d", i+1, w.Code)
+			}
+			if w.Header().Get(RateLimitLimitHeader) != "2" || w.Header().Get(RateLimitRemainingHeader) != want {
+				t.Errorf("expected limit 2 with %s remaining, got %v", want, w.Header())
+			}
+			if w.Header().Get(RateLimitPolicyHeader) != "2;w=60" {
+				t.Errorf("expected policy 2;w=60, got %q", w.Header().Get(RateLimitPolicyHeader))
+			}
+		}
+
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, newRequest("192.0.2.1:5678", ""))
+		if w.Code != http.StatusTooManyRequests {
+			t.Fatalf("expected 429, got %d", w.Code)
+		}
+		if w.Header().Get(RetryAfterHeader) != "30" {
+			t.Errorf("expected to retry in 30 seconds, got %q", w.Header().Get(RetryAfterHeader))
+		}
+		if w.Header().Get(RateLimitResetHeader) != "60" {
+			t.Errorf("expected the bucket to be full in 60 seconds, got %q", w.Header().Get(RateLimitResetHeader))
+		}
+	})
+
+	t.Run("keys by user or IP address", func(t *testing.T) {
+		store := &MockRateLimitStore{result: &models.RateLimitResult{Allowed: true}}
+		handler := RateLimit(store, config)(okHandler)
+
+		handler.ServeHTTP(httptest.NewRecorder(), newRequest("192.0.2.1:1234", ""))
+		handler.ServeHTTP(httptest.NewRecorder(), newRequest("192.0.2.1", ""))
+		handler.ServeHTTP(httptest.NewRecorder(), newRequest("192.0.2.1:1234", "user-1"))
+
+		expected := []string{"auth:ip:192.0.2.1", "auth:ip:192.0.2.1", "auth:user:user-1"}
+		if len(store.keys) != len(expected) {
+			t.Fatalf("expected keys %v, got %v", expected, store.keys)
+		}
+		for i := range expected {
+			if store.keys[i] != expected[i] {
+				t.Errorf("expected keys %v, got %v", expected, store.keys)
+			}
+		}
+	})
+
+	t.Run("retry after is at least a second", func(t *testing.T) {
+		store := &MockRateLimitStore{result: &models.RateLimitResult{RetryAfter: time.Millisecond}}
+		w := httptest.NewRecorder()
+		RateLimit(store, config)(okHandler).ServeHTTP(w, newRequest("192.0.2.1:1234", ""))
+
+		if w.Code != http.StatusTooManyRequests || w.Header().Get(RetryAfterHeader) != "1" {
+			t.Errorf("expected 429 with Retry-After 1, got %d %q", w.Code, w.Header().Get(RetryAfterHeader))
+		}
+	})
+
+	t.Run("store errors let requests through", func(t *testing.T) {
+		store := &MockRateLimitStore{err: errors.DatabaseError("take rate limit token", errors.New("connection refused"))}
+		w := httptest.NewRecorder()
+		RateLimit(store, config)(okHandler).ServeHTTP(w, newRequest("192.0.2.1:1234", ""))
+
+		if w.Code != http.StatusOK || w.Header().Get(RateLimitLimitHeader) != "" {
+			t.Errorf("expected the request to pass without rate limit headers, got %d %v", w.Code, w.Header())
+		}
+	})
+}
@@ -0,0 +1,32 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/alinoer/go-std-api/internal/models"
+	"github.com/alinoer/go-std-api/internal/response"
+)
+
+// RequirePermission only lets requests through when the role set by
+// JWTAuthMiddleware grants every one of the given permissions. It must be
+// mounted after JWTAuthMiddleware.
+func RequirePermission(permissions ...models.Permission) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			role, ok := GetRoleFromContext(r.Context())
+			if !ok {
+				response.NewResponseWriter(w, r).Unauthorized("Authentication required")
+				return
+			}
+
+			for _, permission := range permissions {
+				if !role.HasPermission(permission) {
+					response.NewResponseWriter(w, r).Forbidden("Insufficient permissions")
+					return
+				}
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
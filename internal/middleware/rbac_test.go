@@ -0,0 +1,138 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/alinoer/go-std-api/internal/models"
+	"github.com/alinoer/go-std-api/internal/repository"
+	"github.com/alinoer/go-std-api/internal/response"
+	"github.com/alinoer/go-std-api/internal/service"
+	"github.com/google/uuid"
+)
+
+func TestRequirePermission(t *testing.T) {
+	tests := []struct {
+		name               string
+		role               *models.Role
+		permissions        []models.Permission
+		expectedStatusCode int
+		expectedBody       string
+		expectedError      string
+	}{
+		{
+			name:               "no authenticated role",
+			permissions:        []models.Permission{models.PermissionDeleteAnyPost},
+			expectedStatusCode: http.StatusUnauthorized,
+			expectedError:      "Authentication required",
+		},
+		{
+			name:               "role without permission",
+			role:               rolePtr(models.RoleUser),
+			permissions:        []models.Permission{models.PermissionDeleteAnyPost},
+			expectedStatusCode: http.StatusForbidden,
+			expectedError:      "Insufficient permissions",
+		},
+		{
+			name:               "role with permission",
+			role:               rolePtr(models.RoleModerator),
+			permissions:        []models.Permission{models.PermissionDeleteAnyPost},
+			expectedStatusCode: http.StatusOK,
+			expectedBody:       "OK",
+		},
+		{
+			name:               "role missing one of several permissions",
+			role:               rolePtr(models.RoleModerator),
+			permissions:        []models.Permission{models.PermissionDeleteAnyPost, models.PermissionManageUsers},
+			expectedStatusCode: http.StatusForbidden,
+			expectedError:      "Insufficient permissions",
+		},
+		{
+			name:               "role with all permissions",
+			role:               rolePtr(models.RoleAdmin),
+			permissions:        []models.Permission{models.PermissionDeleteAnyPost, models.PermissionManageUsers},
+			expectedStatusCode: http.StatusOK,
+			expectedBody:       "OK",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+				w.Write([]byte("OK"))
+			})
+			handler := RequirePermission(tt.permissions...)(testHandler)
+
+			req := httptest.NewRequest(http.MethodGet, "/admin", nil)
+			if tt.role != nil {
+				req = req.WithContext(context.WithValue(req.Context(), RoleKey, *tt.role))
+			}
+			w := httptest.NewRecorder()
+
+			handler.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedStatusCode {
+				t.Errorf("expected status code %d, got %d", tt.expectedStatusCode, w.Code)
+			}
+			if tt.expectedError != "" {
+				var errResp response.ErrorResponse
+				if err := json.Unmarshal(w.Body.Bytes(), &errResp); err != nil {
+					t.Fatalf("failed to decode error response %q: %v", w.Body.String(), err)
+				}
+				if errResp.Error != tt.expectedError {
+					t.Errorf("expected error %q, got %q", tt.expectedError, errResp.Error)
+				}
+				return
+			}
+			if w.Body.String() != tt.expectedBody {
+				t.Errorf("expected body %q, got %q", tt.expectedBody, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestRequirePermission_WithJWTAuthMiddleware(t *testing.T) {
+	authService := service.NewAuthService("test-secret-key", nil, nil, repository.NewMemoryTokenRevocationStore())
+
+	testHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	handler := JWTAuthMiddleware(authService)(RequirePermission(models.PermissionManageUsers)(testHandler))
+
+	tests := []struct {
+		role               models.Role
+		expectedStatusCode int
+	}{
+		{role: models.RoleUser, expectedStatusCode: http.StatusForbidden},
+		{role: models.RoleModerator, expectedStatusCode: http.StatusForbidden},
+		{role: models.RoleAdmin, expectedStatusCode: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.role), func(t *testing.T) {
+			user := &models.User{ID: uuid.New(), Username: "testuser", Role: tt.role}
+			token, _, err := authService.GenerateToken(context.Background(), user)
+			if err != nil {
+				t.Fatalf("failed to generate token: %v", err)
+			}
+
+			req := httptest.NewRequest(http.MethodGet, "/admin", nil)
+			req.Header.Set("Authorization", "Bearer "+token)
+			w := httptest.NewRecorder()
+
+			handler.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedStatusCode {
+				t.Errorf("expected status code %d, got %d", tt.expectedStatusCode, w.Code)
+			}
+		})
+	}
+}
+
+func rolePtr(role models.Role) *models.Role {
+	return &role
+}
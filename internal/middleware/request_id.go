@@ -0,0 +1,32 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/alinoer/go-std-api/internal/logger"
+	chimw "github.com/go-chi/chi/v5/middleware"
+)
+
+// RequestIDHeader is the response header that echoes the request ID
+const RequestIDHeader = "X-Request-ID"
+
+// RequestID copies the ID assigned by chi's RequestID middleware into
+// logger.RequestIDKey, so log lines and error bodies carry the same ID, and
+// echoes it back to the client. It must run after chimw.RequestID.
+func RequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestID := chimw.GetReqID(r.Context())
+		if requestID == "" {
+			requestID = r.Header.Get(RequestIDHeader)
+		}
+		if requestID == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		w.Header().Set(RequestIDHeader, requestID)
+		ctx := context.WithValue(r.Context(), logger.RequestIDKey, requestID)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
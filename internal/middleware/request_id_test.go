@@ -0,0 +1,65 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/alinoer/go-std-api/internal/logger"
+	"github.com/alinoer/go-std-api/internal/response"
+	chimw "github.com/go-chi/chi/v5/middleware"
+)
+
+func TestRequestID(t *testing.T) {
+	tests := []struct {
+		name            string
+		requestHeader   string
+		expectGenerated bool
+	}{
+		{
+			name:            "generated by chi",
+			expectGenerated: true,
+		},
+		{
+			name:          "propagated from the client",
+			requestHeader: "client-request-id",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var contextID string
+			handler := chimw.RequestID(RequestID(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				contextID, _ = r.Context().Value(logger.RequestIDKey).(string)
+				response.NewResponseWriter(w, r).NotFound("Post")
+			})))
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.requestHeader != "" {
+				req.Header.Set(chimw.RequestIDHeader, tt.requestHeader)
+			}
+			w := httptest.NewRecorder()
+
+			handler.ServeHTTP(w, req)
+
+			if contextID == "" {
+				t.Fatal("expected request ID in context")
+			}
+			if !tt.expectGenerated && contextID != tt.requestHeader {
+				t.Errorf("expected request ID %q, got %q", tt.requestHeader, contextID)
+			}
+
+			var errResp response.ErrorResponse
+			if err := json.NewDecoder(w.Body).Decode(&errResp); err != nil {
+				t.Fatalf("failed to decode error response: %v", err)
+			}
+			if errResp.RequestID != contextID {
+				t.Errorf("expected error body request ID %q, got %q", contextID, errResp.RequestID)
+			}
+			if header := w.Header().Get(RequestIDHeader); header != contextID {
+				t.Errorf("expected %s header %q, got %q", RequestIDHeader, contextID, header)
+			}
+		})
+	}
+}
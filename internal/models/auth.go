@@ -13,10 +13,51 @@ type LoginRequest struct {
 }
 
 type LoginResponse struct {
-	User        *User  `json:"user"`
-	AccessToken string `json:"access_token"`
-	TokenType   string `json:"token_type"`
-	ExpiresIn   int64  `json:"expires_in"`
+	User             *User  `json:"user"`
+	AccessToken      string `json:"access_token"`
+	TokenType        string `json:"token_type"`
+	ExpiresIn        int64  `json:"expires_in"`
+	RefreshToken     string `json:"refresh_token,omitempty"`
+	RefreshExpiresIn int64  `json:"refresh_expires_in,omitempty"`
+}
+
+// UnlockUserRequest optionally names an IP address, as recorded in the login
+// events, whose lockout is lifted along with the user's
+type UnlockUserRequest struct {
+	IPAddress string `json:"ip_address"`
+}
+
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+type TokenResponse struct {
+	AccessToken      string `json:"access_token"`
+	TokenType        string `json:"token_type"`
+	ExpiresIn        int64  `json:"expires_in"`
+	RefreshToken     string `json:"refresh_token"`
+	RefreshExpiresIn int64  `json:"refresh_expires_in"`
+}
+
+// TokenPair is an access token together with the refresh token that can renew it
+type TokenPair struct {
+	AccessToken      string
+	ExpiresIn        int64
+	RefreshToken     string
+	RefreshExpiresIn int64
+}
+
+// RefreshToken is a stored refresh token. Only the SHA-256 hash of the opaque
+// token is persisted; every rotation of a login shares the same FamilyID.
+type RefreshToken struct {
+	ID         uuid.UUID  `json:"id" db:"id"`
+	UserID     uuid.UUID  `json:"user_id" db:"user_id"`
+	FamilyID   uuid.UUID  `json:"family_id" db:"family_id"`
+	TokenHash  string     `json:"-" db:"token_hash"`
+	ExpiresAt  time.Time  `json:"expires_at" db:"expires_at"`
+	CreatedAt  time.Time  `json:"created_at" db:"created_at"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty" db:"revoked_at"`
+	ReplacedBy *uuid.UUID `json:"replaced_by,omitempty" db:"replaced_by"`
 }
 
 type RegisterRequest struct {
@@ -24,19 +65,37 @@ type RegisterRequest struct {
 	Password string `json:"password"`
 }
 
-type RegisterResponse struct {
-	User    *User  `json:"user"`
-	Message string `json:"message"`
-}
-
+// JWTClaims are the claims carried by access tokens. RegisteredClaims.ID is
+// serialized as the jti claim and identifies the token for revocation;
+// Generation is the user's token generation at the time the token was minted.
 type JWTClaims struct {
-	UserID   uuid.UUID `json:"user_id"`
-	Username string    `json:"username"`
+	UserID     uuid.UUID `json:"user_id"`
+	Username   string    `json:"username"`
+	Role       Role      `json:"role"`
+	Generation int64     `json:"gen"`
 	jwt.RegisteredClaims
 }
 
+// JWK is a public JSON Web Key (RFC 7517) used to verify access tokens
+type JWK struct {
+	Kty string `json:"kty"`
+	Use string `json:"use,omitempty"`
+	Alg string `json:"alg,omitempty"`
+	Kid string `json:"kid,omitempty"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+// JWKS is the JSON Web Key Set served at /.well-known/jwks.json
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
 type TokenConfig struct {
-	SecretKey string
-	ExpiresIn time.Duration
-	Issuer    string
+	SecretKey        string
+	ExpiresIn        time.Duration
+	RefreshExpiresIn time.Duration
+	Issuer           string
 }
\ No newline at end of file
@@ -0,0 +1,29 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Comment is a comment on a post. Top-level comments have no ParentID and
+// carry their replies; replies cannot be replied to.
+type Comment struct {
+	ID        uuid.UUID  `json:"id" db:"id"`
+	PostID    uuid.UUID  `json:"post_id" db:"post_id"`
+	UserID    uuid.UUID  `json:"user_id" db:"user_id"`
+	ParentID  *uuid.UUID `json:"parent_id,omitempty" db:"parent_id"`
+	Content   string     `json:"content" db:"content"`
+	CreatedAt time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at" db:"updated_at"`
+	Replies   []*Comment `json:"replies,omitempty"`
+}
+
+type CreateCommentRequest struct {
+	Content  string     `json:"content"`
+	ParentID *uuid.UUID `json:"parent_id,omitempty"`
+}
+
+type UpdateCommentRequest struct {
+	Content string `json:"content"`
+}
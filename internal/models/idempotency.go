@@ -0,0 +1,17 @@
+package models
+
+// IdempotencyRecord is a request made with an Idempotency-Key header and,
+// once it has finished, the response to replay when it is retried
+type IdempotencyRecord struct {
+	// Fingerprint identifies the request the key was first used for
+	Fingerprint string
+	// StatusCode is zero while the request is in flight
+	StatusCode int
+	Header     map[string][]string
+	Body       []byte
+}
+
+// InFlight reports whether the request has not finished yet
+func (r *IdempotencyRecord) InFlight() bool {
+	return r.StatusCode == 0
+}
@@ -0,0 +1,40 @@
+package models
+
+// FilterOp is a comparison a list filter applies to a field
+type FilterOp string
+
+const (
+	FilterEquals   FilterOp = "eq"
+	FilterContains FilterOp = "contains"
+	FilterAfter    FilterOp = "after"
+	FilterBefore   FilterOp = "before"
+)
+
+// Filter restricts a list to rows whose field matches the value. Value holds
+// a string, uuid.UUID or time.Time depending on the field.
+type Filter struct {
+	Field string
+	Op    FilterOp
+	Value interface{}
+}
+
+// SortField orders a list by a field
+type SortField struct {
+	Field      string
+	Descending bool
+}
+
+// ListOptions holds the filters and sort order requested for a list. Field
+// names are API names; repositories map them to columns. A nil *ListOptions
+// means no filters and the default order.
+type ListOptions struct {
+	Filters []Filter
+	Sort    []SortField
+	// Tags restricts a post list to posts carrying every one of the tags
+	Tags []string
+}
+
+// HasSort reports whether a sort order other than the default was requested
+func (o *ListOptions) HasSort() bool {
+	return o != nil && len(o.Sort) > 0
+}
@@ -0,0 +1,58 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// LoginOutcome is what happened to a login attempt
+type LoginOutcome string
+
+const (
+	LoginOutcomeSuccess LoginOutcome = "success"
+	// LoginOutcomeFailure is a login with a wrong username or password
+	LoginOutcomeFailure LoginOutcome = "failure"
+	// LoginOutcomeLocked is a login rejected because of earlier failures
+	LoginOutcomeLocked LoginOutcome = "locked"
+	// LoginOutcomeUnlocked records an admin lifting a user's lockout
+	LoginOutcomeUnlocked LoginOutcome = "unlocked"
+)
+
+// LoginEvent is an entry in the login audit log
+type LoginEvent struct {
+	ID int64 `json:"id"`
+	// UserID is only known for successful logins and unlocks
+	UserID    *uuid.UUID   `json:"user_id,omitempty"`
+	Username  string       `json:"username"`
+	IPAddress string       `json:"ip_address"`
+	Outcome   LoginOutcome `json:"outcome"`
+	CreatedAt time.Time    `json:"created_at"`
+}
+
+// LoginLimit is how many failed logins a key, such as a username or an IP
+// address, may have before it is locked out. The first lockout lasts Lockout
+// and every further failure doubles it, up to MaxLockout. Failures older than
+// Window no longer count.
+type LoginLimit struct {
+	MaxFailures int
+	Lockout     time.Duration
+	MaxLockout  time.Duration
+	Window      time.Duration
+}
+
+// LockoutAfter returns how long a key is locked out after the given number of
+// failures, or zero if that many are allowed
+func (l LoginLimit) LockoutAfter(failures int) time.Duration {
+	if failures < l.MaxFailures {
+		return 0
+	}
+	lockout := l.Lockout
+	for i := l.MaxFailures; i < failures && lockout < l.MaxLockout; i++ {
+		lockout *= 2
+	}
+	if lockout > l.MaxLockout {
+		lockout = l.MaxLockout
+	}
+	return lockout
+}
@@ -0,0 +1,31 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLoginLimit_LockoutAfter(t *testing.T) {
+	limit := LoginLimit{MaxFailures: 3, Lockout: time.Minute, MaxLockout: 5 * time.Minute}
+
+	tests := []struct {
+		name     string
+		failures int
+		expected time.Duration
+	}{
+		{name: "below the limit", failures: 2, expected: 0},
+		{name: "at the limit", failures: 3, expected: time.Minute},
+		{name: "one more failure doubles it", failures: 4, expected: 2 * time.Minute},
+		{name: "two more failures", failures: 5, expected: 4 * time.Minute},
+		{name: "capped at the maximum", failures: 6, expected: 5 * time.Minute},
+		{name: "many failures", failures: 1000, expected: 5 * time.Minute},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := limit.LockoutAfter(tt.failures); got != tt.expected {
+				t.Errorf("expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
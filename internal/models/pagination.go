@@ -1,18 +1,38 @@
 package models
 
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	DefaultPageSize = 10
+	MaxPageSize     = 100
+)
+
 type PaginationParams struct {
 	Page     int `json:"page"`
 	PageSize int `json:"page_size"`
 	Offset   int `json:"offset"`
 }
 
+// PaginationMeta describes a page of results. Page-number pagination fills
+// page, page_size, total and total_pages; cursor pagination fills limit and
+// the cursors, and total only when it was requested.
 type PaginationMeta struct {
-	Page         int   `json:"page"`
-	PageSize     int   `json:"page_size"`
-	Total        int64 `json:"total"`
-	TotalPages   int   `json:"total_pages"`
-	HasNext      bool  `json:"has_next"`
-	HasPrevious  bool  `json:"has_previous"`
+	Page        int    `json:"page,omitempty"`
+	PageSize    int    `json:"page_size,omitempty"`
+	Limit       int    `json:"limit,omitempty"`
+	Total       *int64 `json:"total,omitempty"`
+	TotalPages  *int   `json:"total_pages,omitempty"`
+	HasNext     bool   `json:"has_next"`
+	HasPrevious bool   `json:"has_previous"`
+	NextCursor  string `json:"next_cursor,omitempty"`
+	PrevCursor  string `json:"prev_cursor,omitempty"`
 }
 
 type PaginatedResponse struct {
@@ -24,8 +44,8 @@ func NewPaginationParams(page, pageSize int) *PaginationParams {
 	if page < 1 {
 		page = 1
 	}
-	if pageSize < 1 || pageSize > 100 {
-		pageSize = 10 // default page size
+	if pageSize < 1 || pageSize > MaxPageSize {
+		pageSize = DefaultPageSize
 	}
 
 	offset := (page - 1) * pageSize
@@ -39,13 +59,112 @@ func NewPaginationParams(page, pageSize int) *PaginationParams {
 
 func NewPaginationMeta(page, pageSize int, total int64) *PaginationMeta {
 	totalPages := int((total + int64(pageSize) - 1) / int64(pageSize)) // ceiling division
-	
+
 	return &PaginationMeta{
-		Page:         page,
-		PageSize:     pageSize,
-		Total:        total,
-		TotalPages:   totalPages,
-		HasNext:      page < totalPages,
-		HasPrevious:  page > 1,
-	}
-}
\ No newline at end of file
+		Page:        page,
+		PageSize:    pageSize,
+		Total:       &total,
+		TotalPages:  &totalPages,
+		HasNext:     page < totalPages,
+		HasPrevious: page > 1,
+	}
+}
+
+// CursorDirection is the direction a cursor pages in, relative to the
+// newest-first order results are returned in
+type CursorDirection string
+
+const (
+	CursorNext     CursorDirection = "next"
+	CursorPrevious CursorDirection = "prev"
+)
+
+// Cursor is the keyset position of a row in (created_at, id) order. Clients
+// receive it as an opaque token.
+type Cursor struct {
+	CreatedAt time.Time       `json:"created_at"`
+	ID        uuid.UUID       `json:"id"`
+	Direction CursorDirection `json:"direction"`
+}
+
+// Encode returns the opaque token for the cursor
+func (c Cursor) Encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeCursor parses a token produced by Cursor.Encode
+func DecodeCursor(token string) (*Cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, fmt.Errorf("malformed cursor: %w", err)
+	}
+
+	var cursor Cursor
+	if err := json.Unmarshal(data, &cursor); err != nil {
+		return nil, fmt.Errorf("malformed cursor: %w", err)
+	}
+	if cursor.CreatedAt.IsZero() || cursor.ID == uuid.Nil {
+		return nil, fmt.Errorf("malformed cursor: missing position")
+	}
+	switch cursor.Direction {
+	case CursorNext, CursorPrevious:
+	default:
+		return nil, fmt.Errorf("malformed cursor: unknown direction %q", cursor.Direction)
+	}
+
+	return &cursor, nil
+}
+
+// CursorParams selects a page for keyset pagination. A nil Cursor selects the
+// first page.
+type CursorParams struct {
+	Cursor       *Cursor
+	Limit        int
+	IncludeTotal bool
+}
+
+func NewCursorParams(cursor *Cursor, limit int, includeTotal bool) *CursorParams {
+	if limit < 1 || limit > MaxPageSize {
+		limit = DefaultPageSize
+	}
+
+	return &CursorParams{
+		Cursor:       cursor,
+		Limit:        limit,
+		IncludeTotal: includeTotal,
+	}
+}
+
+// Backward reports whether the page lies before the cursor
+func (p *CursorParams) Backward() bool {
+	return p.Cursor != nil && p.Cursor.Direction == CursorPrevious
+}
+
+// NewCursorPaginationMeta builds the metadata for a cursor page. first and
+// last are the positions of the first and last rows on the page, nil when the
+// page is empty, and hasMore reports whether rows remain beyond the page in
+// the direction that was requested.
+func NewCursorPaginationMeta(params *CursorParams, first, last *Cursor, hasMore bool, total *int64) *PaginationMeta {
+	meta := &PaginationMeta{
+		Limit: params.Limit,
+		Total: total,
+	}
+
+	if params.Backward() {
+		meta.HasPrevious = hasMore
+		meta.HasNext = true
+	} else {
+		meta.HasNext = hasMore
+		meta.HasPrevious = params.Cursor != nil
+	}
+
+	if meta.HasNext && last != nil {
+		meta.NextCursor = Cursor{CreatedAt: last.CreatedAt, ID: last.ID, Direction: CursorNext}.Encode()
+	}
+	if meta.HasPrevious && first != nil {
+		meta.PrevCursor = Cursor{CreatedAt: first.CreatedAt, ID: first.ID, Direction: CursorPrevious}.Encode()
+	}
+
+	return meta
+}
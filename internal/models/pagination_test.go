@@ -1,7 +1,11 @@
 package models
 
 import (
+	"encoding/base64"
 	"testing"
+	"time"
+
+	"github.com/google/uuid"
 )
 
 func TestNewPaginationParams(t *testing.T) {
@@ -164,18 +168,144 @@ func TestNewPaginationMeta(t *testing.T) {
 			if meta.PageSize != tt.pageSize {
 				t.Errorf("expected page size %d, got %d", tt.pageSize, meta.PageSize)
 			}
-			if meta.Total != tt.total {
-				t.Errorf("expected total %d, got %d", tt.total, meta.Total)
+			if meta.Total == nil || *meta.Total != tt.total {
+				t.Errorf("expected total %d, got %v", tt.total, meta.Total)
+			}
+			if meta.TotalPages == nil || *meta.TotalPages != tt.expectedPages {
+				t.Errorf("expected total pages %d, got %v", tt.expectedPages, meta.TotalPages)
+			}
+			if meta.HasNext != tt.expectedHasNext {
+				t.Errorf("expected has next %t, got %t", tt.expectedHasNext, meta.HasNext)
 			}
-			if meta.TotalPages != tt.expectedPages {
-				t.Errorf("expected total pages %d, got %d", tt.expectedPages, meta.TotalPages)
+			if meta.HasPrevious != tt.expectedHasPrev {
+				t.Errorf("expected has previous %t, got %t", tt.expectedHasPrev, meta.HasPrevious)
+			}
+		})
+	}
+}
+func TestCursor_EncodeDecode(t *testing.T) {
+	cursor := Cursor{
+		CreatedAt: time.Date(2024, 1, 2, 3, 4, 5, 123456000, time.UTC),
+		ID:        uuid.New(),
+		Direction: CursorNext,
+	}
+
+	decoded, err := DecodeCursor(cursor.Encode())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !decoded.CreatedAt.Equal(cursor.CreatedAt) || decoded.ID != cursor.ID || decoded.Direction != cursor.Direction {
+		t.Errorf("expected %+v, got %+v", cursor, *decoded)
+	}
+}
+
+func TestDecodeCursor_Invalid(t *testing.T) {
+	tests := []struct {
+		name  string
+		token string
+	}{
+		{name: "not base64", token: "!!!"},
+		{name: "not JSON", token: base64.RawURLEncoding.EncodeToString([]byte("cursor"))},
+		{name: "missing position", token: base64.RawURLEncoding.EncodeToString([]byte(`{"direction":"next"}`))},
+		{name: "unknown direction", token: Cursor{CreatedAt: time.Now(), ID: uuid.New(), Direction: "sideways"}.Encode()},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := DecodeCursor(tt.token); err == nil {
+				t.Error("expected error, got nil")
 			}
+		})
+	}
+}
+
+func TestNewCursorPaginationMeta(t *testing.T) {
+	first := &Cursor{CreatedAt: time.Now(), ID: uuid.New()}
+	last := &Cursor{CreatedAt: time.Now().Add(-time.Hour), ID: uuid.New()}
+	total := int64(42)
+
+	tests := []struct {
+		name            string
+		params          *CursorParams
+		first, last     *Cursor
+		hasMore         bool
+		total           *int64
+		expectedHasNext bool
+		expectedHasPrev bool
+	}{
+		{
+			name:            "first page with more rows",
+			params:          NewCursorParams(nil, 10, false),
+			first:           first,
+			last:            last,
+			hasMore:         true,
+			expectedHasNext: true,
+		},
+		{
+			name:   "only page",
+			params: NewCursorParams(nil, 10, true),
+			first:  first,
+			last:   last,
+			total:  &total,
+		},
+		{
+			name:            "last page reached going forward",
+			params:          NewCursorParams(&Cursor{CreatedAt: time.Now(), ID: uuid.New(), Direction: CursorNext}, 10, false),
+			first:           first,
+			last:            last,
+			expectedHasPrev: true,
+		},
+		{
+			name:            "first page reached going backward",
+			params:          NewCursorParams(&Cursor{CreatedAt: time.Now(), ID: uuid.New(), Direction: CursorPrevious}, 10, false),
+			first:           first,
+			last:            last,
+			expectedHasNext: true,
+		},
+		{
+			name:            "empty page",
+			params:          NewCursorParams(&Cursor{CreatedAt: time.Now(), ID: uuid.New(), Direction: CursorNext}, 10, false),
+			expectedHasPrev: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			meta := NewCursorPaginationMeta(tt.params, tt.first, tt.last, tt.hasMore, tt.total)
+
 			if meta.HasNext != tt.expectedHasNext {
 				t.Errorf("expected has next %t, got %t", tt.expectedHasNext, meta.HasNext)
 			}
 			if meta.HasPrevious != tt.expectedHasPrev {
 				t.Errorf("expected has previous %t, got %t", tt.expectedHasPrev, meta.HasPrevious)
 			}
+			if meta.Total != tt.total {
+				t.Errorf("expected total %v, got %v", tt.total, meta.Total)
+			}
+
+			if tt.expectedHasNext {
+				next, err := DecodeCursor(meta.NextCursor)
+				if err != nil {
+					t.Fatalf("invalid next cursor: %v", err)
+				}
+				if next.ID != tt.last.ID || next.Direction != CursorNext {
+					t.Errorf("expected next cursor after the last row, got %+v", *next)
+				}
+			} else if meta.NextCursor != "" {
+				t.Errorf("expected no next cursor, got %q", meta.NextCursor)
+			}
+
+			if tt.expectedHasPrev && tt.first != nil {
+				prev, err := DecodeCursor(meta.PrevCursor)
+				if err != nil {
+					t.Fatalf("invalid prev cursor: %v", err)
+				}
+				if prev.ID != tt.first.ID || prev.Direction != CursorPrevious {
+					t.Errorf("expected prev cursor before the first row, got %+v", *prev)
+				}
+			} else if meta.PrevCursor != "" {
+				t.Errorf("expected no prev cursor, got %q", meta.PrevCursor)
+			}
 		})
 	}
-}
\ No newline at end of file
+}
@@ -1,25 +1,220 @@
 package models
 
 import (
+	"context"
+	"fmt"
 	"time"
 
 	"github.com/google/uuid"
 )
 
+// PostStatus is the publication state of a post
+type PostStatus string
+
+const (
+	// PostStatusDraft posts are only visible to their author. A draft with a
+	// PublishedAt time is scheduled and gets published once that time passes.
+	PostStatusDraft     PostStatus = "draft"
+	PostStatusPublished PostStatus = "published"
+	// PostStatusArchived posts stay readable but are no longer current
+	PostStatusArchived PostStatus = "archived"
+)
+
+// PostStatuses lists every post status
+var PostStatuses = []PostStatus{PostStatusDraft, PostStatusPublished, PostStatusArchived}
+
+// IsValid reports whether s is a known post status
+func (s PostStatus) IsValid() bool {
+	for _, status := range PostStatuses {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
+
 type Post struct {
-	ID        uuid.UUID `json:"id" db:"id"`
-	UserID    uuid.UUID `json:"user_id" db:"user_id"`
-	Title     string    `json:"title" db:"title"`
-	Content   string    `json:"content" db:"content"`
-	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	ID        uuid.UUID  `json:"id" db:"id"`
+	UserID    uuid.UUID  `json:"user_id" db:"user_id"`
+	Title     string     `json:"title" db:"title"`
+	Content   string     `json:"content" db:"content"`
+	CreatedAt time.Time  `json:"created_at" db:"created_at"`
+	Status    PostStatus `json:"status" db:"status"`
+	// PublishedAt is when the post was published, or for a scheduled draft
+	// when it will be
+	PublishedAt *time.Time `json:"published_at" db:"published_at"`
+	UpdatedAt   time.Time  `json:"updated_at" db:"updated_at"`
+	// Version starts at 1 and goes up with every update
+	Version int `json:"version" db:"version"`
+	// DeletedAt is set while the post is in the trash
+	DeletedAt *time.Time `json:"deleted_at,omitempty" db:"deleted_at"`
+	// CommentCount counts comments and replies
+	CommentCount int64 `json:"comment_count"`
+	// ActivityAt is when comments or reactions on the post last changed
+	ActivityAt time.Time `json:"-" db:"activity_at"`
+	// ReactionCount counts reactions of every kind
+	ReactionCount int64 `json:"reaction_count" db:"reaction_count"`
+	// Reactions counts reactions by kind
+	Reactions map[ReactionKind]int64 `json:"reactions"`
+	// MyReactions lists the kinds the viewing user reacted with
+	MyReactions []ReactionKind `json:"my_reactions"`
+	// Tags lists the post's normalized tag names in alphabetical order
+	Tags []string `json:"tags"`
+}
+
+// IsScheduled reports whether the post is a draft waiting to be published
+func (p *Post) IsScheduled() bool {
+	return p.Status == PostStatusDraft && p.PublishedAt != nil
+}
+
+// VisibleTo reports whether the viewer recorded in ctx may read the post.
+// Drafts are only visible to their author.
+func (p *Post) VisibleTo(ctx context.Context) bool {
+	if p.Status != PostStatusDraft {
+		return true
+	}
+	viewerID, ok := ViewerFromContext(ctx)
+	return ok && viewerID == p.UserID
 }
 
+// ETag returns the post's strong entity tag. It changes with every version,
+// but not with the counts or reactions.
+func (p *Post) ETag() string {
+	return fmt.Sprintf(`"%s.%d"`, p.ID, p.Version)
+}
+
+// LastModified returns when the post, its comments or its reactions last
+// changed
+func (p *Post) LastModified() time.Time {
+	if p.ActivityAt.After(p.UpdatedAt) {
+		return p.ActivityAt
+	}
+	return p.UpdatedAt
+}
+
+// Precondition limits a change to some versions of a post, as asked for
+// with an If-Match header. A nil Precondition allows every version.
+type Precondition struct {
+	// Any allows every version of an existing post, as If-Match: * does
+	Any      bool
+	Versions []int
+}
+
+// Allows reports whether the precondition holds for the given version
+func (p *Precondition) Allows(version int) bool {
+	if p == nil || p.Any {
+		return true
+	}
+	for _, v := range p.Versions {
+		if v == version {
+			return true
+		}
+	}
+	return false
+}
+
+// CreatePostRequest creates a published post unless Status says otherwise.
+// A future PublishedAt schedules the post as a draft.
 type CreatePostRequest struct {
-	Title   string `json:"title"`
-	Content string `json:"content"`
+	Title       string     `json:"title"`
+	Content     string     `json:"content"`
+	Tags        []string   `json:"tags,omitempty"`
+	Status      PostStatus `json:"status,omitempty"`
+	PublishedAt *time.Time `json:"published_at,omitempty"`
 }
 
+// UpdatePostRequest changes the fields that are set. Tags replaces the whole
+// tag list; an empty list removes every tag. Status moves the post to another
+// state and a future PublishedAt (re)schedules a draft. Version, when set,
+// makes the update fail with a conflict unless the post is still at that
+// version, so that editors do not overwrite each other's changes.
 type UpdatePostRequest struct {
-	Title   *string `json:"title,omitempty"`
-	Content *string `json:"content,omitempty"`
-}
\ No newline at end of file
+	Title       *string     `json:"title,omitempty"`
+	Content     *string     `json:"content,omitempty"`
+	Tags        *[]string   `json:"tags,omitempty"`
+	Status      *PostStatus `json:"status,omitempty"`
+	PublishedAt *time.Time  `json:"published_at,omitempty"`
+	Version     *int        `json:"version,omitempty"`
+}
+
+// ReplacePostRequest replaces every editable field of a post. Title and
+// content are required as on creation, missing tags remove every tag and a
+// missing status publishes the post unless PublishedAt schedules it.
+type ReplacePostRequest struct {
+	Title       string     `json:"title"`
+	Content     string     `json:"content"`
+	Tags        []string   `json:"tags,omitempty"`
+	Status      PostStatus `json:"status,omitempty"`
+	PublishedAt *time.Time `json:"published_at,omitempty"`
+	Version     *int       `json:"version,omitempty"`
+}
+
+// PatchFormat is the media type of a patch document
+type PatchFormat string
+
+const (
+	// PatchFormatMergePatch documents follow RFC 7396
+	PatchFormatMergePatch PatchFormat = "application/merge-patch+json"
+	// PatchFormatJSONPatch documents follow RFC 6902
+	PatchFormatJSONPatch PatchFormat = "application/json-patch+json"
+)
+
+// PatchFormats lists the supported patch formats
+var PatchFormats = []PatchFormat{PatchFormatMergePatch, PatchFormatJSONPatch}
+
+// IsValid reports whether f is a supported patch format
+func (f PatchFormat) IsValid() bool {
+	for _, format := range PatchFormats {
+		if f == format {
+			return true
+		}
+	}
+	return false
+}
+
+// PostPatch is a patch document to apply to a post. Patches apply to the
+// post's title, content, tags, status, published_at and version. Changing
+// the version makes the patch fail with a conflict unless the post is still
+// at that version.
+type PostPatch struct {
+	Format   PatchFormat
+	Document []byte
+}
+
+// Tag is a tag with the number of posts that carry it
+type Tag struct {
+	Name      string `json:"name"`
+	PostCount int64  `json:"post_count"`
+}
+
+// PostRevision is a post's title and content as of one version. Every post
+// has a revision for each of its versions, starting with the first.
+type PostRevision struct {
+	PostID  uuid.UUID `json:"post_id"`
+	Version int       `json:"version"`
+	// EditorID is the user who wrote this version, or nil once their account
+	// is deleted
+	EditorID  *uuid.UUID `json:"editor_id"`
+	Title     string     `json:"title"`
+	Content   string     `json:"content"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// PostRevisionDiff is a unified diff of the title and content of two
+// revisions of a post
+type PostRevisionDiff struct {
+	PostID uuid.UUID `json:"post_id"`
+	From   int       `json:"from"`
+	To     int       `json:"to"`
+	Diff   string    `json:"diff"`
+}
+
+// PostSearchResult is a post matching a full-text search. The headlines are
+// HTML escaped excerpts of the title and content with matching terms wrapped
+// in <mark> tags.
+type PostSearchResult struct {
+	Post
+	Rank            float32 `json:"rank"`
+	TitleHeadline   string  `json:"title_headline"`
+	ContentHeadline string  `json:"content_headline"`
+}
@@ -0,0 +1,82 @@
+package models
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestPost_VisibleTo(t *testing.T) {
+	authorID := uuid.New()
+
+	tests := []struct {
+		name     string
+		post     *Post
+		ctx      context.Context
+		expected bool
+	}{
+		{
+			name:     "published post to anonymous viewer",
+			post:     &Post{UserID: authorID, Status: PostStatusPublished},
+			ctx:      context.Background(),
+			expected: true,
+		},
+		{
+			name:     "draft to its author",
+			post:     &Post{UserID: authorID, Status: PostStatusDraft},
+			ctx:      ContextWithViewer(context.Background(), authorID),
+			expected: true,
+		},
+		{
+			name:     "draft to another user",
+			post:     &Post{UserID: authorID, Status: PostStatusDraft},
+			ctx:      ContextWithViewer(context.Background(), uuid.New()),
+			expected: false,
+		},
+		{
+			name:     "deleted user's draft to anonymous viewer",
+			post:     &Post{UserID: DeletedUserID, Status: PostStatusDraft},
+			ctx:      context.Background(),
+			expected: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.post.VisibleTo(tt.ctx); got != tt.expected {
+				t.Errorf("expected VisibleTo() to be %t, got %t", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestPost_LastModified(t *testing.T) {
+	updated := time.Date(2026, 10, 14, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		post     *Post
+		expected time.Time
+	}{
+		{
+			name:     "edited since the last reaction",
+			post:     &Post{UpdatedAt: updated, ActivityAt: updated.Add(-time.Hour)},
+			expected: updated,
+		},
+		{
+			name:     "reacted to since the last edit",
+			post:     &Post{UpdatedAt: updated, ActivityAt: updated.Add(time.Hour)},
+			expected: updated.Add(time.Hour),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.post.LastModified(); !got.Equal(tt.expected) {
+				t.Errorf("expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,52 @@
+package models
+
+import (
+	"math"
+	"time"
+)
+
+// RateLimit is a token bucket that holds up to Requests tokens and refills
+// completely over Period. Every request takes a token, so a client can make
+// Requests requests in a burst and then one every Period/Requests.
+type RateLimit struct {
+	Requests int
+	Period   time.Duration
+}
+
+// Rate returns how many tokens the bucket earns back per second
+func (l RateLimit) Rate() float64 {
+	return float64(l.Requests) / l.Period.Seconds()
+}
+
+// Result describes the bucket after a request left tokens in it; allowed
+// tells whether the request got one
+func (l RateLimit) Result(tokens float64, allowed bool) *RateLimitResult {
+	result := &RateLimitResult{
+		Allowed:    allowed,
+		Remaining:  int(math.Floor(tokens)),
+		ResetAfter: l.refillTime(float64(l.Requests) - tokens),
+	}
+	if !allowed {
+		result.RetryAfter = l.refillTime(1 - tokens)
+	}
+	return result
+}
+
+// refillTime is how long the bucket takes to earn back tokens
+func (l RateLimit) refillTime(tokens float64) time.Duration {
+	if tokens <= 0 {
+		return 0
+	}
+	return time.Duration(tokens / l.Rate() * float64(time.Second))
+}
+
+// RateLimitResult is the outcome of taking a token from a bucket
+type RateLimitResult struct {
+	Allowed bool
+	// Remaining is how many requests can be made right away
+	Remaining int
+	// RetryAfter is how long a rejected client has to wait for a token
+	RetryAfter time.Duration
+	// ResetAfter is how long the bucket takes to fill up again
+	ResetAfter time.Duration
+}
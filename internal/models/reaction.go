@@ -0,0 +1,26 @@
+package models
+
+// ReactionKind is a kind of reaction a user can leave on a post. A user can
+// leave each kind once per post.
+type ReactionKind string
+
+const (
+	ReactionLike  ReactionKind = "like"
+	ReactionLove  ReactionKind = "love"
+	ReactionLaugh ReactionKind = "laugh"
+	ReactionWow   ReactionKind = "wow"
+	ReactionSad   ReactionKind = "sad"
+)
+
+// ReactionKinds lists every reaction kind
+var ReactionKinds = []ReactionKind{ReactionLike, ReactionLove, ReactionLaugh, ReactionWow, ReactionSad}
+
+// IsValid reports whether k is a known reaction kind
+func (k ReactionKind) IsValid() bool {
+	for _, kind := range ReactionKinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
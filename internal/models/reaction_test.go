@@ -0,0 +1,24 @@
+package models
+
+import "testing"
+
+func TestReactionKind_IsValid(t *testing.T) {
+	tests := []struct {
+		kind     ReactionKind
+		expected bool
+	}{
+		{kind: ReactionLike, expected: true},
+		{kind: ReactionSad, expected: true},
+		{kind: "dislike", expected: false},
+		{kind: "LIKE", expected: false},
+		{kind: "", expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.kind), func(t *testing.T) {
+			if got := tt.kind.IsValid(); got != tt.expected {
+				t.Errorf("expected IsValid() to be %t, got %t", tt.expected, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,55 @@
+package models
+
+// Role is a user's role. Every role has a fixed set of permissions.
+type Role string
+
+const (
+	RoleUser      Role = "user"
+	RoleModerator Role = "moderator"
+	RoleAdmin     Role = "admin"
+)
+
+// Permission names an action that is restricted to some roles
+type Permission string
+
+const (
+	// PermissionDeleteAnyPost allows deleting posts written by other users
+	PermissionDeleteAnyPost Permission = "posts:delete_any"
+	// PermissionReadPrivateUserFields allows listing users with fields that
+	// are hidden from the public user endpoints
+	PermissionReadPrivateUserFields Permission = "users:read_private"
+	// PermissionManageUsers allows changing roles and revoking a user's tokens
+	PermissionManageUsers Permission = "users:manage"
+)
+
+var rolePermissions = map[Role][]Permission{
+	RoleUser: {},
+	RoleModerator: {
+		PermissionDeleteAnyPost,
+	},
+	RoleAdmin: {
+		PermissionDeleteAnyPost,
+		PermissionReadPrivateUserFields,
+		PermissionManageUsers,
+	},
+}
+
+// IsValid reports whether r is a known role
+func (r Role) IsValid() bool {
+	_, ok := rolePermissions[r]
+	return ok
+}
+
+// HasPermission reports whether the role grants p
+func (r Role) HasPermission(p Permission) bool {
+	for _, granted := range rolePermissions[r] {
+		if granted == p {
+			return true
+		}
+	}
+	return false
+}
+
+type UpdateRoleRequest struct {
+	Role Role `json:"role"`
+}
@@ -0,0 +1,79 @@
+package models
+
+import (
+	"testing"
+)
+
+func TestRole_HasPermission(t *testing.T) {
+	tests := []struct {
+		name       string
+		role       Role
+		permission Permission
+		expected   bool
+	}{
+		{
+			name:       "user cannot delete any post",
+			role:       RoleUser,
+			permission: PermissionDeleteAnyPost,
+			expected:   false,
+		},
+		{
+			name:       "moderator can delete any post",
+			role:       RoleModerator,
+			permission: PermissionDeleteAnyPost,
+			expected:   true,
+		},
+		{
+			name:       "moderator cannot manage users",
+			role:       RoleModerator,
+			permission: PermissionManageUsers,
+			expected:   false,
+		},
+		{
+			name:       "admin can manage users",
+			role:       RoleAdmin,
+			permission: PermissionManageUsers,
+			expected:   true,
+		},
+		{
+			name:       "admin can read private user fields",
+			role:       RoleAdmin,
+			permission: PermissionReadPrivateUserFields,
+			expected:   true,
+		},
+		{
+			name:       "unknown role has no permissions",
+			role:       Role("superuser"),
+			permission: PermissionDeleteAnyPost,
+			expected:   false,
+		},
+		{
+			name:       "empty role has no permissions",
+			role:       Role(""),
+			permission: PermissionDeleteAnyPost,
+			expected:   false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if result := tt.role.HasPermission(tt.permission); result != tt.expected {
+				t.Errorf("expected %t, got %t", tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestRole_IsValid(t *testing.T) {
+	for _, role := range []Role{RoleUser, RoleModerator, RoleAdmin} {
+		if !role.IsValid() {
+			t.Errorf("expected %q to be valid", role)
+		}
+	}
+
+	for _, role := range []Role{"", "root", "Admin"} {
+		if role.IsValid() {
+			t.Errorf("expected %q to be invalid", role)
+		}
+	}
+}
@@ -10,9 +10,28 @@ type User struct {
 	ID           uuid.UUID `json:"id" db:"id"`
 	Username     string    `json:"username" db:"username"`
 	PasswordHash string    `json:"-" db:"password_hash"`
+	Role         Role      `json:"-" db:"role"`
 	CreatedAt    time.Time `json:"created_at" db:"created_at"`
 }
 
+// PrivateUser is the view of a user returned by admin endpoints. It includes
+// fields that the public user endpoints never expose.
+type PrivateUser struct {
+	ID        uuid.UUID `json:"id"`
+	Username  string    `json:"username"`
+	Role      Role      `json:"role"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func NewPrivateUser(user *User) *PrivateUser {
+	return &PrivateUser{
+		ID:        user.ID,
+		Username:  user.Username,
+		Role:      user.Role,
+		CreatedAt: user.CreatedAt,
+	}
+}
+
 type CreateUserRequest struct {
 	Username string `json:"username"`
 	Password string `json:"password"`
@@ -21,4 +40,31 @@ type CreateUserRequest struct {
 type UpdateUserRequest struct {
 	Username *string `json:"username,omitempty"`
 	Password *string `json:"password,omitempty"`
+	// CurrentPassword must be supplied to change the password
+	CurrentPassword *string `json:"current_password,omitempty"`
+}
+
+// DeletedUserID identifies the placeholder account that keeps the posts of
+// deleted users when they are anonymized. It cannot log in.
+var DeletedUserID = uuid.Nil
+
+// UserPostPolicy decides what happens to a user's posts when the user deletes
+// their account
+type UserPostPolicy string
+
+const (
+	// UserPostPolicyCascade deletes the posts along with the user
+	UserPostPolicyCascade UserPostPolicy = "cascade"
+	// UserPostPolicyAnonymize keeps the posts and moves them to DeletedUserID
+	UserPostPolicyAnonymize UserPostPolicy = "anonymize"
+	// UserPostPolicyBlock refuses to delete a user who still has posts
+	UserPostPolicyBlock UserPostPolicy = "block"
+)
+
+func (p UserPostPolicy) IsValid() bool {
+	switch p {
+	case UserPostPolicyCascade, UserPostPolicyAnonymize, UserPostPolicyBlock:
+		return true
+	}
+	return false
 }
\ No newline at end of file
@@ -0,0 +1,22 @@
+package models
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+type viewerContextKey struct{}
+
+// ContextWithViewer records the user a request is made by, so that posts can
+// flag the viewer's own reactions, the viewer's drafts are listed and the
+// viewer's edits are recorded in revisions
+func ContextWithViewer(ctx context.Context, userID uuid.UUID) context.Context {
+	return context.WithValue(ctx, viewerContextKey{}, userID)
+}
+
+// ViewerFromContext returns the user recorded by ContextWithViewer
+func ViewerFromContext(ctx context.Context) (uuid.UUID, bool) {
+	userID, ok := ctx.Value(viewerContextKey{}).(uuid.UUID)
+	return userID, ok
+}
@@ -0,0 +1,20 @@
+package models
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestViewerFromContext(t *testing.T) {
+	if _, ok := ViewerFromContext(context.Background()); ok {
+		t.Error("expected no viewer in an empty context")
+	}
+
+	userID := uuid.New()
+	viewerID, ok := ViewerFromContext(ContextWithViewer(context.Background(), userID))
+	if !ok || viewerID != userID {
+		t.Errorf("expected viewer %s, got %s (%t)", userID, viewerID, ok)
+	}
+}
@@ -0,0 +1,211 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/alinoer/go-std-api/internal/errors"
+	"github.com/alinoer/go-std-api/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type CommentRepository interface {
+	Create(ctx context.Context, comment *models.Comment) error
+	GetByID(ctx context.Context, id uuid.UUID) (*models.Comment, error)
+	// ListByPostID returns a page of a post's top-level comments, oldest
+	// first, and the total number of top-level comments
+	ListByPostID(ctx context.Context, postID uuid.UUID, pagination *models.PaginationParams) ([]*models.Comment, int64, error)
+	// ListReplies returns the replies to any of the given comments, oldest first
+	ListReplies(ctx context.Context, parentIDs []uuid.UUID) ([]*models.Comment, error)
+	Update(ctx context.Context, id uuid.UUID, comment *models.Comment) error
+	// Delete deletes a comment along with its replies
+	Delete(ctx context.Context, id uuid.UUID) error
+}
+
+type commentRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewCommentRepository(db *pgxpool.Pool) CommentRepository {
+	return &commentRepository{db: db}
+}
+
+const commentColumns = `id, post_id, user_id, parent_id, content, created_at, updated_at`
+
+func (r *commentRepository) Create(ctx context.Context, comment *models.Comment) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return errors.DatabaseError("begin transaction", err)
+	}
+	defer tx.Rollback(ctx)
+
+	query := `
+		INSERT INTO comments (id, post_id, user_id, parent_id, content, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)`
+
+	_, err = tx.Exec(ctx, query,
+		comment.ID,
+		comment.PostID,
+		comment.UserID,
+		comment.ParentID,
+		comment.Content,
+		comment.CreatedAt,
+		comment.UpdatedAt,
+	)
+	if err != nil {
+		return errors.DatabaseError("create comment", err)
+	}
+
+	if err := touchPost(ctx, tx, comment.PostID); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return errors.DatabaseError("commit transaction", err)
+	}
+
+	return nil
+}
+
+func (r *commentRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Comment, error) {
+	query := `
+		SELECT ` + commentColumns + `
+		FROM comments
+		WHERE id = $1`
+
+	var comment models.Comment
+	if err := scanComment(r.db.QueryRow(ctx, query, id), &comment); err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, errors.NotFound("Comment")
+		}
+		return nil, errors.DatabaseError("get comment", err)
+	}
+
+	return &comment, nil
+}
+
+func (r *commentRepository) ListByPostID(ctx context.Context, postID uuid.UUID, pagination *models.PaginationParams) ([]*models.Comment, int64, error) {
+	countQuery := `SELECT COUNT(*) FROM comments WHERE post_id = $1 AND parent_id IS NULL`
+	var total int64
+	if err := r.db.QueryRow(ctx, countQuery, postID).Scan(&total); err != nil {
+		return nil, 0, errors.DatabaseError("count comments", err)
+	}
+
+	query := `
+		SELECT ` + commentColumns + `
+		FROM comments
+		WHERE post_id = $1 AND parent_id IS NULL
+		ORDER BY created_at ASC, id ASC
+		LIMIT $2 OFFSET $3`
+
+	comments, err := r.queryComments(ctx, query, postID, pagination.PageSize, pagination.Offset)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return comments, total, nil
+}
+
+func (r *commentRepository) ListReplies(ctx context.Context, parentIDs []uuid.UUID) ([]*models.Comment, error) {
+	if len(parentIDs) == 0 {
+		return nil, nil
+	}
+
+	query := `
+		SELECT ` + commentColumns + `
+		FROM comments
+		WHERE parent_id = ANY($1)
+		ORDER BY created_at ASC, id ASC`
+
+	return r.queryComments(ctx, query, parentIDs)
+}
+
+func (r *commentRepository) Update(ctx context.Context, id uuid.UUID, comment *models.Comment) error {
+	query := `
+		UPDATE comments
+		SET content = $1, updated_at = $2
+		WHERE id = $3`
+
+	result, err := r.db.Exec(ctx, query, comment.Content, comment.UpdatedAt, id)
+	if err != nil {
+		return errors.DatabaseError("update comment", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return errors.NotFound("Comment")
+	}
+
+	return nil
+}
+
+func (r *commentRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return errors.DatabaseError("begin transaction", err)
+	}
+	defer tx.Rollback(ctx)
+
+	var postID uuid.UUID
+	if err := tx.QueryRow(ctx, `DELETE FROM comments WHERE id = $1 RETURNING post_id`, id).Scan(&postID); err != nil {
+		if err == pgx.ErrNoRows {
+			return errors.NotFound("Comment")
+		}
+		return errors.DatabaseError("delete comment", err)
+	}
+
+	if err := touchPost(ctx, tx, postID); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return errors.DatabaseError("commit transaction", err)
+	}
+
+	return nil
+}
+
+// touchPost records that the comments of a post changed, which its version
+// does not track
+func touchPost(ctx context.Context, tx pgx.Tx, postID uuid.UUID) error {
+	if _, err := tx.Exec(ctx, `UPDATE posts SET activity_at = NOW() WHERE id = $1`, postID); err != nil {
+		return errors.DatabaseError("touch post", err)
+	}
+	return nil
+}
+
+func (r *commentRepository) queryComments(ctx context.Context, query string, args ...interface{}) ([]*models.Comment, error) {
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, errors.DatabaseError("list comments", err)
+	}
+	defer rows.Close()
+
+	var comments []*models.Comment
+	for rows.Next() {
+		var comment models.Comment
+		if err := scanComment(rows, &comment); err != nil {
+			return nil, errors.DatabaseError("scan comment", err)
+		}
+		comments = append(comments, &comment)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, errors.DatabaseError("iterate comments", err)
+	}
+
+	return comments, nil
+}
+
+func scanComment(row pgx.Row, comment *models.Comment) error {
+	return row.Scan(
+		&comment.ID,
+		&comment.PostID,
+		&comment.UserID,
+		&comment.ParentID,
+		&comment.Content,
+		&comment.CreatedAt,
+		&comment.UpdatedAt,
+	)
+}
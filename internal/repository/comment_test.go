@@ -0,0 +1,87 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alinoer/go-std-api/internal/models"
+	"github.com/alinoer/go-std-api/internal/testutils"
+	"github.com/google/uuid"
+)
+
+func TestCommentRepository_Threads(t *testing.T) {
+	testutils.SkipIfShort(t)
+	testutils.SkipIfNoDatabase(t)
+
+	testDB := testutils.SetupTestDB(t)
+	defer testDB.Cleanup(t)
+
+	ctx := context.Background()
+	userRepo := NewUserRepository(testDB.DB)
+	postRepo := NewPostRepository(testDB.DB)
+	commentRepo := NewCommentRepository(testDB.DB)
+
+	user := &models.User{ID: uuid.New(), Username: "commenter", PasswordHash: "hash", CreatedAt: time.Now()}
+	if err := userRepo.Create(ctx, user); err != nil {
+		t.Fatalf("failed to create user: %v", err)
+	}
+	post := &models.Post{ID: uuid.New(), UserID: user.ID, Title: "Post", Content: "Content", CreatedAt: time.Now()}
+	if err := postRepo.Create(ctx, post); err != nil {
+		t.Fatalf("failed to create post: %v", err)
+	}
+
+	base := time.Now().Add(-time.Hour)
+	first := &models.Comment{ID: uuid.New(), PostID: post.ID, UserID: user.ID, Content: "First", CreatedAt: base, UpdatedAt: base}
+	second := &models.Comment{ID: uuid.New(), PostID: post.ID, UserID: user.ID, Content: "Second", CreatedAt: base.Add(time.Minute), UpdatedAt: base.Add(time.Minute)}
+	reply := &models.Comment{ID: uuid.New(), PostID: post.ID, UserID: user.ID, ParentID: &first.ID, Content: "Reply", CreatedAt: base.Add(2 * time.Minute), UpdatedAt: base.Add(2 * time.Minute)}
+	for _, comment := range []*models.Comment{first, second, reply} {
+		if err := commentRepo.Create(ctx, comment); err != nil {
+			t.Fatalf("failed to create comment: %v", err)
+		}
+	}
+
+	comments, total, err := commentRepo.ListByPostID(ctx, post.ID, models.NewPaginationParams(1, 10))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if total != 2 || len(comments) != 2 {
+		t.Fatalf("expected 2 top-level comments, got %d of %d", len(comments), total)
+	}
+	if comments[0].ID != first.ID || comments[1].ID != second.ID {
+		t.Errorf("expected comments oldest first, got %s, %s", comments[0].ID, comments[1].ID)
+	}
+
+	replies, err := commentRepo.ListReplies(ctx, []uuid.UUID{first.ID, second.ID})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(replies) != 1 || replies[0].ID != reply.ID || *replies[0].ParentID != first.ID {
+		t.Errorf("expected the reply to the first comment, got %v", replies)
+	}
+
+	withCount, err := postRepo.GetByID(ctx, post.ID)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if withCount.CommentCount != 3 {
+		t.Errorf("expected comment count 3, got %d", withCount.CommentCount)
+	}
+
+	if !withCount.ActivityAt.After(withCount.UpdatedAt) {
+		t.Errorf("expected the comments to be recorded as activity, got %v", withCount.ActivityAt)
+	}
+
+	if err := commentRepo.Delete(ctx, first.ID); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if deleted, _ := postRepo.GetByID(ctx, post.ID); !deleted.ActivityAt.After(withCount.ActivityAt) {
+		t.Errorf("expected the deletion to be recorded as activity, got %v", deleted.ActivityAt)
+	}
+	if _, err := commentRepo.GetByID(ctx, reply.ID); err == nil {
+		t.Error("expected the reply to be deleted with its parent")
+	}
+	if err := commentRepo.Delete(ctx, first.ID); err == nil {
+		t.Error("expected deleting a missing comment to fail")
+	}
+}
@@ -0,0 +1,114 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/alinoer/go-std-api/internal/errors"
+	"github.com/alinoer/go-std-api/internal/models"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// IdempotencyStore keeps the responses to requests made with an
+// Idempotency-Key header. Keys are unique within a scope, such as a user.
+type IdempotencyStore interface {
+	// Claim reserves key for a request with the given fingerprint until
+	// lockTimeout passes. It returns nil when the key was free and the record
+	// of the request that holds it otherwise.
+	Claim(ctx context.Context, scope, key, fingerprint string, lockTimeout time.Duration) (*models.IdempotencyRecord, error)
+	// Complete stores the response to the request that claimed key and keeps
+	// it for ttl
+	Complete(ctx context.Context, scope, key string, record *models.IdempotencyRecord, ttl time.Duration) error
+	// Release frees a claimed key whose request did not finish, so that it
+	// can be retried
+	Release(ctx context.Context, scope, key string) error
+}
+
+type idempotencyRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewIdempotencyRepository(db *pgxpool.Pool) IdempotencyStore {
+	return &idempotencyRepository{db: db}
+}
+
+func (r *idempotencyRepository) Claim(ctx context.Context, scope, key, fingerprint string, lockTimeout time.Duration) (*models.IdempotencyRecord, error) {
+	// Expired keys can be used again
+	_, err := r.db.Exec(ctx, `DELETE FROM idempotency_keys WHERE expires_at <= NOW()`)
+	if err != nil {
+		return nil, errors.DatabaseError("prune idempotency keys", err)
+	}
+
+	query := `
+		INSERT INTO idempotency_keys (scope, key, fingerprint, expires_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (scope, key) DO NOTHING`
+
+	result, err := r.db.Exec(ctx, query, scope, key, fingerprint, time.Now().Add(lockTimeout))
+	if err != nil {
+		return nil, errors.DatabaseError("claim idempotency key", err)
+	}
+	if result.RowsAffected() == 1 {
+		return nil, nil
+	}
+
+	query = `
+		SELECT fingerprint, status_code, headers, body
+		FROM idempotency_keys
+		WHERE scope = $1 AND key = $2`
+
+	var statusCode *int
+	var headers []byte
+	record := &models.IdempotencyRecord{}
+	err = r.db.QueryRow(ctx, query, scope, key).Scan(&record.Fingerprint, &statusCode, &headers, &record.Body)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			// The key expired after the insert ran into it; report it as
+			// busy and let the client retry
+			return &models.IdempotencyRecord{Fingerprint: fingerprint}, nil
+		}
+		return nil, errors.DatabaseError("get idempotency key", err)
+	}
+	if statusCode != nil {
+		record.StatusCode = *statusCode
+	}
+	if headers != nil {
+		if err := json.Unmarshal(headers, &record.Header); err != nil {
+			return nil, errors.DatabaseError("decode idempotency key headers", err)
+		}
+	}
+
+	return record, nil
+}
+
+func (r *idempotencyRepository) Complete(ctx context.Context, scope, key string, record *models.IdempotencyRecord, ttl time.Duration) error {
+	headers, err := json.Marshal(record.Header)
+	if err != nil {
+		return errors.DatabaseError("encode idempotency key headers", err)
+	}
+
+	query := `
+		UPDATE idempotency_keys
+		SET status_code = $3, headers = $4, body = $5, expires_at = $6
+		WHERE scope = $1 AND key = $2 AND status_code IS NULL`
+
+	_, err = r.db.Exec(ctx, query, scope, key, record.StatusCode, headers, record.Body, time.Now().Add(ttl))
+	if err != nil {
+		return errors.DatabaseError("complete idempotency key", err)
+	}
+
+	return nil
+}
+
+func (r *idempotencyRepository) Release(ctx context.Context, scope, key string) error {
+	query := `DELETE FROM idempotency_keys WHERE scope = $1 AND key = $2 AND status_code IS NULL`
+
+	if _, err := r.db.Exec(ctx, query, scope, key); err != nil {
+		return errors.DatabaseError("release idempotency key", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,82 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alinoer/go-std-api/internal/models"
+	"github.com/alinoer/go-std-api/internal/testutils"
+)
+
+func TestIdempotencyRepository(t *testing.T) {
+	testutils.SkipIfShort(t)
+	testutils.SkipIfNoDatabase(t)
+
+	testDB := testutils.SetupTestDB(t)
+	defer testDB.Cleanup(t)
+
+	store := NewIdempotencyRepository(testDB.DB)
+	ctx := context.Background()
+
+	record, err := store.Claim(ctx, "user:1", "key-1", "fingerprint", time.Minute)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if record != nil {
+		t.Fatalf("expected a free key, got %+v", record)
+	}
+
+	record, err = store.Claim(ctx, "user:1", "key-1", "fingerprint", time.Minute)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if record == nil || !record.InFlight() || record.Fingerprint != "fingerprint" {
+		t.Fatalf("expected the in-flight request, got %+v", record)
+	}
+
+	// Keys are unique within a scope only
+	if record, _ := store.Claim(ctx, "user:2", "key-1", "other", time.Minute); record != nil {
+		t.Errorf("expected the key to be free for another user, got %+v", record)
+	}
+
+	err = store.Complete(ctx, "user:1", "key-1", &models.IdempotencyRecord{
+		Fingerprint: "fingerprint",
+		StatusCode:  201,
+		Header:      map[string][]string{"Content-Type": {"application/json"}},
+		Body:        []byte(`{"id":1}`),
+	}, time.Hour)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	record, err = store.Claim(ctx, "user:1", "key-1", "fingerprint", time.Minute)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if record == nil || record.StatusCode != 201 || string(record.Body) != `{"id":1}` || record.Header["Content-Type"][0] != "application/json" {
+		t.Fatalf("expected the stored response, got %+v", record)
+	}
+
+	// Completed keys are not released
+	if err := store.Release(ctx, "user:1", "key-1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if record, _ := store.Claim(ctx, "user:1", "key-1", "fingerprint", time.Minute); record == nil || record.InFlight() {
+		t.Errorf("expected the stored response to survive a release, got %+v", record)
+	}
+
+	// Released and expired keys can be claimed again
+	if err := store.Release(ctx, "user:2", "key-1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if record, _ := store.Claim(ctx, "user:2", "key-1", "other", time.Minute); record != nil {
+		t.Errorf("expected a released key to be free, got %+v", record)
+	}
+	if record, _ := store.Claim(ctx, "user:3", "key-1", "fingerprint", -time.Minute); record != nil {
+		t.Fatalf("expected a free key, got %+v", record)
+	}
+	if record, _ := store.Claim(ctx, "user:3", "key-1", "fingerprint", time.Minute); record != nil {
+		t.Errorf("expected an expired claim to be free, got %+v", record)
+	}
+}
@@ -0,0 +1,149 @@
+package repository
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/alinoer/go-std-api/internal/errors"
+	"github.com/alinoer/go-std-api/internal/models"
+)
+
+// listColumns maps the API field names a list accepts to SQL columns. It is
+// the whitelist that keeps caller supplied names out of the SQL text; values
+// are always bound as arguments.
+type listColumns map[string]string
+
+var postListColumns = listColumns{
+	"created_at":     "created_at",
+	"title":          "title",
+	"user_id":        "user_id",
+	"reaction_count": "reaction_count",
+	"status":         "status",
+}
+
+var userListColumns = listColumns{
+	"created_at": "created_at",
+	"username":   "username",
+}
+
+const defaultListOrder = "created_at DESC, id DESC"
+
+// listQuery builds the WHERE and ORDER BY clauses of a list query along with
+// its positional arguments
+type listQuery struct {
+	conditions []string
+	args       []interface{}
+	orderBy    string
+}
+
+func newListQuery() *listQuery {
+	return &listQuery{orderBy: defaultListOrder}
+}
+
+// arg binds a value and returns its placeholder
+func (q *listQuery) arg(value interface{}) string {
+	q.args = append(q.args, value)
+	return fmt.Sprintf("$%d", len(q.args))
+}
+
+func (q *listQuery) where(condition string) {
+	q.conditions = append(q.conditions, condition)
+}
+
+// whereClause returns the WHERE clause, or an empty string without conditions
+func (q *listQuery) whereClause() string {
+	if len(q.conditions) == 0 {
+		return ""
+	}
+	return "WHERE " + strings.Join(q.conditions, " AND ")
+}
+
+// apply adds the filters and sort order of opts, which may be nil
+func (q *listQuery) apply(opts *models.ListOptions, columns listColumns) error {
+	if opts == nil {
+		return nil
+	}
+
+	for _, filter := range opts.Filters {
+		column, ok := columns[filter.Field]
+		if !ok {
+			return errors.ValidationError(filter.Field, "Field cannot be used to filter this list")
+		}
+
+		switch filter.Op {
+		case models.FilterEquals:
+			q.where(column + " = " + q.arg(filter.Value))
+		case models.FilterContains:
+			q.where(column + " ILIKE " + q.arg("%"+escapeLike(fmt.Sprint(filter.Value))+"%"))
+		case models.FilterAfter:
+			q.where(column + " > " + q.arg(filter.Value))
+		case models.FilterBefore:
+			q.where(column + " < " + q.arg(filter.Value))
+		default:
+			return errors.ValidationError(filter.Field, fmt.Sprintf("Unsupported filter operator %q", filter.Op))
+		}
+	}
+
+	if len(opts.Sort) > 0 {
+		order := make([]string, 0, len(opts.Sort)+1)
+		for _, sort := range opts.Sort {
+			column, ok := columns[sort.Field]
+			if !ok {
+				return errors.ValidationError("sort", fmt.Sprintf("Cannot sort by %s", sort.Field))
+			}
+			if sort.Descending {
+				order = append(order, column+" DESC")
+			} else {
+				order = append(order, column+" ASC")
+			}
+		}
+		// Keep the order stable between pages
+		order = append(order, "id DESC")
+		q.orderBy = strings.Join(order, ", ")
+	}
+
+	return nil
+}
+
+// keyset adds the condition and order that select the rows after or before
+// params.Cursor in newest-first (created_at, id) order. Queries must fetch
+// params.Limit+1 rows so trimKeysetPage can tell whether more rows follow.
+func (q *listQuery) keyset(params *models.CursorParams) {
+	if params.Backward() {
+		q.orderBy = "created_at ASC, id ASC"
+	} else {
+		q.orderBy = defaultListOrder
+	}
+
+	if params.Cursor == nil {
+		return
+	}
+
+	op := "<"
+	if params.Backward() {
+		op = ">"
+	}
+	q.where(fmt.Sprintf("(created_at, id) %s (%s, %s)", op, q.arg(params.Cursor.CreatedAt), q.arg(params.Cursor.ID)))
+}
+
+// trimKeysetPage drops the extra row fetched to detect further pages and
+// restores newest-first order for backward pages
+func trimKeysetPage[T any](rows []T, params *models.CursorParams) ([]T, bool) {
+	hasMore := len(rows) > params.Limit
+	if hasMore {
+		rows = rows[:params.Limit]
+	}
+
+	if params.Backward() {
+		for i, j := 0, len(rows)-1; i < j; i, j = i+1, j-1 {
+			rows[i], rows[j] = rows[j], rows[i]
+		}
+	}
+
+	return rows, hasMore
+}
+
+// escapeLike escapes the LIKE wildcards in a literal search term
+func escapeLike(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(value)
+}
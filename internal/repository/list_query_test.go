@@ -0,0 +1,220 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alinoer/go-std-api/internal/errors"
+	"github.com/alinoer/go-std-api/internal/models"
+	"github.com/google/uuid"
+)
+
+func TestKeysetPage(t *testing.T) {
+	cursorAt := time.Now()
+	cursorID := uuid.New()
+
+	tests := []struct {
+		name              string
+		params            *models.CursorParams
+		expectedCondition string
+		expectedOrderBy   string
+		expectedArgs      int
+	}{
+		{
+			name:            "first page",
+			params:          models.NewCursorParams(nil, 10, false),
+			expectedOrderBy: "created_at DESC, id DESC",
+		},
+		{
+			name:              "next page",
+			params:            models.NewCursorParams(&models.Cursor{CreatedAt: cursorAt, ID: cursorID, Direction: models.CursorNext}, 10, false),
+			expectedCondition: "(created_at, id) < ($2, $3)",
+			expectedOrderBy:   "created_at DESC, id DESC",
+			expectedArgs:      2,
+		},
+		{
+			name:              "previous page",
+			params:            models.NewCursorParams(&models.Cursor{CreatedAt: cursorAt, ID: cursorID, Direction: models.CursorPrevious}, 10, false),
+			expectedCondition: "(created_at, id) > ($2, $3)",
+			expectedOrderBy:   "created_at ASC, id ASC",
+			expectedArgs:      2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := newListQuery()
+			q.where("user_id = " + q.arg(uuid.New()))
+			q.keyset(tt.params)
+
+			condition := ""
+			if len(q.conditions) > 1 {
+				condition = q.conditions[1]
+			}
+			if condition != tt.expectedCondition {
+				t.Errorf("expected condition %q, got %q", tt.expectedCondition, condition)
+			}
+			if q.orderBy != tt.expectedOrderBy {
+				t.Errorf("expected order %q, got %q", tt.expectedOrderBy, q.orderBy)
+			}
+			if len(q.args)-1 != tt.expectedArgs {
+				t.Errorf("expected %d args, got %d", tt.expectedArgs, len(q.args)-1)
+			}
+		})
+	}
+}
+
+func TestListQuery_Apply(t *testing.T) {
+	userID := uuid.New()
+	after := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name            string
+		opts            *models.ListOptions
+		expectedWhere   string
+		expectedOrderBy string
+		expectedArgs    []interface{}
+		expectError     bool
+	}{
+		{
+			name:            "no options",
+			opts:            nil,
+			expectedOrderBy: "created_at DESC, id DESC",
+		},
+		{
+			name: "filters",
+			opts: &models.ListOptions{Filters: []models.Filter{
+				{Field: "user_id", Op: models.FilterEquals, Value: userID},
+				{Field: "title", Op: models.FilterContains, Value: "50%_off"},
+				{Field: "created_at", Op: models.FilterAfter, Value: after},
+			}},
+			expectedWhere:   "WHERE user_id = $1 AND title ILIKE $2 AND created_at > $3",
+			expectedOrderBy: "created_at DESC, id DESC",
+			expectedArgs:    []interface{}{userID, `%50\%\_off%`, after},
+		},
+		{
+			name: "sort",
+			opts: &models.ListOptions{Sort: []models.SortField{
+				{Field: "title"},
+				{Field: "created_at", Descending: true},
+			}},
+			expectedOrderBy: "title ASC, created_at DESC, id DESC",
+		},
+		{
+			name:        "unknown filter field",
+			opts:        &models.ListOptions{Filters: []models.Filter{{Field: "password_hash", Op: models.FilterEquals, Value: "x"}}},
+			expectError: true,
+		},
+		{
+			name:        "unknown sort field",
+			opts:        &models.ListOptions{Sort: []models.SortField{{Field: "content; DROP TABLE posts"}}},
+			expectError: true,
+		},
+		{
+			name:        "unknown operator",
+			opts:        &models.ListOptions{Filters: []models.Filter{{Field: "title", Op: "regex", Value: "x"}}},
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := newListQuery()
+
+			err := q.apply(tt.opts, postListColumns)
+
+			if tt.expectError {
+				if err == nil {
+					t.Fatal("expected error but got none")
+				}
+				if appErr := errors.AsAppError(err); appErr.Code != errors.ErrCodeValidation {
+					t.Errorf("expected validation error, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if where := q.whereClause(); where != tt.expectedWhere {
+				t.Errorf("expected where %q, got %q", tt.expectedWhere, where)
+			}
+			if q.orderBy != tt.expectedOrderBy {
+				t.Errorf("expected order %q, got %q", tt.expectedOrderBy, q.orderBy)
+			}
+			if len(q.args) != len(tt.expectedArgs) {
+				t.Fatalf("expected args %v, got %v", tt.expectedArgs, q.args)
+			}
+			for i := range q.args {
+				if q.args[i] != tt.expectedArgs[i] {
+					t.Errorf("expected arg %d to be %v, got %v", i, tt.expectedArgs[i], q.args[i])
+				}
+			}
+		})
+	}
+}
+
+func TestTrimKeysetPage(t *testing.T) {
+	tests := []struct {
+		name            string
+		rows            []int
+		direction       models.CursorDirection
+		expectedRows    []int
+		expectedHasMore bool
+	}{
+		{
+			name:            "extra row forward",
+			rows:            []int{5, 4, 3},
+			direction:       models.CursorNext,
+			expectedRows:    []int{5, 4},
+			expectedHasMore: true,
+		},
+		{
+			name:         "short page forward",
+			rows:         []int{5},
+			direction:    models.CursorNext,
+			expectedRows: []int{5},
+		},
+		{
+			name:            "extra row backward",
+			rows:            []int{6, 7, 8},
+			direction:       models.CursorPrevious,
+			expectedRows:    []int{7, 6},
+			expectedHasMore: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			params := models.NewCursorParams(&models.Cursor{CreatedAt: time.Now(), ID: uuid.New(), Direction: tt.direction}, 2, false)
+
+			rows, hasMore := trimKeysetPage(tt.rows, params)
+
+			if hasMore != tt.expectedHasMore {
+				t.Errorf("expected has more %t, got %t", tt.expectedHasMore, hasMore)
+			}
+			if len(rows) != len(tt.expectedRows) {
+				t.Fatalf("expected rows %v, got %v", tt.expectedRows, rows)
+			}
+			for i := range rows {
+				if rows[i] != tt.expectedRows[i] {
+					t.Fatalf("expected rows %v, got %v", tt.expectedRows, rows)
+				}
+			}
+		})
+	}
+}
+
+func TestEscapeLike(t *testing.T) {
+	tests := map[string]string{
+		"plain":   "plain",
+		"100%":    `100\%`,
+		"a_b":     `a\_b`,
+		`back\sl`: `back\\sl`,
+	}
+
+	for input, expected := range tests {
+		if got := escapeLike(input); got != expected {
+			t.Errorf("escapeLike(%q): expected %q, got %q", input, expected, got)
+		}
+	}
+}
@@ -0,0 +1,153 @@
+package repository
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/alinoer/go-std-api/internal/errors"
+	"github.com/alinoer/go-std-api/internal/models"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// loginPruneInterval is how often the repository drops failures that no
+// longer count
+const loginPruneInterval = time.Minute
+
+// LoginRepository tracks failed logins per key, such as a username or an IP
+// address, and keeps the login audit log
+type LoginRepository interface {
+	// ReserveAttempt counts a login attempt for key as a failure before its
+	// password is checked and locks key for limit.LockoutAfter the count as
+	// soon as it reaches limit.MaxFailures. Attempts for a key are counted one
+	// at a time and not at all while it is locked, so concurrent attempts
+	// cannot get past a lockout. It reports whether the attempt was counted
+	// and the time key is locked until, or the zero time if it is not locked.
+	ReserveAttempt(ctx context.Context, key string, limit models.LoginLimit) (bool, time.Time, error)
+	// Release takes back a counted attempt that did not fail and lifts the
+	// lockout it started, if any
+	Release(ctx context.Context, key string, lockedUntil time.Time) error
+	// Reset forgets the failures and lockouts of keys
+	Reset(ctx context.Context, keys []string) error
+	CreateEvent(ctx context.Context, event *models.LoginEvent) error
+}
+
+type loginRepository struct {
+	db *pgxpool.Pool
+
+	mu         sync.Mutex
+	lastPruned time.Time
+}
+
+func NewLoginRepository(db *pgxpool.Pool) LoginRepository {
+	return &loginRepository{db: db}
+}
+
+func (r *loginRepository) ReserveAttempt(ctx context.Context, key string, limit models.LoginLimit) (bool, time.Time, error) {
+	if err := r.prune(ctx, limit.Window); err != nil {
+		return false, time.Time{}, err
+	}
+
+	// Failures outside the window do not count, whether or not they have
+	// been pruned yet, so the count starts over after them.
+	failures := `CASE WHEN f.last_failed_at <= NOW() - $5::INTERVAL THEN 1 ELSE f.failures + 1 END`
+
+	// The conflicting row stays locked until the statement is done, so every
+	// attempt sees the count and the lockout left by the one before it. The
+	// lockout is computed like models.LoginLimit.LockoutAfter.
+	query := `
+		INSERT INTO login_failures AS f (key, failures, last_failed_at, locked_until)
+		VALUES ($1, 1, NOW(), CASE WHEN $2::INTEGER <= 1 THEN NOW() + LEAST($3::INTERVAL, $4::INTERVAL) END)
+		ON CONFLICT (key) DO UPDATE
+		SET failures = ` + failures + `,
+			last_failed_at = NOW(),
+			locked_until = CASE WHEN ` + failures + ` >= $2::INTEGER
+				THEN NOW() + LEAST($3::INTERVAL * POWER(2, LEAST(` + failures + ` - $2::INTEGER, 30))::DOUBLE PRECISION, $4::INTERVAL)
+				END
+		WHERE f.locked_until IS NULL OR f.locked_until <= NOW()
+		RETURNING locked_until`
+
+	var lockedUntil *time.Time
+	err := r.db.QueryRow(ctx, query, key, limit.MaxFailures, limit.Lockout, limit.MaxLockout, limit.Window).Scan(&lockedUntil)
+	if err == nil {
+		if lockedUntil == nil {
+			return true, time.Time{}, nil
+		}
+		return true, *lockedUntil, nil
+	}
+	if err != pgx.ErrNoRows {
+		return false, time.Time{}, errors.DatabaseError("reserve login attempt", err)
+	}
+
+	// The key is locked. It may have been unlocked since, which only makes
+	// the client try again sooner.
+	var until time.Time
+	err = r.db.QueryRow(ctx, `SELECT locked_until FROM login_failures WHERE key = $1 AND locked_until IS NOT NULL`, key).Scan(&until)
+	if err != nil && err != pgx.ErrNoRows {
+		return false, time.Time{}, errors.DatabaseError("get login lockout", err)
+	}
+
+	return false, until, nil
+}
+
+// prune deletes the failures outside window whose lockout is over, at most
+// once per loginPruneInterval
+func (r *loginRepository) prune(ctx context.Context, window time.Duration) error {
+	r.mu.Lock()
+	if time.Since(r.lastPruned) < loginPruneInterval {
+		r.mu.Unlock()
+		return nil
+	}
+	r.lastPruned = time.Now()
+	r.mu.Unlock()
+
+	_, err := r.db.Exec(ctx, `
+		DELETE FROM login_failures
+		WHERE last_failed_at <= NOW() - $1::INTERVAL
+		AND (locked_until IS NULL OR locked_until <= NOW())`, window)
+	if err != nil {
+		return errors.DatabaseError("prune login failures", err)
+	}
+
+	return nil
+}
+
+func (r *loginRepository) Release(ctx context.Context, key string, lockedUntil time.Time) error {
+	query := `
+		UPDATE login_failures
+		SET failures = GREATEST(failures - 1, 0),
+			locked_until = CASE WHEN locked_until = $2 THEN NULL ELSE locked_until END
+		WHERE key = $1`
+
+	if _, err := r.db.Exec(ctx, query, key, lockedUntil); err != nil {
+		return errors.DatabaseError("release login attempt", err)
+	}
+
+	return nil
+}
+
+func (r *loginRepository) Reset(ctx context.Context, keys []string) error {
+	query := `DELETE FROM login_failures WHERE key = ANY($1)`
+
+	if _, err := r.db.Exec(ctx, query, keys); err != nil {
+		return errors.DatabaseError("reset login failures", err)
+	}
+
+	return nil
+}
+
+func (r *loginRepository) CreateEvent(ctx context.Context, event *models.LoginEvent) error {
+	query := `
+		INSERT INTO login_events (user_id, username, ip_address, outcome)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, created_at`
+
+	err := r.db.QueryRow(ctx, query, event.UserID, event.Username, event.IPAddress, event.Outcome).Scan(&event.ID, &event.CreatedAt)
+	if err != nil {
+		return errors.DatabaseError("create login event", err)
+	}
+
+	return nil
+}
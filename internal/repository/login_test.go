@@ -0,0 +1,126 @@
+package repository
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alinoer/go-std-api/internal/models"
+	"github.com/alinoer/go-std-api/internal/testutils"
+	"github.com/google/uuid"
+)
+
+func TestLoginRepository(t *testing.T) {
+	testutils.SkipIfShort(t)
+	testutils.SkipIfNoDatabase(t)
+
+	testDB := testutils.SetupTestDB(t)
+	defer testDB.Cleanup(t)
+
+	repo := NewLoginRepository(testDB.DB)
+	ctx := context.Background()
+	limit := models.LoginLimit{MaxFailures: 3, Lockout: time.Minute, MaxLockout: 5 * time.Minute, Window: time.Hour}
+
+	for want := 1; want < 3; want++ {
+		reserved, lockedUntil, err := repo.ReserveAttempt(ctx, "username:alice", limit)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !reserved || !lockedUntil.IsZero() {
+			t.Errorf("expected attempt %d to be counted without a lockout, got %v and %v", want, reserved, lockedUntil)
+		}
+	}
+
+	// The attempt that reaches the limit locks the key while it is checked
+	reserved, lockedUntil, err := repo.ReserveAttempt(ctx, "username:alice", limit)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reserved || time.Until(lockedUntil) <= 50*time.Second || time.Until(lockedUntil) > time.Minute {
+		t.Errorf("expected the third attempt to lock the key for a minute, got %v and %v", reserved, lockedUntil)
+	}
+	if again, until, _ := repo.ReserveAttempt(ctx, "username:alice", limit); again || !until.Equal(lockedUntil) {
+		t.Errorf("expected attempts to be rejected until %v, got %v and %v", lockedUntil, again, until)
+	}
+
+	// Releasing the attempt lifts the lockout it started
+	if err := repo.Release(ctx, "username:alice", lockedUntil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	reserved, lockedUntil, _ = repo.ReserveAttempt(ctx, "username:alice", limit)
+	if !reserved || lockedUntil.IsZero() {
+		t.Errorf("expected the third attempt to be counted again, got %v and %v", reserved, lockedUntil)
+	}
+
+	// Attempts after a lockout double it
+	if _, err := testDB.DB.Exec(ctx, `UPDATE login_failures SET locked_until = NOW() WHERE key = 'username:alice'`); err != nil {
+		t.Fatalf("failed to end the lockout: %v", err)
+	}
+	_, lockedUntil, _ = repo.ReserveAttempt(ctx, "username:alice", limit)
+	if time.Until(lockedUntil) <= 110*time.Second || time.Until(lockedUntil) > 2*time.Minute {
+		t.Errorf("expected a lockout of two minutes, got %v", time.Until(lockedUntil))
+	}
+
+	if err := repo.Reset(ctx, []string{"username:alice"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if reserved, lockedUntil, _ := repo.ReserveAttempt(ctx, "username:alice", limit); !reserved || !lockedUntil.IsZero() {
+		t.Errorf("expected the lockout to be lifted, got %v and %v", reserved, lockedUntil)
+	}
+
+	// Failures older than the window start the count over
+	repo.ReserveAttempt(ctx, "username:bob", limit)
+	repo.ReserveAttempt(ctx, "username:bob", limit)
+	expired := limit
+	expired.Window = 0
+	if _, lockedUntil, _ := repo.ReserveAttempt(ctx, "username:bob", expired); !lockedUntil.IsZero() {
+		t.Errorf("expected the count to start over, got a lockout until %v", lockedUntil)
+	}
+
+	// Concurrent attempts are counted one at a time
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	counted := 0
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			reserved, _, err := repo.ReserveAttempt(ctx, "ip:192.0.2.1", limit)
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+				return
+			}
+			if reserved {
+				mu.Lock()
+				counted++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	if counted != limit.MaxFailures {
+		t.Errorf("expected %d concurrent attempts to be counted, got %d", limit.MaxFailures, counted)
+	}
+
+	user := &models.User{
+		ID:           uuid.New(),
+		Username:     "alice",
+		PasswordHash: "hashedpassword",
+		CreatedAt:    time.Now(),
+	}
+	if err := NewUserRepository(testDB.DB).Create(ctx, user); err != nil {
+		t.Fatalf("failed to create user: %v", err)
+	}
+
+	event := &models.LoginEvent{UserID: &user.ID, Username: "alice", IPAddress: "192.0.2.1", Outcome: models.LoginOutcomeSuccess}
+	if err := repo.CreateEvent(ctx, event); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if event.ID == 0 || event.CreatedAt.IsZero() {
+		t.Errorf("expected the event to get an ID and time, got %+v", event)
+	}
+	if err := repo.CreateEvent(ctx, &models.LoginEvent{Username: "nobody", IPAddress: "192.0.2.1", Outcome: models.LoginOutcomeFailure}); err != nil {
+		t.Errorf("expected events without a user to be recorded, got %v", err)
+	}
+}
@@ -3,7 +3,11 @@ package repository
 import (
 	"context"
 	"fmt"
+	"html"
+	"strings"
+	"time"
 
+	"github.com/alinoer/go-std-api/internal/errors"
 	"github.com/alinoer/go-std-api/internal/models"
 
 	"github.com/google/uuid"
@@ -11,15 +15,56 @@ import (
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
+// postColumns is the select list read by scanPost
+const postColumns = `id, user_id, title, content, created_at, status, published_at, updated_at, version, deleted_at, activity_at, reaction_count`
+
 type PostRepository interface {
+	// Create stores a post. Posts without a status are stored as published.
 	Create(ctx context.Context, post *models.Post) error
+	// GetByID returns the post whatever its status; callers check that the
+	// viewer may read it. The list methods leave out other users' drafts.
+	// Posts in the trash are left out everywhere but the methods for the
+	// trash.
 	GetByID(ctx context.Context, id uuid.UUID) (*models.Post, error)
-	List(ctx context.Context) ([]*models.Post, error)
-	ListPaginated(ctx context.Context, pagination *models.PaginationParams) ([]*models.Post, int64, error)
+	List(ctx context.Context, opts *models.ListOptions) ([]*models.Post, error)
+	ListPaginated(ctx context.Context, pagination *models.PaginationParams, opts *models.ListOptions) ([]*models.Post, int64, error)
 	GetByUserID(ctx context.Context, userID uuid.UUID) ([]*models.Post, error)
 	GetByUserIDPaginated(ctx context.Context, userID uuid.UUID, pagination *models.PaginationParams) ([]*models.Post, int64, error)
+	ListByCursor(ctx context.Context, params *models.CursorParams, opts *models.ListOptions) ([]*models.Post, bool, error)
+	GetByUserIDByCursor(ctx context.Context, userID uuid.UUID, params *models.CursorParams) ([]*models.Post, bool, error)
+	Count(ctx context.Context, opts *models.ListOptions) (int64, error)
+	CountByUserID(ctx context.Context, userID uuid.UUID) (int64, error)
+	Search(ctx context.Context, query string, pagination *models.PaginationParams) ([]*models.PostSearchResult, int64, error)
+	// Update stores a new version of the post and records it as a revision
+	// edited by the viewer in ctx. If post.Version is set, the update fails
+	// with a conflict unless the stored post is still at that version.
 	Update(ctx context.Context, id uuid.UUID, post *models.Post) error
-	Delete(ctx context.Context, id uuid.UUID) error
+	// Delete moves the post to the trash. A non-zero version makes it fail
+	// with a conflict unless the post is still at that version.
+	Delete(ctx context.Context, id uuid.UUID, version int) error
+	// GetDeleted returns a post in the trash
+	GetDeleted(ctx context.Context, id uuid.UUID) (*models.Post, error)
+	// ListDeleted returns a page of the user's posts in the trash, most
+	// recently deleted first
+	ListDeleted(ctx context.Context, userID uuid.UUID, pagination *models.PaginationParams) ([]*models.Post, int64, error)
+	// Restore takes a post out of the trash
+	Restore(ctx context.Context, id uuid.UUID) error
+	// Purge permanently deletes the posts that went to the trash before the
+	// given time and returns how many it deleted
+	Purge(ctx context.Context, before time.Time) (int64, error)
+	// ListRevisions returns a page of the post's revisions, newest first
+	ListRevisions(ctx context.Context, postID uuid.UUID, pagination *models.PaginationParams) ([]*models.PostRevision, int64, error)
+	GetRevision(ctx context.Context, postID uuid.UUID, version int) (*models.PostRevision, error)
+	// PublishDue publishes the scheduled drafts whose time has come by now
+	// and returns how many it published
+	PublishDue(ctx context.Context, now time.Time) (int64, error)
+	// ListTags returns the tags carried by at least one visible post, most
+	// used first
+	ListTags(ctx context.Context) ([]*models.Tag, error)
+	// AddReaction records a reaction unless the user already left it
+	AddReaction(ctx context.Context, postID, userID uuid.UUID, kind models.ReactionKind) error
+	// RemoveReaction removes a reaction if the user left it
+	RemoveReaction(ctx context.Context, postID, userID uuid.UUID, kind models.ReactionKind) error
 }
 
 type postRepository struct {
@@ -31,13 +76,42 @@ func NewPostRepository(db *pgxpool.Pool) PostRepository {
 }
 
 func (r *postRepository) Create(ctx context.Context, post *models.Post) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return errors.DatabaseError("begin transaction", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if post.Status == "" {
+		post.Status = models.PostStatusPublished
+		if post.PublishedAt == nil {
+			publishedAt := post.CreatedAt
+			post.PublishedAt = &publishedAt
+		}
+	}
+
+	post.Version = 1
+	post.UpdatedAt = post.CreatedAt
+
 	query := `
-		INSERT INTO posts (id, user_id, title, content, created_at)
-		VALUES ($1, $2, $3, $4, $5)`
+		INSERT INTO posts (id, user_id, title, content, created_at, status, published_at, updated_at, version)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`
 
-	_, err := r.db.Exec(ctx, query, post.ID, post.UserID, post.Title, post.Content, post.CreatedAt)
+	_, err = tx.Exec(ctx, query, post.ID, post.UserID, post.Title, post.Content, post.CreatedAt, post.Status, post.PublishedAt, post.UpdatedAt, post.Version)
 	if err != nil {
-		return fmt.Errorf("failed to create post: %w", err)
+		return errors.DatabaseError("create post", err)
+	}
+
+	if err := insertPostRevision(ctx, tx, post, post.UserID); err != nil {
+		return err
+	}
+
+	if err := setPostTags(ctx, tx, post.ID, post.Tags); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return errors.DatabaseError("commit transaction", err)
 	}
 
 	return nil
@@ -45,222 +119,858 @@ func (r *postRepository) Create(ctx context.Context, post *models.Post) error {
 
 func (r *postRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Post, error) {
 	query := `
-		SELECT id, user_id, title, content, created_at
+		SELECT ` + postColumns + `
 		FROM posts
-		WHERE id = $1`
+		WHERE id = $1 AND deleted_at IS NULL`
 
 	var post models.Post
-	err := r.db.QueryRow(ctx, query, id).Scan(
-		&post.ID,
-		&post.UserID,
-		&post.Title,
-		&post.Content,
-		&post.CreatedAt,
-	)
+	err := scanPost(r.db.QueryRow(ctx, query, id), &post)
+
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, errors.NotFound("Post")
+		}
+		return nil, errors.DatabaseError("get post", err)
+	}
+
+	if err := r.loadPostDetails(ctx, []*models.Post{&post}); err != nil {
+		return nil, err
+	}
+
+	return &post, nil
+}
+
+func (r *postRepository) List(ctx context.Context, opts *models.ListOptions) ([]*models.Post, error) {
+	q, err := newPostListQuery(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM posts
+		%s
+		ORDER BY %s`, postColumns, q.whereClause(), q.orderBy)
+
+	return r.queryPosts(ctx, query, q.args...)
+}
+
+func (r *postRepository) GetByUserID(ctx context.Context, userID uuid.UUID) ([]*models.Post, error) {
+	q := newUserPostsQuery(ctx, userID)
+
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM posts
+		%s
+		ORDER BY created_at DESC`, postColumns, q.whereClause())
+
+	return r.queryPosts(ctx, query, q.args...)
+}
+
+func (r *postRepository) Update(ctx context.Context, id uuid.UUID, post *models.Post) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return errors.DatabaseError("begin transaction", err)
+	}
+	defer tx.Rollback(ctx)
+
+	// Version 0 skips the check for callers that do not track versions
+	query := `
+		UPDATE posts
+		SET title = $1, content = $2, status = $3, published_at = $4,
+			updated_at = NOW(), version = version + 1
+		WHERE id = $5 AND deleted_at IS NULL AND ($6 = 0 OR version = $6)
+		RETURNING version, updated_at`
 
+	err = tx.QueryRow(ctx, query, post.Title, post.Content, post.Status, post.PublishedAt, id, post.Version).
+		Scan(&post.Version, &post.UpdatedAt)
 	if err != nil {
+		if err != pgx.ErrNoRows {
+			return errors.DatabaseError("update post", err)
+		}
+		var exists bool
+		if err := tx.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM posts WHERE id = $1 AND deleted_at IS NULL)`, id).Scan(&exists); err != nil {
+			return errors.DatabaseError("update post", err)
+		}
+		if !exists {
+			return errors.NotFound("Post")
+		}
+		return errors.StaleVersion("Post", post.Version)
+	}
+
+	editorID, ok := models.ViewerFromContext(ctx)
+	if !ok {
+		editorID = post.UserID
+	}
+	if err := insertPostRevision(ctx, tx, post, editorID); err != nil {
+		return err
+	}
+
+	if err := setPostTags(ctx, tx, id, post.Tags); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return errors.DatabaseError("commit transaction", err)
+	}
+
+	return nil
+}
+
+func (r *postRepository) Delete(ctx context.Context, id uuid.UUID, version int) error {
+	query := `
+		UPDATE posts
+		SET deleted_at = NOW()
+		WHERE id = $1 AND deleted_at IS NULL AND ($2 = 0 OR version = $2)`
+
+	result, err := r.db.Exec(ctx, query, id, version)
+	if err != nil {
+		return errors.DatabaseError("delete post", err)
+	}
+
+	rowsAffected := result.RowsAffected()
+	if rowsAffected == 0 {
+		if version == 0 {
+			return errors.NotFound("Post")
+		}
+		var exists bool
+		if err := r.db.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM posts WHERE id = $1 AND deleted_at IS NULL)`, id).Scan(&exists); err != nil {
+			return errors.DatabaseError("delete post", err)
+		}
+		if !exists {
+			return errors.NotFound("Post")
+		}
+		return errors.StaleVersion("Post", version)
+	}
+
+	return nil
+}
+
+func (r *postRepository) GetDeleted(ctx context.Context, id uuid.UUID) (*models.Post, error) {
+	query := `
+		SELECT ` + postColumns + `
+		FROM posts
+		WHERE id = $1 AND deleted_at IS NOT NULL`
+
+	var post models.Post
+	if err := scanPost(r.db.QueryRow(ctx, query, id), &post); err != nil {
 		if err == pgx.ErrNoRows {
-			return nil, fmt.Errorf("post not found")
+			return nil, errors.NotFound("Post")
 		}
-		return nil, fmt.Errorf("failed to get post: %w", err)
+		return nil, errors.DatabaseError("get deleted post", err)
+	}
+
+	if err := r.loadPostDetails(ctx, []*models.Post{&post}); err != nil {
+		return nil, err
 	}
 
 	return &post, nil
 }
 
-func (r *postRepository) List(ctx context.Context) ([]*models.Post, error) {
+func (r *postRepository) ListDeleted(ctx context.Context, userID uuid.UUID, pagination *models.PaginationParams) ([]*models.Post, int64, error) {
+	var total int64
+	err := r.db.QueryRow(ctx, `SELECT COUNT(*) FROM posts WHERE user_id = $1 AND deleted_at IS NOT NULL`, userID).Scan(&total)
+	if err != nil {
+		return nil, 0, errors.DatabaseError("count deleted posts", err)
+	}
+
 	query := `
-		SELECT id, user_id, title, content, created_at
+		SELECT ` + postColumns + `
+		FROM posts
+		WHERE user_id = $1 AND deleted_at IS NOT NULL
+		ORDER BY deleted_at DESC, id DESC
+		LIMIT $2 OFFSET $3`
+
+	posts, err := r.queryPosts(ctx, query, userID, pagination.PageSize, pagination.Offset)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return posts, total, nil
+}
+
+func (r *postRepository) Restore(ctx context.Context, id uuid.UUID) error {
+	query := `UPDATE posts SET deleted_at = NULL WHERE id = $1 AND deleted_at IS NOT NULL`
+
+	result, err := r.db.Exec(ctx, query, id)
+	if err != nil {
+		return errors.DatabaseError("restore post", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return errors.NotFound("Post")
+	}
+
+	return nil
+}
+
+func (r *postRepository) Purge(ctx context.Context, before time.Time) (int64, error) {
+	result, err := r.db.Exec(ctx, `DELETE FROM posts WHERE deleted_at < $1`, before)
+	if err != nil {
+		return 0, errors.DatabaseError("purge deleted posts", err)
+	}
+
+	return result.RowsAffected(), nil
+}
+
+func (r *postRepository) ListPaginated(ctx context.Context, pagination *models.PaginationParams, opts *models.ListOptions) ([]*models.Post, int64, error) {
+	// First, get the total count
+	total, err := r.Count(ctx, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// Then get the paginated results
+	q, err := newPostListQuery(ctx, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM posts
+		%s
+		ORDER BY %s
+		LIMIT %s OFFSET %s`, postColumns, q.whereClause(), q.orderBy, q.arg(pagination.PageSize), q.arg(pagination.Offset))
+
+	posts, err := r.queryPosts(ctx, query, q.args...)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return posts, total, nil
+}
+
+func (r *postRepository) GetByUserIDPaginated(ctx context.Context, userID uuid.UUID, pagination *models.PaginationParams) ([]*models.Post, int64, error) {
+	// First, get the total count for this user
+	total, err := r.CountByUserID(ctx, userID)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// Then get the paginated results
+	q := newUserPostsQuery(ctx, userID)
+	query := fmt.Sprintf(`
+		SELECT %s
 		FROM posts
-		ORDER BY created_at DESC`
+		%s
+		ORDER BY created_at DESC
+		LIMIT %s OFFSET %s`, postColumns, q.whereClause(), q.arg(pagination.PageSize), q.arg(pagination.Offset))
+
+	posts, err := r.queryPosts(ctx, query, q.args...)
+	if err != nil {
+		return nil, 0, err
+	}
 
-	rows, err := r.db.Query(ctx, query)
+	return posts, total, nil
+}
+
+// ListByCursor returns the page of posts selected by params, newest first,
+// and whether more posts follow in the requested direction
+func (r *postRepository) ListByCursor(ctx context.Context, params *models.CursorParams, opts *models.ListOptions) ([]*models.Post, bool, error) {
+	q, err := newPostListQuery(ctx, opts)
 	if err != nil {
-		return nil, fmt.Errorf("failed to list posts: %w", err)
+		return nil, false, err
+	}
+	q.keyset(params)
+
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM posts
+		%s
+		ORDER BY %s
+		LIMIT %s`, postColumns, q.whereClause(), q.orderBy, q.arg(params.Limit+1))
+
+	posts, err := r.queryPosts(ctx, query, q.args...)
+	if err != nil {
+		return nil, false, err
+	}
+
+	posts, hasMore := trimKeysetPage(posts, params)
+	return posts, hasMore, nil
+}
+
+// GetByUserIDByCursor returns the page of a user's posts selected by params,
+// newest first, and whether more posts follow in the requested direction
+func (r *postRepository) GetByUserIDByCursor(ctx context.Context, userID uuid.UUID, params *models.CursorParams) ([]*models.Post, bool, error) {
+	q := newUserPostsQuery(ctx, userID)
+	q.keyset(params)
+
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM posts
+		%s
+		ORDER BY %s
+		LIMIT %s`, postColumns, q.whereClause(), q.orderBy, q.arg(params.Limit+1))
+
+	posts, err := r.queryPosts(ctx, query, q.args...)
+	if err != nil {
+		return nil, false, err
+	}
+
+	posts, hasMore := trimKeysetPage(posts, params)
+	return posts, hasMore, nil
+}
+
+func (r *postRepository) Count(ctx context.Context, opts *models.ListOptions) (int64, error) {
+	q, err := newPostListQuery(ctx, opts)
+	if err != nil {
+		return 0, err
+	}
+
+	var total int64
+	if err := r.db.QueryRow(ctx, `SELECT COUNT(*) FROM posts `+q.whereClause(), q.args...).Scan(&total); err != nil {
+		return 0, errors.DatabaseError("count posts", err)
+	}
+	return total, nil
+}
+
+func (r *postRepository) CountByUserID(ctx context.Context, userID uuid.UUID) (int64, error) {
+	q := newUserPostsQuery(ctx, userID)
+
+	var total int64
+	if err := r.db.QueryRow(ctx, `SELECT COUNT(*) FROM posts `+q.whereClause(), q.args...).Scan(&total); err != nil {
+		return 0, errors.DatabaseError("count posts for user", err)
+	}
+	return total, nil
+}
+
+// Search finds posts matching a web-style search query, best matches first.
+// Title matches rank above content matches.
+func (r *postRepository) Search(ctx context.Context, query string, pagination *models.PaginationParams) ([]*models.PostSearchResult, int64, error) {
+	q := newListQuery()
+	tsquery := "websearch_to_tsquery('english', " + q.arg(query) + ")"
+	q.where("search_vector @@ " + tsquery)
+	visible := visiblePostsCondition(ctx, q)
+	q.where(visible)
+
+	var total int64
+	if err := r.db.QueryRow(ctx, `SELECT COUNT(*) FROM posts `+q.whereClause(), q.args...).Scan(&total); err != nil {
+		return nil, 0, errors.DatabaseError("count search results", err)
+	}
+
+	searchQuery := `
+		SELECT ` + postColumns + `,
+			ts_rank(search_vector, q) AS rank,
+			ts_headline('english', translate(title, ` + q.arg(headlineMarkers) + `, ''), q, ` + q.arg(headlineOptions+", HighlightAll=true") + `),
+			ts_headline('english', translate(content, ` + q.arg(headlineMarkers) + `, ''), q, ` + q.arg(headlineOptions+", MaxFragments=2, MaxWords=30, MinWords=10") + `)
+		FROM posts, ` + tsquery + ` AS q
+		WHERE search_vector @@ q AND ` + visible + `
+		ORDER BY rank DESC, created_at DESC, id DESC
+		LIMIT ` + q.arg(pagination.PageSize) + ` OFFSET ` + q.arg(pagination.Offset)
+
+	rows, err := r.db.Query(ctx, searchQuery, q.args...)
+	if err != nil {
+		return nil, 0, errors.DatabaseError("search posts", err)
 	}
 	defer rows.Close()
 
-	var posts []*models.Post
+	var results []*models.PostSearchResult
 	for rows.Next() {
-		var post models.Post
+		var result models.PostSearchResult
 		err := rows.Scan(
-			&post.ID,
-			&post.UserID,
-			&post.Title,
-			&post.Content,
-			&post.CreatedAt,
+			&result.ID,
+			&result.UserID,
+			&result.Title,
+			&result.Content,
+			&result.CreatedAt,
+			&result.Status,
+			&result.PublishedAt,
+			&result.UpdatedAt,
+			&result.Version,
+			&result.DeletedAt,
+			&result.ActivityAt,
+			&result.ReactionCount,
+			&result.Rank,
+			&result.TitleHeadline,
+			&result.ContentHeadline,
 		)
 		if err != nil {
-			return nil, fmt.Errorf("failed to scan post: %w", err)
+			return nil, 0, errors.DatabaseError("scan search result", err)
 		}
-		posts = append(posts, &post)
+		result.TitleHeadline = markHeadline(result.TitleHeadline)
+		result.ContentHeadline = markHeadline(result.ContentHeadline)
+		results = append(results, &result)
 	}
 
 	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("error iterating posts: %w", err)
+		return nil, 0, errors.DatabaseError("iterate search results", err)
 	}
 
-	return posts, nil
+	posts := make([]*models.Post, len(results))
+	for i, result := range results {
+		posts[i] = &result.Post
+	}
+	if err := r.loadPostDetails(ctx, posts); err != nil {
+		return nil, 0, err
+	}
+
+	return results, total, nil
 }
 
-func (r *postRepository) GetByUserID(ctx context.Context, userID uuid.UUID) ([]*models.Post, error) {
-	query := `
-		SELECT id, user_id, title, content, created_at
-		FROM posts
-		WHERE user_id = $1
-		ORDER BY created_at DESC`
+// ts_headline marks matches with control characters, which are removed from
+// the text beforehand, so that the text can be HTML escaped before the
+// markers become <mark> tags
+const (
+	headlineStart   = "\x01"
+	headlineStop    = "\x02"
+	headlineMarkers = headlineStart + headlineStop
+	headlineOptions = `StartSel="` + headlineStart + `", StopSel="` + headlineStop + `"`
+)
+
+var headlineReplacer = strings.NewReplacer(headlineStart, "<mark>", headlineStop, "</mark>")
+
+// markHeadline HTML escapes a headline from ts_headline and wraps its matches
+// in <mark> tags
+func markHeadline(headline string) string {
+	return headlineReplacer.Replace(html.EscapeString(headline))
+}
 
-	rows, err := r.db.Query(ctx, query, userID)
+func (r *postRepository) queryPosts(ctx context.Context, query string, args ...interface{}) ([]*models.Post, error) {
+	rows, err := r.db.Query(ctx, query, args...)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get posts by user ID: %w", err)
+		return nil, errors.DatabaseError("list posts", err)
 	}
 	defer rows.Close()
 
 	var posts []*models.Post
 	for rows.Next() {
 		var post models.Post
-		err := rows.Scan(
-			&post.ID,
-			&post.UserID,
-			&post.Title,
-			&post.Content,
-			&post.CreatedAt,
-		)
-		if err != nil {
-			return nil, fmt.Errorf("failed to scan post: %w", err)
+		if err := scanPost(rows, &post); err != nil {
+			return nil, errors.DatabaseError("scan post", err)
 		}
 		posts = append(posts, &post)
 	}
 
 	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("error iterating posts: %w", err)
+		return nil, errors.DatabaseError("iterate posts", err)
+	}
+
+	if err := r.loadPostDetails(ctx, posts); err != nil {
+		return nil, err
 	}
 
 	return posts, nil
 }
 
-func (r *postRepository) Update(ctx context.Context, id uuid.UUID, post *models.Post) error {
-	query := `
-		UPDATE posts
-		SET title = $1, content = $2
-		WHERE id = $3`
+func scanPost(row pgx.Row, post *models.Post) error {
+	return row.Scan(
+		&post.ID,
+		&post.UserID,
+		&post.Title,
+		&post.Content,
+		&post.CreatedAt,
+		&post.Status,
+		&post.PublishedAt,
+		&post.UpdatedAt,
+		&post.Version,
+		&post.DeletedAt,
+		&post.ActivityAt,
+		&post.ReactionCount,
+	)
+}
 
-	result, err := r.db.Exec(ctx, query, post.Title, post.Content, id)
+func (r *postRepository) AddReaction(ctx context.Context, postID, userID uuid.UUID, kind models.ReactionKind) error {
+	tx, err := r.db.Begin(ctx)
 	if err != nil {
-		return fmt.Errorf("failed to update post: %w", err)
+		return errors.DatabaseError("begin transaction", err)
 	}
+	defer tx.Rollback(ctx)
 
-	rowsAffected := result.RowsAffected()
-	if rowsAffected == 0 {
-		return fmt.Errorf("post not found")
+	result, err := tx.Exec(ctx, `
+		INSERT INTO post_reactions (post_id, user_id, kind)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (post_id, user_id, kind) DO NOTHING`, postID, userID, kind)
+	if err != nil {
+		return errors.DatabaseError("add reaction", err)
+	}
+
+	if result.RowsAffected() > 0 {
+		if _, err := tx.Exec(ctx, `UPDATE posts SET reaction_count = reaction_count + 1, activity_at = NOW() WHERE id = $1`, postID); err != nil {
+			return errors.DatabaseError("count reaction", err)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return errors.DatabaseError("commit transaction", err)
 	}
 
 	return nil
 }
 
-func (r *postRepository) Delete(ctx context.Context, id uuid.UUID) error {
-	query := `DELETE FROM posts WHERE id = $1`
+func (r *postRepository) RemoveReaction(ctx context.Context, postID, userID uuid.UUID, kind models.ReactionKind) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return errors.DatabaseError("begin transaction", err)
+	}
+	defer tx.Rollback(ctx)
 
-	result, err := r.db.Exec(ctx, query, id)
+	result, err := tx.Exec(ctx, `
+		DELETE FROM post_reactions
+		WHERE post_id = $1 AND user_id = $2 AND kind = $3`, postID, userID, kind)
 	if err != nil {
-		return fmt.Errorf("failed to delete post: %w", err)
+		return errors.DatabaseError("remove reaction", err)
 	}
 
-	rowsAffected := result.RowsAffected()
-	if rowsAffected == 0 {
-		return fmt.Errorf("post not found")
+	if result.RowsAffected() > 0 {
+		if _, err := tx.Exec(ctx, `UPDATE posts SET reaction_count = reaction_count - 1, activity_at = NOW() WHERE id = $1`, postID); err != nil {
+			return errors.DatabaseError("count reaction", err)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return errors.DatabaseError("commit transaction", err)
 	}
 
 	return nil
 }
 
-func (r *postRepository) ListPaginated(ctx context.Context, pagination *models.PaginationParams) ([]*models.Post, int64, error) {
-	// First, get the total count
-	countQuery := `SELECT COUNT(*) FROM posts`
-	var total int64
-	err := r.db.QueryRow(ctx, countQuery).Scan(&total)
+// insertPostRevision records the post's current version
+func insertPostRevision(ctx context.Context, tx pgx.Tx, post *models.Post, editorID uuid.UUID) error {
+	_, err := tx.Exec(ctx, `
+		INSERT INTO post_revisions (post_id, version, editor_id, title, content, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)`,
+		post.ID, post.Version, editorID, post.Title, post.Content, post.UpdatedAt)
 	if err != nil {
-		return nil, 0, fmt.Errorf("failed to count posts: %w", err)
+		return errors.DatabaseError("create post revision", err)
+	}
+	return nil
+}
+
+func (r *postRepository) ListRevisions(ctx context.Context, postID uuid.UUID, pagination *models.PaginationParams) ([]*models.PostRevision, int64, error) {
+	var total int64
+	if err := r.db.QueryRow(ctx, `SELECT COUNT(*) FROM post_revisions WHERE post_id = $1`, postID).Scan(&total); err != nil {
+		return nil, 0, errors.DatabaseError("count post revisions", err)
 	}
 
-	// Then get the paginated results
 	query := `
-		SELECT id, user_id, title, content, created_at
-		FROM posts
-		ORDER BY created_at DESC
-		LIMIT $1 OFFSET $2`
+		SELECT post_id, version, editor_id, title, content, created_at
+		FROM post_revisions
+		WHERE post_id = $1
+		ORDER BY version DESC
+		LIMIT $2 OFFSET $3`
 
-	rows, err := r.db.Query(ctx, query, pagination.PageSize, pagination.Offset)
+	rows, err := r.db.Query(ctx, query, postID, pagination.PageSize, pagination.Offset)
 	if err != nil {
-		return nil, 0, fmt.Errorf("failed to list posts with pagination: %w", err)
+		return nil, 0, errors.DatabaseError("list post revisions", err)
 	}
 	defer rows.Close()
 
-	var posts []*models.Post
+	revisions := []*models.PostRevision{}
 	for rows.Next() {
-		var post models.Post
-		err := rows.Scan(
-			&post.ID,
-			&post.UserID,
-			&post.Title,
-			&post.Content,
-			&post.CreatedAt,
+		var revision models.PostRevision
+		if err := scanPostRevision(rows, &revision); err != nil {
+			return nil, 0, errors.DatabaseError("scan post revision", err)
+		}
+		revisions = append(revisions, &revision)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, 0, errors.DatabaseError("iterate post revisions", err)
+	}
+
+	return revisions, total, nil
+}
+
+func (r *postRepository) GetRevision(ctx context.Context, postID uuid.UUID, version int) (*models.PostRevision, error) {
+	query := `
+		SELECT post_id, version, editor_id, title, content, created_at
+		FROM post_revisions
+		WHERE post_id = $1 AND version = $2`
+
+	var revision models.PostRevision
+	if err := scanPostRevision(r.db.QueryRow(ctx, query, postID, version), &revision); err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, errors.NotFound("Revision")
+		}
+		return nil, errors.DatabaseError("get post revision", err)
+	}
+
+	return &revision, nil
+}
+
+func scanPostRevision(row pgx.Row, revision *models.PostRevision) error {
+	return row.Scan(
+		&revision.PostID,
+		&revision.Version,
+		&revision.EditorID,
+		&revision.Title,
+		&revision.Content,
+		&revision.CreatedAt,
+	)
+}
+
+// newPostListQuery starts a post list query with the filters of opts,
+// limited to the posts the viewer in ctx may read
+func newPostListQuery(ctx context.Context, opts *models.ListOptions) (*listQuery, error) {
+	q := newListQuery()
+	q.where(visiblePostsCondition(ctx, q))
+	if err := q.apply(opts, postListColumns); err != nil {
+		return nil, err
+	}
+
+	if opts != nil && len(opts.Tags) > 0 {
+		q.where(fmt.Sprintf(`id IN (
+			SELECT post_tags.post_id
+			FROM post_tags JOIN tags ON tags.id = post_tags.tag_id
+			WHERE tags.name = ANY(%s)
+			GROUP BY post_tags.post_id
+			HAVING COUNT(*) = %s)`, q.arg(opts.Tags), q.arg(len(opts.Tags))))
+	}
+
+	return q, nil
+}
+
+// newUserPostsQuery starts a query for the posts of userID the viewer in ctx
+// may read
+func newUserPostsQuery(ctx context.Context, userID uuid.UUID) *listQuery {
+	q := newListQuery()
+	q.where("user_id = " + q.arg(userID))
+	q.where(visiblePostsCondition(ctx, q))
+	return q
+}
+
+// visiblePostsCondition leaves out posts in the trash and the drafts of
+// everyone but the viewer recorded in ctx. Anonymous requests see no drafts;
+// matching them against the zero user ID would reveal those of
+// DeletedUserID.
+func visiblePostsCondition(ctx context.Context, q *listQuery) string {
+	viewerID, ok := models.ViewerFromContext(ctx)
+	if !ok {
+		return fmt.Sprintf("deleted_at IS NULL AND status <> '%s'", models.PostStatusDraft)
+	}
+	return fmt.Sprintf("deleted_at IS NULL AND (status <> '%s' OR user_id = %s)", models.PostStatusDraft, q.arg(viewerID))
+}
+
+// Publishing makes a new version of each post, recorded as a revision
+// without an editor
+func (r *postRepository) PublishDue(ctx context.Context, now time.Time) (int64, error) {
+	query := `
+		WITH published AS (
+			UPDATE posts
+			SET status = $1, updated_at = NOW(), version = version + 1
+			WHERE status = $2 AND published_at <= $3 AND deleted_at IS NULL
+			RETURNING id, version, title, content, updated_at
 		)
-		if err != nil {
-			return nil, 0, fmt.Errorf("failed to scan post: %w", err)
+		INSERT INTO post_revisions (post_id, version, title, content, created_at)
+		SELECT id, version, title, content, updated_at FROM published`
+
+	result, err := r.db.Exec(ctx, query, models.PostStatusPublished, models.PostStatusDraft, now)
+	if err != nil {
+		return 0, errors.DatabaseError("publish scheduled posts", err)
+	}
+
+	return result.RowsAffected(), nil
+}
+
+// setPostTags replaces the tags of a post, creating tags that do not exist yet
+func setPostTags(ctx context.Context, tx pgx.Tx, postID uuid.UUID, tags []string) error {
+	if _, err := tx.Exec(ctx, `DELETE FROM post_tags WHERE post_id = $1`, postID); err != nil {
+		return errors.DatabaseError("clear post tags", err)
+	}
+
+	if len(tags) == 0 {
+		return nil
+	}
+
+	_, err := tx.Exec(ctx, `
+		INSERT INTO tags (name)
+		SELECT unnest($1::text[])
+		ON CONFLICT (name) DO NOTHING`, tags)
+	if err != nil {
+		return errors.DatabaseError("create tags", err)
+	}
+
+	_, err = tx.Exec(ctx, `
+		INSERT INTO post_tags (post_id, tag_id)
+		SELECT $1, id FROM tags WHERE name = ANY($2)`, postID, tags)
+	if err != nil {
+		return errors.DatabaseError("tag post", err)
+	}
+
+	return nil
+}
+
+func (r *postRepository) ListTags(ctx context.Context) ([]*models.Tag, error) {
+	query := `
+		SELECT tags.name, COUNT(*) AS post_count
+		FROM tags
+		JOIN post_tags ON post_tags.tag_id = tags.id
+		JOIN posts ON posts.id = post_tags.post_id
+		WHERE posts.status <> $1 AND posts.deleted_at IS NULL
+		GROUP BY tags.name
+		ORDER BY post_count DESC, tags.name ASC`
+
+	rows, err := r.db.Query(ctx, query, models.PostStatusDraft)
+	if err != nil {
+		return nil, errors.DatabaseError("list tags", err)
+	}
+	defer rows.Close()
+
+	tags := []*models.Tag{}
+	for rows.Next() {
+		var tag models.Tag
+		if err := rows.Scan(&tag.Name, &tag.PostCount); err != nil {
+			return nil, errors.DatabaseError("scan tag", err)
 		}
-		posts = append(posts, &post)
+		tags = append(tags, &tag)
 	}
 
 	if err := rows.Err(); err != nil {
-		return nil, 0, fmt.Errorf("error iterating posts: %w", err)
+		return nil, errors.DatabaseError("iterate tags", err)
 	}
 
-	return posts, total, nil
+	return tags, nil
 }
 
-func (r *postRepository) GetByUserIDPaginated(ctx context.Context, userID uuid.UUID, pagination *models.PaginationParams) ([]*models.Post, int64, error) {
-	// First, get the total count for this user
-	countQuery := `SELECT COUNT(*) FROM posts WHERE user_id = $1`
-	var total int64
-	err := r.db.QueryRow(ctx, countQuery, userID).Scan(&total)
+// loadPostDetails fills in the reactions, comment counts and tags of posts
+// with one query each
+func (r *postRepository) loadPostDetails(ctx context.Context, posts []*models.Post) error {
+	if err := r.loadReactions(ctx, posts); err != nil {
+		return err
+	}
+	if err := r.loadCommentCounts(ctx, posts); err != nil {
+		return err
+	}
+	return r.loadTags(ctx, posts)
+}
+
+func (r *postRepository) loadCommentCounts(ctx context.Context, posts []*models.Post) error {
+	if len(posts) == 0 {
+		return nil
+	}
+
+	byID := make(map[uuid.UUID]*models.Post, len(posts))
+	ids := make([]uuid.UUID, 0, len(posts))
+	for _, post := range posts {
+		post.CommentCount = 0
+		byID[post.ID] = post
+		ids = append(ids, post.ID)
+	}
+
+	query := `
+		SELECT post_id, COUNT(*)
+		FROM comments
+		WHERE post_id = ANY($1)
+		GROUP BY post_id`
+
+	rows, err := r.db.Query(ctx, query, ids)
 	if err != nil {
-		return nil, 0, fmt.Errorf("failed to count posts for user: %w", err)
+		return errors.DatabaseError("load comment counts", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var postID uuid.UUID
+		var count int64
+		if err := rows.Scan(&postID, &count); err != nil {
+			return errors.DatabaseError("scan comment count", err)
+		}
+		byID[postID].CommentCount = count
+	}
+
+	if err := rows.Err(); err != nil {
+		return errors.DatabaseError("iterate comment counts", err)
+	}
+
+	return nil
+}
+
+func (r *postRepository) loadTags(ctx context.Context, posts []*models.Post) error {
+	if len(posts) == 0 {
+		return nil
+	}
+
+	byID := make(map[uuid.UUID]*models.Post, len(posts))
+	ids := make([]uuid.UUID, 0, len(posts))
+	for _, post := range posts {
+		post.Tags = []string{}
+		byID[post.ID] = post
+		ids = append(ids, post.ID)
 	}
 
-	// Then get the paginated results
 	query := `
-		SELECT id, user_id, title, content, created_at
-		FROM posts
-		WHERE user_id = $1
-		ORDER BY created_at DESC
-		LIMIT $2 OFFSET $3`
+		SELECT post_tags.post_id, tags.name
+		FROM post_tags JOIN tags ON tags.id = post_tags.tag_id
+		WHERE post_tags.post_id = ANY($1)
+		ORDER BY tags.name`
 
-	rows, err := r.db.Query(ctx, query, userID, pagination.PageSize, pagination.Offset)
+	rows, err := r.db.Query(ctx, query, ids)
 	if err != nil {
-		return nil, 0, fmt.Errorf("failed to get posts by user ID with pagination: %w", err)
+		return errors.DatabaseError("load tags", err)
 	}
 	defer rows.Close()
 
-	var posts []*models.Post
 	for rows.Next() {
-		var post models.Post
-		err := rows.Scan(
-			&post.ID,
-			&post.UserID,
-			&post.Title,
-			&post.Content,
-			&post.CreatedAt,
+		var postID uuid.UUID
+		var name string
+		if err := rows.Scan(&postID, &name); err != nil {
+			return errors.DatabaseError("scan tag", err)
+		}
+		byID[postID].Tags = append(byID[postID].Tags, name)
+	}
+
+	if err := rows.Err(); err != nil {
+		return errors.DatabaseError("iterate tags", err)
+	}
+
+	return nil
+}
+
+// loadReactions fills in the reaction counts by kind of every post, and the
+// reactions of the viewer recorded in ctx, with a single query
+func (r *postRepository) loadReactions(ctx context.Context, posts []*models.Post) error {
+	if len(posts) == 0 {
+		return nil
+	}
+
+	byID := make(map[uuid.UUID]*models.Post, len(posts))
+	ids := make([]uuid.UUID, 0, len(posts))
+	for _, post := range posts {
+		post.Reactions = make(map[models.ReactionKind]int64)
+		post.MyReactions = []models.ReactionKind{}
+		byID[post.ID] = post
+		ids = append(ids, post.ID)
+	}
+
+	var viewerID *uuid.UUID
+	if id, ok := models.ViewerFromContext(ctx); ok {
+		viewerID = &id
+	}
+
+	query := `
+		SELECT post_id, kind, COUNT(*), COALESCE(bool_or(user_id = $2), false)
+		FROM post_reactions
+		WHERE post_id = ANY($1)
+		GROUP BY post_id, kind
+		ORDER BY post_id, kind`
+
+	rows, err := r.db.Query(ctx, query, ids, viewerID)
+	if err != nil {
+		return errors.DatabaseError("load reactions", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			postID uuid.UUID
+			kind   models.ReactionKind
+			count  int64
+			mine   bool
 		)
-		if err != nil {
-			return nil, 0, fmt.Errorf("failed to scan post: %w", err)
+		if err := rows.Scan(&postID, &kind, &count, &mine); err != nil {
+			return errors.DatabaseError("scan reaction", err)
+		}
+		post := byID[postID]
+		post.Reactions[kind] = count
+		if mine {
+			post.MyReactions = append(post.MyReactions, kind)
 		}
-		posts = append(posts, &post)
 	}
 
 	if err := rows.Err(); err != nil {
-		return nil, 0, fmt.Errorf("error iterating posts: %w", err)
+		return errors.DatabaseError("iterate reactions", err)
 	}
 
-	return posts, total, nil
-}
\ No newline at end of file
+	return nil
+}
@@ -3,9 +3,11 @@ package repository
 import (
 	"context"
 	"fmt"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/alinoer/go-std-api/internal/errors"
 	"github.com/alinoer/go-std-api/internal/models"
 	"github.com/alinoer/go-std-api/internal/testutils"
 	"github.com/google/uuid"
@@ -343,7 +345,7 @@ func TestPostRepository_Delete(t *testing.T) {
 				postID = uuid.New()
 			}
 
-			err := postRepo.Delete(context.Background(), postID)
+			err := postRepo.Delete(context.Background(), postID, 0)
 
 			if tt.expectedError {
 				if err == nil {
@@ -549,7 +551,7 @@ func TestPostRepository_ListPaginated(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			posts, total, err := postRepo.ListPaginated(context.Background(), tt.pagination)
+			posts, total, err := postRepo.ListPaginated(context.Background(), tt.pagination, nil)
 			if err != nil {
 				t.Fatalf("failed to list posts paginated: %v", err)
 			}
@@ -573,6 +575,211 @@ func TestPostRepository_ListPaginated(t *testing.T) {
 	}
 }
 
+func TestPostRepository_ListByCursor(t *testing.T) {
+	testutils.SkipIfShort(t)
+	testutils.SkipIfNoDatabase(t)
+
+	testDB := testutils.SetupTestDB(t)
+	defer testDB.Cleanup(t)
+
+	userRepo := NewUserRepository(testDB.DB)
+	postRepo := NewPostRepository(testDB.DB)
+
+	testUser := &models.User{
+		ID:           uuid.New(),
+		Username:     "testuser",
+		PasswordHash: "hashedpassword",
+		CreatedAt:    time.Now(),
+	}
+	if err := userRepo.Create(context.Background(), testUser); err != nil {
+		t.Fatalf("failed to create test user: %v", err)
+	}
+
+	// Pairs of posts share a timestamp so the id tiebreaker is exercised
+	numPosts := 15
+	base := time.Now().Truncate(time.Second)
+	for i := 0; i < numPosts; i++ {
+		post := &models.Post{
+			ID:        uuid.New(),
+			UserID:    testUser.ID,
+			Title:     fmt.Sprintf("Post %d", i+1),
+			Content:   fmt.Sprintf("Content %d", i+1),
+			CreatedAt: base.Add(time.Duration(i/2) * time.Second),
+		}
+		if err := postRepo.Create(context.Background(), post); err != nil {
+			t.Fatalf("failed to create post %d: %v", i+1, err)
+		}
+	}
+
+	// Walk forward through every page
+	seen := make(map[uuid.UUID]bool)
+	var pages [][]*models.Post
+	params := models.NewCursorParams(nil, 4, false)
+	for {
+		posts, hasMore, err := postRepo.ListByCursor(context.Background(), params, nil)
+		if err != nil {
+			t.Fatalf("failed to list posts by cursor: %v", err)
+		}
+		for _, post := range posts {
+			if seen[post.ID] {
+				t.Fatalf("post %s returned twice", post.ID)
+			}
+			seen[post.ID] = true
+		}
+		pages = append(pages, posts)
+		if !hasMore {
+			break
+		}
+		last := posts[len(posts)-1]
+		params = models.NewCursorParams(&models.Cursor{CreatedAt: last.CreatedAt, ID: last.ID, Direction: models.CursorNext}, 4, false)
+	}
+
+	if len(seen) != numPosts {
+		t.Errorf("expected %d posts across all pages, got %d", numPosts, len(seen))
+	}
+	if len(pages) != 4 {
+		t.Errorf("expected 4 pages, got %d", len(pages))
+	}
+
+	// Going back from the last page returns the page before it, newest first
+	first := pages[len(pages)-1][0]
+	params = models.NewCursorParams(&models.Cursor{CreatedAt: first.CreatedAt, ID: first.ID, Direction: models.CursorPrevious}, 4, false)
+	posts, hasMore, err := postRepo.ListByCursor(context.Background(), params, nil)
+	if err != nil {
+		t.Fatalf("failed to list previous page: %v", err)
+	}
+	if !hasMore {
+		t.Error("expected more posts before the previous page")
+	}
+	expected := pages[len(pages)-2]
+	if len(posts) != len(expected) {
+		t.Fatalf("expected %d posts, got %d", len(expected), len(posts))
+	}
+	for i := range posts {
+		if posts[i].ID != expected[i].ID {
+			t.Errorf("expected post %d to be %s, got %s", i, expected[i].ID, posts[i].ID)
+		}
+	}
+
+	total, err := postRepo.Count(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("failed to count posts: %v", err)
+	}
+	if total != int64(numPosts) {
+		t.Errorf("expected total %d, got %d", numPosts, total)
+	}
+}
+
+func TestPostRepository_Search(t *testing.T) {
+	testutils.SkipIfShort(t)
+	testutils.SkipIfNoDatabase(t)
+
+	testDB := testutils.SetupTestDB(t)
+	defer testDB.Cleanup(t)
+
+	userRepo := NewUserRepository(testDB.DB)
+	postRepo := NewPostRepository(testDB.DB)
+
+	testUser := &models.User{
+		ID:           uuid.New(),
+		Username:     "testuser",
+		PasswordHash: "hashedpassword",
+		CreatedAt:    time.Now(),
+	}
+	if err := userRepo.Create(context.Background(), testUser); err != nil {
+		t.Fatalf("failed to create test user: %v", err)
+	}
+
+	titleMatch := &models.Post{ID: uuid.New(), UserID: testUser.ID, Title: "Postgres indexing", Content: "Notes on B-trees", CreatedAt: time.Now()}
+	contentMatch := &models.Post{ID: uuid.New(), UserID: testUser.ID, Title: "Weekend notes", Content: "I spent the weekend tuning postgres", CreatedAt: time.Now()}
+	noMatch := &models.Post{ID: uuid.New(), UserID: testUser.ID, Title: "Gardening", Content: "Tomatoes and basil", CreatedAt: time.Now()}
+	for _, post := range []*models.Post{titleMatch, contentMatch, noMatch} {
+		if err := postRepo.Create(context.Background(), post); err != nil {
+			t.Fatalf("failed to create post: %v", err)
+		}
+	}
+
+	results, total, err := postRepo.Search(context.Background(), "postgres", models.NewPaginationParams(1, 10))
+	if err != nil {
+		t.Fatalf("failed to search posts: %v", err)
+	}
+
+	if total != 2 || len(results) != 2 {
+		t.Fatalf("expected 2 results, got %d (total %d)", len(results), total)
+	}
+	if results[0].ID != titleMatch.ID {
+		t.Errorf("expected title match to rank first, got %q", results[0].Title)
+	}
+	if results[0].Rank <= results[1].Rank {
+		t.Errorf("expected descending rank, got %f then %f", results[0].Rank, results[1].Rank)
+	}
+	if !strings.Contains(results[1].ContentHeadline, "<mark>postgres</mark>") {
+		t.Errorf("expected highlighted content headline, got %q", results[1].ContentHeadline)
+	}
+
+	script := &models.Post{ID: uuid.New(), UserID: testUser.ID, Title: "<b>Scripting</b>", Content: `Run <script>alert("mysql")</script> against mysql`, CreatedAt: time.Now()}
+	if err := postRepo.Create(context.Background(), script); err != nil {
+		t.Fatalf("failed to create post: %v", err)
+	}
+
+	results, _, err = postRepo.Search(context.Background(), "mysql scripting", models.NewPaginationParams(1, 10))
+	if err != nil {
+		t.Fatalf("failed to search posts: %v", err)
+	}
+	if len(results) != 1 {
+		t.Fatalf("expected 1 result, got %d", len(results))
+	}
+	if strings.Contains(results[0].ContentHeadline, "<script>") || strings.Contains(results[0].TitleHeadline, "<b>") {
+		t.Errorf("expected escaped headlines, got %q and %q", results[0].TitleHeadline, results[0].ContentHeadline)
+	}
+	if !strings.Contains(results[0].ContentHeadline, "<mark>mysql</mark>") {
+		t.Errorf("expected highlighted content headline, got %q", results[0].ContentHeadline)
+	}
+}
+
+func TestVisiblePostsCondition(t *testing.T) {
+	viewerID := uuid.New()
+
+	q := newListQuery()
+	condition := visiblePostsCondition(context.Background(), q)
+	if strings.Contains(condition, "user_id") || len(q.args) != 0 {
+		t.Errorf("expected anonymous requests to see no drafts, got %q with %v", condition, q.args)
+	}
+
+	q = newListQuery()
+	condition = visiblePostsCondition(models.ContextWithViewer(context.Background(), viewerID), q)
+	if !strings.Contains(condition, "user_id = $1") || len(q.args) != 1 || q.args[0] != viewerID {
+		t.Errorf("expected the viewer to see their drafts, got %q with %v", condition, q.args)
+	}
+}
+
+func TestMarkHeadline(t *testing.T) {
+	tests := []struct {
+		name     string
+		headline string
+		expected string
+	}{
+		{
+			name:     "plain text",
+			headline: "tuning " + headlineStart + "postgres" + headlineStop + " indexes",
+			expected: "tuning <mark>postgres</mark> indexes",
+		},
+		{
+			name:     "markup in the text",
+			headline: `<script>alert("` + headlineStart + "x" + headlineStop + `")</script> & more`,
+			expected: "&lt;script&gt;alert(&#34;<mark>x</mark>&#34;)&lt;/script&gt; &amp; more",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := markHeadline(tt.headline); got != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
 // Benchmark tests
 func BenchmarkPostRepository_Create(b *testing.B) {
 	testutils.SkipIfShort(b)
@@ -603,4 +810,432 @@ func BenchmarkPostRepository_Create(b *testing.B) {
 		}
 		postRepo.Create(context.Background(), post)
 	}
-}
\ No newline at end of file
+}
+func TestPostRepository_Reactions(t *testing.T) {
+	testutils.SkipIfShort(t)
+	testutils.SkipIfNoDatabase(t)
+
+	testDB := testutils.SetupTestDB(t)
+	defer testDB.Cleanup(t)
+
+	ctx := context.Background()
+	userRepo := NewUserRepository(testDB.DB)
+	postRepo := NewPostRepository(testDB.DB)
+
+	alice := &models.User{ID: uuid.New(), Username: "alice", PasswordHash: "hash", CreatedAt: time.Now()}
+	bob := &models.User{ID: uuid.New(), Username: "bob", PasswordHash: "hash", CreatedAt: time.Now()}
+	for _, user := range []*models.User{alice, bob} {
+		if err := userRepo.Create(ctx, user); err != nil {
+			t.Fatalf("failed to create user: %v", err)
+		}
+	}
+
+	popular := &models.Post{ID: uuid.New(), UserID: alice.ID, Title: "Popular", Content: "Content", CreatedAt: time.Now().Add(-time.Hour)}
+	quiet := &models.Post{ID: uuid.New(), UserID: alice.ID, Title: "Quiet", Content: "Content", CreatedAt: time.Now()}
+	for _, post := range []*models.Post{popular, quiet} {
+		if err := postRepo.Create(ctx, post); err != nil {
+			t.Fatalf("failed to create post: %v", err)
+		}
+	}
+
+	for _, reaction := range []struct {
+		userID uuid.UUID
+		kind   models.ReactionKind
+	}{
+		{alice.ID, models.ReactionLike},
+		{alice.ID, models.ReactionLike}, // repeated, counted once
+		{bob.ID, models.ReactionLike},
+		{bob.ID, models.ReactionLove},
+	} {
+		if err := postRepo.AddReaction(ctx, popular.ID, reaction.userID, reaction.kind); err != nil {
+			t.Fatalf("failed to add reaction: %v", err)
+		}
+	}
+
+	post, err := postRepo.GetByID(models.ContextWithViewer(ctx, alice.ID), popular.ID)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if post.ReactionCount != 3 {
+		t.Errorf("expected 3 reactions, got %d", post.ReactionCount)
+	}
+	if post.Reactions[models.ReactionLike] != 2 || post.Reactions[models.ReactionLove] != 1 {
+		t.Errorf("unexpected counts by kind: %v", post.Reactions)
+	}
+	if len(post.MyReactions) != 1 || post.MyReactions[0] != models.ReactionLike {
+		t.Errorf("expected alice's like to be flagged, got %v", post.MyReactions)
+	}
+
+	opts := &models.ListOptions{Sort: []models.SortField{{Field: "reaction_count", Descending: true}}}
+	posts, err := postRepo.List(ctx, opts)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(posts) != 2 || posts[0].ID != popular.ID {
+		t.Fatalf("expected the popular post first, got %v", posts)
+	}
+	if len(posts[0].MyReactions) != 0 {
+		t.Errorf("expected no flagged reactions without a viewer, got %v", posts[0].MyReactions)
+	}
+
+	if !post.LastModified().After(post.UpdatedAt) {
+		t.Errorf("expected reactions to move the last modification past %v, got %v", post.UpdatedAt, post.LastModified())
+	}
+	reactedAt := post.ActivityAt
+
+	if err := postRepo.RemoveReaction(ctx, popular.ID, bob.ID, models.ReactionLove); err != nil {
+		t.Fatalf("failed to remove reaction: %v", err)
+	}
+	if err := postRepo.RemoveReaction(ctx, popular.ID, bob.ID, models.ReactionLove); err != nil {
+		t.Fatalf("failed to remove missing reaction: %v", err)
+	}
+	post, err = postRepo.GetByID(ctx, popular.ID)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if post.ReactionCount != 2 {
+		t.Errorf("expected 2 reactions after removal, got %d", post.ReactionCount)
+	}
+	if !post.ActivityAt.After(reactedAt) || post.Version != 1 {
+		t.Errorf("expected the removal to be recorded without a new version, got %v and version %d", post.ActivityAt, post.Version)
+	}
+
+	if err := userRepo.Delete(ctx, bob.ID, models.UserPostPolicyCascade); err != nil {
+		t.Fatalf("failed to delete user: %v", err)
+	}
+	if _, err := userRepo.Purge(ctx, time.Now().Add(time.Minute), models.UserPostPolicyCascade); err != nil {
+		t.Fatalf("failed to purge user: %v", err)
+	}
+	post, err = postRepo.GetByID(ctx, popular.ID)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if post.ReactionCount != 1 {
+		t.Errorf("expected the deleted user's reactions to be uncounted, got %d", post.ReactionCount)
+	}
+}
+
+func TestPostRepository_Tags(t *testing.T) {
+	testutils.SkipIfShort(t)
+	testutils.SkipIfNoDatabase(t)
+
+	testDB := testutils.SetupTestDB(t)
+	defer testDB.Cleanup(t)
+
+	ctx := context.Background()
+	userRepo := NewUserRepository(testDB.DB)
+	postRepo := NewPostRepository(testDB.DB)
+
+	user := &models.User{ID: uuid.New(), Username: "tagger", PasswordHash: "hash", CreatedAt: time.Now()}
+	if err := userRepo.Create(ctx, user); err != nil {
+		t.Fatalf("failed to create user: %v", err)
+	}
+
+	both := &models.Post{ID: uuid.New(), UserID: user.ID, Title: "Both", Content: "Content", Tags: []string{"api", "go"}, CreatedAt: time.Now()}
+	goOnly := &models.Post{ID: uuid.New(), UserID: user.ID, Title: "Go", Content: "Content", Tags: []string{"go"}, CreatedAt: time.Now()}
+	untagged := &models.Post{ID: uuid.New(), UserID: user.ID, Title: "Untagged", Content: "Content", CreatedAt: time.Now()}
+	for _, post := range []*models.Post{both, goOnly, untagged} {
+		if err := postRepo.Create(ctx, post); err != nil {
+			t.Fatalf("failed to create post: %v", err)
+		}
+	}
+
+	post, err := postRepo.GetByID(ctx, both.ID)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(post.Tags) != 2 || post.Tags[0] != "api" || post.Tags[1] != "go" {
+		t.Errorf("expected tags [api go], got %v", post.Tags)
+	}
+
+	posts, err := postRepo.List(ctx, &models.ListOptions{Tags: []string{"go", "api"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(posts) != 1 || posts[0].ID != both.ID {
+		t.Errorf("expected only the post with both tags, got %v", posts)
+	}
+
+	total, err := postRepo.Count(ctx, &models.ListOptions{Tags: []string{"go"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if total != 2 {
+		t.Errorf("expected 2 posts tagged go, got %d", total)
+	}
+
+	tags, err := postRepo.ListTags(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(tags) != 2 || tags[0].Name != "go" || tags[0].PostCount != 2 || tags[1].Name != "api" || tags[1].PostCount != 1 {
+		t.Errorf("unexpected tag counts: %v", tags)
+	}
+
+	goOnly.Tags = []string{"rust"}
+	if err := postRepo.Update(ctx, goOnly.ID, goOnly); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	post, err = postRepo.GetByID(ctx, goOnly.ID)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(post.Tags) != 1 || post.Tags[0] != "rust" {
+		t.Errorf("expected tags to be replaced, got %v", post.Tags)
+	}
+}
+
+func TestPostRepository_Drafts(t *testing.T) {
+	testutils.SkipIfShort(t)
+	testutils.SkipIfNoDatabase(t)
+
+	testDB := testutils.SetupTestDB(t)
+	defer testDB.Cleanup(t)
+
+	ctx := context.Background()
+	userRepo := NewUserRepository(testDB.DB)
+	postRepo := NewPostRepository(testDB.DB)
+
+	author := &models.User{ID: uuid.New(), Username: "author", PasswordHash: "hash", CreatedAt: time.Now()}
+	reader := &models.User{ID: uuid.New(), Username: "reader", PasswordHash: "hash", CreatedAt: time.Now()}
+	for _, user := range []*models.User{author, reader} {
+		if err := userRepo.Create(ctx, user); err != nil {
+			t.Fatalf("failed to create user: %v", err)
+		}
+	}
+
+	publishAt := time.Now().Add(time.Hour)
+	published := &models.Post{ID: uuid.New(), UserID: author.ID, Title: "Published", Content: "Content", CreatedAt: time.Now()}
+	scheduled := &models.Post{ID: uuid.New(), UserID: author.ID, Title: "Scheduled", Content: "Content", Status: models.PostStatusDraft, PublishedAt: &publishAt, CreatedAt: time.Now()}
+	// A draft left behind by a deleted user
+	orphaned := &models.Post{ID: uuid.New(), UserID: models.DeletedUserID, Title: "Orphaned", Content: "Content", Status: models.PostStatusDraft, CreatedAt: time.Now()}
+	for _, post := range []*models.Post{published, scheduled, orphaned} {
+		if err := postRepo.Create(ctx, post); err != nil {
+			t.Fatalf("failed to create post: %v", err)
+		}
+	}
+	if published.Status != models.PostStatusPublished || published.PublishedAt == nil {
+		t.Errorf("expected a post without status to be published, got %s", published.Status)
+	}
+
+	for _, tt := range []struct {
+		name     string
+		ctx      context.Context
+		expected int
+	}{
+		{"anonymous", ctx, 1},
+		{"another user", models.ContextWithViewer(ctx, reader.ID), 1},
+		{"author", models.ContextWithViewer(ctx, author.ID), 2},
+	} {
+		posts, err := postRepo.List(tt.ctx, nil)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(posts) != tt.expected {
+			t.Errorf("%s: expected %d posts listed, got %d", tt.name, tt.expected, len(posts))
+		}
+		total, err := postRepo.CountByUserID(tt.ctx, author.ID)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if total != int64(tt.expected) {
+			t.Errorf("%s: expected %d posts counted for the author, got %d", tt.name, tt.expected, total)
+		}
+	}
+
+	orphans, err := postRepo.GetByUserID(ctx, models.DeletedUserID)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(orphans) != 0 {
+		t.Errorf("expected the deleted user's draft to be hidden from anonymous requests, got %d posts", len(orphans))
+	}
+	results, _, err := postRepo.Search(ctx, "orphaned", models.NewPaginationParams(1, 10))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(results) != 0 {
+		t.Errorf("expected the deleted user's draft not to be found by anonymous requests, got %d results", len(results))
+	}
+
+	count, err := postRepo.PublishDue(ctx, time.Now())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if count != 0 {
+		t.Errorf("expected nothing due yet, got %d", count)
+	}
+	count, err = postRepo.PublishDue(ctx, publishAt.Add(time.Second))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if count != 1 {
+		t.Errorf("expected the scheduled post to be published, got %d", count)
+	}
+
+	post, err := postRepo.GetByID(ctx, scheduled.ID)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if post.Status != models.PostStatusPublished || post.PublishedAt == nil || !post.PublishedAt.Equal(publishAt.Truncate(time.Microsecond)) {
+		t.Errorf("expected the post published at its scheduled time, got %s at %v", post.Status, post.PublishedAt)
+	}
+	if post.Version != 2 {
+		t.Errorf("expected publishing to make version 2, got %d", post.Version)
+	}
+	if _, err := postRepo.GetRevision(ctx, scheduled.ID, 2); err != nil {
+		t.Errorf("expected a revision for the published version: %v", err)
+	}
+}
+
+func TestPostRepository_Revisions(t *testing.T) {
+	testutils.SkipIfShort(t)
+	testutils.SkipIfNoDatabase(t)
+
+	testDB := testutils.SetupTestDB(t)
+	defer testDB.Cleanup(t)
+
+	ctx := context.Background()
+	userRepo := NewUserRepository(testDB.DB)
+	postRepo := NewPostRepository(testDB.DB)
+
+	author := &models.User{ID: uuid.New(), Username: "author", PasswordHash: "hash", CreatedAt: time.Now()}
+	if err := userRepo.Create(ctx, author); err != nil {
+		t.Fatalf("failed to create user: %v", err)
+	}
+	post := &models.Post{ID: uuid.New(), UserID: author.ID, Title: "Title", Content: "Original", CreatedAt: time.Now()}
+	if err := postRepo.Create(ctx, post); err != nil {
+		t.Fatalf("failed to create post: %v", err)
+	}
+	if post.Version != 1 {
+		t.Errorf("expected a new post at version 1, got %d", post.Version)
+	}
+
+	post.Content = "Edited"
+	if err := postRepo.Update(ctx, post.ID, post); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if post.Version != 2 {
+		t.Errorf("expected version 2 after an update, got %d", post.Version)
+	}
+
+	stale := *post
+	stale.Version = 1
+	stale.Content = "Clobbered"
+	err := postRepo.Update(ctx, post.ID, &stale)
+	if err == nil || errors.AsAppError(err).Code != errors.ErrCodeConflict {
+		t.Errorf("expected a conflict for a stale version, got %v", err)
+	}
+
+	revisions, total, err := postRepo.ListRevisions(ctx, post.ID, models.NewPaginationParams(1, 10))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if total != 2 || len(revisions) != 2 {
+		t.Fatalf("expected 2 revisions, got %d of %d", len(revisions), total)
+	}
+	if revisions[0].Version != 2 || revisions[0].Content != "Edited" {
+		t.Errorf("expected the newest revision first, got version %d", revisions[0].Version)
+	}
+	if revisions[1].EditorID == nil || *revisions[1].EditorID != author.ID {
+		t.Errorf("expected the author as editor of the first revision, got %v", revisions[1].EditorID)
+	}
+
+	revision, err := postRepo.GetRevision(ctx, post.ID, 1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if revision.Content != "Original" {
+		t.Errorf("expected the original content, got %q", revision.Content)
+	}
+	if _, err := postRepo.GetRevision(ctx, post.ID, 3); err == nil {
+		t.Error("expected a missing revision to fail")
+	}
+
+	err = postRepo.Delete(ctx, post.ID, 1)
+	if err == nil || errors.AsAppError(err).Code != errors.ErrCodeConflict {
+		t.Errorf("expected a conflict deleting a stale version, got %v", err)
+	}
+	if err := postRepo.Delete(ctx, post.ID, post.Version); err != nil {
+		t.Errorf("unexpected error deleting the current version: %v", err)
+	}
+}
+
+func TestPostRepository_Trash(t *testing.T) {
+	testutils.SkipIfShort(t)
+	testutils.SkipIfNoDatabase(t)
+
+	testDB := testutils.SetupTestDB(t)
+	defer testDB.Cleanup(t)
+
+	ctx := context.Background()
+	userRepo := NewUserRepository(testDB.DB)
+	postRepo := NewPostRepository(testDB.DB)
+
+	author := &models.User{ID: uuid.New(), Username: "author", PasswordHash: "hash", CreatedAt: time.Now()}
+	if err := userRepo.Create(ctx, author); err != nil {
+		t.Fatalf("failed to create user: %v", err)
+	}
+	kept := &models.Post{ID: uuid.New(), UserID: author.ID, Title: "Kept", Content: "Content", CreatedAt: time.Now()}
+	trashed := &models.Post{ID: uuid.New(), UserID: author.ID, Title: "Trashed", Content: "Content", CreatedAt: time.Now()}
+	for _, post := range []*models.Post{kept, trashed} {
+		if err := postRepo.Create(ctx, post); err != nil {
+			t.Fatalf("failed to create post: %v", err)
+		}
+	}
+
+	if err := postRepo.Delete(ctx, trashed.ID, 0); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := postRepo.Delete(ctx, trashed.ID, 0); err == nil {
+		t.Error("expected deleting a post in the trash to fail")
+	}
+	if _, err := postRepo.GetByID(ctx, trashed.ID); err == nil {
+		t.Error("expected a deleted post to be hidden")
+	}
+	if total, err := postRepo.CountByUserID(ctx, author.ID); err != nil || total != 1 {
+		t.Errorf("expected 1 post counted, got %d (%v)", total, err)
+	}
+
+	deleted, total, err := postRepo.ListDeleted(ctx, author.ID, models.NewPaginationParams(1, 10))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if total != 1 || len(deleted) != 1 || deleted[0].ID != trashed.ID || deleted[0].DeletedAt == nil {
+		t.Fatalf("expected the deleted post in the trash, got %d of %d", len(deleted), total)
+	}
+
+	if err := postRepo.Restore(ctx, trashed.ID); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := postRepo.GetByID(ctx, trashed.ID); err != nil {
+		t.Errorf("expected the restored post to be visible: %v", err)
+	}
+	if err := postRepo.Restore(ctx, trashed.ID); err == nil {
+		t.Error("expected restoring a post outside the trash to fail")
+	}
+
+	if err := postRepo.Delete(ctx, trashed.ID, 0); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	purged, err := postRepo.Purge(ctx, time.Now().Add(-time.Hour))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if purged != 0 {
+		t.Errorf("expected nothing purged before the retention period, got %d", purged)
+	}
+	purged, err = postRepo.Purge(ctx, time.Now().Add(time.Minute))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if purged != 1 {
+		t.Errorf("expected the deleted post to be purged, got %d", purged)
+	}
+	if _, err := postRepo.GetDeleted(ctx, trashed.ID); err == nil {
+		t.Error("expected the purged post to be gone")
+	}
+	if _, err := postRepo.GetByID(ctx, kept.ID); err != nil {
+		t.Errorf("expected the other post to be kept: %v", err)
+	}
+}
@@ -0,0 +1,155 @@
+package repository
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/alinoer/go-std-api/internal/errors"
+	"github.com/alinoer/go-std-api/internal/models"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// rateLimitPruneInterval is how often stores drop buckets that have filled
+// up again
+const rateLimitPruneInterval = time.Minute
+
+// RateLimitStore keeps the token buckets of the rate limiter. A key that has
+// not been seen has a full bucket.
+type RateLimitStore interface {
+	// Take takes a token from the bucket for key if it has one, after
+	// refilling it for the time since the last request
+	Take(ctx context.Context, key string, limit models.RateLimit) (*models.RateLimitResult, error)
+}
+
+// rateLimitRepository keeps buckets in Postgres so that every instance of
+// the API shares them
+type rateLimitRepository struct {
+	db *pgxpool.Pool
+
+	mu         sync.Mutex
+	lastPruned time.Time
+}
+
+func NewRateLimitRepository(db *pgxpool.Pool) RateLimitStore {
+	return &rateLimitRepository{db: db}
+}
+
+func (r *rateLimitRepository) Take(ctx context.Context, key string, limit models.RateLimit) (*models.RateLimitResult, error) {
+	if err := r.prune(ctx); err != nil {
+		return nil, err
+	}
+
+	// The bucket only changes when it has a token to give, so rejected
+	// requests do not push back the time a client can try again. A bucket is
+	// full again at the latest one period after it was last used.
+	query := `
+		INSERT INTO rate_limit_buckets AS b (key, tokens, expires_at)
+		VALUES ($1, $2::DOUBLE PRECISION - 1, NOW() + $4::INTERVAL)
+		ON CONFLICT (key) DO UPDATE
+		SET tokens = LEAST($2::DOUBLE PRECISION, b.tokens + EXTRACT(EPOCH FROM NOW() - b.updated_at)::DOUBLE PRECISION * $3::DOUBLE PRECISION) - 1,
+			updated_at = NOW(),
+			expires_at = NOW() + $4::INTERVAL
+		WHERE LEAST($2::DOUBLE PRECISION, b.tokens + EXTRACT(EPOCH FROM NOW() - b.updated_at)::DOUBLE PRECISION * $3::DOUBLE PRECISION) >= 1
+		RETURNING tokens`
+
+	var tokens float64
+	err := r.db.QueryRow(ctx, query, key, float64(limit.Requests), limit.Rate(), limit.Period).Scan(&tokens)
+	if err == nil {
+		return limit.Result(tokens, true), nil
+	}
+	if err != pgx.ErrNoRows {
+		return nil, errors.DatabaseError("take rate limit token", err)
+	}
+
+	query = `
+		SELECT LEAST($2::DOUBLE PRECISION, tokens + EXTRACT(EPOCH FROM NOW() - updated_at)::DOUBLE PRECISION * $3::DOUBLE PRECISION)
+		FROM rate_limit_buckets
+		WHERE key = $1`
+
+	err = r.db.QueryRow(ctx, query, key, float64(limit.Requests), limit.Rate()).Scan(&tokens)
+	if err != nil && err != pgx.ErrNoRows {
+		return nil, errors.DatabaseError("get rate limit bucket", err)
+	}
+
+	return limit.Result(tokens, false), nil
+}
+
+// prune deletes the buckets that have filled up again, at most once per
+// rateLimitPruneInterval
+func (r *rateLimitRepository) prune(ctx context.Context) error {
+	r.mu.Lock()
+	if time.Since(r.lastPruned) < rateLimitPruneInterval {
+		r.mu.Unlock()
+		return nil
+	}
+	r.lastPruned = time.Now()
+	r.mu.Unlock()
+
+	_, err := r.db.Exec(ctx, `DELETE FROM rate_limit_buckets WHERE expires_at <= NOW()`)
+	if err != nil {
+		return errors.DatabaseError("prune rate limit buckets", err)
+	}
+
+	return nil
+}
+
+// memoryRateLimitStore keeps buckets in process memory. It suits
+// single-instance deployments and tests; every instance limits on its own.
+type memoryRateLimitStore struct {
+	mu         sync.Mutex
+	buckets    map[string]*memoryRateLimitBucket
+	now        func() time.Time
+	lastPruned time.Time
+}
+
+type memoryRateLimitBucket struct {
+	tokens    float64
+	updatedAt time.Time
+	expiresAt time.Time
+}
+
+func NewMemoryRateLimitStore() RateLimitStore {
+	return newMemoryRateLimitStore(time.Now)
+}
+
+func newMemoryRateLimitStore(now func() time.Time) *memoryRateLimitStore {
+	return &memoryRateLimitStore{
+		buckets: make(map[string]*memoryRateLimitBucket),
+		now:     now,
+	}
+}
+
+func (s *memoryRateLimitStore) Take(ctx context.Context, key string, limit models.RateLimit) (*models.RateLimitResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	if now.Sub(s.lastPruned) >= rateLimitPruneInterval {
+		for k, bucket := range s.buckets {
+			if !bucket.expiresAt.After(now) {
+				delete(s.buckets, k)
+			}
+		}
+		s.lastPruned = now
+	}
+
+	tokens := float64(limit.Requests)
+	if bucket, exists := s.buckets[key]; exists {
+		tokens = math.Min(tokens, bucket.tokens+now.Sub(bucket.updatedAt).Seconds()*limit.Rate())
+	}
+	if tokens < 1 {
+		return limit.Result(tokens, false), nil
+	}
+
+	tokens--
+	s.buckets[key] = &memoryRateLimitBucket{
+		tokens:    tokens,
+		updatedAt: now,
+		expiresAt: now.Add(limit.Period),
+	}
+	return limit.Result(tokens, true), nil
+}
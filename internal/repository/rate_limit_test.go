@@ -0,0 +1,93 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alinoer/go-std-api/internal/models"
+	"github.com/alinoer/go-std-api/internal/testutils"
+)
+
+// testRateLimitStore exercises the behaviour shared by every RateLimitStore
+// implementation. The period is long enough for no token to come back
+// during the test.
+func testRateLimitStore(t *testing.T, store RateLimitStore) {
+	ctx := context.Background()
+	limit := models.RateLimit{Requests: 3, Period: time.Hour}
+
+	for want := 2; want >= 0; want-- {
+		result, err := store.Take(ctx, "client-1", limit)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !result.Allowed || result.Remaining != want {
+			t.Errorf("expected an allowed request with %d remaining, got %+v", want, result)
+		}
+		if result.RetryAfter != 0 {
+			t.Errorf("expected no retry after an allowed request, got %v", result.RetryAfter)
+		}
+	}
+
+	result, err := store.Take(ctx, "client-1", limit)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Allowed || result.Remaining != 0 {
+		t.Errorf("expected the fourth request to be rejected, got %+v", result)
+	}
+	// A token comes back every 20 minutes
+	if result.RetryAfter <= 19*time.Minute || result.RetryAfter > 20*time.Minute {
+		t.Errorf("expected to retry in about 20 minutes, got %v", result.RetryAfter)
+	}
+	if result.ResetAfter <= 59*time.Minute || result.ResetAfter > time.Hour {
+		t.Errorf("expected the bucket to be full in about an hour, got %v", result.ResetAfter)
+	}
+
+	// Buckets are separate per key
+	if result, _ := store.Take(ctx, "client-2", limit); result == nil || !result.Allowed {
+		t.Errorf("expected another client's request to be allowed, got %+v", result)
+	}
+}
+
+func TestMemoryRateLimitStore(t *testing.T) {
+	testRateLimitStore(t, NewMemoryRateLimitStore())
+
+	t.Run("refill", func(t *testing.T) {
+		now := time.Now()
+		store := newMemoryRateLimitStore(func() time.Time { return now })
+		limit := models.RateLimit{Requests: 2, Period: time.Minute}
+		ctx := context.Background()
+
+		store.Take(ctx, "client", limit)
+		store.Take(ctx, "client", limit)
+		if result, _ := store.Take(ctx, "client", limit); result.Allowed {
+			t.Fatal("expected the bucket to be empty")
+		}
+
+		// Half a period brings back one token
+		now = now.Add(30 * time.Second)
+		if result, _ := store.Take(ctx, "client", limit); !result.Allowed || result.Remaining != 0 {
+			t.Errorf("expected a refilled token, got %+v", result)
+		}
+
+		// Buckets never hold more than Requests tokens
+		now = now.Add(time.Hour)
+		if result, _ := store.Take(ctx, "client", limit); !result.Allowed || result.Remaining != 1 {
+			t.Errorf("expected a full bucket, got %+v", result)
+		}
+		if len(store.buckets) != 1 {
+			t.Errorf("expected expired buckets to be pruned, got %d", len(store.buckets))
+		}
+	})
+}
+
+func TestRateLimitRepository(t *testing.T) {
+	testutils.SkipIfShort(t)
+	testutils.SkipIfNoDatabase(t)
+
+	testDB := testutils.SetupTestDB(t)
+	defer testDB.Cleanup(t)
+
+	testRateLimitStore(t, NewRateLimitRepository(testDB.DB))
+}
@@ -0,0 +1,147 @@
+package repository
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/alinoer/go-std-api/internal/errors"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// TokenRevocationStore tracks access tokens that must be rejected before they
+// expire. Single tokens are revoked by their jti; all of a user's tokens are
+// revoked at once by bumping the user's token generation, which invalidates
+// every token minted for an older generation.
+type TokenRevocationStore interface {
+	RevokeToken(ctx context.Context, jti string, expiresAt time.Time) error
+	IsTokenRevoked(ctx context.Context, jti string) (bool, error)
+	GetTokenGeneration(ctx context.Context, userID uuid.UUID) (int64, error)
+	IncrementTokenGeneration(ctx context.Context, userID uuid.UUID) (int64, error)
+}
+
+type tokenRevocationRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewTokenRevocationRepository(db *pgxpool.Pool) TokenRevocationStore {
+	return &tokenRevocationRepository{db: db}
+}
+
+func (r *tokenRevocationRepository) RevokeToken(ctx context.Context, jti string, expiresAt time.Time) error {
+	// Entries are only needed until the token would have expired anyway
+	_, err := r.db.Exec(ctx, `DELETE FROM revoked_tokens WHERE expires_at <= NOW()`)
+	if err != nil {
+		return errors.DatabaseError("prune revoked tokens", err)
+	}
+
+	query := `
+		INSERT INTO revoked_tokens (jti, expires_at)
+		VALUES ($1, $2)
+		ON CONFLICT (jti) DO NOTHING`
+
+	_, err = r.db.Exec(ctx, query, jti, expiresAt)
+	if err != nil {
+		return errors.DatabaseError("revoke token", err)
+	}
+
+	return nil
+}
+
+func (r *tokenRevocationRepository) IsTokenRevoked(ctx context.Context, jti string) (bool, error) {
+	query := `SELECT EXISTS(SELECT 1 FROM revoked_tokens WHERE jti = $1 AND expires_at > NOW())`
+
+	var revoked bool
+	if err := r.db.QueryRow(ctx, query, jti).Scan(&revoked); err != nil {
+		return false, errors.DatabaseError("check token revocation", err)
+	}
+
+	return revoked, nil
+}
+
+func (r *tokenRevocationRepository) GetTokenGeneration(ctx context.Context, userID uuid.UUID) (int64, error) {
+	query := `SELECT generation FROM user_token_generations WHERE user_id = $1`
+
+	var generation int64
+	err := r.db.QueryRow(ctx, query, userID).Scan(&generation)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return 0, nil
+		}
+		return 0, errors.DatabaseError("get token generation", err)
+	}
+
+	return generation, nil
+}
+
+func (r *tokenRevocationRepository) IncrementTokenGeneration(ctx context.Context, userID uuid.UUID) (int64, error) {
+	query := `
+		INSERT INTO user_token_generations (user_id, generation)
+		VALUES ($1, 1)
+		ON CONFLICT (user_id) DO UPDATE
+		SET generation = user_token_generations.generation + 1, updated_at = NOW()
+		RETURNING generation`
+
+	var generation int64
+	if err := r.db.QueryRow(ctx, query, userID).Scan(&generation); err != nil {
+		return 0, errors.DatabaseError("increment token generation", err)
+	}
+
+	return generation, nil
+}
+
+// memoryTokenRevocationStore keeps revocations in process memory. It suits
+// single-instance deployments and tests; revocations are lost on restart.
+type memoryTokenRevocationStore struct {
+	mu          sync.RWMutex
+	revoked     map[string]time.Time
+	generations map[uuid.UUID]int64
+}
+
+func NewMemoryTokenRevocationStore() TokenRevocationStore {
+	return &memoryTokenRevocationStore{
+		revoked:     make(map[string]time.Time),
+		generations: make(map[uuid.UUID]int64),
+	}
+}
+
+func (s *memoryTokenRevocationStore) RevokeToken(ctx context.Context, jti string, expiresAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	for id, exp := range s.revoked {
+		if !exp.After(now) {
+			delete(s.revoked, id)
+		}
+	}
+
+	s.revoked[jti] = expiresAt
+	return nil
+}
+
+func (s *memoryTokenRevocationStore) IsTokenRevoked(ctx context.Context, jti string) (bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	expiresAt, exists := s.revoked[jti]
+	return exists && expiresAt.After(time.Now()), nil
+}
+
+func (s *memoryTokenRevocationStore) GetTokenGeneration(ctx context.Context, userID uuid.UUID) (int64, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.generations[userID], nil
+}
+
+func (s *memoryTokenRevocationStore) IncrementTokenGeneration(ctx context.Context, userID uuid.UUID) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.generations[userID]++
+	return s.generations[userID], nil
+}
@@ -0,0 +1,90 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alinoer/go-std-api/internal/models"
+	"github.com/alinoer/go-std-api/internal/testutils"
+	"github.com/google/uuid"
+)
+
+// testTokenRevocationStore exercises the behaviour shared by every
+// TokenRevocationStore implementation
+func testTokenRevocationStore(t *testing.T, store TokenRevocationStore, userID uuid.UUID) {
+	ctx := context.Background()
+
+	revoked, err := store.IsTokenRevoked(ctx, "unknown-jti")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if revoked {
+		t.Error("expected unknown jti not to be revoked")
+	}
+
+	if err := store.RevokeToken(ctx, "active-jti", time.Now().Add(time.Hour)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if revoked, _ := store.IsTokenRevoked(ctx, "active-jti"); !revoked {
+		t.Error("expected revoked jti to be reported as revoked")
+	}
+
+	// Revoking twice is not an error
+	if err := store.RevokeToken(ctx, "active-jti", time.Now().Add(time.Hour)); err != nil {
+		t.Errorf("expected repeated revocation to succeed, got %v", err)
+	}
+
+	if err := store.RevokeToken(ctx, "expired-jti", time.Now().Add(-time.Minute)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if revoked, _ := store.IsTokenRevoked(ctx, "expired-jti"); revoked {
+		t.Error("expected revocation of an expired token to be ignored")
+	}
+
+	generation, err := store.GetTokenGeneration(ctx, userID)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if generation != 0 {
+		t.Errorf("expected initial generation 0, got %d", generation)
+	}
+
+	for want := int64(1); want <= 2; want++ {
+		generation, err := store.IncrementTokenGeneration(ctx, userID)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if generation != want {
+			t.Errorf("expected generation %d, got %d", want, generation)
+		}
+	}
+
+	if generation, _ := store.GetTokenGeneration(ctx, userID); generation != 2 {
+		t.Errorf("expected generation 2, got %d", generation)
+	}
+}
+
+func TestMemoryTokenRevocationStore(t *testing.T) {
+	testTokenRevocationStore(t, NewMemoryTokenRevocationStore(), uuid.New())
+}
+
+func TestTokenRevocationRepository(t *testing.T) {
+	testutils.SkipIfShort(t)
+	testutils.SkipIfNoDatabase(t)
+
+	testDB := testutils.SetupTestDB(t)
+	defer testDB.Cleanup(t)
+
+	user := &models.User{
+		ID:           uuid.New(),
+		Username:     "revocationuser",
+		PasswordHash: "hashedpassword",
+		CreatedAt:    time.Now(),
+	}
+	if err := NewUserRepository(testDB.DB).Create(context.Background(), user); err != nil {
+		t.Fatalf("failed to create user: %v", err)
+	}
+
+	testTokenRevocationStore(t, NewTokenRevocationRepository(testDB.DB), user.ID)
+}
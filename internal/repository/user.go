@@ -3,11 +3,14 @@ package repository
 import (
 	"context"
 	"fmt"
+	"time"
 
+	"github.com/alinoer/go-std-api/internal/errors"
 	"github.com/alinoer/go-std-api/internal/models"
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
@@ -15,8 +18,37 @@ type UserRepository interface {
 	Create(ctx context.Context, user *models.User) error
 	GetByID(ctx context.Context, id uuid.UUID) (*models.User, error)
 	GetByUsername(ctx context.Context, username string) (*models.User, error)
-	List(ctx context.Context) ([]*models.User, error)
-	ListPaginated(ctx context.Context, pagination *models.PaginationParams) ([]*models.User, int64, error)
+	List(ctx context.Context, opts *models.ListOptions) ([]*models.User, error)
+	ListPaginated(ctx context.Context, pagination *models.PaginationParams, opts *models.ListOptions) ([]*models.User, int64, error)
+	ListByCursor(ctx context.Context, params *models.CursorParams, opts *models.ListOptions) ([]*models.User, bool, error)
+	Count(ctx context.Context, opts *models.ListOptions) (int64, error)
+	UpdatePasswordHash(ctx context.Context, id uuid.UUID, passwordHash string) error
+	UpdateRole(ctx context.Context, id uuid.UUID, role models.Role) error
+	// Update stores the user's username and password hash. It returns a
+	// Conflict error when another user already has the username.
+	Update(ctx context.Context, user *models.User) error
+	// Delete moves the user to the trash, after which the user is treated as
+	// missing. UserPostPolicyCascade moves the user's posts to the trash as
+	// well; with UserPostPolicyBlock it returns a Conflict error if the user
+	// has posts. Under UserPostPolicyAnonymize the posts stay up.
+	Delete(ctx context.Context, id uuid.UUID, policy models.UserPostPolicy) error
+	// Restore takes the user out of the trash along with the posts that
+	// were moved there with the user
+	Restore(ctx context.Context, id uuid.UUID) error
+	// Purge permanently deletes the users that went to the trash before the
+	// given time and returns how many it deleted. The user's comments are
+	// kept under UserPostPolicyAnonymize, which hands them and the posts to
+	// DeletedUserID, and deleted with the user otherwise; reactions are
+	// always deleted.
+	Purge(ctx context.Context, before time.Time, policy models.UserPostPolicy) (int64, error)
+}
+
+// uniqueViolation is the Postgres error code for unique constraint violations
+const uniqueViolation = "23505"
+
+func isUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == uniqueViolation
 }
 
 type userRepository struct {
@@ -29,12 +61,19 @@ func NewUserRepository(db *pgxpool.Pool) UserRepository {
 
 func (r *userRepository) Create(ctx context.Context, user *models.User) error {
 	query := `
-		INSERT INTO users (id, username, password_hash, created_at)
-		VALUES ($1, $2, $3, $4)`
+		INSERT INTO users (id, username, password_hash, role, created_at)
+		VALUES ($1, $2, $3, $4, $5)`
+
+	if user.Role == "" {
+		user.Role = models.RoleUser
+	}
 
-	_, err := r.db.Exec(ctx, query, user.ID, user.Username, user.PasswordHash, user.CreatedAt)
+	_, err := r.db.Exec(ctx, query, user.ID, user.Username, user.PasswordHash, user.Role, user.CreatedAt)
 	if err != nil {
-		return fmt.Errorf("failed to create user: %w", err)
+		if isUniqueViolation(err) {
+			return errors.Conflict("Username", "choose a different username")
+		}
+		return errors.DatabaseError("create user", err)
 	}
 
 	return nil
@@ -42,23 +81,24 @@ func (r *userRepository) Create(ctx context.Context, user *models.User) error {
 
 func (r *userRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.User, error) {
 	query := `
-		SELECT id, username, password_hash, created_at
+		SELECT id, username, password_hash, role, created_at
 		FROM users
-		WHERE id = $1`
+		WHERE id = $1 AND deleted_at IS NULL`
 
 	var user models.User
 	err := r.db.QueryRow(ctx, query, id).Scan(
 		&user.ID,
 		&user.Username,
 		&user.PasswordHash,
+		&user.Role,
 		&user.CreatedAt,
 	)
 
 	if err != nil {
 		if err == pgx.ErrNoRows {
-			return nil, fmt.Errorf("user not found")
+			return nil, errors.NotFound("User")
 		}
-		return nil, fmt.Errorf("failed to get user: %w", err)
+		return nil, errors.DatabaseError("get user", err)
 	}
 
 	return &user, nil
@@ -66,81 +106,359 @@ func (r *userRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Use
 
 func (r *userRepository) GetByUsername(ctx context.Context, username string) (*models.User, error) {
 	query := `
-		SELECT id, username, password_hash, created_at
+		SELECT id, username, password_hash, role, created_at
 		FROM users
-		WHERE username = $1`
+		WHERE username = $1 AND deleted_at IS NULL`
 
 	var user models.User
 	err := r.db.QueryRow(ctx, query, username).Scan(
 		&user.ID,
 		&user.Username,
 		&user.PasswordHash,
+		&user.Role,
 		&user.CreatedAt,
 	)
 
 	if err != nil {
 		if err == pgx.ErrNoRows {
-			return nil, fmt.Errorf("user not found")
+			return nil, errors.NotFound("User")
 		}
-		return nil, fmt.Errorf("failed to get user: %w", err)
+		return nil, errors.DatabaseError("get user", err)
 	}
 
 	return &user, nil
 }
 
-func (r *userRepository) List(ctx context.Context) ([]*models.User, error) {
-	query := `
-		SELECT id, username, password_hash, created_at
+func (r *userRepository) List(ctx context.Context, opts *models.ListOptions) ([]*models.User, error) {
+	q, err := r.newListQuery(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	query := fmt.Sprintf(`
+		SELECT id, username, password_hash, role, created_at
 		FROM users
-		ORDER BY created_at DESC`
+		%s
+		ORDER BY %s`, q.whereClause(), q.orderBy)
+
+	return r.queryUsers(ctx, query, q.args...)
+}
+
+func (r *userRepository) ListPaginated(ctx context.Context, pagination *models.PaginationParams, opts *models.ListOptions) ([]*models.User, int64, error) {
+	// First, get the total count
+	total, err := r.Count(ctx, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// Then get the paginated results
+	q, err := r.newListQuery(opts)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	query := fmt.Sprintf(`
+		SELECT id, username, password_hash, role, created_at
+		FROM users
+		%s
+		ORDER BY %s
+		LIMIT %s OFFSET %s`, q.whereClause(), q.orderBy, q.arg(pagination.PageSize), q.arg(pagination.Offset))
+
+	users, err := r.queryUsers(ctx, query, q.args...)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return users, total, nil
+}
+
+func (r *userRepository) UpdatePasswordHash(ctx context.Context, id uuid.UUID, passwordHash string) error {
+	query := `
+		UPDATE users
+		SET password_hash = $1
+		WHERE id = $2 AND deleted_at IS NULL`
+
+	result, err := r.db.Exec(ctx, query, passwordHash, id)
+	if err != nil {
+		return errors.DatabaseError("update password hash", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return errors.NotFound("User")
+	}
+
+	return nil
+}
+
+func (r *userRepository) UpdateRole(ctx context.Context, id uuid.UUID, role models.Role) error {
+	query := `
+		UPDATE users
+		SET role = $1
+		WHERE id = $2 AND deleted_at IS NULL`
+
+	result, err := r.db.Exec(ctx, query, role, id)
+	if err != nil {
+		return errors.DatabaseError("update role", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return errors.NotFound("User")
+	}
+
+	return nil
+}
+
+func (r *userRepository) Update(ctx context.Context, user *models.User) error {
+	query := `
+		UPDATE users
+		SET username = $1, password_hash = $2
+		WHERE id = $3 AND deleted_at IS NULL`
+
+	result, err := r.db.Exec(ctx, query, user.Username, user.PasswordHash, user.ID)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return errors.Conflict("Username", "choose a different username")
+		}
+		return errors.DatabaseError("update user", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return errors.NotFound("User")
+	}
+
+	return nil
+}
+
+func (r *userRepository) Delete(ctx context.Context, id uuid.UUID, policy models.UserPostPolicy) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return errors.DatabaseError("begin transaction", err)
+	}
+	defer tx.Rollback(ctx)
+
+	// Lock the user row so no posts can be added while the policy is applied
+	var exists bool
+	err = tx.QueryRow(ctx, `SELECT true FROM users WHERE id = $1 AND deleted_at IS NULL FOR UPDATE`, id).Scan(&exists)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return errors.NotFound("User")
+		}
+		return errors.DatabaseError("lock user", err)
+	}
+
+	// NOW() is fixed for the transaction, so the posts share the user's
+	// deleted_at and Restore can tell them from posts deleted earlier
+	switch policy {
+	case models.UserPostPolicyCascade:
+		if _, err := tx.Exec(ctx, `UPDATE posts SET deleted_at = NOW() WHERE user_id = $1 AND deleted_at IS NULL`, id); err != nil {
+			return errors.DatabaseError("delete user posts", err)
+		}
+	case models.UserPostPolicyAnonymize:
+		// The posts are handed to DeletedUserID when the user is purged
+	case models.UserPostPolicyBlock:
+		var hasPosts bool
+		err := tx.QueryRow(ctx, `SELECT EXISTS(SELECT 1 FROM posts WHERE user_id = $1 AND deleted_at IS NULL)`, id).Scan(&hasPosts)
+		if err != nil {
+			return errors.DatabaseError("check user posts", err)
+		}
+		if hasPosts {
+			return errors.NewAppError(errors.ErrCodeConflict, "User still has posts").
+				WithDetails("Delete your posts before deleting your account")
+		}
+	default:
+		return errors.InternalError(fmt.Sprintf("Unknown user post policy %q", policy))
+	}
+
+	if _, err := tx.Exec(ctx, `UPDATE users SET deleted_at = NOW() WHERE id = $1`, id); err != nil {
+		return errors.DatabaseError("delete user", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return errors.DatabaseError("commit transaction", err)
+	}
+
+	return nil
+}
+
+func (r *userRepository) Restore(ctx context.Context, id uuid.UUID) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return errors.DatabaseError("begin transaction", err)
+	}
+	defer tx.Rollback(ctx)
+
+	var deletedAt time.Time
+	err = tx.QueryRow(ctx, `SELECT deleted_at FROM users WHERE id = $1 AND deleted_at IS NOT NULL FOR UPDATE`, id).Scan(&deletedAt)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return errors.NotFound("User")
+		}
+		return errors.DatabaseError("lock user", err)
+	}
 
-	rows, err := r.db.Query(ctx, query)
+	if _, err := tx.Exec(ctx, `UPDATE posts SET deleted_at = NULL WHERE user_id = $1 AND deleted_at = $2`, id, deletedAt); err != nil {
+		return errors.DatabaseError("restore user posts", err)
+	}
+	if _, err := tx.Exec(ctx, `UPDATE users SET deleted_at = NULL WHERE id = $1`, id); err != nil {
+		return errors.DatabaseError("restore user", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return errors.DatabaseError("commit transaction", err)
+	}
+
+	return nil
+}
+
+func (r *userRepository) Purge(ctx context.Context, before time.Time, policy models.UserPostPolicy) (int64, error) {
+	rows, err := r.db.Query(ctx, `SELECT id FROM users WHERE deleted_at < $1`, before)
 	if err != nil {
-		return nil, fmt.Errorf("failed to list users: %w", err)
+		return 0, errors.DatabaseError("list deleted users", err)
 	}
 	defer rows.Close()
 
-	var users []*models.User
+	var ids []uuid.UUID
 	for rows.Next() {
-		var user models.User
-		err := rows.Scan(
-			&user.ID,
-			&user.Username,
-			&user.PasswordHash,
-			&user.CreatedAt,
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return 0, errors.DatabaseError("scan deleted user", err)
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		return 0, errors.DatabaseError("iterate deleted users", err)
+	}
+
+	// Each user is purged in a transaction of its own to keep locks short
+	var purged int64
+	for _, id := range ids {
+		if err := r.purgeUser(ctx, id, before, policy); err != nil {
+			return purged, err
+		}
+		purged++
+	}
+
+	return purged, nil
+}
+
+// purgeUser permanently deletes a user who went to the trash before the
+// given time. Under UserPostPolicyBlock the user had no posts left when
+// deleted, so any posts in the trash are deleted as with cascade.
+func (r *userRepository) purgeUser(ctx context.Context, id uuid.UUID, before time.Time, policy models.UserPostPolicy) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return errors.DatabaseError("begin transaction", err)
+	}
+	defer tx.Rollback(ctx)
+
+	// Lock the user row and make sure nobody restored the user meanwhile
+	var exists bool
+	err = tx.QueryRow(ctx, `SELECT true FROM users WHERE id = $1 AND deleted_at < $2 FOR UPDATE`, id, before).Scan(&exists)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil
+		}
+		return errors.DatabaseError("lock user", err)
+	}
+
+	// Reactions are never kept, so take them out of the posts' counts
+	_, err = tx.Exec(ctx, `
+		WITH removed AS (
+			DELETE FROM post_reactions WHERE user_id = $1 RETURNING post_id
 		)
-		if err != nil {
-			return nil, fmt.Errorf("failed to scan user: %w", err)
+		UPDATE posts SET reaction_count = posts.reaction_count - r.n, activity_at = NOW()
+		FROM (SELECT post_id, COUNT(*) AS n FROM removed GROUP BY post_id) r
+		WHERE posts.id = r.post_id`, id)
+	if err != nil {
+		return errors.DatabaseError("delete user reactions", err)
+	}
+
+	switch policy {
+	case models.UserPostPolicyCascade, models.UserPostPolicyBlock:
+		// The user's comments go with the user, which changes the comment
+		// counts of the posts they were on
+		if _, err := tx.Exec(ctx, `UPDATE posts SET activity_at = NOW() WHERE id IN (SELECT post_id FROM comments WHERE user_id = $1)`, id); err != nil {
+			return errors.DatabaseError("touch commented posts", err)
 		}
-		users = append(users, &user)
+		if _, err := tx.Exec(ctx, `DELETE FROM posts WHERE user_id = $1`, id); err != nil {
+			return errors.DatabaseError("delete user posts", err)
+		}
+	case models.UserPostPolicyAnonymize:
+		if _, err := tx.Exec(ctx, `UPDATE posts SET user_id = $1 WHERE user_id = $2`, models.DeletedUserID, id); err != nil {
+			return errors.DatabaseError("anonymize user posts", err)
+		}
+		if _, err := tx.Exec(ctx, `UPDATE comments SET user_id = $1 WHERE user_id = $2`, models.DeletedUserID, id); err != nil {
+			return errors.DatabaseError("anonymize user comments", err)
+		}
+	default:
+		return errors.InternalError(fmt.Sprintf("Unknown user post policy %q", policy))
 	}
 
-	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("error iterating users: %w", err)
+	if _, err := tx.Exec(ctx, `DELETE FROM users WHERE id = $1`, id); err != nil {
+		return errors.DatabaseError("purge user", err)
 	}
 
-	return users, nil
+	if err := tx.Commit(ctx); err != nil {
+		return errors.DatabaseError("commit transaction", err)
+	}
+
+	return nil
 }
 
-func (r *userRepository) ListPaginated(ctx context.Context, pagination *models.PaginationParams) ([]*models.User, int64, error) {
-	// First, get the total count
-	countQuery := `SELECT COUNT(*) FROM users`
-	var total int64
-	err := r.db.QueryRow(ctx, countQuery).Scan(&total)
+// ListByCursor returns the page of users selected by params, newest first,
+// and whether more users follow in the requested direction
+func (r *userRepository) ListByCursor(ctx context.Context, params *models.CursorParams, opts *models.ListOptions) ([]*models.User, bool, error) {
+	q, err := r.newListQuery(opts)
 	if err != nil {
-		return nil, 0, fmt.Errorf("failed to count users: %w", err)
+		return nil, false, err
 	}
+	q.keyset(params)
 
-	// Then get the paginated results
-	query := `
-		SELECT id, username, password_hash, created_at
+	query := fmt.Sprintf(`
+		SELECT id, username, password_hash, role, created_at
 		FROM users
-		ORDER BY created_at DESC
-		LIMIT $1 OFFSET $2`
+		%s
+		ORDER BY %s
+		LIMIT %s`, q.whereClause(), q.orderBy, q.arg(params.Limit+1))
+
+	users, err := r.queryUsers(ctx, query, q.args...)
+	if err != nil {
+		return nil, false, err
+	}
+
+	users, hasMore := trimKeysetPage(users, params)
+	return users, hasMore, nil
+}
 
-	rows, err := r.db.Query(ctx, query, pagination.PageSize, pagination.Offset)
+func (r *userRepository) Count(ctx context.Context, opts *models.ListOptions) (int64, error) {
+	q, err := r.newListQuery(opts)
 	if err != nil {
-		return nil, 0, fmt.Errorf("failed to list users with pagination: %w", err)
+		return 0, err
+	}
+
+	var total int64
+	if err := r.db.QueryRow(ctx, `SELECT COUNT(*) FROM users `+q.whereClause(), q.args...).Scan(&total); err != nil {
+		return 0, errors.DatabaseError("count users", err)
+	}
+	return total, nil
+}
+
+// newListQuery starts a list query that skips deleted users and the deleted
+// user placeholder
+func (r *userRepository) newListQuery(opts *models.ListOptions) (*listQuery, error) {
+	q := newListQuery()
+	q.where("id <> " + q.arg(models.DeletedUserID))
+	q.where("deleted_at IS NULL")
+	if err := q.apply(opts, userListColumns); err != nil {
+		return nil, err
+	}
+	return q, nil
+}
+
+func (r *userRepository) queryUsers(ctx context.Context, query string, args ...interface{}) ([]*models.User, error) {
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, errors.DatabaseError("list users", err)
 	}
 	defer rows.Close()
 
@@ -151,17 +469,18 @@ func (r *userRepository) ListPaginated(ctx context.Context, pagination *models.P
 			&user.ID,
 			&user.Username,
 			&user.PasswordHash,
+			&user.Role,
 			&user.CreatedAt,
 		)
 		if err != nil {
-			return nil, 0, fmt.Errorf("failed to scan user: %w", err)
+			return nil, errors.DatabaseError("scan user", err)
 		}
 		users = append(users, &user)
 	}
 
 	if err := rows.Err(); err != nil {
-		return nil, 0, fmt.Errorf("error iterating users: %w", err)
+		return nil, errors.DatabaseError("iterate users", err)
 	}
 
-	return users, total, nil
-}
\ No newline at end of file
+	return users, nil
+}
@@ -6,6 +6,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/alinoer/go-std-api/internal/errors"
 	"github.com/alinoer/go-std-api/internal/models"
 	"github.com/alinoer/go-std-api/internal/testutils"
 	"github.com/google/uuid"
@@ -244,7 +245,7 @@ func TestUserRepository_List(t *testing.T) {
 		}
 	}
 
-	users, err := repo.List(context.Background())
+	users, err := repo.List(context.Background(), nil)
 	if err != nil {
 		t.Fatalf("failed to list users: %v", err)
 	}
@@ -325,7 +326,7 @@ func TestUserRepository_ListPaginated(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			users, total, err := repo.ListPaginated(context.Background(), tt.pagination)
+			users, total, err := repo.ListPaginated(context.Background(), tt.pagination, nil)
 			if err != nil {
 				t.Fatalf("failed to list users paginated: %v", err)
 			}
@@ -391,4 +392,192 @@ func BenchmarkUserRepository_GetByID(b *testing.B) {
 	for i := 0; i < b.N; i++ {
 		repo.GetByID(context.Background(), testUser.ID)
 	}
-}
\ No newline at end of file
+}
+
+func TestUserRepository_Update(t *testing.T) {
+	testutils.SkipIfShort(t)
+	testutils.SkipIfNoDatabase(t)
+
+	testDB := testutils.SetupTestDB(t)
+	defer testDB.Cleanup(t)
+
+	repo := NewUserRepository(testDB.DB)
+
+	user := &models.User{ID: uuid.New(), Username: "original", PasswordHash: "hash", CreatedAt: time.Now()}
+	other := &models.User{ID: uuid.New(), Username: "taken", PasswordHash: "hash", CreatedAt: time.Now()}
+	for _, u := range []*models.User{user, other} {
+		if err := repo.Create(context.Background(), u); err != nil {
+			t.Fatalf("failed to create test user: %v", err)
+		}
+	}
+
+	user.Username = "renamed"
+	user.PasswordHash = "newhash"
+	if err := repo.Update(context.Background(), user); err != nil {
+		t.Fatalf("failed to update user: %v", err)
+	}
+
+	stored, err := repo.GetByID(context.Background(), user.ID)
+	if err != nil {
+		t.Fatalf("failed to get updated user: %v", err)
+	}
+	if stored.Username != "renamed" || stored.PasswordHash != "newhash" {
+		t.Errorf("expected updated username and hash, got %q and %q", stored.Username, stored.PasswordHash)
+	}
+
+	user.Username = "taken"
+	err = repo.Update(context.Background(), user)
+	if appErr := errors.AsAppError(err); appErr == nil || appErr.Code != errors.ErrCodeConflict {
+		t.Errorf("expected conflict error, got %v", err)
+	}
+
+	missing := &models.User{ID: uuid.New(), Username: "missing", PasswordHash: "hash"}
+	err = repo.Update(context.Background(), missing)
+	if appErr := errors.AsAppError(err); appErr == nil || appErr.Code != errors.ErrCodeNotFound {
+		t.Errorf("expected not found error, got %v", err)
+	}
+}
+
+func TestUserRepository_Delete(t *testing.T) {
+	testutils.SkipIfShort(t)
+	testutils.SkipIfNoDatabase(t)
+
+	tests := []struct {
+		name          string
+		policy        models.UserPostPolicy
+		expectedCode  errors.ErrorCode
+		postOwner     *uuid.UUID
+	}{
+		{
+			name:   "cascade deletes posts",
+			policy: models.UserPostPolicyCascade,
+		},
+		{
+			name:      "anonymize keeps posts",
+			policy:    models.UserPostPolicyAnonymize,
+			postOwner: &models.DeletedUserID,
+		},
+		{
+			name:          "block keeps user with posts",
+			policy:        models.UserPostPolicyBlock,
+			expectedCode:  errors.ErrCodeConflict,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testDB := testutils.SetupTestDB(t)
+			defer testDB.Cleanup(t)
+
+			userRepo := NewUserRepository(testDB.DB)
+			postRepo := NewPostRepository(testDB.DB)
+
+			user := &models.User{ID: uuid.New(), Username: "leaving", PasswordHash: "hash", CreatedAt: time.Now()}
+			if err := userRepo.Create(context.Background(), user); err != nil {
+				t.Fatalf("failed to create test user: %v", err)
+			}
+			post := &models.Post{ID: uuid.New(), UserID: user.ID, Title: "Title", Content: "Content", CreatedAt: time.Now()}
+			if err := postRepo.Create(context.Background(), post); err != nil {
+				t.Fatalf("failed to create test post: %v", err)
+			}
+
+			err := userRepo.Delete(context.Background(), user.ID, tt.policy)
+			if tt.expectedCode == "" && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tt.expectedCode != "" {
+				if appErr := errors.AsAppError(err); appErr == nil || appErr.Code != tt.expectedCode {
+					t.Fatalf("expected %s error, got %v", tt.expectedCode, err)
+				}
+			}
+
+			_, userErr := userRepo.GetByID(context.Background(), user.ID)
+			if tt.expectedCode != "" {
+				if userErr != nil {
+					t.Error("expected user to be kept")
+				}
+				return
+			}
+			if userErr == nil {
+				t.Error("expected user to be deleted")
+			}
+
+			// The policy is only applied for good when the user is purged
+			purged, err := userRepo.Purge(context.Background(), time.Now().Add(time.Minute), tt.policy)
+			if err != nil {
+				t.Fatalf("unexpected error purging: %v", err)
+			}
+			if purged != 1 {
+				t.Errorf("expected 1 purged user, got %d", purged)
+			}
+
+			stored, postErr := postRepo.GetByID(context.Background(), post.ID)
+			if tt.postOwner == nil {
+				if postErr == nil {
+					t.Error("expected post to be deleted")
+				}
+				return
+			}
+			if postErr != nil {
+				t.Fatalf("expected post to be kept: %v", postErr)
+			}
+			if stored.UserID != *tt.postOwner {
+				t.Errorf("expected post owner %s, got %s", *tt.postOwner, stored.UserID)
+			}
+		})
+	}
+}
+
+func TestUserRepository_Restore(t *testing.T) {
+	testutils.SkipIfShort(t)
+	testutils.SkipIfNoDatabase(t)
+
+	testDB := testutils.SetupTestDB(t)
+	defer testDB.Cleanup(t)
+
+	ctx := context.Background()
+	userRepo := NewUserRepository(testDB.DB)
+	postRepo := NewPostRepository(testDB.DB)
+
+	user := &models.User{ID: uuid.New(), Username: "returning", PasswordHash: "hash", CreatedAt: time.Now()}
+	if err := userRepo.Create(ctx, user); err != nil {
+		t.Fatalf("failed to create test user: %v", err)
+	}
+	trashedEarlier := &models.Post{ID: uuid.New(), UserID: user.ID, Title: "Earlier", Content: "Content", CreatedAt: time.Now()}
+	withUser := &models.Post{ID: uuid.New(), UserID: user.ID, Title: "With user", Content: "Content", CreatedAt: time.Now()}
+	for _, post := range []*models.Post{trashedEarlier, withUser} {
+		if err := postRepo.Create(ctx, post); err != nil {
+			t.Fatalf("failed to create test post: %v", err)
+		}
+	}
+	if err := postRepo.Delete(ctx, trashedEarlier.ID, 0); err != nil {
+		t.Fatalf("failed to delete post: %v", err)
+	}
+
+	if err := userRepo.Delete(ctx, user.ID, models.UserPostPolicyCascade); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := userRepo.GetByUsername(ctx, user.Username); err == nil {
+		t.Error("expected a deleted user to be hidden")
+	}
+	if _, err := postRepo.GetByID(ctx, withUser.ID); err == nil {
+		t.Error("expected the user's posts to be deleted with the user")
+	}
+
+	if err := userRepo.Restore(ctx, user.ID); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := userRepo.GetByUsername(ctx, user.Username); err != nil {
+		t.Errorf("expected the user to be restored: %v", err)
+	}
+	if _, err := postRepo.GetByID(ctx, withUser.ID); err != nil {
+		t.Errorf("expected the post deleted with the user to be restored: %v", err)
+	}
+	if _, err := postRepo.GetByID(ctx, trashedEarlier.ID); err == nil {
+		t.Error("expected the post deleted earlier to stay in the trash")
+	}
+
+	if err := userRepo.Restore(ctx, user.ID); err == nil {
+		t.Error("expected restoring a user outside the trash to fail")
+	}
+}
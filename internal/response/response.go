@@ -90,6 +90,12 @@ func (rw *ResponseWriter) NoContent() error {
 	return nil
 }
 
+// NotModified writes a 304 Not Modified response
+func (rw *ResponseWriter) NotModified() error {
+	rw.w.WriteHeader(http.StatusNotModified)
+	return nil
+}
+
 // Error writes an error response using AppError
 func (rw *ResponseWriter) Error(err error) error {
 	appErr := errors.AsAppError(err)
@@ -0,0 +1,182 @@
+package response
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/alinoer/go-std-api/internal/errors"
+)
+
+func TestResponseWriter_Success(t *testing.T) {
+	tests := []struct {
+		name               string
+		write              func(rw *ResponseWriter)
+		expectedStatusCode int
+		expectedMessage    string
+		expectMeta         bool
+	}{
+		{
+			name:               "success",
+			write:              func(rw *ResponseWriter) { rw.Success(map[string]string{"key": "value"}) },
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:               "created",
+			write:              func(rw *ResponseWriter) { rw.Created(map[string]string{"key": "value"}) },
+			expectedStatusCode: http.StatusCreated,
+			expectedMessage:    "Resource created successfully",
+		},
+		{
+			name: "with meta",
+			write: func(rw *ResponseWriter) {
+				rw.JSONWithMeta(http.StatusOK, []string{"a"}, "", map[string]int{"total": 1})
+			},
+			expectedStatusCode: http.StatusOK,
+			expectMeta:         true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set("X-Request-ID", "req-123")
+			w := httptest.NewRecorder()
+
+			tt.write(NewResponseWriter(w, req))
+
+			if w.Code != tt.expectedStatusCode {
+				t.Errorf("expected status code %d, got %d", tt.expectedStatusCode, w.Code)
+			}
+			if contentType := w.Header().Get("Content-Type"); contentType != "application/json" {
+				t.Errorf("expected Content-Type application/json, got %s", contentType)
+			}
+
+			var resp StandardResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("failed to unmarshal response: %v", err)
+			}
+			if !resp.Success {
+				t.Error("expected success to be true")
+			}
+			if resp.Data == nil {
+				t.Error("expected non-nil data")
+			}
+			if resp.Message != tt.expectedMessage {
+				t.Errorf("expected message %q, got %q", tt.expectedMessage, resp.Message)
+			}
+			if tt.expectMeta && resp.Meta == nil {
+				t.Error("expected non-nil meta")
+			}
+			if resp.RequestID != "req-123" {
+				t.Errorf("expected request ID %q, got %q", "req-123", resp.RequestID)
+			}
+		})
+	}
+}
+
+func TestResponseWriter_Error(t *testing.T) {
+	validationErrors := &errors.ValidationErrors{}
+	validationErrors.Add("title", "Title is required")
+
+	tests := []struct {
+		name               string
+		write              func(rw *ResponseWriter)
+		expectedStatusCode int
+		expectedCode       errors.ErrorCode
+		expectedError      string
+	}{
+		{
+			name:               "not found",
+			write:              func(rw *ResponseWriter) { rw.Error(errors.NotFound("Post")) },
+			expectedStatusCode: http.StatusNotFound,
+			expectedCode:       errors.ErrCodeNotFound,
+			expectedError:      "Post not found",
+		},
+		{
+			name:               "conflict",
+			write:              func(rw *ResponseWriter) { rw.Error(errors.Conflict("Username", "choose a different username")) },
+			expectedStatusCode: http.StatusConflict,
+			expectedCode:       errors.ErrCodeConflict,
+			expectedError:      "Username already exists",
+		},
+		{
+			name:               "database error",
+			write:              func(rw *ResponseWriter) { rw.Error(errors.DatabaseError("get post", fmt.Errorf("connection refused"))) },
+			expectedStatusCode: http.StatusInternalServerError,
+			expectedCode:       errors.ErrCodeDatabaseError,
+			expectedError:      "Database operation failed: get post",
+		},
+		{
+			name:               "untyped error",
+			write:              func(rw *ResponseWriter) { rw.Error(fmt.Errorf("boom")) },
+			expectedStatusCode: http.StatusInternalServerError,
+			expectedCode:       errors.ErrCodeInternal,
+			expectedError:      "An unexpected error occurred",
+		},
+		{
+			name:               "validation errors",
+			write:              func(rw *ResponseWriter) { rw.ValidationError(validationErrors) },
+			expectedStatusCode: http.StatusBadRequest,
+			expectedCode:       errors.ErrCodeValidation,
+			expectedError:      "Multiple validation errors",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			w := httptest.NewRecorder()
+
+			tt.write(NewResponseWriter(w, req))
+
+			if w.Code != tt.expectedStatusCode {
+				t.Errorf("expected status code %d, got %d", tt.expectedStatusCode, w.Code)
+			}
+
+			var resp ErrorResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("failed to unmarshal response: %v", err)
+			}
+			if resp.Success {
+				t.Error("expected success to be false")
+			}
+			if resp.Code != string(tt.expectedCode) {
+				t.Errorf("expected code %q, got %q", tt.expectedCode, resp.Code)
+			}
+			if resp.Error != tt.expectedError {
+				t.Errorf("expected error %q, got %q", tt.expectedError, resp.Error)
+			}
+		})
+	}
+}
+
+func TestResponseWriter_NoContent(t *testing.T) {
+	req := httptest.NewRequest(http.MethodDelete, "/", nil)
+	w := httptest.NewRecorder()
+
+	NewResponseWriter(w, req).NoContent()
+
+	if w.Code != http.StatusNoContent {
+		t.Errorf("expected status code %d, got %d", http.StatusNoContent, w.Code)
+	}
+	if w.Body.Len() != 0 {
+		t.Errorf("expected empty body, got %q", w.Body.String())
+	}
+}
+
+func TestResponseWriter_NotModified(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	w := httptest.NewRecorder()
+
+	NewResponseWriter(w, req).NotModified()
+
+	if w.Code != http.StatusNotModified {
+		t.Errorf("expected status code %d, got %d", http.StatusNotModified, w.Code)
+	}
+	if w.Body.Len() != 0 {
+		t.Errorf("expected empty body, got %q", w.Body.String())
+	}
+}
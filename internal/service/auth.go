@@ -1,60 +1,91 @@
 package service
 
 import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"fmt"
 	"time"
 
+	"github.com/alinoer/go-std-api/internal/errors"
+	"github.com/alinoer/go-std-api/internal/logger"
 	"github.com/alinoer/go-std-api/internal/models"
+	"github.com/alinoer/go-std-api/internal/repository"
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
 )
 
+const refreshTokenBytes = 32
+
 type AuthService struct {
-	config models.TokenConfig
+	config           models.TokenConfig
+	keys             *KeySet
+	userRepo         repository.UserRepository
+	refreshTokenRepo repository.RefreshTokenRepository
+	revocationStore  repository.TokenRevocationStore
+}
+
+// DefaultTokenConfig returns the token configuration used by NewAuthService
+func DefaultTokenConfig(secretKey string) models.TokenConfig {
+	return models.TokenConfig{
+		SecretKey:        secretKey,
+		ExpiresIn:        24 * time.Hour,      // 24 hours
+		RefreshExpiresIn: 30 * 24 * time.Hour, // 30 days
+		Issuer:           "go-std-api",
+	}
+}
+
+// NewAuthService returns an AuthService that signs tokens with HS256
+func NewAuthService(secretKey string, userRepo repository.UserRepository, refreshTokenRepo repository.RefreshTokenRepository, revocationStore repository.TokenRevocationStore) *AuthService {
+	return NewAuthServiceWithConfig(DefaultTokenConfig(secretKey), NewHMACKeySet(secretKey), userRepo, refreshTokenRepo, revocationStore)
 }
 
-func NewAuthService(secretKey string) *AuthService {
+func NewAuthServiceWithConfig(config models.TokenConfig, keys *KeySet, userRepo repository.UserRepository, refreshTokenRepo repository.RefreshTokenRepository, revocationStore repository.TokenRevocationStore) *AuthService {
 	return &AuthService{
-		config: models.TokenConfig{
-			SecretKey: secretKey,
-			ExpiresIn: 24 * time.Hour, // 24 hours
-			Issuer:    "go-std-api",
-		},
+		config:           config,
+		keys:             keys,
+		userRepo:         userRepo,
+		refreshTokenRepo: refreshTokenRepo,
+		revocationStore:  revocationStore,
 	}
 }
 
-func (s *AuthService) GenerateToken(userID uuid.UUID, username string) (string, int64, error) {
+func (s *AuthService) GenerateToken(ctx context.Context, user *models.User) (string, int64, error) {
+	generation, err := s.revocationStore.GetTokenGeneration(ctx, user.ID)
+	if err != nil {
+		return "", 0, err
+	}
+
 	now := time.Now()
 	expirationTime := now.Add(s.config.ExpiresIn)
 
 	claims := models.JWTClaims{
-		UserID:   userID,
-		Username: username,
+		UserID:     user.ID,
+		Username:   user.Username,
+		Role:       user.Role,
+		Generation: generation,
 		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.NewString(),
 			ExpiresAt: jwt.NewNumericDate(expirationTime),
 			IssuedAt:  jwt.NewNumericDate(now),
 			NotBefore: jwt.NewNumericDate(now),
 			Issuer:    s.config.Issuer,
-			Subject:   userID.String(),
+			Subject:   user.ID.String(),
 		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	tokenString, err := token.SignedString([]byte(s.config.SecretKey))
+	tokenString, err := s.keys.sign(claims)
 	if err != nil {
-		return "", 0, fmt.Errorf("failed to generate token: %w", err)
+		return "", 0, errors.InternalError("Failed to generate token").WithInternal(err)
 	}
 
 	return tokenString, int64(s.config.ExpiresIn.Seconds()), nil
 }
 
 func (s *AuthService) ValidateToken(tokenString string) (*models.JWTClaims, error) {
-	token, err := jwt.ParseWithClaims(tokenString, &models.JWTClaims{}, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-		}
-		return []byte(s.config.SecretKey), nil
-	})
+	token, err := jwt.ParseWithClaims(tokenString, &models.JWTClaims{}, s.keys.keyFunc)
 
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse token: %w", err)
@@ -67,16 +98,197 @@ func (s *AuthService) ValidateToken(tokenString string) (*models.JWTClaims, erro
 	return nil, fmt.Errorf("invalid token")
 }
 
-func (s *AuthService) RefreshToken(oldTokenString string) (string, int64, error) {
-	claims, err := s.ValidateToken(oldTokenString)
+// JWKS returns the public keys that verify access tokens
+func (s *AuthService) JWKS() models.JWKS {
+	return s.keys.JWKS()
+}
+
+// CheckRevocation rejects claims whose token was revoked individually or was
+// minted before the user's tokens were last revoked
+func (s *AuthService) CheckRevocation(ctx context.Context, claims *models.JWTClaims) error {
+	if claims.ID != "" {
+		revoked, err := s.revocationStore.IsTokenRevoked(ctx, claims.ID)
+		if err != nil {
+			return err
+		}
+		if revoked {
+			return errors.Unauthorized("Token has been revoked")
+		}
+	}
+
+	generation, err := s.revocationStore.GetTokenGeneration(ctx, claims.UserID)
+	if err != nil {
+		return err
+	}
+	if claims.Generation < generation {
+		return errors.Unauthorized("Token has been revoked")
+	}
+
+	return nil
+}
+
+// RevokeAccessToken revokes a single access token until it expires
+func (s *AuthService) RevokeAccessToken(ctx context.Context, tokenString string) error {
+	claims, err := s.ValidateToken(tokenString)
+	if err != nil {
+		return errors.Unauthorized("Invalid or expired token")
+	}
+
+	if claims.ID == "" || claims.ExpiresAt == nil {
+		return errors.Unauthorized("Token cannot be revoked")
+	}
+
+	if err := s.revocationStore.RevokeToken(ctx, claims.ID, claims.ExpiresAt.Time); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// RevokeUserTokens signs the user out everywhere: every access token issued so
+// far stops being accepted and every refresh token is revoked
+func (s *AuthService) RevokeUserTokens(ctx context.Context, userID uuid.UUID) error {
+	if _, err := s.userRepo.GetByID(ctx, userID); err != nil {
+		return err
+	}
+
+	return s.RevokeAllTokens(ctx, userID)
+}
+
+// RevokeAllTokens revokes every access and refresh token of a user without
+// looking the user up, so that it also signs out accounts in the trash
+func (s *AuthService) RevokeAllTokens(ctx context.Context, userID uuid.UUID) error {
+	if _, err := s.revocationStore.IncrementTokenGeneration(ctx, userID); err != nil {
+		return err
+	}
+
+	if err := s.refreshTokenRepo.RevokeAllForUser(ctx, userID); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// IssueTokens creates an access token and a refresh token that starts a new
+// refresh token family for the user
+func (s *AuthService) IssueTokens(ctx context.Context, user *models.User) (*models.TokenPair, error) {
+	refreshToken, record, err := s.newRefreshToken(user.ID, uuid.New())
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.refreshTokenRepo.Create(ctx, record); err != nil {
+		return nil, err
+	}
+
+	return s.buildTokenPair(ctx, user, refreshToken)
+}
+
+// RefreshToken exchanges a refresh token for a new token pair. The presented
+// token is revoked and replaced; presenting an already revoked token revokes
+// every token in its family, since it means the token was stolen or replayed.
+func (s *AuthService) RefreshToken(ctx context.Context, refreshToken string) (*models.TokenPair, error) {
+	current, err := s.refreshTokenRepo.GetByTokenHash(ctx, hashRefreshToken(refreshToken))
+	if err != nil {
+		return nil, errors.Unauthorized("Invalid refresh token")
+	}
+
+	if current.RevokedAt != nil {
+		return nil, s.handleRefreshTokenReuse(ctx, current)
+	}
+
+	if time.Now().After(current.ExpiresAt) {
+		return nil, errors.Unauthorized("Refresh token has expired")
+	}
+
+	user, err := s.userRepo.GetByID(ctx, current.UserID)
+	if err != nil {
+		return nil, errors.Unauthorized("Invalid refresh token")
+	}
+
+	nextToken, next, err := s.newRefreshToken(current.UserID, current.FamilyID)
+	if err != nil {
+		return nil, err
+	}
+
+	rotated, err := s.refreshTokenRepo.Rotate(ctx, current.ID, next)
+	if err != nil {
+		return nil, err
+	}
+	if !rotated {
+		// Another request rotated this token first
+		return nil, s.handleRefreshTokenReuse(ctx, current)
+	}
+
+	return s.buildTokenPair(ctx, user, nextToken)
+}
+
+// RevokeRefreshToken revokes the refresh token family the token belongs to,
+// ending the login session it was issued for
+func (s *AuthService) RevokeRefreshToken(ctx context.Context, refreshToken string) error {
+	current, err := s.refreshTokenRepo.GetByTokenHash(ctx, hashRefreshToken(refreshToken))
+	if err != nil {
+		return errors.Unauthorized("Invalid refresh token")
+	}
+
+	if err := s.refreshTokenRepo.RevokeFamily(ctx, current.FamilyID); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *AuthService) handleRefreshTokenReuse(ctx context.Context, token *models.RefreshToken) error {
+	logger.GetLogger().WithContext(ctx).Warn("Refresh token reuse detected",
+		"user_id", token.UserID,
+		"family_id", token.FamilyID,
+	)
+
+	if err := s.refreshTokenRepo.RevokeFamily(ctx, token.FamilyID); err != nil {
+		return err
+	}
+
+	return errors.Unauthorized("Refresh token has been revoked")
+}
+
+func (s *AuthService) buildTokenPair(ctx context.Context, user *models.User, refreshToken string) (*models.TokenPair, error) {
+	accessToken, expiresIn, err := s.GenerateToken(ctx, user)
 	if err != nil {
-		return "", 0, fmt.Errorf("invalid token for refresh: %w", err)
+		return nil, err
 	}
 
-	// Check if token is close to expiration (within 1 hour)
-	if time.Until(claims.ExpiresAt.Time) > time.Hour {
-		return "", 0, fmt.Errorf("token is still valid, refresh not needed")
+	return &models.TokenPair{
+		AccessToken:      accessToken,
+		ExpiresIn:        expiresIn,
+		RefreshToken:     refreshToken,
+		RefreshExpiresIn: int64(s.config.RefreshExpiresIn.Seconds()),
+	}, nil
+}
+
+// newRefreshToken generates an opaque refresh token and the record to store for it
+func (s *AuthService) newRefreshToken(userID, familyID uuid.UUID) (string, *models.RefreshToken, error) {
+	buf := make([]byte, refreshTokenBytes)
+	if _, err := rand.Read(buf); err != nil {
+		return "", nil, errors.InternalError("Failed to generate refresh token").WithInternal(err)
 	}
+	token := base64.RawURLEncoding.EncodeToString(buf)
 
-	return s.GenerateToken(claims.UserID, claims.Username)
-}
\ No newline at end of file
+	now := time.Now()
+	record := &models.RefreshToken{
+		ID:        uuid.New(),
+		UserID:    userID,
+		FamilyID:  familyID,
+		TokenHash: hashRefreshToken(token),
+		ExpiresAt: now.Add(s.config.RefreshExpiresIn),
+		CreatedAt: now,
+	}
+
+	return token, record, nil
+}
+
+// hashRefreshToken hashes a refresh token for storage. Refresh tokens carry
+// 256 bits of entropy, so an unsalted fast hash is sufficient.
+func hashRefreshToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,395 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alinoer/go-std-api/internal/errors"
+	"github.com/alinoer/go-std-api/internal/models"
+	"github.com/alinoer/go-std-api/internal/repository"
+	"github.com/google/uuid"
+)
+
+// MockRefreshTokenRepository is an in-memory RefreshTokenRepository
+type MockRefreshTokenRepository struct {
+	tokens            map[uuid.UUID]*models.RefreshToken
+	createError       error
+	rotateError       error
+	revokeFamilyCalls []uuid.UUID
+}
+
+func NewMockRefreshTokenRepository() *MockRefreshTokenRepository {
+	return &MockRefreshTokenRepository{
+		tokens: make(map[uuid.UUID]*models.RefreshToken),
+	}
+}
+
+func (m *MockRefreshTokenRepository) Create(ctx context.Context, token *models.RefreshToken) error {
+	if m.createError != nil {
+		return m.createError
+	}
+	m.tokens[token.ID] = token
+	return nil
+}
+
+func (m *MockRefreshTokenRepository) GetByTokenHash(ctx context.Context, tokenHash string) (*models.RefreshToken, error) {
+	for _, token := range m.tokens {
+		if token.TokenHash == tokenHash {
+			copied := *token
+			return &copied, nil
+		}
+	}
+	return nil, errors.NotFound("Refresh token")
+}
+
+func (m *MockRefreshTokenRepository) Rotate(ctx context.Context, oldID uuid.UUID, next *models.RefreshToken) (bool, error) {
+	if m.rotateError != nil {
+		return false, m.rotateError
+	}
+	old, exists := m.tokens[oldID]
+	if !exists || old.RevokedAt != nil {
+		return false, nil
+	}
+	now := time.Now()
+	old.RevokedAt = &now
+	old.ReplacedBy = &next.ID
+	m.tokens[next.ID] = next
+	return true, nil
+}
+
+func (m *MockRefreshTokenRepository) RevokeFamily(ctx context.Context, familyID uuid.UUID) error {
+	m.revokeFamilyCalls = append(m.revokeFamilyCalls, familyID)
+	now := time.Now()
+	for _, token := range m.tokens {
+		if token.FamilyID == familyID && token.RevokedAt == nil {
+			token.RevokedAt = &now
+		}
+	}
+	return nil
+}
+
+func (m *MockRefreshTokenRepository) RevokeAllForUser(ctx context.Context, userID uuid.UUID) error {
+	now := time.Now()
+	for _, token := range m.tokens {
+		if token.UserID == userID && token.RevokedAt == nil {
+			token.RevokedAt = &now
+		}
+	}
+	return nil
+}
+
+func (m *MockRefreshTokenRepository) activeTokensInFamily(familyID uuid.UUID) int {
+	count := 0
+	for _, token := range m.tokens {
+		if token.FamilyID == familyID && token.RevokedAt == nil {
+			count++
+		}
+	}
+	return count
+}
+
+func newTestAuthService(t *testing.T) (*AuthService, *MockUserRepository, *MockRefreshTokenRepository, *models.User) {
+	userRepo := NewMockUserRepository()
+	refreshRepo := NewMockRefreshTokenRepository()
+	user := &models.User{ID: uuid.New(), Username: "testuser", Role: models.RoleModerator}
+	userRepo.AddUser(user)
+	revocationStore := repository.NewMemoryTokenRevocationStore()
+	return NewAuthService("test-secret-key", userRepo, refreshRepo, revocationStore), userRepo, refreshRepo, user
+}
+
+func TestAuthService_GenerateAndValidateToken(t *testing.T) {
+	authService, _, _, user := newTestAuthService(t)
+
+	token, expiresIn, err := authService.GenerateToken(context.Background(), user)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if expiresIn != int64((24 * time.Hour).Seconds()) {
+		t.Errorf("expected expiresIn of 24h, got %d", expiresIn)
+	}
+
+	claims, err := authService.ValidateToken(token)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if claims.UserID != user.ID {
+		t.Errorf("expected user ID %s, got %s", user.ID, claims.UserID)
+	}
+	if claims.ID == "" {
+		t.Error("expected token to carry a jti claim")
+	}
+	if claims.Role != user.Role {
+		t.Errorf("expected role %q, got %q", user.Role, claims.Role)
+	}
+
+	other := NewAuthService("other-secret-key", nil, nil, nil)
+	if _, err := other.ValidateToken(token); err == nil {
+		t.Error("expected token signed with another key to be rejected")
+	}
+}
+
+func TestAuthService_IssueTokens(t *testing.T) {
+	authService, _, refreshRepo, user := newTestAuthService(t)
+
+	tokens, err := authService.IssueTokens(context.Background(), user)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if tokens.AccessToken == "" || tokens.RefreshToken == "" {
+		t.Fatal("expected access and refresh tokens")
+	}
+	if tokens.RefreshExpiresIn != int64((30 * 24 * time.Hour).Seconds()) {
+		t.Errorf("expected refresh expiry of 30 days, got %d", tokens.RefreshExpiresIn)
+	}
+
+	stored, err := refreshRepo.GetByTokenHash(context.Background(), hashRefreshToken(tokens.RefreshToken))
+	if err != nil {
+		t.Fatalf("expected refresh token to be stored: %v", err)
+	}
+	if stored.TokenHash == tokens.RefreshToken {
+		t.Error("expected refresh token to be stored hashed")
+	}
+	if stored.UserID != user.ID {
+		t.Errorf("expected stored user ID %s, got %s", user.ID, stored.UserID)
+	}
+}
+
+func TestAuthService_RefreshToken(t *testing.T) {
+	tests := []struct {
+		name               string
+		setup              func(t *testing.T, s *AuthService, repo *MockRefreshTokenRepository, user *models.User) string
+		expectedError      string
+		expectFamilyRevoke bool
+	}{
+		{
+			name: "successful rotation",
+			setup: func(t *testing.T, s *AuthService, repo *MockRefreshTokenRepository, user *models.User) string {
+				tokens, err := s.IssueTokens(context.Background(), user)
+				if err != nil {
+					t.Fatalf("failed to issue tokens: %v", err)
+				}
+				return tokens.RefreshToken
+			},
+		},
+		{
+			name: "unknown token",
+			setup: func(t *testing.T, s *AuthService, repo *MockRefreshTokenRepository, user *models.User) string {
+				return "not-a-real-token"
+			},
+			expectedError: "UNAUTHORIZED: Invalid refresh token",
+		},
+		{
+			name: "expired token",
+			setup: func(t *testing.T, s *AuthService, repo *MockRefreshTokenRepository, user *models.User) string {
+				tokens, err := s.IssueTokens(context.Background(), user)
+				if err != nil {
+					t.Fatalf("failed to issue tokens: %v", err)
+				}
+				for _, token := range repo.tokens {
+					token.ExpiresAt = time.Now().Add(-time.Minute)
+				}
+				return tokens.RefreshToken
+			},
+			expectedError: "UNAUTHORIZED: Refresh token has expired",
+		},
+		{
+			name: "reused token revokes family",
+			setup: func(t *testing.T, s *AuthService, repo *MockRefreshTokenRepository, user *models.User) string {
+				tokens, err := s.IssueTokens(context.Background(), user)
+				if err != nil {
+					t.Fatalf("failed to issue tokens: %v", err)
+				}
+				if _, err := s.RefreshToken(context.Background(), tokens.RefreshToken); err != nil {
+					t.Fatalf("failed to rotate token: %v", err)
+				}
+				return tokens.RefreshToken
+			},
+			expectedError:      "UNAUTHORIZED: Refresh token has been revoked",
+			expectFamilyRevoke: true,
+		},
+		{
+			name: "user no longer exists",
+			setup: func(t *testing.T, s *AuthService, repo *MockRefreshTokenRepository, user *models.User) string {
+				ghost := &models.User{ID: uuid.New(), Username: "ghost"}
+				tokens, err := s.IssueTokens(context.Background(), ghost)
+				if err != nil {
+					t.Fatalf("failed to issue tokens: %v", err)
+				}
+				return tokens.RefreshToken
+			},
+			expectedError: "UNAUTHORIZED: Invalid refresh token",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			authService, _, refreshRepo, user := newTestAuthService(t)
+			refreshToken := tt.setup(t, authService, refreshRepo, user)
+
+			tokens, err := authService.RefreshToken(context.Background(), refreshToken)
+
+			if tt.expectedError != "" {
+				if err == nil {
+					t.Fatalf("expected error %q, got nil", tt.expectedError)
+				}
+				if err.Error() != tt.expectedError {
+					t.Errorf("expected error %q, got %q", tt.expectedError, err.Error())
+				}
+				if errors.AsAppError(err).Code != errors.ErrCodeUnauthorized {
+					t.Errorf("expected unauthorized error code, got %s", errors.AsAppError(err).Code)
+				}
+				if tt.expectFamilyRevoke {
+					if len(refreshRepo.revokeFamilyCalls) != 1 {
+						t.Fatalf("expected family to be revoked once, got %d calls", len(refreshRepo.revokeFamilyCalls))
+					}
+					if active := refreshRepo.activeTokensInFamily(refreshRepo.revokeFamilyCalls[0]); active != 0 {
+						t.Errorf("expected no active tokens in family, got %d", active)
+					}
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tokens.RefreshToken == refreshToken {
+				t.Error("expected refresh token to be rotated")
+			}
+
+			old, _ := refreshRepo.GetByTokenHash(context.Background(), hashRefreshToken(refreshToken))
+			next, err := refreshRepo.GetByTokenHash(context.Background(), hashRefreshToken(tokens.RefreshToken))
+			if err != nil {
+				t.Fatalf("expected new refresh token to be stored: %v", err)
+			}
+			if old.RevokedAt == nil {
+				t.Error("expected old refresh token to be revoked")
+			}
+			if old.ReplacedBy == nil || *old.ReplacedBy != next.ID {
+				t.Error("expected old refresh token to point at its replacement")
+			}
+			if old.FamilyID != next.FamilyID {
+				t.Error("expected rotated token to stay in the same family")
+			}
+		})
+	}
+}
+
+func TestAuthService_RevokeRefreshToken(t *testing.T) {
+	authService, _, refreshRepo, user := newTestAuthService(t)
+
+	tokens, err := authService.IssueTokens(context.Background(), user)
+	if err != nil {
+		t.Fatalf("failed to issue tokens: %v", err)
+	}
+
+	if err := authService.RevokeRefreshToken(context.Background(), tokens.RefreshToken); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, err := authService.RefreshToken(context.Background(), tokens.RefreshToken); err == nil {
+		t.Error("expected revoked refresh token to be rejected")
+	}
+
+	if err := authService.RevokeRefreshToken(context.Background(), "unknown"); err == nil {
+		t.Error("expected unknown refresh token to be rejected")
+	}
+
+	if len(refreshRepo.revokeFamilyCalls) == 0 {
+		t.Error("expected refresh token family to be revoked")
+	}
+}
+
+func TestAuthService_RevokeAccessToken(t *testing.T) {
+	authService, _, _, user := newTestAuthService(t)
+	ctx := context.Background()
+
+	token, _, err := authService.GenerateToken(ctx, user)
+	if err != nil {
+		t.Fatalf("failed to generate token: %v", err)
+	}
+	other, _, err := authService.GenerateToken(ctx, user)
+	if err != nil {
+		t.Fatalf("failed to generate token: %v", err)
+	}
+
+	if err := authService.RevokeAccessToken(ctx, token); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	claims, _ := authService.ValidateToken(token)
+	if err := authService.CheckRevocation(ctx, claims); err == nil || err.Error() != "UNAUTHORIZED: Token has been revoked" {
+		t.Errorf("expected revoked token to be rejected, got %v", err)
+	}
+
+	otherClaims, _ := authService.ValidateToken(other)
+	if err := authService.CheckRevocation(ctx, otherClaims); err != nil {
+		t.Errorf("expected other token to remain valid, got %v", err)
+	}
+
+	if err := authService.RevokeAccessToken(ctx, "not-a-token"); err == nil {
+		t.Error("expected invalid token to be rejected")
+	}
+}
+
+func TestAuthService_RevokeUserTokens(t *testing.T) {
+	authService, userRepo, refreshRepo, user := newTestAuthService(t)
+	ctx := context.Background()
+
+	otherUser := &models.User{ID: uuid.New(), Username: "otheruser"}
+	userRepo.AddUser(otherUser)
+
+	tokens, err := authService.IssueTokens(ctx, user)
+	if err != nil {
+		t.Fatalf("failed to issue tokens: %v", err)
+	}
+	otherTokens, err := authService.IssueTokens(ctx, otherUser)
+	if err != nil {
+		t.Fatalf("failed to issue tokens: %v", err)
+	}
+
+	if err := authService.RevokeUserTokens(ctx, user.ID); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	claims, _ := authService.ValidateToken(tokens.AccessToken)
+	if err := authService.CheckRevocation(ctx, claims); err == nil {
+		t.Error("expected access token issued before revocation to be rejected")
+	}
+
+	stored, _ := refreshRepo.GetByTokenHash(ctx, hashRefreshToken(tokens.RefreshToken))
+	if stored.RevokedAt == nil {
+		t.Error("expected refresh token to be revoked")
+	}
+
+	otherClaims, _ := authService.ValidateToken(otherTokens.AccessToken)
+	if err := authService.CheckRevocation(ctx, otherClaims); err != nil {
+		t.Errorf("expected other user's token to remain valid, got %v", err)
+	}
+
+	fresh, _, err := authService.GenerateToken(ctx, user)
+	if err != nil {
+		t.Fatalf("failed to generate token: %v", err)
+	}
+	freshClaims, _ := authService.ValidateToken(fresh)
+	if err := authService.CheckRevocation(ctx, freshClaims); err != nil {
+		t.Errorf("expected token issued after revocation to be valid, got %v", err)
+	}
+
+	err = authService.RevokeUserTokens(ctx, uuid.New())
+	if err == nil || errors.AsAppError(err).Code != errors.ErrCodeNotFound {
+		t.Errorf("expected not found error for unknown user, got %v", err)
+	}
+
+	// Accounts in the trash are no longer found but can still be signed out
+	if err := userRepo.Delete(ctx, otherUser.ID, models.UserPostPolicyAnonymize); err != nil {
+		t.Fatalf("failed to delete user: %v", err)
+	}
+	if err := authService.RevokeAllTokens(ctx, otherUser.ID); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := authService.CheckRevocation(ctx, otherClaims); err == nil {
+		t.Error("expected the deleted user's access token to be rejected")
+	}
+}
@@ -0,0 +1,84 @@
+package service
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+// breachedPrefixLength is the length of the hash prefixes the corpus is
+// indexed by, as in the Pwned Passwords range API
+const breachedPrefixLength = 5
+
+// BreachedPasswords is a corpus of passwords known from data breaches, kept
+// as SHA-1 hashes indexed by their first five hex digits
+type BreachedPasswords struct {
+	suffixes map[string][]string
+	count    int
+}
+
+// LoadBreachedPasswordsFile reads a corpus file; see LoadBreachedPasswords
+func LoadBreachedPasswordsFile(path string) (*BreachedPasswords, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open breached passwords file: %w", err)
+	}
+	defer file.Close()
+
+	return LoadBreachedPasswords(file)
+}
+
+// LoadBreachedPasswords reads a corpus with the SHA-1 hash of a password on
+// each line, in hex and optionally followed by ":count", as in the Pwned
+// Passwords downloads. Blank lines and lines starting with # are skipped.
+func LoadBreachedPasswords(r io.Reader) (*BreachedPasswords, error) {
+	b := &BreachedPasswords{suffixes: make(map[string][]string)}
+
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		hash, _, _ := strings.Cut(text, ":")
+		hash = strings.ToUpper(hash)
+		if _, err := hex.DecodeString(hash); err != nil || len(hash) != 2*sha1.Size {
+			return nil, fmt.Errorf("invalid SHA-1 hash on line %d of breached passwords", line)
+		}
+
+		prefix := hash[:breachedPrefixLength]
+		b.suffixes[prefix] = append(b.suffixes[prefix], hash[breachedPrefixLength:])
+		b.count++
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read breached passwords: %w", err)
+	}
+
+	for _, suffixes := range b.suffixes {
+		sort.Strings(suffixes)
+	}
+
+	return b, nil
+}
+
+// Contains reports whether password is in the corpus
+func (b *BreachedPasswords) Contains(password string) bool {
+	sum := sha1.Sum([]byte(password))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+
+	suffixes := b.suffixes[hash[:breachedPrefixLength]]
+	suffix := hash[breachedPrefixLength:]
+	i := sort.SearchStrings(suffixes, suffix)
+	return i < len(suffixes) && suffixes[i] == suffix
+}
+
+// Len returns how many hashes the corpus holds
+func (b *BreachedPasswords) Len() int {
+	return b.count
+}
@@ -0,0 +1,170 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/alinoer/go-std-api/internal/errors"
+	"github.com/alinoer/go-std-api/internal/models"
+	"github.com/alinoer/go-std-api/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+const maxCommentLength = 10000
+
+type CommentService interface {
+	CreateComment(ctx context.Context, userID, postID uuid.UUID, req *models.CreateCommentRequest) (*models.Comment, error)
+	ListComments(ctx context.Context, postID uuid.UUID, pagination *models.PaginationParams) (*models.PaginatedResponse, error)
+	UpdateComment(ctx context.Context, userID, id uuid.UUID, req *models.UpdateCommentRequest) (*models.Comment, error)
+	DeleteComment(ctx context.Context, userID, id uuid.UUID) error
+}
+
+type commentService struct {
+	commentRepo repository.CommentRepository
+	postRepo    repository.PostRepository
+}
+
+func NewCommentService(commentRepo repository.CommentRepository, postRepo repository.PostRepository) CommentService {
+	return &commentService{
+		commentRepo: commentRepo,
+		postRepo:    postRepo,
+	}
+}
+
+// CreateComment adds a comment to a post on behalf of userID. A comment with
+// a ParentID is a reply, which must answer a top-level comment on the same post.
+func (s *commentService) CreateComment(ctx context.Context, userID, postID uuid.UUID, req *models.CreateCommentRequest) (*models.Comment, error) {
+	content, err := validateCommentContent(req.Content)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := getVisiblePost(models.ContextWithViewer(ctx, userID), s.postRepo, postID); err != nil {
+		return nil, err
+	}
+
+	if req.ParentID != nil {
+		parent, err := s.commentRepo.GetByID(ctx, *req.ParentID)
+		if err != nil {
+			if errors.AsAppError(err).Code == errors.ErrCodeNotFound {
+				return nil, errors.ValidationError("parent_id", "Parent comment does not exist")
+			}
+			return nil, err
+		}
+		if parent.PostID != postID {
+			return nil, errors.ValidationError("parent_id", "Parent comment belongs to another post")
+		}
+		if parent.ParentID != nil {
+			return nil, errors.ValidationError("parent_id", "Replies cannot be replied to")
+		}
+	}
+
+	now := time.Now()
+	comment := &models.Comment{
+		ID:        uuid.New(),
+		PostID:    postID,
+		UserID:    userID,
+		ParentID:  req.ParentID,
+		Content:   content,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	if err := s.commentRepo.Create(ctx, comment); err != nil {
+		return nil, err
+	}
+
+	return comment, nil
+}
+
+// ListComments returns a page of a post's top-level comments, oldest first,
+// each with all of its replies
+func (s *commentService) ListComments(ctx context.Context, postID uuid.UUID, pagination *models.PaginationParams) (*models.PaginatedResponse, error) {
+	if _, err := getVisiblePost(ctx, s.postRepo, postID); err != nil {
+		return nil, err
+	}
+
+	comments, total, err := s.commentRepo.ListByPostID(ctx, postID, pagination)
+	if err != nil {
+		return nil, err
+	}
+
+	byID := make(map[uuid.UUID]*models.Comment, len(comments))
+	parentIDs := make([]uuid.UUID, 0, len(comments))
+	for _, comment := range comments {
+		byID[comment.ID] = comment
+		parentIDs = append(parentIDs, comment.ID)
+	}
+
+	replies, err := s.commentRepo.ListReplies(ctx, parentIDs)
+	if err != nil {
+		return nil, err
+	}
+	for _, reply := range replies {
+		if parent, ok := byID[*reply.ParentID]; ok {
+			parent.Replies = append(parent.Replies, reply)
+		}
+	}
+
+	return &models.PaginatedResponse{
+		Data:       comments,
+		Pagination: models.NewPaginationMeta(pagination.Page, pagination.PageSize, total),
+	}, nil
+}
+
+// UpdateComment replaces the content of a comment on behalf of userID, who
+// must have written it
+func (s *commentService) UpdateComment(ctx context.Context, userID, id uuid.UUID, req *models.UpdateCommentRequest) (*models.Comment, error) {
+	content, err := validateCommentContent(req.Content)
+	if err != nil {
+		return nil, err
+	}
+
+	comment, err := s.commentRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if comment.UserID != userID {
+		return nil, errors.Forbidden("You can only update your own comments")
+	}
+
+	comment.Content = content
+	comment.UpdatedAt = time.Now()
+
+	if err := s.commentRepo.Update(ctx, id, comment); err != nil {
+		return nil, err
+	}
+
+	return comment, nil
+}
+
+// DeleteComment deletes a comment and its replies on behalf of userID, who
+// must have written the comment
+func (s *commentService) DeleteComment(ctx context.Context, userID, id uuid.UUID) error {
+	comment, err := s.commentRepo.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if comment.UserID != userID {
+		return errors.Forbidden("You can only delete your own comments")
+	}
+
+	return s.commentRepo.Delete(ctx, id)
+}
+
+func validateCommentContent(content string) (string, error) {
+	content = strings.TrimSpace(content)
+	if content == "" {
+		return "", errors.ValidationError("content", "Content is required")
+	}
+	if utf8.RuneCountInString(content) > maxCommentLength {
+		return "", errors.ValidationError("content", fmt.Sprintf("Content must be at most %d characters", maxCommentLength))
+	}
+	return content, nil
+}
@@ -0,0 +1,464 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alinoer/go-std-api/internal/errors"
+	"github.com/alinoer/go-std-api/internal/models"
+	"github.com/google/uuid"
+)
+
+type MockCommentRepository struct {
+	comments         map[uuid.UUID]*models.Comment
+	createError      error
+	listError        error
+	listRepliesError error
+	updateError      error
+	deleteError      error
+	listRepliesCalls int
+}
+
+func NewMockCommentRepository() *MockCommentRepository {
+	return &MockCommentRepository{
+		comments: make(map[uuid.UUID]*models.Comment),
+	}
+}
+
+func (m *MockCommentRepository) AddComment(comment *models.Comment) {
+	m.comments[comment.ID] = comment
+}
+
+func (m *MockCommentRepository) Create(ctx context.Context, comment *models.Comment) error {
+	if m.createError != nil {
+		return m.createError
+	}
+	m.comments[comment.ID] = comment
+	return nil
+}
+
+func (m *MockCommentRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Comment, error) {
+	comment, exists := m.comments[id]
+	if !exists {
+		return nil, errors.NotFound("Comment")
+	}
+	copied := *comment
+	return &copied, nil
+}
+
+func (m *MockCommentRepository) ListByPostID(ctx context.Context, postID uuid.UUID, pagination *models.PaginationParams) ([]*models.Comment, int64, error) {
+	if m.listError != nil {
+		return nil, 0, m.listError
+	}
+	var comments []*models.Comment
+	for _, comment := range m.comments {
+		if comment.PostID == postID && comment.ParentID == nil {
+			copied := *comment
+			comments = append(comments, &copied)
+		}
+	}
+	sortComments(comments)
+	total := int64(len(comments))
+
+	start := pagination.Offset
+	if start > len(comments) {
+		start = len(comments)
+	}
+	end := start + pagination.PageSize
+	if end > len(comments) {
+		end = len(comments)
+	}
+	return comments[start:end], total, nil
+}
+
+func (m *MockCommentRepository) ListReplies(ctx context.Context, parentIDs []uuid.UUID) ([]*models.Comment, error) {
+	m.listRepliesCalls++
+	if m.listRepliesError != nil {
+		return nil, m.listRepliesError
+	}
+	parents := make(map[uuid.UUID]bool, len(parentIDs))
+	for _, id := range parentIDs {
+		parents[id] = true
+	}
+	var replies []*models.Comment
+	for _, comment := range m.comments {
+		if comment.ParentID != nil && parents[*comment.ParentID] {
+			copied := *comment
+			replies = append(replies, &copied)
+		}
+	}
+	sortComments(replies)
+	return replies, nil
+}
+
+func (m *MockCommentRepository) Update(ctx context.Context, id uuid.UUID, comment *models.Comment) error {
+	if m.updateError != nil {
+		return m.updateError
+	}
+	if _, exists := m.comments[id]; !exists {
+		return errors.NotFound("Comment")
+	}
+	m.comments[id] = comment
+	return nil
+}
+
+func (m *MockCommentRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	if m.deleteError != nil {
+		return m.deleteError
+	}
+	if _, exists := m.comments[id]; !exists {
+		return errors.NotFound("Comment")
+	}
+	delete(m.comments, id)
+	for replyID, comment := range m.comments {
+		if comment.ParentID != nil && *comment.ParentID == id {
+			delete(m.comments, replyID)
+		}
+	}
+	return nil
+}
+
+func sortComments(comments []*models.Comment) {
+	sort.Slice(comments, func(i, j int) bool {
+		return comments[i].CreatedAt.Before(comments[j].CreatedAt)
+	})
+}
+
+func TestCommentService_CreateComment(t *testing.T) {
+	userID := uuid.New()
+	post := &models.Post{ID: uuid.New(), UserID: uuid.New(), Title: "Post", CreatedAt: time.Now()}
+	otherPost := &models.Post{ID: uuid.New(), UserID: uuid.New(), Title: "Other", CreatedAt: time.Now()}
+	topLevel := &models.Comment{ID: uuid.New(), PostID: post.ID, UserID: uuid.New(), Content: "Top", CreatedAt: time.Now()}
+	reply := &models.Comment{ID: uuid.New(), PostID: post.ID, UserID: uuid.New(), ParentID: &topLevel.ID, Content: "Reply", CreatedAt: time.Now()}
+	elsewhere := &models.Comment{ID: uuid.New(), PostID: otherPost.ID, UserID: uuid.New(), Content: "Elsewhere", CreatedAt: time.Now()}
+	missingID := uuid.New()
+
+	tests := []struct {
+		name          string
+		postID        uuid.UUID
+		req           *models.CreateCommentRequest
+		createError   error
+		expectedError string
+	}{
+		{
+			name:   "top-level comment",
+			postID: post.ID,
+			req:    &models.CreateCommentRequest{Content: "  Nice post  "},
+		},
+		{
+			name:   "reply",
+			postID: post.ID,
+			req:    &models.CreateCommentRequest{Content: "Agreed", ParentID: &topLevel.ID},
+		},
+		{
+			name:          "empty content",
+			postID:        post.ID,
+			req:           &models.CreateCommentRequest{Content: "   "},
+			expectedError: "VALIDATION_ERROR: Validation failed for field 'content' - Content is required",
+		},
+		{
+			name:          "content too long",
+			postID:        post.ID,
+			req:           &models.CreateCommentRequest{Content: strings.Repeat("a", maxCommentLength+1)},
+			expectedError: fmt.Sprintf("VALIDATION_ERROR: Validation failed for field 'content' - Content must be at most %d characters", maxCommentLength),
+		},
+		{
+			name:   "multibyte content at the limit",
+			postID: post.ID,
+			req:    &models.CreateCommentRequest{Content: strings.Repeat("ж", maxCommentLength)},
+		},
+		{
+			name:          "post not found",
+			postID:        uuid.New(),
+			req:           &models.CreateCommentRequest{Content: "Hello"},
+			expectedError: "NOT_FOUND: Post not found",
+		},
+		{
+			name:          "parent not found",
+			postID:        post.ID,
+			req:           &models.CreateCommentRequest{Content: "Hello", ParentID: &missingID},
+			expectedError: "VALIDATION_ERROR: Validation failed for field 'parent_id' - Parent comment does not exist",
+		},
+		{
+			name:          "parent on another post",
+			postID:        post.ID,
+			req:           &models.CreateCommentRequest{Content: "Hello", ParentID: &elsewhere.ID},
+			expectedError: "VALIDATION_ERROR: Validation failed for field 'parent_id' - Parent comment belongs to another post",
+		},
+		{
+			name:          "reply to a reply",
+			postID:        post.ID,
+			req:           &models.CreateCommentRequest{Content: "Hello", ParentID: &reply.ID},
+			expectedError: "VALIDATION_ERROR: Validation failed for field 'parent_id' - Replies cannot be replied to",
+		},
+		{
+			name:          "repository error",
+			postID:        post.ID,
+			req:           &models.CreateCommentRequest{Content: "Hello"},
+			createError:   errors.DatabaseError("create comment", fmt.Errorf("connection refused")),
+			expectedError: "DATABASE_ERROR: Database operation failed: create comment",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			postRepo := NewMockPostRepository()
+			postRepo.AddPost(post)
+			postRepo.AddPost(otherPost)
+			commentRepo := NewMockCommentRepository()
+			commentRepo.AddComment(topLevel)
+			commentRepo.AddComment(reply)
+			commentRepo.AddComment(elsewhere)
+			commentRepo.createError = tt.createError
+			service := NewCommentService(commentRepo, postRepo)
+
+			comment, err := service.CreateComment(context.Background(), userID, tt.postID, tt.req)
+
+			if tt.expectedError != "" {
+				if err == nil || err.Error() != tt.expectedError {
+					t.Errorf("expected error %q, got %v", tt.expectedError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if comment.UserID != userID || comment.PostID != tt.postID {
+				t.Errorf("expected comment by %s on %s, got %+v", userID, tt.postID, comment)
+			}
+			if comment.Content != strings.TrimSpace(tt.req.Content) {
+				t.Errorf("expected trimmed content, got %q", comment.Content)
+			}
+			if _, exists := commentRepo.comments[comment.ID]; !exists {
+				t.Error("expected comment to be stored")
+			}
+		})
+	}
+}
+
+func TestCommentService_ListComments(t *testing.T) {
+	post := &models.Post{ID: uuid.New(), UserID: uuid.New(), Title: "Post", CreatedAt: time.Now()}
+	base := time.Now().Add(-time.Hour)
+	first := &models.Comment{ID: uuid.New(), PostID: post.ID, Content: "First", CreatedAt: base}
+	second := &models.Comment{ID: uuid.New(), PostID: post.ID, Content: "Second", CreatedAt: base.Add(time.Minute)}
+	firstReply := &models.Comment{ID: uuid.New(), PostID: post.ID, ParentID: &first.ID, Content: "Reply 1", CreatedAt: base.Add(2 * time.Minute)}
+	secondReply := &models.Comment{ID: uuid.New(), PostID: post.ID, ParentID: &first.ID, Content: "Reply 2", CreatedAt: base.Add(3 * time.Minute)}
+
+	tests := []struct {
+		name            string
+		postID          uuid.UUID
+		pagination      *models.PaginationParams
+		listError       error
+		expectedError   string
+		expectedIDs     []uuid.UUID
+		expectedReplies []int
+		expectedTotal   int64
+	}{
+		{
+			name:            "replies grouped under their parents",
+			postID:          post.ID,
+			pagination:      models.NewPaginationParams(1, 10),
+			expectedIDs:     []uuid.UUID{first.ID, second.ID},
+			expectedReplies: []int{2, 0},
+			expectedTotal:   2,
+		},
+		{
+			name:            "second page",
+			postID:          post.ID,
+			pagination:      models.NewPaginationParams(2, 1),
+			expectedIDs:     []uuid.UUID{second.ID},
+			expectedReplies: []int{0},
+			expectedTotal:   2,
+		},
+		{
+			name:          "post not found",
+			postID:        uuid.New(),
+			pagination:    models.NewPaginationParams(1, 10),
+			expectedError: "NOT_FOUND: Post not found",
+		},
+		{
+			name:          "repository error",
+			postID:        post.ID,
+			pagination:    models.NewPaginationParams(1, 10),
+			listError:     errors.DatabaseError("list comments", fmt.Errorf("connection refused")),
+			expectedError: "DATABASE_ERROR: Database operation failed: list comments",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			postRepo := NewMockPostRepository()
+			postRepo.AddPost(post)
+			commentRepo := NewMockCommentRepository()
+			for _, comment := range []*models.Comment{first, second, firstReply, secondReply} {
+				commentRepo.AddComment(comment)
+			}
+			commentRepo.listError = tt.listError
+			service := NewCommentService(commentRepo, postRepo)
+
+			result, err := service.ListComments(context.Background(), tt.postID, tt.pagination)
+
+			if tt.expectedError != "" {
+				if err == nil || err.Error() != tt.expectedError {
+					t.Errorf("expected error %q, got %v", tt.expectedError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			comments := result.Data.([]*models.Comment)
+			if len(comments) != len(tt.expectedIDs) {
+				t.Fatalf("expected %d comments, got %d", len(tt.expectedIDs), len(comments))
+			}
+			for i, comment := range comments {
+				if comment.ID != tt.expectedIDs[i] {
+					t.Errorf("expected comment %d to be %s, got %s", i, tt.expectedIDs[i], comment.ID)
+				}
+				if len(comment.Replies) != tt.expectedReplies[i] {
+					t.Errorf("expected comment %d to have %d replies, got %d", i, tt.expectedReplies[i], len(comment.Replies))
+				}
+			}
+			if *result.Pagination.Total != tt.expectedTotal {
+				t.Errorf("expected total %d, got %d", tt.expectedTotal, *result.Pagination.Total)
+			}
+			if commentRepo.listRepliesCalls != 1 {
+				t.Errorf("expected replies to be loaded with one query, got %d", commentRepo.listRepliesCalls)
+			}
+		})
+	}
+}
+
+func TestCommentService_UpdateComment(t *testing.T) {
+	authorID := uuid.New()
+	comment := &models.Comment{ID: uuid.New(), PostID: uuid.New(), UserID: authorID, Content: "Original", CreatedAt: time.Now()}
+
+	tests := []struct {
+		name          string
+		userID        uuid.UUID
+		commentID     uuid.UUID
+		req           *models.UpdateCommentRequest
+		expectedError string
+	}{
+		{
+			name:      "author edits",
+			userID:    authorID,
+			commentID: comment.ID,
+			req:       &models.UpdateCommentRequest{Content: "Edited"},
+		},
+		{
+			name:          "another user",
+			userID:        uuid.New(),
+			commentID:     comment.ID,
+			req:           &models.UpdateCommentRequest{Content: "Edited"},
+			expectedError: "FORBIDDEN: You can only update your own comments",
+		},
+		{
+			name:          "empty content",
+			userID:        authorID,
+			commentID:     comment.ID,
+			req:           &models.UpdateCommentRequest{},
+			expectedError: "VALIDATION_ERROR: Validation failed for field 'content' - Content is required",
+		},
+		{
+			name:          "comment not found",
+			userID:        authorID,
+			commentID:     uuid.New(),
+			req:           &models.UpdateCommentRequest{Content: "Edited"},
+			expectedError: "NOT_FOUND: Comment not found",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			commentRepo := NewMockCommentRepository()
+			copied := *comment
+			commentRepo.AddComment(&copied)
+			service := NewCommentService(commentRepo, NewMockPostRepository())
+
+			updated, err := service.UpdateComment(context.Background(), tt.userID, tt.commentID, tt.req)
+
+			if tt.expectedError != "" {
+				if err == nil || err.Error() != tt.expectedError {
+					t.Errorf("expected error %q, got %v", tt.expectedError, err)
+				}
+				if commentRepo.comments[comment.ID].Content != comment.Content {
+					t.Error("expected comment to be unchanged")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if updated.Content != tt.req.Content {
+				t.Errorf("expected content %q, got %q", tt.req.Content, updated.Content)
+			}
+			if !updated.UpdatedAt.After(comment.CreatedAt) {
+				t.Error("expected updated_at to move forward")
+			}
+		})
+	}
+}
+
+func TestCommentService_DeleteComment(t *testing.T) {
+	authorID := uuid.New()
+	comment := &models.Comment{ID: uuid.New(), PostID: uuid.New(), UserID: authorID, Content: "Comment", CreatedAt: time.Now()}
+	reply := &models.Comment{ID: uuid.New(), PostID: comment.PostID, UserID: uuid.New(), ParentID: &comment.ID, Content: "Reply", CreatedAt: time.Now()}
+
+	tests := []struct {
+		name          string
+		userID        uuid.UUID
+		commentID     uuid.UUID
+		expectedError string
+	}{
+		{
+			name:      "author deletes",
+			userID:    authorID,
+			commentID: comment.ID,
+		},
+		{
+			name:          "another user",
+			userID:        uuid.New(),
+			commentID:     comment.ID,
+			expectedError: "FORBIDDEN: You can only delete your own comments",
+		},
+		{
+			name:          "comment not found",
+			userID:        authorID,
+			commentID:     uuid.New(),
+			expectedError: "NOT_FOUND: Comment not found",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			commentRepo := NewMockCommentRepository()
+			commentRepo.AddComment(comment)
+			commentRepo.AddComment(reply)
+			service := NewCommentService(commentRepo, NewMockPostRepository())
+
+			err := service.DeleteComment(context.Background(), tt.userID, tt.commentID)
+
+			if tt.expectedError != "" {
+				if err == nil || err.Error() != tt.expectedError {
+					t.Errorf("expected error %q, got %v", tt.expectedError, err)
+				}
+				if len(commentRepo.comments) != 2 {
+					t.Error("expected comments to be kept")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(commentRepo.comments) != 0 {
+				t.Errorf("expected the comment and its reply to be deleted, %d remain", len(commentRepo.comments))
+			}
+		})
+	}
+}
@@ -0,0 +1,220 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+)
+
+const diffContextLines = 3
+
+type diffOp byte
+
+const (
+	diffEqual  diffOp = ' '
+	diffDelete diffOp = '-'
+	diffInsert diffOp = '+'
+)
+
+type diffLine struct {
+	op   diffOp
+	text string
+}
+
+// unifiedDiff returns the line diff of from and to in unified format with
+// three lines of context, or an empty string if they are equal
+func unifiedDiff(fromName, toName, from, to string) string {
+	lines := diffLines(splitLines(from), splitLines(to))
+
+	var b strings.Builder
+	fromLine, toLine := 0, 0
+	for i := 0; i < len(lines); {
+		if lines[i].op == diffEqual {
+			fromLine++
+			toLine++
+			i++
+			continue
+		}
+
+		// Start the hunk with up to diffContextLines lines of context and
+		// extend it until a run of unchanged lines too long to bridge
+		start := i
+		for start > 0 && i-start < diffContextLines {
+			start--
+		}
+		end := i
+		for end < len(lines) {
+			if lines[end].op != diffEqual {
+				end++
+				continue
+			}
+			run := 0
+			for end+run < len(lines) && lines[end+run].op == diffEqual {
+				run++
+			}
+			if end+run == len(lines) || run > 2*diffContextLines {
+				end += min(run, diffContextLines)
+				break
+			}
+			end += run
+		}
+
+		hunkFrom, hunkTo := fromLine-(i-start), toLine-(i-start)
+		var fromCount, toCount int
+		for _, line := range lines[start:end] {
+			if line.op != diffInsert {
+				fromCount++
+			}
+			if line.op != diffDelete {
+				toCount++
+			}
+		}
+
+		if b.Len() == 0 {
+			fmt.Fprintf(&b, "--- %s\n+++ %s\n", fromName, toName)
+		}
+		fmt.Fprintf(&b, "@@ -%s +%s @@\n", hunkRange(hunkFrom, fromCount), hunkRange(hunkTo, toCount))
+		for _, line := range lines[start:end] {
+			b.WriteByte(byte(line.op))
+			b.WriteString(line.text)
+			b.WriteByte('\n')
+		}
+
+		fromLine, toLine = hunkFrom+fromCount, hunkTo+toCount
+		i = end
+	}
+
+	return b.String()
+}
+
+// hunkRange formats the start line and length of a hunk, where start is the
+// zero-based index of its first line
+func hunkRange(start, count int) string {
+	if count == 0 {
+		// An empty range names the line it follows
+		return fmt.Sprintf("%d,0", start)
+	}
+	if count == 1 {
+		return fmt.Sprintf("%d", start+1)
+	}
+	return fmt.Sprintf("%d,%d", start+1, count)
+}
+
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+}
+
+// diffLines computes a line diff of a and b. Lines shared at both ends are
+// matched directly and the rest with Myers' algorithm; when the middle needs
+// more than maxDiffEdits edits it is shown as replaced wholesale instead.
+func diffLines(a, b []string) []diffLine {
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	lines := make([]diffLine, 0, len(a)+len(b))
+	for _, text := range a[:prefix] {
+		lines = append(lines, diffLine{op: diffEqual, text: text})
+	}
+
+	midA, midB := a[prefix:len(a)-suffix], b[prefix:len(b)-suffix]
+	if middle, ok := myersDiff(midA, midB); ok {
+		lines = append(lines, middle...)
+	} else {
+		for _, text := range midA {
+			lines = append(lines, diffLine{op: diffDelete, text: text})
+		}
+		for _, text := range midB {
+			lines = append(lines, diffLine{op: diffInsert, text: text})
+		}
+	}
+
+	for _, text := range a[len(a)-suffix:] {
+		lines = append(lines, diffLine{op: diffEqual, text: text})
+	}
+	return lines
+}
+
+// maxDiffEdits bounds the work and memory of myersDiff, which keeps O(d²)
+// state for d edits
+const maxDiffEdits = 1000
+
+// myersDiff computes a shortest edit script from a to b, or reports false if
+// it needs more than maxDiffEdits edits
+func myersDiff(a, b []string) ([]diffLine, bool) {
+	n, m := len(a), len(b)
+	offset := maxDiffEdits + 2
+	v := make([]int, 2*offset+1)
+	// trace[d] holds v for diagonals -d-1 through d+1 as it was before step d
+	var trace [][]int
+
+	for d := 0; d <= n+m && d <= maxDiffEdits; d++ {
+		trace = append(trace, append([]int(nil), v[offset-d-1:offset+d+2]...))
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				return backtrackDiff(a, b, trace), true
+			}
+		}
+	}
+
+	return nil, false
+}
+
+// backtrackDiff walks the saved states of myersDiff back from the end of both
+// inputs to recover the edit script
+func backtrackDiff(a, b []string, trace [][]int) []diffLine {
+	var reversed []diffLine
+	x, y := len(a), len(b)
+
+	for d := len(trace) - 1; d >= 0; d-- {
+		v := func(k int) int { return trace[d][k+d+1] }
+		k := x - y
+		var prevK int
+		if k == -d || (k != d && v(k-1) < v(k+1)) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := v(prevK)
+		prevY := prevX - prevK
+
+		for x > prevX && y > prevY {
+			x--
+			y--
+			reversed = append(reversed, diffLine{op: diffEqual, text: a[x]})
+		}
+		if d > 0 {
+			if x == prevX {
+				reversed = append(reversed, diffLine{op: diffInsert, text: b[prevY]})
+			} else {
+				reversed = append(reversed, diffLine{op: diffDelete, text: a[prevX]})
+			}
+		}
+		x, y = prevX, prevY
+	}
+
+	lines := make([]diffLine, len(reversed))
+	for i, line := range reversed {
+		lines[len(reversed)-1-i] = line
+	}
+	return lines
+}
@@ -0,0 +1,101 @@
+package service
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestUnifiedDiff(t *testing.T) {
+	numbered := "one\ntwo\nthree\nfour\nfive\nsix\nseven\neight\nnine\nten\neleven\ntwelve"
+
+	tests := []struct {
+		name     string
+		from     string
+		to       string
+		expected string
+	}{
+		{
+			name:     "equal",
+			from:     "same\ntext",
+			to:       "same\ntext",
+			expected: "",
+		},
+		{
+			name: "changed line",
+			from: "a\nb\nc",
+			to:   "a\nB\nc",
+			expected: "--- old\n+++ new\n" +
+				"@@ -1,3 +1,3 @@\n a\n-b\n+B\n c\n",
+		},
+		{
+			name: "from empty",
+			from: "",
+			to:   "hello",
+			expected: "--- old\n+++ new\n" +
+				"@@ -0,0 +1 @@\n+hello\n",
+		},
+		{
+			name: "distant changes get separate hunks",
+			from: numbered,
+			to:   strings.Replace(numbered, "two", "TWO", 1) + "\nthirteen",
+			expected: "--- old\n+++ new\n" +
+				"@@ -1,5 +1,5 @@\n one\n-two\n+TWO\n three\n four\n five\n" +
+				"@@ -10,3 +10,4 @@\n ten\n eleven\n twelve\n+thirteen\n",
+		},
+		{
+			name: "nearby changes share a hunk",
+			from: "a\nb\nc\nd\ne\nf",
+			to:   "A\nb\nc\nd\ne\nF",
+			expected: "--- old\n+++ new\n" +
+				"@@ -1,6 +1,6 @@\n-a\n+A\n b\n c\n d\n e\n-f\n+F\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diff := unifiedDiff("old", "new", tt.from, tt.to)
+			if diff != tt.expected {
+				t.Errorf("expected diff\n%s\ngot\n%s", tt.expected, diff)
+			}
+		})
+	}
+}
+
+func TestDiffLines_ReconstructsBothSides(t *testing.T) {
+	tests := []struct {
+		name string
+		a    []string
+		b    []string
+	}{
+		{"reordered", []string{"a", "b", "c", "d"}, []string{"d", "c", "b", "a"}},
+		{"interleaved", []string{"x", "a", "y", "b", "z"}, []string{"a", "q", "b", "r"}},
+		{"only inserts", nil, []string{"a", "b"}},
+		{"only deletes", []string{"a", "b"}, nil},
+		{"too many edits", manyLines("a", maxDiffEdits+1), manyLines("b", maxDiffEdits+1)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var a, b []string
+			for _, line := range diffLines(tt.a, tt.b) {
+				if line.op != diffInsert {
+					a = append(a, line.text)
+				}
+				if line.op != diffDelete {
+					b = append(b, line.text)
+				}
+			}
+			if strings.Join(a, "\n") != strings.Join(tt.a, "\n") || strings.Join(b, "\n") != strings.Join(tt.b, "\n") {
+				t.Errorf("diff does not reproduce its inputs: got %v and %v", a, b)
+			}
+		})
+	}
+}
+
+func manyLines(prefix string, n int) []string {
+	lines := make([]string, n)
+	for i := range lines {
+		lines[i] = prefix + strings.Repeat("x", i%7)
+	}
+	return lines
+}
@@ -0,0 +1,194 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/alinoer/go-std-api/internal/errors"
+	"github.com/alinoer/go-std-api/internal/logger"
+	"github.com/alinoer/go-std-api/internal/models"
+	"github.com/alinoer/go-std-api/internal/repository"
+)
+
+// LoginThrottleConfig holds the brute-force protection settings
+type LoginThrottleConfig struct {
+	// MaxFailures is how many failed logins for a username lock it. Zero
+	// never locks usernames.
+	MaxFailures int
+	// MaxFailuresPerIP is how many failed logins from an IP address lock
+	// it. It is higher than MaxFailures since many users can share an
+	// address. Zero never locks addresses.
+	MaxFailuresPerIP int
+	// Lockout is how long the first lockout lasts. Every further failure
+	// doubles it, up to MaxLockout.
+	Lockout    time.Duration
+	MaxLockout time.Duration
+	// FailureWindow is how long failures count towards a lockout
+	FailureWindow time.Duration
+}
+
+// DefaultLoginThrottleConfig locks a username for a minute after five
+// failures and an IP address after twenty
+func DefaultLoginThrottleConfig() LoginThrottleConfig {
+	return LoginThrottleConfig{
+		MaxFailures:      5,
+		MaxFailuresPerIP: 20,
+		Lockout:          time.Minute,
+		MaxLockout:       time.Hour,
+		FailureWindow:    24 * time.Hour,
+	}
+}
+
+// LoginThrottle protects logins against password guessing. It counts failed
+// logins per username and per IP address and locks either out for a while
+// once it has too many failures, with every further failure doubling the
+// lockout. Usernames are tracked whether or not they exist, so lockouts do
+// not reveal which do. Every attempt is recorded in the login audit log.
+type LoginThrottle struct {
+	loginRepo repository.LoginRepository
+	config    LoginThrottleConfig
+	now       func() time.Time
+}
+
+func NewLoginThrottle(loginRepo repository.LoginRepository, config LoginThrottleConfig) *LoginThrottle {
+	return &LoginThrottle{
+		loginRepo: loginRepo,
+		config:    config,
+		now:       time.Now,
+	}
+}
+
+// LoginAttempt is a login whose attempt has been counted against its
+// username and IP address while the password is checked
+type LoginAttempt struct {
+	Username  string
+	IPAddress string
+	// reserved holds the lockout each counted key got from the attempt
+	reserved map[string]time.Time
+}
+
+// Reserve counts a login as a failure before its password is checked, so
+// that concurrent guesses cannot all get past a lockout. It returns a rate
+// limit error if the username or the IP address is locked out; the error's
+// "retry_after" context holds the seconds left. Otherwise the attempt must
+// end in RecordFailure, RecordSuccess or Release.
+func (t *LoginThrottle) Reserve(ctx context.Context, username, ip string) (*LoginAttempt, error) {
+	attempt := &LoginAttempt{Username: username, IPAddress: ip, reserved: make(map[string]time.Time)}
+
+	limits := []struct {
+		key         string
+		maxFailures int
+	}{
+		{usernameLoginKey(username), t.config.MaxFailures},
+		{ipLoginKey(ip), t.config.MaxFailuresPerIP},
+	}
+	for _, limit := range limits {
+		if limit.maxFailures <= 0 {
+			continue
+		}
+		reserved, lockedUntil, err := t.loginRepo.ReserveAttempt(ctx, limit.key, models.LoginLimit{
+			MaxFailures: limit.maxFailures,
+			Lockout:     t.config.Lockout,
+			MaxLockout:  t.config.MaxLockout,
+			Window:      t.config.FailureWindow,
+		})
+		if err != nil {
+			t.Release(ctx, attempt)
+			return nil, err
+		}
+		if !reserved {
+			t.Release(ctx, attempt)
+			t.recordEvent(ctx, &models.LoginEvent{Username: username, IPAddress: ip, Outcome: models.LoginOutcomeLocked})
+			return nil, loginLockedError(lockedUntil.Sub(t.now()))
+		}
+		attempt.reserved[limit.key] = lockedUntil
+	}
+
+	return attempt, nil
+}
+
+// RecordFailure records a login with a wrong username or password. Its
+// attempt was already counted, and the username or the IP address locked if
+// it had too many failures.
+func (t *LoginThrottle) RecordFailure(ctx context.Context, attempt *LoginAttempt) {
+	t.recordEvent(ctx, &models.LoginEvent{Username: attempt.Username, IPAddress: attempt.IPAddress, Outcome: models.LoginOutcomeFailure})
+}
+
+// RecordSuccess forgets the failures of the user's username. Failures from
+// the IP address still count, so that guessing cannot be reset by logging in
+// to an account of one's own, but the attempt itself no longer does.
+func (t *LoginThrottle) RecordSuccess(ctx context.Context, attempt *LoginAttempt, user *models.User) error {
+	if err := t.release(ctx, attempt); err != nil {
+		return err
+	}
+	if err := t.loginRepo.Reset(ctx, []string{usernameLoginKey(user.Username)}); err != nil {
+		return err
+	}
+
+	t.recordEvent(ctx, &models.LoginEvent{UserID: &user.ID, Username: user.Username, IPAddress: attempt.IPAddress, Outcome: models.LoginOutcomeSuccess})
+	return nil
+}
+
+// Release takes back an attempt whose password could not be checked, such as
+// after a database error. Failures to release are logged, since the login
+// fails anyway.
+func (t *LoginThrottle) Release(ctx context.Context, attempt *LoginAttempt) {
+	if err := t.release(ctx, attempt); err != nil {
+		logger.GetLogger().WithContext(ctx).Error("Failed to release login attempt", err, "username", attempt.Username)
+	}
+}
+
+func (t *LoginThrottle) release(ctx context.Context, attempt *LoginAttempt) error {
+	for key, lockedUntil := range attempt.reserved {
+		if err := t.loginRepo.Release(ctx, key, lockedUntil); err != nil {
+			return err
+		}
+		delete(attempt.reserved, key)
+	}
+	return nil
+}
+
+// Unlock lifts the lockout of the user's username and forgets its failures.
+// Unless ip is empty, it does the same for that address, such as the one the
+// user logs in from, which the unlock event then records.
+func (t *LoginThrottle) Unlock(ctx context.Context, user *models.User, ip string) error {
+	keys := []string{usernameLoginKey(user.Username)}
+	if ip != "" {
+		keys = append(keys, ipLoginKey(ip))
+	}
+	if err := t.loginRepo.Reset(ctx, keys); err != nil {
+		return err
+	}
+
+	t.recordEvent(ctx, &models.LoginEvent{UserID: &user.ID, Username: user.Username, IPAddress: ip, Outcome: models.LoginOutcomeUnlocked})
+	return nil
+}
+
+// recordEvent adds an entry to the audit log. Failures are logged rather
+// than returned so that the log never decides the outcome of a login.
+func (t *LoginThrottle) recordEvent(ctx context.Context, event *models.LoginEvent) {
+	if err := t.loginRepo.CreateEvent(ctx, event); err != nil {
+		logger.GetLogger().WithContext(ctx).Error("Failed to record login event", err, "outcome", event.Outcome)
+	}
+}
+
+func usernameLoginKey(username string) string {
+	return "username:" + username
+}
+
+func ipLoginKey(ip string) string {
+	return "ip:" + ip
+}
+
+// loginLockedError rejects a login during a lockout
+func loginLockedError(retryAfter time.Duration) *errors.AppError {
+	seconds := int(math.Ceil(retryAfter.Seconds()))
+	if seconds < 1 {
+		seconds = 1
+	}
+	return errors.NewAppError(errors.ErrCodeRateLimit, "Too many failed login attempts").
+		WithDetails(fmt.Sprintf("Try again in %d seconds", seconds)).
+		WithContext("retry_after", seconds)
+}
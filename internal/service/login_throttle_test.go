@@ -0,0 +1,324 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alinoer/go-std-api/internal/errors"
+	"github.com/alinoer/go-std-api/internal/models"
+	"github.com/google/uuid"
+)
+
+// MockLoginRepository keeps failures in memory and ignores the window
+type MockLoginRepository struct {
+	mu          sync.Mutex
+	failures    map[string]int
+	lockedUntil map[string]time.Time
+	events      []*models.LoginEvent
+	now         func() time.Time
+}
+
+func NewMockLoginRepository(now func() time.Time) *MockLoginRepository {
+	return &MockLoginRepository{
+		failures:    make(map[string]int),
+		lockedUntil: make(map[string]time.Time),
+		now:         now,
+	}
+}
+
+func (m *MockLoginRepository) ReserveAttempt(ctx context.Context, key string, limit models.LoginLimit) (bool, time.Time, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if until := m.lockedUntil[key]; until.After(m.now()) {
+		return false, until, nil
+	}
+	m.failures[key]++
+	delete(m.lockedUntil, key)
+	if lockout := limit.LockoutAfter(m.failures[key]); lockout > 0 {
+		m.lockedUntil[key] = m.now().Add(lockout)
+	}
+	return true, m.lockedUntil[key], nil
+}
+
+func (m *MockLoginRepository) Release(ctx context.Context, key string, lockedUntil time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.failures[key] > 0 {
+		m.failures[key]--
+	}
+	if until, ok := m.lockedUntil[key]; ok && until.Equal(lockedUntil) {
+		delete(m.lockedUntil, key)
+	}
+	return nil
+}
+
+func (m *MockLoginRepository) Reset(ctx context.Context, keys []string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, key := range keys {
+		delete(m.failures, key)
+		delete(m.lockedUntil, key)
+	}
+	return nil
+}
+
+func (m *MockLoginRepository) CreateEvent(ctx context.Context, event *models.LoginEvent) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.events = append(m.events, event)
+	return nil
+}
+
+func TestLoginThrottle(t *testing.T) {
+	ctx := context.Background()
+	config := LoginThrottleConfig{
+		MaxFailures:      3,
+		MaxFailuresPerIP: 5,
+		Lockout:          time.Minute,
+		MaxLockout:       3 * time.Minute,
+		FailureWindow:    time.Hour,
+	}
+
+	newThrottle := func() (*LoginThrottle, *MockLoginRepository, *time.Time) {
+		now := time.Now()
+		repo := NewMockLoginRepository(func() time.Time { return now })
+		throttle := NewLoginThrottle(repo, config)
+		throttle.now = func() time.Time { return now }
+		return throttle, repo, &now
+	}
+
+	// fail makes a login with a wrong password
+	fail := func(t *testing.T, throttle *LoginThrottle, username, ip string) {
+		t.Helper()
+		attempt, err := throttle.Reserve(ctx, username, ip)
+		if err != nil {
+			t.Fatalf("expected the login to be allowed, got %v", err)
+		}
+		throttle.RecordFailure(ctx, attempt)
+	}
+
+	// expectAllowed checks that a login may try a password without counting
+	// the attempt
+	expectAllowed := func(t *testing.T, throttle *LoginThrottle, username, ip string) {
+		t.Helper()
+		attempt, err := throttle.Reserve(ctx, username, ip)
+		if err != nil {
+			t.Fatalf("expected no lockout, got %v", err)
+		}
+		throttle.Release(ctx, attempt)
+	}
+
+	// expectLocked checks that logins are rejected for the given time
+	expectLocked := func(t *testing.T, throttle *LoginThrottle, username, ip string, retryAfter string) {
+		t.Helper()
+		_, err := throttle.Reserve(ctx, username, ip)
+		if err == nil {
+			t.Fatal("expected the login to be locked out")
+		}
+		appErr := errors.AsAppError(err)
+		if appErr.Code != errors.ErrCodeRateLimit || appErr.Details != "Try again in "+retryAfter+" seconds" {
+			t.Errorf("expected a lockout for %s seconds, got %v", retryAfter, err)
+		}
+	}
+
+	t.Run("locks a username with exponential backoff", func(t *testing.T) {
+		throttle, _, now := newThrottle()
+
+		for i := 0; i < 2; i++ {
+			fail(t, throttle, "alice", "192.0.2.1")
+			expectAllowed(t, throttle, "alice", "192.0.2.1")
+		}
+
+		fail(t, throttle, "alice", "192.0.2.1")
+		expectLocked(t, throttle, "alice", "192.0.2.2", "60")
+
+		// Each further failure doubles the lockout up to the maximum
+		*now = now.Add(time.Minute)
+		fail(t, throttle, "alice", "192.0.2.1")
+		expectLocked(t, throttle, "alice", "192.0.2.2", "120")
+		*now = now.Add(2 * time.Minute)
+		fail(t, throttle, "alice", "192.0.2.1")
+		expectLocked(t, throttle, "alice", "192.0.2.2", "180")
+		*now = now.Add(3 * time.Minute)
+		fail(t, throttle, "alice", "192.0.2.1")
+		expectLocked(t, throttle, "alice", "192.0.2.2", "180")
+
+		// Other usernames are not affected
+		expectAllowed(t, throttle, "bob", "192.0.2.2")
+
+		*now = now.Add(3 * time.Minute)
+		expectAllowed(t, throttle, "alice", "192.0.2.2")
+	})
+
+	t.Run("locks an IP address across usernames", func(t *testing.T) {
+		throttle, _, _ := newThrottle()
+
+		for _, username := range []string{"a", "b", "c", "d", "e"} {
+			fail(t, throttle, username, "192.0.2.1")
+		}
+
+		expectLocked(t, throttle, "f", "192.0.2.1", "60")
+		expectAllowed(t, throttle, "f", "192.0.2.2")
+	})
+
+	t.Run("concurrent guesses stop at the limit", func(t *testing.T) {
+		throttle, _, _ := newThrottle()
+
+		var mu sync.Mutex
+		var wg sync.WaitGroup
+		allowed := 0
+		for i := 0; i < 20; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				attempt, err := throttle.Reserve(ctx, "alice", "192.0.2.1")
+				if err != nil {
+					return
+				}
+				mu.Lock()
+				allowed++
+				mu.Unlock()
+				throttle.RecordFailure(ctx, attempt)
+			}()
+		}
+		wg.Wait()
+
+		if allowed != config.MaxFailures {
+			t.Errorf("expected %d guesses to be allowed, got %d", config.MaxFailures, allowed)
+		}
+	})
+
+	t.Run("a lockout holds while its attempt is checked", func(t *testing.T) {
+		throttle, repo, _ := newThrottle()
+		user := &models.User{ID: uuid.New(), Username: "alice"}
+
+		fail(t, throttle, "alice", "192.0.2.1")
+		fail(t, throttle, "alice", "192.0.2.1")
+		attempt, err := throttle.Reserve(ctx, "alice", "192.0.2.1")
+		if err != nil {
+			t.Fatalf("expected the last allowed guess, got %v", err)
+		}
+		expectLocked(t, throttle, "alice", "192.0.2.2", "60")
+
+		// The right password lifts it again
+		if err := throttle.RecordSuccess(ctx, attempt, user); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		expectAllowed(t, throttle, "alice", "192.0.2.2")
+		if repo.failures["ip:192.0.2.1"] != 2 {
+			t.Errorf("expected the address to keep its 2 failures, got %d", repo.failures["ip:192.0.2.1"])
+		}
+	})
+
+	t.Run("a locked address takes back the username's attempt", func(t *testing.T) {
+		throttle, repo, _ := newThrottle()
+
+		for _, username := range []string{"a", "b", "c", "d", "e"} {
+			fail(t, throttle, username, "192.0.2.1")
+		}
+		expectLocked(t, throttle, "alice", "192.0.2.1", "60")
+
+		if repo.failures["username:alice"] != 0 {
+			t.Errorf("expected the rejected login not to count, got %d failures", repo.failures["username:alice"])
+		}
+	})
+
+	t.Run("success forgets the username's failures", func(t *testing.T) {
+		throttle, repo, _ := newThrottle()
+		user := &models.User{ID: uuid.New(), Username: "alice"}
+
+		fail(t, throttle, "alice", "192.0.2.1")
+		fail(t, throttle, "alice", "192.0.2.1")
+		attempt, _ := throttle.Reserve(ctx, "alice", "192.0.2.1")
+		if err := throttle.RecordSuccess(ctx, attempt, user); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		fail(t, throttle, "alice", "192.0.2.1")
+
+		expectAllowed(t, throttle, "alice", "192.0.2.1")
+		if repo.failures["ip:192.0.2.1"] != 3 {
+			t.Errorf("expected the address to keep its failures, got %d", repo.failures["ip:192.0.2.1"])
+		}
+	})
+
+	t.Run("unlock lifts the lockout", func(t *testing.T) {
+		throttle, repo, _ := newThrottle()
+		user := &models.User{ID: uuid.New(), Username: "alice"}
+
+		for i := 0; i < 3; i++ {
+			fail(t, throttle, "alice", "192.0.2.1")
+		}
+		if err := throttle.Unlock(ctx, user, ""); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		expectAllowed(t, throttle, "alice", "192.0.2.2")
+		last := repo.events[len(repo.events)-1]
+		if last.Outcome != models.LoginOutcomeUnlocked || last.UserID == nil || *last.UserID != user.ID {
+			t.Errorf("expected an unlock event for the user, got %+v", last)
+		}
+	})
+
+	t.Run("unlock lifts the lockout of an address", func(t *testing.T) {
+		throttle, repo, _ := newThrottle()
+		user := &models.User{ID: uuid.New(), Username: "alice"}
+
+		for _, username := range []string{"a", "b", "c", "d", "e"} {
+			fail(t, throttle, username, "192.0.2.1")
+		}
+		expectLocked(t, throttle, "alice", "192.0.2.1", "60")
+		if err := throttle.Unlock(ctx, user, "192.0.2.1"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		expectAllowed(t, throttle, "alice", "192.0.2.1")
+		if last := repo.events[len(repo.events)-1]; last.IPAddress != "192.0.2.1" {
+			t.Errorf("expected the unlock event to record the address, got %q", last.IPAddress)
+		}
+	})
+
+	t.Run("records events", func(t *testing.T) {
+		throttle, repo, _ := newThrottle()
+		user := &models.User{ID: uuid.New(), Username: "alice"}
+
+		for i := 0; i < 3; i++ {
+			fail(t, throttle, "alice", "192.0.2.1")
+		}
+		expectLocked(t, throttle, "alice", "192.0.2.1", "60")
+		repo.Reset(ctx, []string{"username:alice"})
+		attempt, _ := throttle.Reserve(ctx, "alice", "192.0.2.1")
+		throttle.RecordSuccess(ctx, attempt, user)
+
+		expected := []models.LoginOutcome{
+			models.LoginOutcomeFailure,
+			models.LoginOutcomeFailure,
+			models.LoginOutcomeFailure,
+			models.LoginOutcomeLocked,
+			models.LoginOutcomeSuccess,
+		}
+		if len(repo.events) != len(expected) {
+			t.Fatalf("expected %d events, got %d", len(expected), len(repo.events))
+		}
+		for i, event := range repo.events {
+			if event.Outcome != expected[i] || event.Username != "alice" || event.IPAddress != "192.0.2.1" {
+				t.Errorf("unexpected event %d: %+v", i, event)
+			}
+		}
+		if repo.events[0].UserID != nil {
+			t.Error("expected failures not to name the user")
+		}
+	})
+
+	t.Run("zero limits never lock", func(t *testing.T) {
+		now := time.Now()
+		repo := NewMockLoginRepository(func() time.Time { return now })
+		throttle := NewLoginThrottle(repo, LoginThrottleConfig{Lockout: time.Minute, MaxLockout: time.Minute})
+
+		for i := 0; i < 10; i++ {
+			fail(t, throttle, "alice", "192.0.2.1")
+		}
+		expectAllowed(t, throttle, "alice", "192.0.2.1")
+	})
+}
@@ -0,0 +1,288 @@
+package service
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	PasswordAlgorithmArgon2id = "argon2id"
+	PasswordAlgorithmBcrypt   = "bcrypt"
+
+	argon2SaltLength = 16
+	argon2KeyLength  = 32
+
+	// bcryptMaxPasswordBytes is the longest password bcrypt hashes
+	bcryptMaxPasswordBytes = 72
+)
+
+// PasswordHasher hashes and verifies user passwords
+type PasswordHasher interface {
+	// Hash returns an encoded hash that embeds the algorithm and its parameters
+	Hash(password string) (string, error)
+	// Verify reports whether password matches the encoded hash
+	Verify(password, encodedHash string) (bool, error)
+	// NeedsRehash reports whether the encoded hash was produced by a different
+	// algorithm or with different parameters than the hasher currently uses
+	NeedsRehash(encodedHash string) bool
+	// MaxPasswordBytes returns the length in bytes of the longest password
+	// Hash accepts, or zero if there is no limit
+	MaxPasswordBytes() int
+}
+
+// PasswordHashConfig configures the password hasher used for new hashes
+type PasswordHashConfig struct {
+	Algorithm         string
+	Argon2Memory      uint32 // KiB
+	Argon2Iterations  uint32
+	Argon2Parallelism uint8
+	BcryptCost        int
+}
+
+// NewPasswordHasher creates a hasher that produces hashes with the configured
+// algorithm but can still verify argon2id, bcrypt and legacy SHA-256 hashes
+func NewPasswordHasher(cfg PasswordHashConfig) (PasswordHasher, error) {
+	argon2Hasher, err := NewArgon2idHasher(cfg.Argon2Memory, cfg.Argon2Iterations, cfg.Argon2Parallelism)
+	if err != nil {
+		return nil, err
+	}
+	bcryptHasher, err := NewBcryptHasher(cfg.BcryptCost)
+	if err != nil {
+		return nil, err
+	}
+
+	h := &upgradingHasher{
+		argon2: argon2Hasher,
+		bcrypt: bcryptHasher,
+	}
+
+	switch cfg.Algorithm {
+	case PasswordAlgorithmArgon2id, "":
+		h.primary = argon2Hasher
+	case PasswordAlgorithmBcrypt:
+		h.primary = bcryptHasher
+	default:
+		return nil, fmt.Errorf("unsupported password hash algorithm: %s", cfg.Algorithm)
+	}
+
+	return h, nil
+}
+
+// upgradingHasher delegates verification to the hasher matching the encoded
+// hash format and flags anything not produced by the primary hasher for rehash
+type upgradingHasher struct {
+	primary PasswordHasher
+	argon2  *Argon2idHasher
+	bcrypt  *BcryptHasher
+}
+
+func (h *upgradingHasher) Hash(password string) (string, error) {
+	return h.primary.Hash(password)
+}
+
+func (h *upgradingHasher) Verify(password, encodedHash string) (bool, error) {
+	switch {
+	case strings.HasPrefix(encodedHash, "$argon2id$"):
+		return h.argon2.Verify(password, encodedHash)
+	case isBcryptHash(encodedHash):
+		return h.bcrypt.Verify(password, encodedHash)
+	case isLegacySHA256Hash(encodedHash):
+		return verifyLegacySHA256(password, encodedHash), nil
+	default:
+		return false, fmt.Errorf("unrecognized password hash format")
+	}
+}
+
+func (h *upgradingHasher) NeedsRehash(encodedHash string) bool {
+	return h.primary.NeedsRehash(encodedHash)
+}
+
+func (h *upgradingHasher) MaxPasswordBytes() int {
+	return h.primary.MaxPasswordBytes()
+}
+
+// Argon2idHasher hashes passwords with argon2id and encodes them in PHC format:
+// $argon2id$v=19$m=<memory>,t=<iterations>,p=<parallelism>$<salt>$<hash>
+type Argon2idHasher struct {
+	memory      uint32
+	iterations  uint32
+	parallelism uint8
+}
+
+// NewArgon2idHasher creates an argon2id hasher with the given cost parameters
+func NewArgon2idHasher(memory, iterations uint32, parallelism uint8) (*Argon2idHasher, error) {
+	if parallelism < 1 {
+		return nil, fmt.Errorf("argon2 parallelism must be at least 1")
+	}
+	if iterations < 1 {
+		return nil, fmt.Errorf("argon2 iterations must be at least 1")
+	}
+	if memory < 8*uint32(parallelism) {
+		return nil, fmt.Errorf("argon2 memory must be at least 8 KiB per thread")
+	}
+
+	return &Argon2idHasher{
+		memory:      memory,
+		iterations:  iterations,
+		parallelism: parallelism,
+	}, nil
+}
+
+func (h *Argon2idHasher) Hash(password string) (string, error) {
+	salt := make([]byte, argon2SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", fmt.Errorf("failed to generate salt: %w", err)
+	}
+
+	key := argon2.IDKey([]byte(password), salt, h.iterations, h.memory, h.parallelism, argon2KeyLength)
+
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version,
+		h.memory,
+		h.iterations,
+		h.parallelism,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	), nil
+}
+
+func (h *Argon2idHasher) Verify(password, encodedHash string) (bool, error) {
+	params, salt, key, err := decodeArgon2idHash(encodedHash)
+	if err != nil {
+		return false, err
+	}
+
+	otherKey := argon2.IDKey([]byte(password), salt, params.iterations, params.memory, params.parallelism, uint32(len(key)))
+
+	return subtle.ConstantTimeCompare(key, otherKey) == 1, nil
+}
+
+func (h *Argon2idHasher) NeedsRehash(encodedHash string) bool {
+	params, _, _, err := decodeArgon2idHash(encodedHash)
+	if err != nil {
+		return true
+	}
+
+	return params.memory != h.memory ||
+		params.iterations != h.iterations ||
+		params.parallelism != h.parallelism
+}
+
+func (h *Argon2idHasher) MaxPasswordBytes() int {
+	return 0
+}
+
+// decodeArgon2idHash parses a PHC-encoded argon2id hash
+func decodeArgon2idHash(encodedHash string) (*Argon2idHasher, []byte, []byte, error) {
+	parts := strings.Split(encodedHash, "$")
+	if len(parts) != 6 || parts[1] != PasswordAlgorithmArgon2id {
+		return nil, nil, nil, fmt.Errorf("invalid argon2id hash format")
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil {
+		return nil, nil, nil, fmt.Errorf("invalid argon2id version: %w", err)
+	}
+	if version != argon2.Version {
+		return nil, nil, nil, fmt.Errorf("incompatible argon2id version: %d", version)
+	}
+
+	params := &Argon2idHasher{}
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.memory, &params.iterations, &params.parallelism); err != nil {
+		return nil, nil, nil, fmt.Errorf("invalid argon2id parameters: %w", err)
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("invalid argon2id salt: %w", err)
+	}
+
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("invalid argon2id key: %w", err)
+	}
+
+	return params, salt, key, nil
+}
+
+// BcryptHasher hashes passwords with bcrypt using its modular crypt format
+type BcryptHasher struct {
+	cost int
+}
+
+// NewBcryptHasher creates a bcrypt hasher with the given cost
+func NewBcryptHasher(cost int) (*BcryptHasher, error) {
+	if cost < bcrypt.MinCost || cost > bcrypt.MaxCost {
+		return nil, fmt.Errorf("bcrypt cost must be between %d and %d", bcrypt.MinCost, bcrypt.MaxCost)
+	}
+
+	return &BcryptHasher{cost: cost}, nil
+}
+
+func (h *BcryptHasher) Hash(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), h.cost)
+	if err != nil {
+		return "", fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	return string(hash), nil
+}
+
+func (h *BcryptHasher) Verify(password, encodedHash string) (bool, error) {
+	err := bcrypt.CompareHashAndPassword([]byte(encodedHash), []byte(password))
+	if err == bcrypt.ErrMismatchedHashAndPassword {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to verify password: %w", err)
+	}
+
+	return true, nil
+}
+
+func (h *BcryptHasher) NeedsRehash(encodedHash string) bool {
+	if !isBcryptHash(encodedHash) {
+		return true
+	}
+
+	cost, err := bcrypt.Cost([]byte(encodedHash))
+	if err != nil {
+		return true
+	}
+
+	return cost != h.cost
+}
+
+func (h *BcryptHasher) MaxPasswordBytes() int {
+	return bcryptMaxPasswordBytes
+}
+
+func isBcryptHash(encodedHash string) bool {
+	return strings.HasPrefix(encodedHash, "$2a$") ||
+		strings.HasPrefix(encodedHash, "$2b$") ||
+		strings.HasPrefix(encodedHash, "$2y$")
+}
+
+// isLegacySHA256Hash matches the unsalted hex-encoded SHA-256 hashes written
+// before PasswordHasher existed
+func isLegacySHA256Hash(encodedHash string) bool {
+	if len(encodedHash) != sha256.Size*2 {
+		return false
+	}
+	_, err := hex.DecodeString(encodedHash)
+	return err == nil
+}
+
+func verifyLegacySHA256(password, encodedHash string) bool {
+	sum := sha256.Sum256([]byte(password))
+	expected := hex.EncodeToString(sum[:])
+	return subtle.ConstantTimeCompare([]byte(expected), []byte(strings.ToLower(encodedHash))) == 1
+}
@@ -0,0 +1,154 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/alinoer/go-std-api/internal/errors"
+)
+
+// PasswordPolicyConfig holds the rules new passwords must follow
+type PasswordPolicyConfig struct {
+	// MinLength and MaxLength count characters, not bytes
+	MinLength int
+	MaxLength int
+	// MaxBytes limits the length in bytes, which is what password hashers
+	// such as bcrypt care about. Zero allows any length.
+	MaxBytes int
+	// MinCharacterClasses is how many of lowercase letters, uppercase
+	// letters, digits and symbols a password must mix
+	MinCharacterClasses int
+	// DisallowUsername rejects passwords that contain the username, forwards
+	// or backwards, or are part of it, ignoring case
+	DisallowUsername bool
+}
+
+// DefaultPasswordPolicyConfig asks for at least eight characters that are
+// not based on the username
+func DefaultPasswordPolicyConfig() PasswordPolicyConfig {
+	return PasswordPolicyConfig{
+		MinLength:           8,
+		MaxLength:           128,
+		MinCharacterClasses: 1,
+		DisallowUsername:    true,
+	}
+}
+
+// PasswordPolicy decides whether a password may be set for a user
+type PasswordPolicy struct {
+	config   PasswordPolicyConfig
+	breached *BreachedPasswords
+}
+
+// NewPasswordPolicy creates a policy that also rejects the passwords in
+// breached, if it is not nil
+func NewPasswordPolicy(config PasswordPolicyConfig, breached *BreachedPasswords) *PasswordPolicy {
+	return &PasswordPolicy{
+		config:   config,
+		breached: breached,
+	}
+}
+
+// LimitBytes returns a copy of the policy that also rejects passwords longer
+// than maxBytes bytes, unless its own limit is lower. Zero adds no limit.
+func (p *PasswordPolicy) LimitBytes(maxBytes int) *PasswordPolicy {
+	limited := *p
+	if maxBytes > 0 && (limited.config.MaxBytes == 0 || maxBytes < limited.config.MaxBytes) {
+		limited.config.MaxBytes = maxBytes
+	}
+	return &limited
+}
+
+// Validate checks password against every rule and returns an error for each
+// one it breaks, or nil if it follows them all
+func (p *PasswordPolicy) Validate(username, password string) *errors.ValidationErrors {
+	validationErrors := &errors.ValidationErrors{}
+
+	length := utf8.RuneCountInString(password)
+	if length < p.config.MinLength {
+		validationErrors.Add("password", fmt.Sprintf("Password must be at least %d characters long", p.config.MinLength))
+	}
+	if p.config.MaxLength > 0 && length > p.config.MaxLength {
+		validationErrors.Add("password", fmt.Sprintf("Password must be at most %d characters long", p.config.MaxLength))
+	} else if p.config.MaxBytes > 0 && len(password) > p.config.MaxBytes {
+		validationErrors.Add("password", fmt.Sprintf("Password must be at most %d bytes long", p.config.MaxBytes))
+	}
+
+	if characterClasses(password) < p.config.MinCharacterClasses {
+		validationErrors.Add("password", fmt.Sprintf("Password must mix at least %d of lowercase letters, uppercase letters, digits and symbols", p.config.MinCharacterClasses))
+	}
+
+	if p.config.DisallowUsername && resemblesUsername(username, password) {
+		validationErrors.Add("password", "Password must not be based on the username")
+	}
+
+	if p.breached != nil && p.breached.Contains(password) {
+		validationErrors.Add("password", "Password has appeared in a data breach, choose a different one")
+	}
+
+	if !validationErrors.HasErrors() {
+		return nil
+	}
+
+	return validationErrors
+}
+
+// ValidateUsername checks that password, which the user keeps, is not based
+// on username, which the user is changing to. It returns nil if the rule is
+// off or followed.
+func (p *PasswordPolicy) ValidateUsername(username, password string) *errors.ValidationErrors {
+	if !p.config.DisallowUsername || !resemblesUsername(username, password) {
+		return nil
+	}
+
+	validationErrors := &errors.ValidationErrors{}
+	validationErrors.Add("username", "Password is based on this username, change the password along with it")
+	return validationErrors
+}
+
+// characterClasses counts which of lowercase letters, uppercase letters,
+// digits and anything else password uses
+func characterClasses(password string) int {
+	var lower, upper, digit, other bool
+	for _, r := range password {
+		switch {
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsDigit(r):
+			digit = true
+		default:
+			other = true
+		}
+	}
+
+	count := 0
+	for _, used := range []bool{lower, upper, digit, other} {
+		if used {
+			count++
+		}
+	}
+	return count
+}
+
+// resemblesUsername reports whether password contains the username, forwards
+// or backwards, or is part of it, ignoring case
+func resemblesUsername(username, password string) bool {
+	username = strings.ToLower(username)
+	password = strings.ToLower(password)
+	if username == "" || password == "" {
+		return false
+	}
+
+	reversed := []rune(username)
+	for i, j := 0, len(reversed)-1; i < j; i, j = i+1, j-1 {
+		reversed[i], reversed[j] = reversed[j], reversed[i]
+	}
+
+	return strings.Contains(password, username) ||
+		strings.Contains(password, string(reversed)) ||
+		strings.Contains(username, password)
+}
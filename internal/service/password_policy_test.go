@@ -0,0 +1,228 @@
+package service
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPasswordPolicy_Validate(t *testing.T) {
+	breached, err := LoadBreachedPasswords(strings.NewReader(
+		// SHA-1 of "password1" and "qwerty123"
+		"E38AD214943DAAD1D64C102FAEC29DE4AFE9DA3D:2413945\n5cec175b165e3d5e62c9e13ce848ef6feac81bff\n",
+	))
+	if err != nil {
+		t.Fatalf("failed to load breached passwords: %v", err)
+	}
+
+	tests := []struct {
+		name             string
+		config           PasswordPolicyConfig
+		username         string
+		password         string
+		expectedMessages []string
+	}{
+		{
+			name:     "acceptable password",
+			config:   DefaultPasswordPolicyConfig(),
+			username: "alice",
+			password: "correct horse",
+		},
+		{
+			name:             "too short",
+			config:           DefaultPasswordPolicyConfig(),
+			username:         "alice",
+			password:         "abc123",
+			expectedMessages: []string{"Password must be at least 8 characters long"},
+		},
+		{
+			name:     "length counts characters",
+			config:   DefaultPasswordPolicyConfig(),
+			username: "alice",
+			password: "пароль12",
+		},
+		{
+			name:             "too long",
+			config:           PasswordPolicyConfig{MinLength: 8, MaxLength: 10},
+			username:         "alice",
+			password:         "abcdefghijk",
+			expectedMessages: []string{"Password must be at most 10 characters long"},
+		},
+		{
+			name:             "too long in bytes",
+			config:           PasswordPolicyConfig{MinLength: 8, MaxLength: 10, MaxBytes: 12},
+			username:         "alice",
+			password:         "пароль12",
+			expectedMessages: []string{"Password must be at most 12 bytes long"},
+		},
+		{
+			name:             "too long in characters and bytes",
+			config:           PasswordPolicyConfig{MinLength: 8, MaxLength: 10, MaxBytes: 10},
+			username:         "alice",
+			password:         "abcdefghijk",
+			expectedMessages: []string{"Password must be at most 10 characters long"},
+		},
+		{
+			name:             "too few character classes",
+			config:           PasswordPolicyConfig{MinLength: 8, MinCharacterClasses: 3},
+			username:         "alice",
+			password:         "lowercase123",
+			expectedMessages: []string{"Password must mix at least 3 of lowercase letters, uppercase letters, digits and symbols"},
+		},
+		{
+			name:     "enough character classes",
+			config:   PasswordPolicyConfig{MinLength: 8, MinCharacterClasses: 3},
+			username: "alice",
+			password: "Lowercase123",
+		},
+		{
+			name:             "contains the username",
+			config:           DefaultPasswordPolicyConfig(),
+			username:         "alice",
+			password:         "ALICE2024!",
+			expectedMessages: []string{"Password must not be based on the username"},
+		},
+		{
+			name:             "contains the reversed username",
+			config:           DefaultPasswordPolicyConfig(),
+			username:         "alice",
+			password:         "123ecila!",
+			expectedMessages: []string{"Password must not be based on the username"},
+		},
+		{
+			name:             "part of the username",
+			config:           DefaultPasswordPolicyConfig(),
+			username:         "administrator",
+			password:         "ministra",
+			expectedMessages: []string{"Password must not be based on the username"},
+		},
+		{
+			name:     "username allowed",
+			config:   PasswordPolicyConfig{MinLength: 8},
+			username: "alice",
+			password: "alice2024",
+		},
+		{
+			name:             "breached password",
+			config:           DefaultPasswordPolicyConfig(),
+			username:         "alice",
+			password:         "qwerty123",
+			expectedMessages: []string{"Password has appeared in a data breach, choose a different one"},
+		},
+		{
+			name:     "every rule broken",
+			config:   PasswordPolicyConfig{MinLength: 12, MinCharacterClasses: 3, DisallowUsername: true},
+			username: "password1",
+			password: "password1",
+			expectedMessages: []string{
+				"Password must be at least 12 characters long",
+				"Password must mix at least 3 of lowercase letters, uppercase letters, digits and symbols",
+				"Password must not be based on the username",
+				"Password has appeared in a data breach, choose a different one",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy := NewPasswordPolicy(tt.config, breached)
+
+			validationErrors := policy.Validate(tt.username, tt.password)
+
+			if len(tt.expectedMessages) == 0 {
+				if validationErrors != nil {
+					t.Fatalf("expected the password to be accepted, got %+v", validationErrors.Errors)
+				}
+				return
+			}
+			if validationErrors == nil {
+				t.Fatalf("expected errors %v, got none", tt.expectedMessages)
+			}
+			if len(validationErrors.Errors) != len(tt.expectedMessages) {
+				t.Fatalf("expected errors %v, got %+v", tt.expectedMessages, validationErrors.Errors)
+			}
+			for i, validationError := range validationErrors.Errors {
+				if validationError.Context["field"] != "password" || validationError.Details != tt.expectedMessages[i] {
+					t.Errorf("expected error %d to be %q on password, got %+v", i, tt.expectedMessages[i], validationError)
+				}
+			}
+		})
+	}
+
+	t.Run("limited to the bytes a hasher accepts", func(t *testing.T) {
+		policy := NewPasswordPolicy(DefaultPasswordPolicyConfig(), nil).LimitBytes(72)
+		if validationErrors := policy.Validate("alice", strings.Repeat("x", 72)); validationErrors != nil {
+			t.Errorf("expected 72 bytes to be accepted, got %+v", validationErrors.Errors)
+		}
+		if validationErrors := policy.Validate("alice", strings.Repeat("x", 73)); validationErrors == nil {
+			t.Error("expected 73 bytes to be rejected")
+		}
+
+		stricter := NewPasswordPolicy(PasswordPolicyConfig{MaxBytes: 16}, nil).LimitBytes(72)
+		if validationErrors := stricter.Validate("alice", strings.Repeat("x", 17)); validationErrors == nil {
+			t.Error("expected a lower limit of the policy to be kept")
+		}
+	})
+
+	t.Run("without a breached password corpus", func(t *testing.T) {
+		policy := NewPasswordPolicy(DefaultPasswordPolicyConfig(), nil)
+		if validationErrors := policy.Validate("alice", "qwerty123"); validationErrors != nil {
+			t.Errorf("expected the password to be accepted, got %+v", validationErrors.Errors)
+		}
+	})
+}
+
+func TestPasswordPolicy_ValidateUsername(t *testing.T) {
+	policy := NewPasswordPolicy(DefaultPasswordPolicyConfig(), nil)
+	if err := policy.ValidateUsername("horse", "correct horse"); err == nil {
+		t.Error("expected a password based on the new username to be rejected")
+	}
+	if err := policy.ValidateUsername("alice", "correct horse"); err != nil {
+		t.Errorf("unexpected error: %v", err.ToAppError())
+	}
+
+	// Only the username rule applies to a password that is kept
+	if err := policy.ValidateUsername("alice", "short"); err != nil {
+		t.Errorf("unexpected error: %v", err.ToAppError())
+	}
+
+	config := DefaultPasswordPolicyConfig()
+	config.DisallowUsername = false
+	if err := NewPasswordPolicy(config, nil).ValidateUsername("horse", "correct horse"); err != nil {
+		t.Errorf("expected the rule to be off, got %v", err.ToAppError())
+	}
+}
+
+func TestLoadBreachedPasswords(t *testing.T) {
+	t.Run("valid corpus", func(t *testing.T) {
+		breached, err := LoadBreachedPasswords(strings.NewReader(`# common passwords
+E38AD214943DAAD1D64C102FAEC29DE4AFE9DA3D:2413945
+
+  5cec175b165e3d5e62c9e13ce848ef6feac81bff
+`))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if breached.Len() != 2 {
+			t.Errorf("expected 2 hashes, got %d", breached.Len())
+		}
+		for _, password := range []string{"password1", "qwerty123"} {
+			if !breached.Contains(password) {
+				t.Errorf("expected %q to be breached", password)
+			}
+		}
+		for _, password := range []string{"password", "Password1", "correct horse"} {
+			if breached.Contains(password) {
+				t.Errorf("expected %q not to be breached", password)
+			}
+		}
+	})
+
+	for _, line := range []string{"not-a-hash", "E38AD214943DAAD1D64C102FAEC29DE4AFE9DA", "Z38AD214943DAAD1D64C102FAEC29DE4AFE9DA3D"} {
+		t.Run("invalid line "+line, func(t *testing.T) {
+			_, err := LoadBreachedPasswords(strings.NewReader("5cec175b165e3d5e62c9e13ce848ef6feac81bff\n" + line + "\n"))
+			if err == nil || !strings.Contains(err.Error(), "line 2") {
+				t.Errorf("expected an error for line 2, got %v", err)
+			}
+		})
+	}
+}
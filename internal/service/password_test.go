@@ -0,0 +1,222 @@
+package service
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestNewPasswordHasher(t *testing.T) {
+	tests := []struct {
+		name          string
+		config        PasswordHashConfig
+		expectedError string
+		expectPrefix  string
+	}{
+		{
+			name: "argon2id",
+			config: PasswordHashConfig{
+				Algorithm:         PasswordAlgorithmArgon2id,
+				Argon2Memory:      1024,
+				Argon2Iterations:  1,
+				Argon2Parallelism: 1,
+				BcryptCost:        4,
+			},
+			expectPrefix: "$argon2id$v=19$m=1024,t=1,p=1$",
+		},
+		{
+			name: "bcrypt",
+			config: PasswordHashConfig{
+				Algorithm:         PasswordAlgorithmBcrypt,
+				Argon2Memory:      1024,
+				Argon2Iterations:  1,
+				Argon2Parallelism: 1,
+				BcryptCost:        4,
+			},
+			expectPrefix: "$2a$04$",
+		},
+		{
+			name: "unknown algorithm",
+			config: PasswordHashConfig{
+				Algorithm:         "md5",
+				Argon2Memory:      1024,
+				Argon2Iterations:  1,
+				Argon2Parallelism: 1,
+				BcryptCost:        4,
+			},
+			expectedError: "unsupported password hash algorithm",
+		},
+		{
+			name: "invalid bcrypt cost",
+			config: PasswordHashConfig{
+				Algorithm:         PasswordAlgorithmBcrypt,
+				Argon2Memory:      1024,
+				Argon2Iterations:  1,
+				Argon2Parallelism: 1,
+				BcryptCost:        99,
+			},
+			expectedError: "bcrypt cost must be between",
+		},
+		{
+			name: "invalid argon2 parameters",
+			config: PasswordHashConfig{
+				Algorithm:         PasswordAlgorithmArgon2id,
+				Argon2Memory:      1024,
+				Argon2Iterations:  0,
+				Argon2Parallelism: 1,
+				BcryptCost:        4,
+			},
+			expectedError: "argon2 iterations must be at least 1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hasher, err := NewPasswordHasher(tt.config)
+
+			if tt.expectedError != "" {
+				if err == nil {
+					t.Fatalf("expected error containing %q, got nil", tt.expectedError)
+				}
+				if !strings.Contains(err.Error(), tt.expectedError) {
+					t.Errorf("expected error containing %q, got %q", tt.expectedError, err.Error())
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			hash, err := hasher.Hash("password123")
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if !strings.HasPrefix(hash, tt.expectPrefix) {
+				t.Errorf("expected hash with prefix %q, got %q", tt.expectPrefix, hash)
+			}
+
+			ok, err := hasher.Verify("password123", hash)
+			if err != nil || !ok {
+				t.Errorf("expected password to verify, got ok=%t err=%v", ok, err)
+			}
+
+			ok, err = hasher.Verify("wrongpassword", hash)
+			if err != nil || ok {
+				t.Errorf("expected wrong password to fail, got ok=%t err=%v", ok, err)
+			}
+
+			if hasher.NeedsRehash(hash) {
+				t.Error("expected freshly created hash not to need rehash")
+			}
+		})
+	}
+}
+
+func TestPasswordHasher_Verify(t *testing.T) {
+	hasher := newTestPasswordHasher(t)
+
+	bcryptHasher, err := NewBcryptHasher(4)
+	if err != nil {
+		t.Fatalf("failed to create bcrypt hasher: %v", err)
+	}
+	bcryptHash, err := bcryptHasher.Hash("password123")
+	if err != nil {
+		t.Fatalf("failed to hash password: %v", err)
+	}
+
+	legacySum := sha256.Sum256([]byte("password123"))
+	legacyHash := fmt.Sprintf("%x", legacySum)
+
+	tests := []struct {
+		name          string
+		password      string
+		encodedHash   string
+		expectedOK    bool
+		expectedError bool
+		needsRehash   bool
+	}{
+		{
+			name:        "bcrypt hash",
+			password:    "password123",
+			encodedHash: bcryptHash,
+			expectedOK:  true,
+			needsRehash: true,
+		},
+		{
+			name:        "bcrypt hash with wrong password",
+			password:    "wrongpassword",
+			encodedHash: bcryptHash,
+			expectedOK:  false,
+			needsRehash: true,
+		},
+		{
+			name:        "legacy sha256 hash",
+			password:    "password123",
+			encodedHash: legacyHash,
+			expectedOK:  true,
+			needsRehash: true,
+		},
+		{
+			name:        "legacy sha256 hash with wrong password",
+			password:    "wrongpassword",
+			encodedHash: legacyHash,
+			expectedOK:  false,
+			needsRehash: true,
+		},
+		{
+			name:        "argon2id hash with different parameters",
+			password:    "password123",
+			encodedHash: "$argon2id$v=19$m=2048,t=1,p=1$c2FsdHNhbHRzYWx0c2FsdA$0Bl+uKEaBbkp4S7uUw8m6nXG1hmYd+QeF6vtxu0u0Y0",
+			expectedOK:  false,
+			needsRehash: true,
+		},
+		{
+			name:          "unrecognized format",
+			password:      "password123",
+			encodedHash:   "not-a-hash",
+			expectedError: true,
+			needsRehash:   true,
+		},
+		{
+			name:          "malformed argon2id hash",
+			password:      "password123",
+			encodedHash:   "$argon2id$v=19$m=x$salt$key",
+			expectedError: true,
+			needsRehash:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ok, err := hasher.Verify(tt.password, tt.encodedHash)
+
+			if tt.expectedError {
+				if err == nil {
+					t.Error("expected error, got nil")
+				}
+			} else if err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+
+			if ok != tt.expectedOK {
+				t.Errorf("expected ok=%t, got %t", tt.expectedOK, ok)
+			}
+
+			if got := hasher.NeedsRehash(tt.encodedHash); got != tt.needsRehash {
+				t.Errorf("expected NeedsRehash=%t, got %t", tt.needsRehash, got)
+			}
+		})
+	}
+}
+
+func BenchmarkArgon2idHasher_Hash(b *testing.B) {
+	hasher := newTestPasswordHasher(b)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		hasher.Hash("password123")
+	}
+}
@@ -0,0 +1,378 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/alinoer/go-std-api/internal/errors"
+	"github.com/alinoer/go-std-api/internal/models"
+)
+
+// patchablePost is the document patches apply to
+type patchablePost struct {
+	Title       string            `json:"title"`
+	Content     string            `json:"content"`
+	Tags        []string          `json:"tags"`
+	Status      models.PostStatus `json:"status"`
+	PublishedAt *time.Time        `json:"published_at,omitempty"`
+	Version     int               `json:"version"`
+}
+
+// patchRequest applies patch to the editable fields of post and returns the
+// outcome as a replacement of those fields
+func patchRequest(post *models.Post, patch *models.PostPatch) (*models.UpdatePostRequest, error) {
+	tags := post.Tags
+	if tags == nil {
+		tags = []string{}
+	}
+	document, err := json.Marshal(patchablePost{
+		Title:       post.Title,
+		Content:     post.Content,
+		Tags:        tags,
+		Status:      post.Status,
+		PublishedAt: post.PublishedAt,
+		Version:     post.Version,
+	})
+	if err != nil {
+		return nil, errors.InternalError("Failed to encode the post")
+	}
+
+	var doc any
+	if err := json.Unmarshal(document, &doc); err != nil {
+		return nil, errors.InternalError("Failed to encode the post")
+	}
+
+	switch patch.Format {
+	case models.PatchFormatMergePatch:
+		var mergePatch any
+		if err := json.Unmarshal(patch.Document, &mergePatch); err != nil {
+			return nil, errors.BadRequest("Invalid merge patch document")
+		}
+		doc = applyMergePatch(doc, mergePatch)
+	case models.PatchFormatJSONPatch:
+		var operations []jsonPatchOperation
+		if err := json.Unmarshal(patch.Document, &operations); err != nil {
+			return nil, errors.BadRequest("Invalid JSON patch document")
+		}
+		if doc, err = applyJSONPatch(doc, operations); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, errors.UnsupportedMediaType(string(patch.Format))
+	}
+
+	if document, err = json.Marshal(doc); err != nil {
+		return nil, errors.InternalError("Failed to encode the patched post")
+	}
+	decoder := json.NewDecoder(bytes.NewReader(document))
+	decoder.DisallowUnknownFields()
+	var patched patchablePost
+	if err := decoder.Decode(&patched); err != nil {
+		return nil, errors.BadRequest(fmt.Sprintf("The patched post is invalid: %v", err))
+	}
+
+	replacement := &models.ReplacePostRequest{
+		Title:       patched.Title,
+		Content:     patched.Content,
+		Tags:        patched.Tags,
+		Status:      patched.Status,
+		PublishedAt: patched.PublishedAt,
+	}
+	if patched.Version != 0 {
+		replacement.Version = &patched.Version
+	}
+	return replacementRequest(post, replacement)
+}
+
+// applyMergePatch applies an RFC 7396 merge patch to doc. It may modify doc.
+func applyMergePatch(doc, patch any) any {
+	patchObject, ok := patch.(map[string]any)
+	if !ok {
+		return patch
+	}
+
+	target, ok := doc.(map[string]any)
+	if !ok {
+		target = map[string]any{}
+	}
+	for name, value := range patchObject {
+		if value == nil {
+			delete(target, name)
+			continue
+		}
+		target[name] = applyMergePatch(target[name], value)
+	}
+
+	return target
+}
+
+// jsonPatchOperation is one operation of an RFC 6902 JSON patch
+type jsonPatchOperation struct {
+	Op    string          `json:"op"`
+	Path  *string         `json:"path"`
+	From  *string         `json:"from"`
+	Value json.RawMessage `json:"value"`
+}
+
+// applyJSONPatch applies the operations to doc in order and fails if any of
+// them does not apply. It may modify doc.
+func applyJSONPatch(doc any, operations []jsonPatchOperation) (any, error) {
+	for _, operation := range operations {
+		if operation.Path == nil {
+			return nil, errors.BadRequest(fmt.Sprintf("Invalid JSON patch: '%s' operation without a path", operation.Op))
+		}
+
+		var err error
+		if doc, err = operation.apply(doc); err != nil {
+			if errors.IsAppError(err) {
+				return nil, err
+			}
+			return nil, errors.BadRequest(fmt.Sprintf("Invalid JSON patch: cannot %s '%s': %v", operation.Op, *operation.Path, err))
+		}
+	}
+	return doc, nil
+}
+
+func (o jsonPatchOperation) apply(doc any) (any, error) {
+	path, err := parseJSONPointer(*o.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	switch o.Op {
+	case "add":
+		value, err := o.value()
+		if err != nil {
+			return nil, err
+		}
+		return addValue(doc, path, value)
+	case "remove":
+		doc, _, err := removeValue(doc, path)
+		return doc, err
+	case "replace":
+		value, err := o.value()
+		if err != nil {
+			return nil, err
+		}
+		if len(path) == 0 {
+			return value, nil
+		}
+		if doc, _, err = removeValue(doc, path); err != nil {
+			return nil, err
+		}
+		return addValue(doc, path, value)
+	case "move", "copy":
+		if o.From == nil {
+			return nil, errors.BadRequest(fmt.Sprintf("Invalid JSON patch: '%s' operation without a from path", o.Op))
+		}
+		from, err := parseJSONPointer(*o.From)
+		if err != nil {
+			return nil, err
+		}
+
+		var value any
+		switch {
+		case o.Op == "copy":
+			if value, err = getValue(doc, from); err == nil {
+				value, err = cloneJSON(value)
+			}
+		case len(from) < len(path) && reflect.DeepEqual(from, path[:len(from)]):
+			err = errors.New("cannot move a value into itself")
+		default:
+			doc, value, err = removeValue(doc, from)
+		}
+		if err != nil {
+			return nil, err
+		}
+		return addValue(doc, path, value)
+	case "test":
+		value, err := o.value()
+		if err != nil {
+			return nil, err
+		}
+		actual, err := getValue(doc, path)
+		if err != nil {
+			return nil, err
+		}
+		if !reflect.DeepEqual(actual, value) {
+			return nil, errors.NewAppError(errors.ErrCodeConflict, fmt.Sprintf("JSON patch test failed for '%s'", *o.Path))
+		}
+		return doc, nil
+	default:
+		return nil, errors.BadRequest(fmt.Sprintf("Invalid JSON patch: unsupported operation '%s'", o.Op))
+	}
+}
+
+func (o jsonPatchOperation) value() (any, error) {
+	var value any
+	if len(o.Value) == 0 {
+		return nil, errors.BadRequest(fmt.Sprintf("Invalid JSON patch: '%s' operation without a value", o.Op))
+	}
+	if err := json.Unmarshal(o.Value, &value); err != nil {
+		return nil, errors.BadRequest("Invalid JSON patch document")
+	}
+	return value, nil
+}
+
+// parseJSONPointer splits an RFC 6901 JSON pointer into its unescaped
+// reference tokens. The empty pointer refers to the whole document.
+func parseJSONPointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, errors.New("pointer must start with '/'")
+	}
+
+	tokens := strings.Split(pointer[1:], "/")
+	for i, token := range tokens {
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+	}
+	return tokens, nil
+}
+
+func getValue(doc any, path []string) (any, error) {
+	for _, token := range path {
+		switch node := doc.(type) {
+		case map[string]any:
+			value, ok := node[token]
+			if !ok {
+				return nil, fmt.Errorf("member '%s' does not exist", token)
+			}
+			doc = value
+		case []any:
+			index, err := arrayIndex(token, len(node)-1)
+			if err != nil {
+				return nil, err
+			}
+			doc = node[index]
+		default:
+			return nil, fmt.Errorf("'%s' is not inside an object or array", token)
+		}
+	}
+	return doc, nil
+}
+
+// addValue adds value at path, which must point at an existing value, a new
+// member of an existing object or a position in an existing array
+func addValue(doc any, path []string, value any) (any, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+
+	token, rest := path[0], path[1:]
+	switch node := doc.(type) {
+	case map[string]any:
+		if len(rest) == 0 {
+			node[token] = value
+			return node, nil
+		}
+		child, ok := node[token]
+		if !ok {
+			return nil, fmt.Errorf("member '%s' does not exist", token)
+		}
+		child, err := addValue(child, rest, value)
+		if err != nil {
+			return nil, err
+		}
+		node[token] = child
+		return node, nil
+	case []any:
+		if len(rest) == 0 {
+			index := len(node)
+			if token != "-" {
+				var err error
+				if index, err = arrayIndex(token, len(node)); err != nil {
+					return nil, err
+				}
+			}
+			node = append(node, nil)
+			copy(node[index+1:], node[index:])
+			node[index] = value
+			return node, nil
+		}
+		index, err := arrayIndex(token, len(node)-1)
+		if err != nil {
+			return nil, err
+		}
+		if node[index], err = addValue(node[index], rest, value); err != nil {
+			return nil, err
+		}
+		return node, nil
+	default:
+		return nil, fmt.Errorf("'%s' is not inside an object or array", token)
+	}
+}
+
+// removeValue removes the value at path and returns the resulting document
+// and the removed value
+func removeValue(doc any, path []string) (any, any, error) {
+	if len(path) == 0 {
+		return nil, nil, errors.New("cannot remove the whole document")
+	}
+
+	token, rest := path[0], path[1:]
+	switch node := doc.(type) {
+	case map[string]any:
+		child, ok := node[token]
+		if !ok {
+			return nil, nil, fmt.Errorf("member '%s' does not exist", token)
+		}
+		if len(rest) == 0 {
+			delete(node, token)
+			return node, child, nil
+		}
+		child, removed, err := removeValue(child, rest)
+		if err != nil {
+			return nil, nil, err
+		}
+		node[token] = child
+		return node, removed, nil
+	case []any:
+		index, err := arrayIndex(token, len(node)-1)
+		if err != nil {
+			return nil, nil, err
+		}
+		if len(rest) == 0 {
+			removed := node[index]
+			return append(node[:index], node[index+1:]...), removed, nil
+		}
+		child, removed, err := removeValue(node[index], rest)
+		if err != nil {
+			return nil, nil, err
+		}
+		node[index] = child
+		return node, removed, nil
+	default:
+		return nil, nil, fmt.Errorf("'%s' is not inside an object or array", token)
+	}
+}
+
+// arrayIndex parses an array index token, which must not exceed max
+func arrayIndex(token string, max int) (int, error) {
+	if token == "" || (len(token) > 1 && token[0] == '0') || strings.TrimLeft(token, "0123456789") != "" {
+		return 0, fmt.Errorf("'%s' is not an array index", token)
+	}
+	index, err := strconv.Atoi(token)
+	if err != nil || index > max {
+		return 0, fmt.Errorf("index %s is out of range", token)
+	}
+	return index, nil
+}
+
+func cloneJSON(value any) (any, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	var clone any
+	if err := json.Unmarshal(data, &clone); err != nil {
+		return nil, err
+	}
+	return clone, nil
+}
@@ -0,0 +1,220 @@
+package service
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/alinoer/go-std-api/internal/errors"
+)
+
+func decodeJSON(t *testing.T, document string) any {
+	t.Helper()
+	var value any
+	if err := json.Unmarshal([]byte(document), &value); err != nil {
+		t.Fatalf("invalid JSON %s: %v", document, err)
+	}
+	return value
+}
+
+func encodeJSON(t *testing.T, value any) string {
+	t.Helper()
+	data, err := json.Marshal(value)
+	if err != nil {
+		t.Fatalf("failed to encode %v: %v", value, err)
+	}
+	return string(data)
+}
+
+func TestApplyMergePatch(t *testing.T) {
+	// Examples from RFC 7396, appendix A
+	tests := []struct {
+		doc      string
+		patch    string
+		expected string
+	}{
+		{`{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{`{"a":"b"}`, `{"a":null}`, `{}`},
+		{`{"a":"b","b":"c"}`, `{"a":null}`, `{"b":"c"}`},
+		{`{"a":["b"]}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"c"}`, `{"a":["b"]}`, `{"a":["b"]}`},
+		{`{"a":{"b":"c"}}`, `{"a":{"b":"d","c":null}}`, `{"a":{"b":"d"}}`},
+		{`{"a":[{"b":"c"}]}`, `{"a":[1]}`, `{"a":[1]}`},
+		{`["a","b"]`, `["c","d"]`, `["c","d"]`},
+		{`{"a":"b"}`, `["c"]`, `["c"]`},
+		{`{"a":"foo"}`, `null`, `null`},
+		{`{"a":"foo"}`, `"bar"`, `"bar"`},
+		{`{"e":null}`, `{"a":1}`, `{"a":1,"e":null}`},
+		{`[1,2]`, `{"a":"b","c":null}`, `{"a":"b"}`},
+		{`{}`, `{"a":{"bb":{"ccc":null}}}`, `{"a":{"bb":{}}}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.doc+" "+tt.patch, func(t *testing.T) {
+			result := encodeJSON(t, applyMergePatch(decodeJSON(t, tt.doc), decodeJSON(t, tt.patch)))
+			if result != tt.expected {
+				t.Errorf("expected %s, got %s", tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestApplyJSONPatch(t *testing.T) {
+	tests := []struct {
+		name        string
+		doc         string
+		patch       string
+		expected    string
+		expectedErr errors.ErrorCode
+	}{
+		{
+			name:     "add member",
+			doc:      `{"foo":"bar"}`,
+			patch:    `[{"op":"add","path":"/baz","value":"qux"}]`,
+			expected: `{"baz":"qux","foo":"bar"}`,
+		},
+		{
+			name:     "add array element",
+			doc:      `{"foo":["bar","baz"]}`,
+			patch:    `[{"op":"add","path":"/foo/1","value":"qux"}]`,
+			expected: `{"foo":["bar","qux","baz"]}`,
+		},
+		{
+			name:     "append to array",
+			doc:      `{"foo":["bar"]}`,
+			patch:    `[{"op":"add","path":"/foo/-","value":["abc","def"]}]`,
+			expected: `{"foo":["bar",["abc","def"]]}`,
+		},
+		{
+			name:     "remove member",
+			doc:      `{"baz":"qux","foo":"bar"}`,
+			patch:    `[{"op":"remove","path":"/baz"}]`,
+			expected: `{"foo":"bar"}`,
+		},
+		{
+			name:     "remove array element",
+			doc:      `{"foo":["bar","qux","baz"]}`,
+			patch:    `[{"op":"remove","path":"/foo/1"}]`,
+			expected: `{"foo":["bar","baz"]}`,
+		},
+		{
+			name:     "replace value",
+			doc:      `{"baz":"qux","foo":"bar"}`,
+			patch:    `[{"op":"replace","path":"/baz","value":"boo"}]`,
+			expected: `{"baz":"boo","foo":"bar"}`,
+		},
+		{
+			name:     "move value",
+			doc:      `{"foo":{"bar":"baz","waldo":"fred"},"qux":{"corge":"grault"}}`,
+			patch:    `[{"op":"move","from":"/foo/waldo","path":"/qux/thud"}]`,
+			expected: `{"foo":{"bar":"baz"},"qux":{"corge":"grault","thud":"fred"}}`,
+		},
+		{
+			name:     "move array element",
+			doc:      `{"foo":["all","grass","cows","eat"]}`,
+			patch:    `[{"op":"move","from":"/foo/1","path":"/foo/3"}]`,
+			expected: `{"foo":["all","cows","eat","grass"]}`,
+		},
+		{
+			name:     "copy value",
+			doc:      `{"a":{"b":[1]}}`,
+			patch:    `[{"op":"copy","from":"/a","path":"/c"},{"op":"add","path":"/c/b/-","value":2}]`,
+			expected: `{"a":{"b":[1]},"c":{"b":[1,2]}}`,
+		},
+		{
+			name:     "successful test",
+			doc:      `{"baz":"qux","foo":["a",2,"c"]}`,
+			patch:    `[{"op":"test","path":"/baz","value":"qux"},{"op":"test","path":"/foo/1","value":2}]`,
+			expected: `{"baz":"qux","foo":["a",2,"c"]}`,
+		},
+		{
+			name:     "escaped pointer",
+			doc:      `{"a/b":1,"m~n":2}`,
+			patch:    `[{"op":"replace","path":"/a~1b","value":3},{"op":"remove","path":"/m~0n"}]`,
+			expected: `{"a/b":3}`,
+		},
+		{
+			name:     "null value",
+			doc:      `{"a":1}`,
+			patch:    `[{"op":"replace","path":"/a","value":null}]`,
+			expected: `{"a":null}`,
+		},
+		{
+			name:        "failed test",
+			doc:         `{"baz":"qux"}`,
+			patch:       `[{"op":"test","path":"/baz","value":"bar"}]`,
+			expectedErr: errors.ErrCodeConflict,
+		},
+		{
+			name:        "missing member",
+			doc:         `{"foo":"bar"}`,
+			patch:       `[{"op":"remove","path":"/baz"}]`,
+			expectedErr: errors.ErrCodeBadRequest,
+		},
+		{
+			name:        "add to missing parent",
+			doc:         `{"foo":"bar"}`,
+			patch:       `[{"op":"add","path":"/baz/bat","value":"qux"}]`,
+			expectedErr: errors.ErrCodeBadRequest,
+		},
+		{
+			name:        "index out of range",
+			doc:         `{"foo":["bar"]}`,
+			patch:       `[{"op":"add","path":"/foo/2","value":"qux"}]`,
+			expectedErr: errors.ErrCodeBadRequest,
+		},
+		{
+			name:        "index with leading zero",
+			doc:         `{"foo":["bar","baz"]}`,
+			patch:       `[{"op":"replace","path":"/foo/01","value":"qux"}]`,
+			expectedErr: errors.ErrCodeBadRequest,
+		},
+		{
+			name:        "move into itself",
+			doc:         `{"a":{"b":{}}}`,
+			patch:       `[{"op":"move","from":"/a","path":"/a/b/c"}]`,
+			expectedErr: errors.ErrCodeBadRequest,
+		},
+		{
+			name:        "missing value",
+			doc:         `{"foo":"bar"}`,
+			patch:       `[{"op":"add","path":"/baz"}]`,
+			expectedErr: errors.ErrCodeBadRequest,
+		},
+		{
+			name:        "missing path",
+			doc:         `{"foo":"bar"}`,
+			patch:       `[{"op":"remove"}]`,
+			expectedErr: errors.ErrCodeBadRequest,
+		},
+		{
+			name:        "unknown operation",
+			doc:         `{"foo":"bar"}`,
+			patch:       `[{"op":"merge","path":"/foo","value":"baz"}]`,
+			expectedErr: errors.ErrCodeBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var operations []jsonPatchOperation
+			if err := json.Unmarshal([]byte(tt.patch), &operations); err != nil {
+				t.Fatalf("invalid patch: %v", err)
+			}
+
+			result, err := applyJSONPatch(decodeJSON(t, tt.doc), operations)
+			if tt.expectedErr != "" {
+				if err == nil || errors.AsAppError(err).Code != tt.expectedErr {
+					t.Errorf("expected %s, got %v", tt.expectedErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if encoded := encodeJSON(t, result); encoded != tt.expected {
+				t.Errorf("expected %s, got %s", tt.expected, encoded)
+			}
+		})
+	}
+}
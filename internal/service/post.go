@@ -3,23 +3,45 @@ package service
 import (
 	"context"
 	"fmt"
+	"strings"
 	"time"
 
+	"github.com/alinoer/go-std-api/internal/errors"
 	"github.com/alinoer/go-std-api/internal/models"
 	"github.com/alinoer/go-std-api/internal/repository"
 
 	"github.com/google/uuid"
 )
 
+const maxSearchQueryLength = 200
+
 type PostService interface {
 	CreatePost(ctx context.Context, userID uuid.UUID, req *models.CreatePostRequest) (*models.Post, error)
 	GetPost(ctx context.Context, id uuid.UUID) (*models.Post, error)
-	ListPosts(ctx context.Context) ([]*models.Post, error)
-	ListPostsPaginated(ctx context.Context, pagination *models.PaginationParams) (*models.PaginatedResponse, error)
+	ListPosts(ctx context.Context, opts *models.ListOptions) ([]*models.Post, error)
+	ListPostsPaginated(ctx context.Context, pagination *models.PaginationParams, opts *models.ListOptions) (*models.PaginatedResponse, error)
 	GetPostsByUser(ctx context.Context, userID uuid.UUID) ([]*models.Post, error)
 	GetPostsByUserPaginated(ctx context.Context, userID uuid.UUID, pagination *models.PaginationParams) (*models.PaginatedResponse, error)
-	UpdatePost(ctx context.Context, id uuid.UUID, req *models.UpdatePostRequest) (*models.Post, error)
-	DeletePost(ctx context.Context, id uuid.UUID) error
+	ListPostsByCursor(ctx context.Context, params *models.CursorParams, opts *models.ListOptions) (*models.PaginatedResponse, error)
+	GetPostsByUserByCursor(ctx context.Context, userID uuid.UUID, params *models.CursorParams) (*models.PaginatedResponse, error)
+	SearchPosts(ctx context.Context, query string, pagination *models.PaginationParams) (*models.PaginatedResponse, error)
+	// UpdatePost, ReplacePost, PatchPost and DeletePost fail with a
+	// precondition error when ifMatch does not allow the post's current version
+	UpdatePost(ctx context.Context, userID, id uuid.UUID, req *models.UpdatePostRequest, ifMatch *models.Precondition) (*models.Post, error)
+	ReplacePost(ctx context.Context, userID, id uuid.UUID, req *models.ReplacePostRequest, ifMatch *models.Precondition) (*models.Post, error)
+	PatchPost(ctx context.Context, userID, id uuid.UUID, patch *models.PostPatch, ifMatch *models.Precondition) (*models.Post, error)
+	DeletePost(ctx context.Context, userID, id uuid.UUID, ifMatch *models.Precondition) error
+	DeleteAnyPost(ctx context.Context, id uuid.UUID) error
+	// ListDeletedPosts lists the user's posts in the trash
+	ListDeletedPosts(ctx context.Context, userID uuid.UUID, pagination *models.PaginationParams) (*models.PaginatedResponse, error)
+	RestorePost(ctx context.Context, userID, id uuid.UUID) (*models.Post, error)
+	RestoreAnyPost(ctx context.Context, id uuid.UUID) (*models.Post, error)
+	AddReaction(ctx context.Context, userID, postID uuid.UUID, kind models.ReactionKind) (*models.Post, error)
+	RemoveReaction(ctx context.Context, userID, postID uuid.UUID, kind models.ReactionKind) (*models.Post, error)
+	ListTags(ctx context.Context) ([]*models.Tag, error)
+	ListRevisions(ctx context.Context, userID, postID uuid.UUID, pagination *models.PaginationParams) (*models.PaginatedResponse, error)
+	DiffRevisions(ctx context.Context, userID, postID uuid.UUID, from, to int) (*models.PostRevisionDiff, error)
+	RestoreRevision(ctx context.Context, userID, postID uuid.UUID, version int) (*models.Post, error)
 }
 
 type postService struct {
@@ -36,51 +58,113 @@ func NewPostService(postRepo repository.PostRepository, userRepo repository.User
 
 func (s *postService) CreatePost(ctx context.Context, userID uuid.UUID, req *models.CreatePostRequest) (*models.Post, error) {
 	if req.Title == "" {
-		return nil, fmt.Errorf("title is required")
+		return nil, errors.ValidationError("title", "Title is required")
 	}
 	if req.Content == "" {
-		return nil, fmt.Errorf("content is required")
+		return nil, errors.ValidationError("content", "Content is required")
+	}
+
+	tags, err := normalizeTags("tags", req.Tags)
+	if err != nil {
+		return nil, err
 	}
 
 	// Verify user exists
-	_, err := s.userRepo.GetByID(ctx, userID)
+	_, err = s.userRepo.GetByID(ctx, userID)
 	if err != nil {
-		return nil, fmt.Errorf("user not found")
+		return nil, err
 	}
 
+	now := time.Now()
 	post := &models.Post{
 		ID:        uuid.New(),
 		UserID:    userID,
 		Title:     req.Title,
 		Content:   req.Content,
-		CreatedAt: time.Now(),
+		Tags:      tags,
+		Status:    models.PostStatusDraft,
+		CreatedAt: now,
+	}
+
+	status := req.Status
+	switch {
+	case status == "" && req.PublishedAt == nil:
+		status = models.PostStatusPublished
+	case status == models.PostStatusArchived:
+		return nil, errors.ValidationError("status", "New posts must be drafts or published")
+	}
+	if err := applyPostStatus(post, status, req.PublishedAt, now); err != nil {
+		return nil, err
 	}
 
 	if err := s.postRepo.Create(ctx, post); err != nil {
-		return nil, fmt.Errorf("failed to create post: %w", err)
+		return nil, err
 	}
 
 	return post, nil
 }
 
+// GetPost returns the post if the viewer in ctx may read it. Other users'
+// drafts are reported as not found.
 func (s *postService) GetPost(ctx context.Context, id uuid.UUID) (*models.Post, error) {
-	return s.postRepo.GetByID(ctx, id)
+	return getVisiblePost(ctx, s.postRepo, id)
 }
 
-func (s *postService) ListPosts(ctx context.Context) ([]*models.Post, error) {
-	return s.postRepo.List(ctx)
+func (s *postService) ListPosts(ctx context.Context, opts *models.ListOptions) ([]*models.Post, error) {
+	if err := normalizeTagFilter(opts); err != nil {
+		return nil, err
+	}
+	return s.postRepo.List(ctx, opts)
 }
 
 func (s *postService) GetPostsByUser(ctx context.Context, userID uuid.UUID) ([]*models.Post, error) {
 	return s.postRepo.GetByUserID(ctx, userID)
 }
 
-func (s *postService) UpdatePost(ctx context.Context, id uuid.UUID, req *models.UpdatePostRequest) (*models.Post, error) {
+// UpdatePost applies req to the post on behalf of userID, who must own it
+func (s *postService) UpdatePost(ctx context.Context, userID, id uuid.UUID, req *models.UpdatePostRequest, ifMatch *models.Precondition) (*models.Post, error) {
+	return s.updatePost(ctx, userID, id, ifMatch, func(*models.Post) (*models.UpdatePostRequest, error) {
+		return req, nil
+	})
+}
+
+// ReplacePost replaces the editable fields of the post on behalf of userID,
+// who must own it
+func (s *postService) ReplacePost(ctx context.Context, userID, id uuid.UUID, req *models.ReplacePostRequest, ifMatch *models.Precondition) (*models.Post, error) {
+	return s.updatePost(ctx, userID, id, ifMatch, func(post *models.Post) (*models.UpdatePostRequest, error) {
+		return replacementRequest(post, req)
+	})
+}
+
+// PatchPost applies patch to the post on behalf of userID, who must own it
+func (s *postService) PatchPost(ctx context.Context, userID, id uuid.UUID, patch *models.PostPatch, ifMatch *models.Precondition) (*models.Post, error) {
+	return s.updatePost(ctx, userID, id, ifMatch, func(post *models.Post) (*models.UpdatePostRequest, error) {
+		return patchRequest(post, patch)
+	})
+}
+
+// updatePost loads the post and applies the update that build derives from it
+func (s *postService) updatePost(ctx context.Context, userID, id uuid.UUID, ifMatch *models.Precondition, build func(*models.Post) (*models.UpdatePostRequest, error)) (*models.Post, error) {
 	// Get existing post
-	existingPost, err := s.postRepo.GetByID(ctx, id)
+	existingPost, err := getVisiblePost(models.ContextWithViewer(ctx, userID), s.postRepo, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if existingPost.UserID != userID {
+		return nil, errors.Forbidden("You can only update your own posts")
+	}
+	if !ifMatch.Allows(existingPost.Version) {
+		return nil, errors.PreconditionFailed("Post")
+	}
+
+	req, err := build(existingPost)
 	if err != nil {
 		return nil, err
 	}
+	if req.Version != nil && *req.Version != existingPost.Version {
+		return nil, errors.StaleVersion("Post", *req.Version)
+	}
 
 	// Update fields if provided
 	if req.Title != nil {
@@ -89,20 +173,122 @@ func (s *postService) UpdatePost(ctx context.Context, id uuid.UUID, req *models.
 	if req.Content != nil {
 		existingPost.Content = *req.Content
 	}
+	if req.Tags != nil {
+		tags, err := normalizeTags("tags", *req.Tags)
+		if err != nil {
+			return nil, err
+		}
+		existingPost.Tags = tags
+	}
+	if req.Status != nil || req.PublishedAt != nil {
+		var status models.PostStatus
+		if req.Status != nil {
+			status = *req.Status
+		}
+		if err := applyPostStatus(existingPost, status, req.PublishedAt, time.Now()); err != nil {
+			return nil, err
+		}
+	}
 
-	if err := s.postRepo.Update(ctx, id, existingPost); err != nil {
-		return nil, fmt.Errorf("failed to update post: %w", err)
+	// The repository only applies the update if nobody changed the post
+	// since it was loaded
+	if err := s.postRepo.Update(models.ContextWithViewer(ctx, userID), id, existingPost); err != nil {
+		return nil, preconditionError(err, ifMatch)
 	}
 
 	return existingPost, nil
 }
 
-func (s *postService) DeletePost(ctx context.Context, id uuid.UUID) error {
-	return s.postRepo.Delete(ctx, id)
+// DeletePost deletes the post on behalf of userID, who must own it
+func (s *postService) DeletePost(ctx context.Context, userID, id uuid.UUID, ifMatch *models.Precondition) error {
+	existingPost, err := getVisiblePost(models.ContextWithViewer(ctx, userID), s.postRepo, id)
+	if err != nil {
+		return err
+	}
+
+	if existingPost.UserID != userID {
+		return errors.Forbidden("You can only delete your own posts")
+	}
+	if ifMatch == nil {
+		return s.postRepo.Delete(ctx, id, 0)
+	}
+	if !ifMatch.Allows(existingPost.Version) {
+		return errors.PreconditionFailed("Post")
+	}
+
+	// Pin the checked version so that a concurrent update fails the delete
+	return preconditionError(s.postRepo.Delete(ctx, id, existingPost.Version), ifMatch)
+}
+
+// preconditionError reports a version conflict as a failed precondition
+// when the caller asked for one, since the post changed after it was checked
+func preconditionError(err error, ifMatch *models.Precondition) error {
+	if err != nil && ifMatch != nil {
+		if appErr := errors.AsAppError(err); appErr != nil && appErr.Code == errors.ErrCodeConflict {
+			return errors.PreconditionFailed("Post")
+		}
+	}
+	return err
+}
+
+// DeleteAnyPost deletes a post regardless of its author. Callers must have
+// checked that the acting user holds models.PermissionDeleteAnyPost.
+func (s *postService) DeleteAnyPost(ctx context.Context, id uuid.UUID) error {
+	if _, err := s.postRepo.GetByID(ctx, id); err != nil {
+		return err
+	}
+
+	return s.postRepo.Delete(ctx, id, 0)
+}
+
+func (s *postService) ListDeletedPosts(ctx context.Context, userID uuid.UUID, pagination *models.PaginationParams) (*models.PaginatedResponse, error) {
+	posts, total, err := s.postRepo.ListDeleted(ctx, userID, pagination)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.PaginatedResponse{
+		Data:       posts,
+		Pagination: models.NewPaginationMeta(pagination.Page, pagination.PageSize, total),
+	}, nil
 }
 
-func (s *postService) ListPostsPaginated(ctx context.Context, pagination *models.PaginationParams) (*models.PaginatedResponse, error) {
-	posts, total, err := s.postRepo.ListPaginated(ctx, pagination)
+// RestorePost takes the post out of the trash on behalf of userID, who must
+// own it
+func (s *postService) RestorePost(ctx context.Context, userID, id uuid.UUID) (*models.Post, error) {
+	deletedPost, err := s.postRepo.GetDeleted(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if deletedPost.UserID != userID {
+		return nil, errors.Forbidden("You can only restore your own posts")
+	}
+
+	return s.restorePost(ctx, id)
+}
+
+// RestoreAnyPost takes a post out of the trash regardless of its author.
+// Callers must have checked that the acting user holds
+// models.PermissionDeleteAnyPost.
+func (s *postService) RestoreAnyPost(ctx context.Context, id uuid.UUID) (*models.Post, error) {
+	return s.restorePost(ctx, id)
+}
+
+func (s *postService) restorePost(ctx context.Context, id uuid.UUID) (*models.Post, error) {
+	if err := s.postRepo.Restore(ctx, id); err != nil {
+		return nil, err
+	}
+
+	return s.postRepo.GetByID(ctx, id)
+}
+
+func (s *postService) ListPostsPaginated(ctx context.Context, pagination *models.PaginationParams, opts *models.ListOptions) (*models.PaginatedResponse, error) {
+	if err := normalizeTagFilter(opts); err != nil {
+		return nil, err
+	}
+
+	posts, total, err := s.postRepo.ListPaginated(ctx, pagination, opts)
 	if err != nil {
 		return nil, err
 	}
@@ -119,7 +305,7 @@ func (s *postService) GetPostsByUserPaginated(ctx context.Context, userID uuid.U
 	// Verify user exists
 	_, err := s.userRepo.GetByID(ctx, userID)
 	if err != nil {
-		return nil, fmt.Errorf("user not found")
+		return nil, err
 	}
 
 	posts, total, err := s.postRepo.GetByUserIDPaginated(ctx, userID, pagination)
@@ -133,4 +319,332 @@ func (s *postService) GetPostsByUserPaginated(ctx context.Context, userID uuid.U
 		Data:       posts,
 		Pagination: meta,
 	}, nil
-}
\ No newline at end of file
+}
+
+// ListPostsByCursor returns a keyset paginated page of posts. The total is
+// only counted when requested, since it costs a scan of the table.
+func (s *postService) ListPostsByCursor(ctx context.Context, params *models.CursorParams, opts *models.ListOptions) (*models.PaginatedResponse, error) {
+	if opts.HasSort() {
+		return nil, errors.ValidationError("sort", "Sorting is not supported with cursor pagination")
+	}
+	if err := normalizeTagFilter(opts); err != nil {
+		return nil, err
+	}
+
+	posts, hasMore, err := s.postRepo.ListByCursor(ctx, params, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var total *int64
+	if params.IncludeTotal {
+		count, err := s.postRepo.Count(ctx, opts)
+		if err != nil {
+			return nil, err
+		}
+		total = &count
+	}
+
+	return newPostCursorPage(posts, params, hasMore, total), nil
+}
+
+// GetPostsByUserByCursor returns a keyset paginated page of a user's posts
+func (s *postService) GetPostsByUserByCursor(ctx context.Context, userID uuid.UUID, params *models.CursorParams) (*models.PaginatedResponse, error) {
+	// Verify user exists
+	if _, err := s.userRepo.GetByID(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	posts, hasMore, err := s.postRepo.GetByUserIDByCursor(ctx, userID, params)
+	if err != nil {
+		return nil, err
+	}
+
+	var total *int64
+	if params.IncludeTotal {
+		count, err := s.postRepo.CountByUserID(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+		total = &count
+	}
+
+	return newPostCursorPage(posts, params, hasMore, total), nil
+}
+
+// SearchPosts runs a full-text search over post titles and content
+func (s *postService) SearchPosts(ctx context.Context, query string, pagination *models.PaginationParams) (*models.PaginatedResponse, error) {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return nil, errors.ValidationError("q", "Search query is required")
+	}
+	if len(query) > maxSearchQueryLength {
+		return nil, errors.ValidationError("q", fmt.Sprintf("Search query must be at most %d characters", maxSearchQueryLength))
+	}
+
+	results, total, err := s.postRepo.Search(ctx, query, pagination)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.PaginatedResponse{
+		Data:       results,
+		Pagination: models.NewPaginationMeta(pagination.Page, pagination.PageSize, total),
+	}, nil
+}
+
+// AddReaction records userID's reaction to the post and returns the post with
+// its updated counts. Adding a reaction the user already left is a no-op.
+func (s *postService) AddReaction(ctx context.Context, userID, postID uuid.UUID, kind models.ReactionKind) (*models.Post, error) {
+	if err := validateReactionKind(kind); err != nil {
+		return nil, err
+	}
+
+	if _, err := getVisiblePost(models.ContextWithViewer(ctx, userID), s.postRepo, postID); err != nil {
+		return nil, err
+	}
+
+	if err := s.postRepo.AddReaction(ctx, postID, userID, kind); err != nil {
+		return nil, err
+	}
+
+	return s.postRepo.GetByID(models.ContextWithViewer(ctx, userID), postID)
+}
+
+// RemoveReaction removes userID's reaction from the post and returns the post
+// with its updated counts. Removing a reaction that does not exist is a no-op.
+func (s *postService) RemoveReaction(ctx context.Context, userID, postID uuid.UUID, kind models.ReactionKind) (*models.Post, error) {
+	if err := validateReactionKind(kind); err != nil {
+		return nil, err
+	}
+
+	if _, err := getVisiblePost(models.ContextWithViewer(ctx, userID), s.postRepo, postID); err != nil {
+		return nil, err
+	}
+
+	if err := s.postRepo.RemoveReaction(ctx, postID, userID, kind); err != nil {
+		return nil, err
+	}
+
+	return s.postRepo.GetByID(models.ContextWithViewer(ctx, userID), postID)
+}
+
+// ListTags returns the tags in use with the number of posts carrying each
+func (s *postService) ListTags(ctx context.Context) ([]*models.Tag, error) {
+	return s.postRepo.ListTags(ctx)
+}
+
+// ListRevisions returns a page of the post's revisions, newest first. Only
+// the author may read a post's history.
+func (s *postService) ListRevisions(ctx context.Context, userID, postID uuid.UUID, pagination *models.PaginationParams) (*models.PaginatedResponse, error) {
+	if _, err := s.getOwnPost(ctx, userID, postID); err != nil {
+		return nil, err
+	}
+
+	revisions, total, err := s.postRepo.ListRevisions(ctx, postID, pagination)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.PaginatedResponse{
+		Data:       revisions,
+		Pagination: models.NewPaginationMeta(pagination.Page, pagination.PageSize, total),
+	}, nil
+}
+
+// DiffRevisions returns a unified diff from revision from to revision to. A
+// zero to means the current version and a zero from the one before to.
+func (s *postService) DiffRevisions(ctx context.Context, userID, postID uuid.UUID, from, to int) (*models.PostRevisionDiff, error) {
+	post, err := s.getOwnPost(ctx, userID, postID)
+	if err != nil {
+		return nil, err
+	}
+
+	if to == 0 {
+		to = post.Version
+	}
+	if from == 0 {
+		from = to - 1
+	}
+	if from < 1 {
+		return nil, errors.ValidationError("from", "Revisions start at version 1")
+	}
+	if to < 1 {
+		return nil, errors.ValidationError("to", "Revisions start at version 1")
+	}
+
+	fromRevision, err := s.postRepo.GetRevision(ctx, postID, from)
+	if err != nil {
+		return nil, err
+	}
+	toRevision, err := s.postRepo.GetRevision(ctx, postID, to)
+	if err != nil {
+		return nil, err
+	}
+
+	diff := unifiedDiff(fmt.Sprintf("v%d/title", from), fmt.Sprintf("v%d/title", to), fromRevision.Title, toRevision.Title) +
+		unifiedDiff(fmt.Sprintf("v%d/content", from), fmt.Sprintf("v%d/content", to), fromRevision.Content, toRevision.Content)
+
+	return &models.PostRevisionDiff{
+		PostID: postID,
+		From:   from,
+		To:     to,
+		Diff:   diff,
+	}, nil
+}
+
+// RestoreRevision makes the title and content of an earlier revision current
+// again. The restore is itself a new version, so it can be undone.
+func (s *postService) RestoreRevision(ctx context.Context, userID, postID uuid.UUID, version int) (*models.Post, error) {
+	post, err := s.getOwnPost(ctx, userID, postID)
+	if err != nil {
+		return nil, err
+	}
+
+	revision, err := s.postRepo.GetRevision(ctx, postID, version)
+	if err != nil {
+		return nil, err
+	}
+
+	post.Title = revision.Title
+	post.Content = revision.Content
+	if err := s.postRepo.Update(models.ContextWithViewer(ctx, userID), postID, post); err != nil {
+		return nil, err
+	}
+
+	return post, nil
+}
+
+// getOwnPost loads a post userID wrote
+func (s *postService) getOwnPost(ctx context.Context, userID, postID uuid.UUID) (*models.Post, error) {
+	post, err := getVisiblePost(models.ContextWithViewer(ctx, userID), s.postRepo, postID)
+	if err != nil {
+		return nil, err
+	}
+	if post.UserID != userID {
+		return nil, errors.Forbidden("You can only manage the revisions of your own posts")
+	}
+	return post, nil
+}
+
+// replacementRequest turns req into an update of every editable field of
+// post. Sending back the publication time the post already has keeps it, so
+// that a post read, edited and written back stays as it was.
+func replacementRequest(post *models.Post, req *models.ReplacePostRequest) (*models.UpdatePostRequest, error) {
+	if req.Title == "" {
+		return nil, errors.ValidationError("title", "Title is required")
+	}
+	if req.Content == "" {
+		return nil, errors.ValidationError("content", "Content is required")
+	}
+
+	tags := req.Tags
+	if tags == nil {
+		tags = []string{}
+	}
+	update := &models.UpdatePostRequest{
+		Title:   &req.Title,
+		Content: &req.Content,
+		Tags:    &tags,
+		Version: req.Version,
+	}
+
+	status, publishedAt := req.Status, req.PublishedAt
+	if publishedAt != nil && post.PublishedAt != nil && publishedAt.Equal(*post.PublishedAt) {
+		if status == "" || status == post.Status {
+			return update, nil
+		}
+		publishedAt = nil
+	}
+	switch {
+	case status == "" && publishedAt == nil:
+		status = models.PostStatusPublished
+	case status == "":
+		status = models.PostStatusDraft
+	}
+	update.Status = &status
+	update.PublishedAt = publishedAt
+
+	return update, nil
+}
+
+// applyPostStatus moves post to status, which may be empty to keep the current
+// one. A publishedAt schedules a draft: it must lie in the future. Publishing
+// stamps the publication time unless the post was published before.
+func applyPostStatus(post *models.Post, status models.PostStatus, publishedAt *time.Time, now time.Time) error {
+	if status != "" && !status.IsValid() {
+		return errors.ValidationError("status", "Status must be one of draft, published, archived")
+	}
+
+	if publishedAt != nil {
+		if status == "" {
+			status = post.Status
+		}
+		if status != models.PostStatusDraft {
+			return errors.ValidationError("published_at", "Only drafts can be scheduled")
+		}
+		if !publishedAt.After(now) {
+			return errors.ValidationError("published_at", "Scheduled publication time must be in the future")
+		}
+		scheduledAt := publishedAt.UTC()
+		post.Status = models.PostStatusDraft
+		post.PublishedAt = &scheduledAt
+		return nil
+	}
+
+	switch status {
+	case models.PostStatusDraft:
+		// Unpublishing or moving back to draft cancels any schedule
+		post.PublishedAt = nil
+	case models.PostStatusPublished:
+		if post.Status == models.PostStatusDraft || post.PublishedAt == nil {
+			post.PublishedAt = &now
+		}
+	case models.PostStatusArchived:
+		if post.Status == models.PostStatusDraft {
+			post.PublishedAt = nil
+		}
+	}
+	if status != "" {
+		post.Status = status
+	}
+
+	return nil
+}
+
+// getVisiblePost loads a post the viewer in ctx may read, treating other
+// users' drafts as missing so that their existence is not revealed
+func getVisiblePost(ctx context.Context, postRepo repository.PostRepository, id uuid.UUID) (*models.Post, error) {
+	post, err := postRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !post.VisibleTo(ctx) {
+		return nil, errors.NotFound("Post")
+	}
+	return post, nil
+}
+
+func validateReactionKind(kind models.ReactionKind) error {
+	if !kind.IsValid() {
+		kinds := make([]string, len(models.ReactionKinds))
+		for i, k := range models.ReactionKinds {
+			kinds[i] = string(k)
+		}
+		return errors.ValidationError("kind", fmt.Sprintf("Reaction must be one of %s", strings.Join(kinds, ", ")))
+	}
+	return nil
+}
+
+func newPostCursorPage(posts []*models.Post, params *models.CursorParams, hasMore bool, total *int64) *models.PaginatedResponse {
+	var first, last *models.Cursor
+	if len(posts) > 0 {
+		first = &models.Cursor{CreatedAt: posts[0].CreatedAt, ID: posts[0].ID}
+		last = &models.Cursor{CreatedAt: posts[len(posts)-1].CreatedAt, ID: posts[len(posts)-1].ID}
+	}
+
+	return &models.PaginatedResponse{
+		Data:       posts,
+		Pagination: models.NewCursorPaginationMeta(params, first, last, hasMore, total),
+	}
+}
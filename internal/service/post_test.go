@@ -3,9 +3,11 @@ package service
 import (
 	"context"
 	"fmt"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/alinoer/go-std-api/internal/errors"
 	"github.com/alinoer/go-std-api/internal/models"
 	"github.com/google/uuid"
 )
@@ -13,6 +15,7 @@ import (
 type MockPostRepository struct {
 	posts                         map[uuid.UUID]*models.Post
 	postsByUser                   map[uuid.UUID][]*models.Post
+	deletedPosts                  map[uuid.UUID]*models.Post
 	createError                   error
 	getByIDError                  error
 	listError                     error
@@ -23,12 +26,26 @@ type MockPostRepository struct {
 	deleteError                   error
 	listPaginatedTotal            int64
 	getByUserIDPaginatedTotal     int64
+	listByCursorError             error
+	cursorPosts                   []*models.Post
+	cursorHasMore                 bool
+	countCalls                    int
+	searchResults                 []*models.PostSearchResult
+	searchQuery                   string
+	reactions                     map[string]bool
+	reactionError                 error
+	listOptions                   *models.ListOptions
+	tags                          []*models.Tag
+	revisions                     map[uuid.UUID][]*models.PostRevision
 }
 
 func NewMockPostRepository() *MockPostRepository {
 	return &MockPostRepository{
-		posts:       make(map[uuid.UUID]*models.Post),
-		postsByUser: make(map[uuid.UUID][]*models.Post),
+		posts:        make(map[uuid.UUID]*models.Post),
+		postsByUser:  make(map[uuid.UUID][]*models.Post),
+		deletedPosts: make(map[uuid.UUID]*models.Post),
+		reactions:    make(map[string]bool),
+		revisions:    make(map[uuid.UUID][]*models.PostRevision),
 	}
 }
 
@@ -36,8 +53,10 @@ func (m *MockPostRepository) Create(ctx context.Context, post *models.Post) erro
 	if m.createError != nil {
 		return m.createError
 	}
+	post.Version = 1
 	m.posts[post.ID] = post
 	m.postsByUser[post.UserID] = append(m.postsByUser[post.UserID], post)
+	m.addRevision(post)
 	return nil
 }
 
@@ -47,12 +66,13 @@ func (m *MockPostRepository) GetByID(ctx context.Context, id uuid.UUID) (*models
 	}
 	post, exists := m.posts[id]
 	if !exists {
-		return nil, fmt.Errorf("post not found")
+		return nil, errors.NotFound("Post")
 	}
 	return post, nil
 }
 
-func (m *MockPostRepository) List(ctx context.Context) ([]*models.Post, error) {
+func (m *MockPostRepository) List(ctx context.Context, opts *models.ListOptions) ([]*models.Post, error) {
+	m.listOptions = opts
 	if m.listError != nil {
 		return nil, m.listError
 	}
@@ -63,7 +83,8 @@ func (m *MockPostRepository) List(ctx context.Context) ([]*models.Post, error) {
 	return posts, nil
 }
 
-func (m *MockPostRepository) ListPaginated(ctx context.Context, pagination *models.PaginationParams) ([]*models.Post, int64, error) {
+func (m *MockPostRepository) ListPaginated(ctx context.Context, pagination *models.PaginationParams, opts *models.ListOptions) ([]*models.Post, int64, error) {
+	m.listOptions = opts
 	if m.listPaginatedError != nil {
 		return nil, 0, m.listPaginatedError
 	}
@@ -111,24 +132,170 @@ func (m *MockPostRepository) Update(ctx context.Context, id uuid.UUID, post *mod
 	if m.updateError != nil {
 		return m.updateError
 	}
-	if _, exists := m.posts[id]; !exists {
-		return fmt.Errorf("post not found")
+	existing, exists := m.posts[id]
+	if !exists {
+		return errors.NotFound("Post")
+	}
+	if post.Version != 0 && post.Version != existing.Version {
+		return errors.StaleVersion("Post", post.Version)
 	}
+	post.Version = existing.Version + 1
 	m.posts[id] = post
+	m.addRevision(post)
 	return nil
 }
 
-func (m *MockPostRepository) Delete(ctx context.Context, id uuid.UUID) error {
+func (m *MockPostRepository) addRevision(post *models.Post) {
+	m.revisions[post.ID] = append(m.revisions[post.ID], &models.PostRevision{
+		PostID: post.ID, Version: post.Version, Title: post.Title, Content: post.Content,
+	})
+}
+
+func (m *MockPostRepository) ListRevisions(ctx context.Context, postID uuid.UUID, pagination *models.PaginationParams) ([]*models.PostRevision, int64, error) {
+	if m.listPaginatedError != nil {
+		return nil, 0, m.listPaginatedError
+	}
+	revisions := []*models.PostRevision{}
+	for i := len(m.revisions[postID]) - 1; i >= 0; i-- {
+		revisions = append(revisions, m.revisions[postID][i])
+	}
+	return revisions, int64(len(revisions)), nil
+}
+
+func (m *MockPostRepository) GetRevision(ctx context.Context, postID uuid.UUID, version int) (*models.PostRevision, error) {
+	for _, revision := range m.revisions[postID] {
+		if revision.Version == version {
+			return revision, nil
+		}
+	}
+	return nil, errors.NotFound("Revision")
+}
+
+func (m *MockPostRepository) Delete(ctx context.Context, id uuid.UUID, version int) error {
 	if m.deleteError != nil {
 		return m.deleteError
 	}
-	if _, exists := m.posts[id]; !exists {
-		return fmt.Errorf("post not found")
+	existing, exists := m.posts[id]
+	if !exists {
+		return errors.NotFound("Post")
+	}
+	if version != 0 && version != existing.Version {
+		return errors.StaleVersion("Post", version)
 	}
+	deletedAt := time.Now()
+	existing.DeletedAt = &deletedAt
 	delete(m.posts, id)
+	m.deletedPosts[id] = existing
+	return nil
+}
+
+func (m *MockPostRepository) GetDeleted(ctx context.Context, id uuid.UUID) (*models.Post, error) {
+	post, exists := m.deletedPosts[id]
+	if !exists {
+		return nil, errors.NotFound("Post")
+	}
+	return post, nil
+}
+
+func (m *MockPostRepository) ListDeleted(ctx context.Context, userID uuid.UUID, pagination *models.PaginationParams) ([]*models.Post, int64, error) {
+	if m.listPaginatedError != nil {
+		return nil, 0, m.listPaginatedError
+	}
+	var posts []*models.Post
+	for _, post := range m.deletedPosts {
+		if post.UserID == userID {
+			posts = append(posts, post)
+		}
+	}
+	return posts, int64(len(posts)), nil
+}
+
+func (m *MockPostRepository) Restore(ctx context.Context, id uuid.UUID) error {
+	post, exists := m.deletedPosts[id]
+	if !exists {
+		return errors.NotFound("Post")
+	}
+	post.DeletedAt = nil
+	delete(m.deletedPosts, id)
+	m.posts[id] = post
 	return nil
 }
 
+func (m *MockPostRepository) Purge(ctx context.Context, before time.Time) (int64, error) {
+	if m.deleteError != nil {
+		return 0, m.deleteError
+	}
+	var purged int64
+	for id, post := range m.deletedPosts {
+		if post.DeletedAt.Before(before) {
+			delete(m.deletedPosts, id)
+			purged++
+		}
+	}
+	return purged, nil
+}
+
+func (m *MockPostRepository) ListByCursor(ctx context.Context, params *models.CursorParams, opts *models.ListOptions) ([]*models.Post, bool, error) {
+	if m.listByCursorError != nil {
+		return nil, false, m.listByCursorError
+	}
+	return m.cursorPosts, m.cursorHasMore, nil
+}
+
+func (m *MockPostRepository) GetByUserIDByCursor(ctx context.Context, userID uuid.UUID, params *models.CursorParams) ([]*models.Post, bool, error) {
+	if m.listByCursorError != nil {
+		return nil, false, m.listByCursorError
+	}
+	var posts []*models.Post
+	for _, post := range m.cursorPosts {
+		if post.UserID == userID {
+			posts = append(posts, post)
+		}
+	}
+	return posts, m.cursorHasMore, nil
+}
+
+func (m *MockPostRepository) Count(ctx context.Context, opts *models.ListOptions) (int64, error) {
+	m.countCalls++
+	return int64(len(m.posts)), nil
+}
+
+func (m *MockPostRepository) CountByUserID(ctx context.Context, userID uuid.UUID) (int64, error) {
+	m.countCalls++
+	return int64(len(m.postsByUser[userID])), nil
+}
+
+func (m *MockPostRepository) Search(ctx context.Context, query string, pagination *models.PaginationParams) ([]*models.PostSearchResult, int64, error) {
+	m.searchQuery = query
+	if m.listPaginatedError != nil {
+		return nil, 0, m.listPaginatedError
+	}
+	return m.searchResults, int64(len(m.searchResults)), nil
+}
+
+func (m *MockPostRepository) PublishDue(ctx context.Context, now time.Time) (int64, error) {
+	if m.updateError != nil {
+		return 0, m.updateError
+	}
+	var published int64
+	for _, post := range m.posts {
+		if post.IsScheduled() && !post.PublishedAt.After(now) {
+			post.Status = models.PostStatusPublished
+			post.Version++
+			m.addRevision(post)
+			published++
+		}
+	}
+	return published, nil
+}
+
+func (m *MockPostRepository) ListTags(ctx context.Context) ([]*models.Tag, error) {
+	if m.listError != nil {
+		return nil, m.listError
+	}
+	return m.tags, nil
+}
+
 func (m *MockPostRepository) SetCreateError(err error) {
 	m.createError = err
 }
@@ -169,6 +336,30 @@ func (m *MockPostRepository) SetGetByUserIDPaginatedTotal(total int64) {
 	m.getByUserIDPaginatedTotal = total
 }
 
+func (m *MockPostRepository) AddReaction(ctx context.Context, postID, userID uuid.UUID, kind models.ReactionKind) error {
+	if m.reactionError != nil {
+		return m.reactionError
+	}
+	key := fmt.Sprintf("%s/%s/%s", postID, userID, kind)
+	if !m.reactions[key] {
+		m.reactions[key] = true
+		m.posts[postID].ReactionCount++
+	}
+	return nil
+}
+
+func (m *MockPostRepository) RemoveReaction(ctx context.Context, postID, userID uuid.UUID, kind models.ReactionKind) error {
+	if m.reactionError != nil {
+		return m.reactionError
+	}
+	key := fmt.Sprintf("%s/%s/%s", postID, userID, kind)
+	if m.reactions[key] {
+		delete(m.reactions, key)
+		m.posts[postID].ReactionCount--
+	}
+	return nil
+}
+
 func (m *MockPostRepository) AddPost(post *models.Post) {
 	m.posts[post.ID] = post
 	m.postsByUser[post.UserID] = append(m.postsByUser[post.UserID], post)
@@ -191,7 +382,7 @@ func (m *MockPostUserRepository) GetByID(ctx context.Context, id uuid.UUID) (*mo
 	}
 	user, exists := m.users[id]
 	if !exists {
-		return nil, fmt.Errorf("user not found")
+		return nil, errors.NotFound("User")
 	}
 	return user, nil
 }
@@ -204,14 +395,46 @@ func (m *MockPostUserRepository) GetByUsername(ctx context.Context, username str
 	return nil, nil
 }
 
-func (m *MockPostUserRepository) List(ctx context.Context) ([]*models.User, error) {
+func (m *MockPostUserRepository) List(ctx context.Context, opts *models.ListOptions) ([]*models.User, error) {
 	return nil, nil
 }
 
-func (m *MockPostUserRepository) ListPaginated(ctx context.Context, pagination *models.PaginationParams) ([]*models.User, int64, error) {
+func (m *MockPostUserRepository) ListPaginated(ctx context.Context, pagination *models.PaginationParams, opts *models.ListOptions) ([]*models.User, int64, error) {
 	return nil, 0, nil
 }
 
+func (m *MockPostUserRepository) ListByCursor(ctx context.Context, params *models.CursorParams, opts *models.ListOptions) ([]*models.User, bool, error) {
+	return nil, false, nil
+}
+
+func (m *MockPostUserRepository) Count(ctx context.Context, opts *models.ListOptions) (int64, error) {
+	return 0, nil
+}
+
+func (m *MockPostUserRepository) UpdatePasswordHash(ctx context.Context, id uuid.UUID, passwordHash string) error {
+	return nil
+}
+
+func (m *MockPostUserRepository) UpdateRole(ctx context.Context, id uuid.UUID, role models.Role) error {
+	return nil
+}
+
+func (m *MockPostUserRepository) Update(ctx context.Context, user *models.User) error {
+	return nil
+}
+
+func (m *MockPostUserRepository) Delete(ctx context.Context, id uuid.UUID, policy models.UserPostPolicy) error {
+	return nil
+}
+
+func (m *MockPostUserRepository) Restore(ctx context.Context, id uuid.UUID) error {
+	return nil
+}
+
+func (m *MockPostUserRepository) Purge(ctx context.Context, before time.Time, policy models.UserPostPolicy) (int64, error) {
+	return 0, nil
+}
+
 func (m *MockPostUserRepository) SetGetByIDError(err error) {
 	m.getByIDError = err
 }
@@ -264,7 +487,7 @@ func TestPostService_CreatePost(t *testing.T) {
 				Content: "This is a test post",
 			},
 			setupMocks:    func(postRepo *MockPostRepository, userRepo *MockPostUserRepository) {},
-			expectedError: "title is required",
+			expectedError: "VALIDATION_ERROR: Validation failed for field 'title' - Title is required",
 		},
 		{
 			name:   "empty content",
@@ -274,7 +497,7 @@ func TestPostService_CreatePost(t *testing.T) {
 				Content: "",
 			},
 			setupMocks:    func(postRepo *MockPostRepository, userRepo *MockPostUserRepository) {},
-			expectedError: "content is required",
+			expectedError: "VALIDATION_ERROR: Validation failed for field 'content' - Content is required",
 		},
 		{
 			name:   "user not found",
@@ -284,9 +507,9 @@ func TestPostService_CreatePost(t *testing.T) {
 				Content: "This is a test post",
 			},
 			setupMocks: func(postRepo *MockPostRepository, userRepo *MockPostUserRepository) {
-				userRepo.SetGetByIDError(fmt.Errorf("user not found"))
+				userRepo.SetGetByIDError(errors.NotFound("User"))
 			},
-			expectedError: "user not found",
+			expectedError: "NOT_FOUND: User not found",
 		},
 		{
 			name:   "repository create error",
@@ -303,7 +526,7 @@ func TestPostService_CreatePost(t *testing.T) {
 				userRepo.AddUser(user)
 				postRepo.SetCreateError(fmt.Errorf("database error"))
 			},
-			expectedError: "failed to create post: database error",
+			expectedError: "database error",
 		},
 	}
 
@@ -388,7 +611,7 @@ func TestPostService_GetPost(t *testing.T) {
 			setupMock: func(mock *MockPostRepository) {
 				// No post added
 			},
-			expectedError: "post not found",
+			expectedError: "NOT_FOUND: Post not found",
 		},
 		{
 			name:   "repository error",
@@ -434,12 +657,14 @@ func TestPostService_GetPost(t *testing.T) {
 
 func TestPostService_UpdatePost(t *testing.T) {
 	postID := uuid.New()
+	ownerID := uuid.New()
 	newTitle := "Updated Title"
 	newContent := "Updated Content"
 
 	tests := []struct {
 		name          string
 		postID        uuid.UUID
+		userID        uuid.UUID
 		request       *models.UpdatePostRequest
 		setupMock     func(*MockPostRepository)
 		expectedError string
@@ -447,6 +672,7 @@ func TestPostService_UpdatePost(t *testing.T) {
 		{
 			name:   "successful update with both fields",
 			postID: postID,
+			userID: ownerID,
 			request: &models.UpdatePostRequest{
 				Title:   &newTitle,
 				Content: &newContent,
@@ -454,6 +680,7 @@ func TestPostService_UpdatePost(t *testing.T) {
 			setupMock: func(mock *MockPostRepository) {
 				post := &models.Post{
 					ID:        postID,
+					UserID:    ownerID,
 					Title:     "Original Title",
 					Content:   "Original Content",
 					CreatedAt: time.Now(),
@@ -465,12 +692,14 @@ func TestPostService_UpdatePost(t *testing.T) {
 		{
 			name:   "successful update with only title",
 			postID: postID,
+			userID: ownerID,
 			request: &models.UpdatePostRequest{
 				Title: &newTitle,
 			},
 			setupMock: func(mock *MockPostRepository) {
 				post := &models.Post{
 					ID:        postID,
+					UserID:    ownerID,
 					Title:     "Original Title",
 					Content:   "Original Content",
 					CreatedAt: time.Now(),
@@ -482,23 +711,26 @@ func TestPostService_UpdatePost(t *testing.T) {
 		{
 			name:   "post not found",
 			postID: postID,
+			userID: ownerID,
 			request: &models.UpdatePostRequest{
 				Title: &newTitle,
 			},
 			setupMock: func(mock *MockPostRepository) {
-				mock.SetGetByIDError(fmt.Errorf("post not found"))
+				mock.SetGetByIDError(errors.NotFound("Post"))
 			},
-			expectedError: "post not found",
+			expectedError: "NOT_FOUND: Post not found",
 		},
 		{
 			name:   "repository update error",
 			postID: postID,
+			userID: ownerID,
 			request: &models.UpdatePostRequest{
 				Title: &newTitle,
 			},
 			setupMock: func(mock *MockPostRepository) {
 				post := &models.Post{
 					ID:        postID,
+					UserID:    ownerID,
 					Title:     "Original Title",
 					Content:   "Original Content",
 					CreatedAt: time.Now(),
@@ -506,7 +738,26 @@ func TestPostService_UpdatePost(t *testing.T) {
 				mock.AddPost(post)
 				mock.SetUpdateError(fmt.Errorf("database error"))
 			},
-			expectedError: "failed to update post: database error",
+			expectedError: "database error",
+		},
+		{
+			name:   "post owned by another user",
+			postID: postID,
+			userID: uuid.New(),
+			request: &models.UpdatePostRequest{
+				Title: &newTitle,
+			},
+			setupMock: func(mock *MockPostRepository) {
+				post := &models.Post{
+					ID:        postID,
+					UserID:    ownerID,
+					Title:     "Original Title",
+					Content:   "Original Content",
+					CreatedAt: time.Now(),
+				}
+				mock.AddPost(post)
+			},
+			expectedError: "FORBIDDEN: You can only update your own posts",
 		},
 	}
 
@@ -517,7 +768,7 @@ func TestPostService_UpdatePost(t *testing.T) {
 			tt.setupMock(mockPostRepo)
 			service := NewPostService(mockPostRepo, mockUserRepo)
 
-			post, err := service.UpdatePost(context.Background(), tt.postID, tt.request)
+			post, err := service.UpdatePost(context.Background(), tt.userID, tt.postID, tt.request, nil)
 
 			if tt.expectedError != "" {
 				if err == nil {
@@ -553,19 +804,23 @@ func TestPostService_UpdatePost(t *testing.T) {
 
 func TestPostService_DeletePost(t *testing.T) {
 	postID := uuid.New()
+	ownerID := uuid.New()
 
 	tests := []struct {
 		name          string
 		postID        uuid.UUID
+		userID        uuid.UUID
 		setupMock     func(*MockPostRepository)
 		expectedError string
 	}{
 		{
 			name:   "successful delete",
 			postID: postID,
+			userID: ownerID,
 			setupMock: func(mock *MockPostRepository) {
 				post := &models.Post{
-					ID: postID,
+					ID:     postID,
+					UserID: ownerID,
 				}
 				mock.AddPost(post)
 			},
@@ -574,10 +829,38 @@ func TestPostService_DeletePost(t *testing.T) {
 		{
 			name:   "post not found",
 			postID: postID,
+			userID: ownerID,
+			setupMock: func(mock *MockPostRepository) {
+				mock.SetGetByIDError(errors.NotFound("Post"))
+			},
+			expectedError: "NOT_FOUND: Post not found",
+		},
+		{
+			name:   "repository delete error",
+			postID: postID,
+			userID: ownerID,
 			setupMock: func(mock *MockPostRepository) {
-				mock.SetDeleteError(fmt.Errorf("post not found"))
+				post := &models.Post{
+					ID:     postID,
+					UserID: ownerID,
+				}
+				mock.AddPost(post)
+				mock.SetDeleteError(fmt.Errorf("database error"))
+			},
+			expectedError: "database error",
+		},
+		{
+			name:   "post owned by another user",
+			postID: postID,
+			userID: uuid.New(),
+			setupMock: func(mock *MockPostRepository) {
+				post := &models.Post{
+					ID:     postID,
+					UserID: ownerID,
+				}
+				mock.AddPost(post)
 			},
-			expectedError: "post not found",
+			expectedError: "FORBIDDEN: You can only delete your own posts",
 		},
 	}
 
@@ -588,7 +871,7 @@ func TestPostService_DeletePost(t *testing.T) {
 			tt.setupMock(mockPostRepo)
 			service := NewPostService(mockPostRepo, mockUserRepo)
 
-			err := service.DeletePost(context.Background(), tt.postID)
+			err := service.DeletePost(context.Background(), tt.userID, tt.postID, nil)
 
 			if tt.expectedError != "" {
 				if err == nil {
@@ -608,54 +891,36 @@ func TestPostService_DeletePost(t *testing.T) {
 	}
 }
 
-func TestPostService_ListPostsPaginated(t *testing.T) {
+func TestPostService_DeleteAnyPost(t *testing.T) {
+	postID := uuid.New()
+
 	tests := []struct {
 		name          string
-		pagination    *models.PaginationParams
-		setupMock     func(*MockPostRepository)
+		postID        uuid.UUID
 		expectedError string
-		expectedTotal int64
 	}{
 		{
-			name: "successful pagination",
-			pagination: &models.PaginationParams{
-				Page:     1,
-				PageSize: 10,
-				Offset:   0,
-			},
-			setupMock: func(mock *MockPostRepository) {
-				mock.SetListPaginatedTotal(25)
-			},
-			expectedError: "",
-			expectedTotal: 25,
+			name:   "deletes post of another user",
+			postID: postID,
 		},
 		{
-			name: "repository error",
-			pagination: &models.PaginationParams{
-				Page:     1,
-				PageSize: 10,
-				Offset:   0,
-			},
-			setupMock: func(mock *MockPostRepository) {
-				mock.SetListPaginatedError(fmt.Errorf("database error"))
-			},
-			expectedError: "database error",
+			name:          "post not found",
+			postID:        uuid.New(),
+			expectedError: "NOT_FOUND: Post not found",
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			mockPostRepo := NewMockPostRepository()
-			mockUserRepo := NewMockPostUserRepository()
-			tt.setupMock(mockPostRepo)
-			service := NewPostService(mockPostRepo, mockUserRepo)
+			mockPostRepo.AddPost(&models.Post{ID: postID, UserID: uuid.New()})
+			service := NewPostService(mockPostRepo, NewMockPostUserRepository())
 
-			response, err := service.ListPostsPaginated(context.Background(), tt.pagination)
+			err := service.DeleteAnyPost(context.Background(), tt.postID)
 
 			if tt.expectedError != "" {
 				if err == nil {
-					t.Errorf("expected error containing %q, got nil", tt.expectedError)
-					return
+					t.Fatalf("expected error %q, got nil", tt.expectedError)
 				}
 				if err.Error() != tt.expectedError {
 					t.Errorf("expected error %q, got %q", tt.expectedError, err.Error())
@@ -664,128 +929,1116 @@ func TestPostService_ListPostsPaginated(t *testing.T) {
 			}
 
 			if err != nil {
-				t.Errorf("unexpected error: %v", err)
-				return
+				t.Fatalf("unexpected error: %v", err)
 			}
-
-			if response == nil {
-				t.Error("expected non-nil response")
-				return
-			}
-
-			if response.Pagination == nil {
-				t.Error("expected non-nil pagination meta")
-				return
-			}
-
-			if response.Pagination.Total != tt.expectedTotal {
-				t.Errorf("expected total %d, got %d", tt.expectedTotal, response.Pagination.Total)
+			if _, err := mockPostRepo.GetByID(context.Background(), postID); err == nil {
+				t.Error("expected post to be deleted")
 			}
 		})
 	}
 }
 
-func TestPostService_GetPostsByUserPaginated(t *testing.T) {
-	userID := uuid.New()
+func TestPostService_ListPostsByCursor(t *testing.T) {
+	newer := &models.Post{ID: uuid.New(), Title: "Newer", CreatedAt: time.Now()}
+	older := &models.Post{ID: uuid.New(), Title: "Older", CreatedAt: time.Now().Add(-time.Hour)}
 
 	tests := []struct {
-		name          string
-		userID        uuid.UUID
-		pagination    *models.PaginationParams
-		setupMocks    func(*MockPostRepository, *MockPostUserRepository)
-		expectedError string
-		expectedTotal int64
+		name               string
+		params             *models.CursorParams
+		opts               *models.ListOptions
+		setupMock          func(*MockPostRepository)
+		expectedError      string
+		expectNextCursor   bool
+		expectTotal        bool
+		expectedCountCalls int
 	}{
 		{
-			name:   "successful pagination",
-			userID: userID,
-			pagination: &models.PaginationParams{
-				Page:     1,
-				PageSize: 10,
-				Offset:   0,
-			},
-			setupMocks: func(postRepo *MockPostRepository, userRepo *MockPostUserRepository) {
-				user := &models.User{
-					ID:       userID,
-					Username: "testuser",
-				}
-				userRepo.AddUser(user)
-				postRepo.SetGetByUserIDPaginatedTotal(10)
+			name:   "first page with more posts",
+			params: models.NewCursorParams(nil, 2, false),
+			setupMock: func(mock *MockPostRepository) {
+				mock.cursorPosts = []*models.Post{newer, older}
+				mock.cursorHasMore = true
 			},
-			expectedError: "",
-			expectedTotal: 10,
+			expectNextCursor: true,
 		},
 		{
-			name:   "user not found",
-			userID: userID,
-			pagination: &models.PaginationParams{
-				Page:     1,
-				PageSize: 10,
-				Offset:   0,
-			},
-			setupMocks: func(postRepo *MockPostRepository, userRepo *MockPostUserRepository) {
-				userRepo.SetGetByIDError(fmt.Errorf("user not found"))
+			name:   "total requested",
+			params: models.NewCursorParams(nil, 2, true),
+			setupMock: func(mock *MockPostRepository) {
+				mock.AddPost(newer)
+				mock.AddPost(older)
+				mock.cursorPosts = []*models.Post{newer, older}
 			},
-			expectedError: "user not found",
+			expectTotal:        true,
+			expectedCountCalls: 1,
 		},
 		{
 			name:   "repository error",
-			userID: userID,
-			pagination: &models.PaginationParams{
-				Page:     1,
-				PageSize: 10,
-				Offset:   0,
-			},
-			setupMocks: func(postRepo *MockPostRepository, userRepo *MockPostUserRepository) {
-				user := &models.User{
-					ID:       userID,
-					Username: "testuser",
-				}
-				userRepo.AddUser(user)
-				postRepo.SetGetByUserIDPaginatedError(fmt.Errorf("database error"))
+			params: models.NewCursorParams(nil, 2, true),
+			setupMock: func(mock *MockPostRepository) {
+				mock.listByCursorError = errors.DatabaseError("list posts", fmt.Errorf("connection refused"))
 			},
-			expectedError: "database error",
+			expectedError: "DATABASE_ERROR: Database operation failed: list posts",
+		},
+		{
+			name:          "sort is rejected",
+			params:        models.NewCursorParams(nil, 2, false),
+			opts:          &models.ListOptions{Sort: []models.SortField{{Field: "title"}}},
+			setupMock:     func(mock *MockPostRepository) {},
+			expectedError: "VALIDATION_ERROR: Validation failed for field 'sort' - Sorting is not supported with cursor pagination",
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			mockPostRepo := NewMockPostRepository()
-			mockUserRepo := NewMockPostUserRepository()
-			tt.setupMocks(mockPostRepo, mockUserRepo)
-			service := NewPostService(mockPostRepo, mockUserRepo)
+			tt.setupMock(mockPostRepo)
+			service := NewPostService(mockPostRepo, NewMockPostUserRepository())
 
-			response, err := service.GetPostsByUserPaginated(context.Background(), tt.userID, tt.pagination)
+			response, err := service.ListPostsByCursor(context.Background(), tt.params, tt.opts)
 
 			if tt.expectedError != "" {
-				if err == nil {
-					t.Errorf("expected error containing %q, got nil", tt.expectedError)
-					return
-				}
-				if err.Error() != tt.expectedError {
-					t.Errorf("expected error %q, got %q", tt.expectedError, err.Error())
+				if err == nil || err.Error() != tt.expectedError {
+					t.Errorf("expected error %q, got %v", tt.expectedError, err)
 				}
 				return
 			}
-
 			if err != nil {
-				t.Errorf("unexpected error: %v", err)
-				return
+				t.Fatalf("unexpected error: %v", err)
 			}
 
-			if response == nil {
-				t.Error("expected non-nil response")
-				return
+			meta := response.Pagination
+			if (meta.NextCursor != "") != tt.expectNextCursor {
+				t.Errorf("expected next cursor present to be %t, got %q", tt.expectNextCursor, meta.NextCursor)
 			}
-
+			if tt.expectNextCursor {
+				cursor, err := models.DecodeCursor(meta.NextCursor)
+				if err != nil {
+					t.Fatalf("invalid next cursor: %v", err)
+				}
+				if cursor.ID != older.ID {
+					t.Errorf("expected next cursor to point at the last post, got %s", cursor.ID)
+				}
+			}
+			if (meta.Total != nil) != tt.expectTotal {
+				t.Errorf("expected total present to be %t, got %v", tt.expectTotal, meta.Total)
+			}
+			if mockPostRepo.countCalls != tt.expectedCountCalls {
+				t.Errorf("expected %d count queries, got %d", tt.expectedCountCalls, mockPostRepo.countCalls)
+			}
+		})
+	}
+}
+
+func TestPostService_SearchPosts(t *testing.T) {
+	tests := []struct {
+		name          string
+		query         string
+		setupMock     func(*MockPostRepository)
+		expectedQuery string
+		expectedError string
+		expectedTotal int64
+	}{
+		{
+			name:  "successful search",
+			query: "  golang generics ",
+			setupMock: func(mock *MockPostRepository) {
+				mock.searchResults = []*models.PostSearchResult{
+					{Post: models.Post{ID: uuid.New(), Title: "Golang generics"}, Rank: 0.9, TitleHeadline: "<mark>Golang</mark> <mark>generics</mark>"},
+				}
+			},
+			expectedQuery: "golang generics",
+			expectedTotal: 1,
+		},
+		{
+			name:          "empty query",
+			query:         "   ",
+			setupMock:     func(mock *MockPostRepository) {},
+			expectedError: "VALIDATION_ERROR: Validation failed for field 'q' - Search query is required",
+		},
+		{
+			name:          "query too long",
+			query:         strings.Repeat("a", 201),
+			setupMock:     func(mock *MockPostRepository) {},
+			expectedError: "VALIDATION_ERROR: Validation failed for field 'q' - Search query must be at most 200 characters",
+		},
+		{
+			name:  "repository error",
+			query: "golang",
+			setupMock: func(mock *MockPostRepository) {
+				mock.SetListPaginatedError(errors.DatabaseError("search posts", fmt.Errorf("connection refused")))
+			},
+			expectedError: "DATABASE_ERROR: Database operation failed: search posts",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockPostRepo := NewMockPostRepository()
+			tt.setupMock(mockPostRepo)
+			service := NewPostService(mockPostRepo, NewMockPostUserRepository())
+
+			response, err := service.SearchPosts(context.Background(), tt.query, models.NewPaginationParams(1, 10))
+
+			if tt.expectedError != "" {
+				if err == nil || err.Error() != tt.expectedError {
+					t.Errorf("expected error %q, got %v", tt.expectedError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if mockPostRepo.searchQuery != tt.expectedQuery {
+				t.Errorf("expected search query %q, got %q", tt.expectedQuery, mockPostRepo.searchQuery)
+			}
+			if response.Pagination.Total == nil || *response.Pagination.Total != tt.expectedTotal {
+				t.Errorf("expected total %d, got %v", tt.expectedTotal, response.Pagination.Total)
+			}
+		})
+	}
+}
+
+func TestPostService_ListPostsPaginated(t *testing.T) {
+	tests := []struct {
+		name          string
+		pagination    *models.PaginationParams
+		setupMock     func(*MockPostRepository)
+		expectedError string
+		expectedTotal int64
+	}{
+		{
+			name: "successful pagination",
+			pagination: &models.PaginationParams{
+				Page:     1,
+				PageSize: 10,
+				Offset:   0,
+			},
+			setupMock: func(mock *MockPostRepository) {
+				mock.SetListPaginatedTotal(25)
+			},
+			expectedError: "",
+			expectedTotal: 25,
+		},
+		{
+			name: "repository error",
+			pagination: &models.PaginationParams{
+				Page:     1,
+				PageSize: 10,
+				Offset:   0,
+			},
+			setupMock: func(mock *MockPostRepository) {
+				mock.SetListPaginatedError(fmt.Errorf("database error"))
+			},
+			expectedError: "database error",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockPostRepo := NewMockPostRepository()
+			mockUserRepo := NewMockPostUserRepository()
+			tt.setupMock(mockPostRepo)
+			service := NewPostService(mockPostRepo, mockUserRepo)
+
+			response, err := service.ListPostsPaginated(context.Background(), tt.pagination, nil)
+
+			if tt.expectedError != "" {
+				if err == nil {
+					t.Errorf("expected error containing %q, got nil", tt.expectedError)
+					return
+				}
+				if err.Error() != tt.expectedError {
+					t.Errorf("expected error %q, got %q", tt.expectedError, err.Error())
+				}
+				return
+			}
+
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+				return
+			}
+
+			if response == nil {
+				t.Error("expected non-nil response")
+				return
+			}
+
+			if response.Pagination == nil {
+				t.Error("expected non-nil pagination meta")
+				return
+			}
+
+			if response.Pagination.Total == nil || *response.Pagination.Total != tt.expectedTotal {
+				t.Errorf("expected total %d, got %v", tt.expectedTotal, response.Pagination.Total)
+			}
+		})
+	}
+}
+
+func TestPostService_GetPostsByUserPaginated(t *testing.T) {
+	userID := uuid.New()
+
+	tests := []struct {
+		name          string
+		userID        uuid.UUID
+		pagination    *models.PaginationParams
+		setupMocks    func(*MockPostRepository, *MockPostUserRepository)
+		expectedError string
+		expectedTotal int64
+	}{
+		{
+			name:   "successful pagination",
+			userID: userID,
+			pagination: &models.PaginationParams{
+				Page:     1,
+				PageSize: 10,
+				Offset:   0,
+			},
+			setupMocks: func(postRepo *MockPostRepository, userRepo *MockPostUserRepository) {
+				user := &models.User{
+					ID:       userID,
+					Username: "testuser",
+				}
+				userRepo.AddUser(user)
+				postRepo.SetGetByUserIDPaginatedTotal(10)
+			},
+			expectedError: "",
+			expectedTotal: 10,
+		},
+		{
+			name:   "user not found",
+			userID: userID,
+			pagination: &models.PaginationParams{
+				Page:     1,
+				PageSize: 10,
+				Offset:   0,
+			},
+			setupMocks: func(postRepo *MockPostRepository, userRepo *MockPostUserRepository) {
+				userRepo.SetGetByIDError(errors.NotFound("User"))
+			},
+			expectedError: "NOT_FOUND: User not found",
+		},
+		{
+			name:   "repository error",
+			userID: userID,
+			pagination: &models.PaginationParams{
+				Page:     1,
+				PageSize: 10,
+				Offset:   0,
+			},
+			setupMocks: func(postRepo *MockPostRepository, userRepo *MockPostUserRepository) {
+				user := &models.User{
+					ID:       userID,
+					Username: "testuser",
+				}
+				userRepo.AddUser(user)
+				postRepo.SetGetByUserIDPaginatedError(fmt.Errorf("database error"))
+			},
+			expectedError: "database error",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockPostRepo := NewMockPostRepository()
+			mockUserRepo := NewMockPostUserRepository()
+			tt.setupMocks(mockPostRepo, mockUserRepo)
+			service := NewPostService(mockPostRepo, mockUserRepo)
+
+			response, err := service.GetPostsByUserPaginated(context.Background(), tt.userID, tt.pagination)
+
+			if tt.expectedError != "" {
+				if err == nil {
+					t.Errorf("expected error containing %q, got nil", tt.expectedError)
+					return
+				}
+				if err.Error() != tt.expectedError {
+					t.Errorf("expected error %q, got %q", tt.expectedError, err.Error())
+				}
+				return
+			}
+
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+				return
+			}
+
+			if response == nil {
+				t.Error("expected non-nil response")
+				return
+			}
+
 			if response.Pagination == nil {
 				t.Error("expected non-nil pagination meta")
 				return
 			}
 
-			if response.Pagination.Total != tt.expectedTotal {
-				t.Errorf("expected total %d, got %d", tt.expectedTotal, response.Pagination.Total)
+			if response.Pagination.Total == nil || *response.Pagination.Total != tt.expectedTotal {
+				t.Errorf("expected total %d, got %v", tt.expectedTotal, response.Pagination.Total)
+			}
+		})
+	}
+}
+func TestPostService_Reactions(t *testing.T) {
+	userID := uuid.New()
+	post := &models.Post{ID: uuid.New(), UserID: uuid.New(), Title: "Post", CreatedAt: time.Now()}
+
+	type step struct {
+		remove        bool
+		postID        uuid.UUID
+		kind          models.ReactionKind
+		expectedCount int64
+		expectedError string
+	}
+
+	tests := []struct {
+		name          string
+		reactionError error
+		steps         []step
+	}{
+		{
+			name: "adding twice counts once",
+			steps: []step{
+				{postID: post.ID, kind: models.ReactionLike, expectedCount: 1},
+				{postID: post.ID, kind: models.ReactionLike, expectedCount: 1},
+				{postID: post.ID, kind: models.ReactionLove, expectedCount: 2},
+			},
+		},
+		{
+			name: "removing twice is a no-op",
+			steps: []step{
+				{postID: post.ID, kind: models.ReactionLike, expectedCount: 1},
+				{remove: true, postID: post.ID, kind: models.ReactionLike, expectedCount: 0},
+				{remove: true, postID: post.ID, kind: models.ReactionLike, expectedCount: 0},
+			},
+		},
+		{
+			name: "unknown kind",
+			steps: []step{
+				{postID: post.ID, kind: "dislike", expectedError: "VALIDATION_ERROR: Validation failed for field 'kind' - Reaction must be one of like, love, laugh, wow, sad"},
+				{remove: true, postID: post.ID, kind: "", expectedError: "VALIDATION_ERROR: Validation failed for field 'kind' - Reaction must be one of like, love, laugh, wow, sad"},
+			},
+		},
+		{
+			name: "post not found",
+			steps: []step{
+				{postID: uuid.New(), kind: models.ReactionLike, expectedError: "NOT_FOUND: Post not found"},
+			},
+		},
+		{
+			name:          "repository error",
+			reactionError: errors.DatabaseError("add reaction", fmt.Errorf("connection refused")),
+			steps: []step{
+				{postID: post.ID, kind: models.ReactionLike, expectedError: "DATABASE_ERROR: Database operation failed: add reaction"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockPostRepo := NewMockPostRepository()
+			copied := *post
+			mockPostRepo.AddPost(&copied)
+			mockPostRepo.reactionError = tt.reactionError
+			service := NewPostService(mockPostRepo, NewMockPostUserRepository())
+
+			for i, step := range tt.steps {
+				var updated *models.Post
+				var err error
+				if step.remove {
+					updated, err = service.RemoveReaction(context.Background(), userID, step.postID, step.kind)
+				} else {
+					updated, err = service.AddReaction(context.Background(), userID, step.postID, step.kind)
+				}
+
+				if step.expectedError != "" {
+					if err == nil || err.Error() != step.expectedError {
+						t.Errorf("step %d: expected error %q, got %v", i, step.expectedError, err)
+					}
+					continue
+				}
+				if err != nil {
+					t.Fatalf("step %d: unexpected error: %v", i, err)
+				}
+				if updated.ReactionCount != step.expectedCount {
+					t.Errorf("step %d: expected %d reactions, got %d", i, step.expectedCount, updated.ReactionCount)
+				}
 			}
 		})
 	}
-}
\ No newline at end of file
+}
+
+func TestPostService_CreatePostStatus(t *testing.T) {
+	userID := uuid.New()
+	future := time.Now().Add(time.Hour)
+	past := time.Now().Add(-time.Hour)
+
+	tests := []struct {
+		name              string
+		status            models.PostStatus
+		publishedAt       *time.Time
+		expectedStatus    models.PostStatus
+		expectedPublished bool
+		expectedError     string
+	}{
+		{
+			name:              "published by default",
+			expectedStatus:    models.PostStatusPublished,
+			expectedPublished: true,
+		},
+		{
+			name:           "draft",
+			status:         models.PostStatusDraft,
+			expectedStatus: models.PostStatusDraft,
+		},
+		{
+			name:              "scheduled",
+			publishedAt:       &future,
+			expectedStatus:    models.PostStatusDraft,
+			expectedPublished: true,
+		},
+		{
+			name:          "scheduled in the past",
+			status:        models.PostStatusDraft,
+			publishedAt:   &past,
+			expectedError: "VALIDATION_ERROR: Validation failed for field 'published_at' - Scheduled publication time must be in the future",
+		},
+		{
+			name:          "scheduled but published",
+			status:        models.PostStatusPublished,
+			publishedAt:   &future,
+			expectedError: "VALIDATION_ERROR: Validation failed for field 'published_at' - Only drafts can be scheduled",
+		},
+		{
+			name:          "archived",
+			status:        models.PostStatusArchived,
+			expectedError: "VALIDATION_ERROR: Validation failed for field 'status' - New posts must be drafts or published",
+		},
+		{
+			name:          "unknown status",
+			status:        "hidden",
+			expectedError: "VALIDATION_ERROR: Validation failed for field 'status' - Status must be one of draft, published, archived",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			postRepo := NewMockPostRepository()
+			userRepo := NewMockPostUserRepository()
+			userRepo.AddUser(&models.User{ID: userID, Username: "testuser"})
+			service := NewPostService(postRepo, userRepo)
+
+			post, err := service.CreatePost(context.Background(), userID, &models.CreatePostRequest{
+				Title: "Post", Content: "Content", Status: tt.status, PublishedAt: tt.publishedAt,
+			})
+
+			if tt.expectedError != "" {
+				if err == nil || err.Error() != tt.expectedError {
+					t.Errorf("expected error %q, got %v", tt.expectedError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if post.Status != tt.expectedStatus {
+				t.Errorf("expected status %s, got %s", tt.expectedStatus, post.Status)
+			}
+			if (post.PublishedAt != nil) != tt.expectedPublished {
+				t.Errorf("expected published_at set: %v, got %v", tt.expectedPublished, post.PublishedAt)
+			}
+		})
+	}
+}
+
+func TestPostService_UpdatePostStatus(t *testing.T) {
+	userID := uuid.New()
+	earlier := time.Now().Add(-24 * time.Hour)
+	future := time.Now().Add(time.Hour)
+	status := func(s models.PostStatus) *models.PostStatus { return &s }
+
+	tests := []struct {
+		name                string
+		post                models.Post
+		request             models.UpdatePostRequest
+		expectedStatus      models.PostStatus
+		expectedPublishedAt func(*time.Time) bool
+		expectedError       string
+	}{
+		{
+			name:                "publish a draft",
+			post:                models.Post{Status: models.PostStatusDraft},
+			request:             models.UpdatePostRequest{Status: status(models.PostStatusPublished)},
+			expectedStatus:      models.PostStatusPublished,
+			expectedPublishedAt: func(at *time.Time) bool { return at != nil && at.After(earlier) },
+		},
+		{
+			name:                "publishing a scheduled draft publishes it now",
+			post:                models.Post{Status: models.PostStatusDraft, PublishedAt: &future},
+			request:             models.UpdatePostRequest{Status: status(models.PostStatusPublished)},
+			expectedStatus:      models.PostStatusPublished,
+			expectedPublishedAt: func(at *time.Time) bool { return at != nil && at.Before(future) },
+		},
+		{
+			name:                "unarchiving keeps the publication time",
+			post:                models.Post{Status: models.PostStatusArchived, PublishedAt: &earlier},
+			request:             models.UpdatePostRequest{Status: status(models.PostStatusPublished)},
+			expectedStatus:      models.PostStatusPublished,
+			expectedPublishedAt: func(at *time.Time) bool { return at != nil && at.Equal(earlier) },
+		},
+		{
+			name:                "archive",
+			post:                models.Post{Status: models.PostStatusPublished, PublishedAt: &earlier},
+			request:             models.UpdatePostRequest{Status: status(models.PostStatusArchived)},
+			expectedStatus:      models.PostStatusArchived,
+			expectedPublishedAt: func(at *time.Time) bool { return at != nil && at.Equal(earlier) },
+		},
+		{
+			name:                "unpublish",
+			post:                models.Post{Status: models.PostStatusPublished, PublishedAt: &earlier},
+			request:             models.UpdatePostRequest{Status: status(models.PostStatusDraft)},
+			expectedStatus:      models.PostStatusDraft,
+			expectedPublishedAt: func(at *time.Time) bool { return at == nil },
+		},
+		{
+			name:                "schedule a draft",
+			post:                models.Post{Status: models.PostStatusDraft},
+			request:             models.UpdatePostRequest{PublishedAt: &future},
+			expectedStatus:      models.PostStatusDraft,
+			expectedPublishedAt: func(at *time.Time) bool { return at != nil && at.Equal(future) },
+		},
+		{
+			name:          "schedule a published post",
+			post:          models.Post{Status: models.PostStatusPublished, PublishedAt: &earlier},
+			request:       models.UpdatePostRequest{PublishedAt: &future},
+			expectedError: "VALIDATION_ERROR: Validation failed for field 'published_at' - Only drafts can be scheduled",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			postRepo := NewMockPostRepository()
+			post := tt.post
+			post.ID = uuid.New()
+			post.UserID = userID
+			postRepo.AddPost(&post)
+			service := NewPostService(postRepo, NewMockPostUserRepository())
+
+			updated, err := service.UpdatePost(context.Background(), userID, post.ID, &tt.request, nil)
+
+			if tt.expectedError != "" {
+				if err == nil || err.Error() != tt.expectedError {
+					t.Errorf("expected error %q, got %v", tt.expectedError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if updated.Status != tt.expectedStatus {
+				t.Errorf("expected status %s, got %s", tt.expectedStatus, updated.Status)
+			}
+			if !tt.expectedPublishedAt(updated.PublishedAt) {
+				t.Errorf("unexpected published_at %v", updated.PublishedAt)
+			}
+		})
+	}
+}
+
+func TestPostService_DraftVisibility(t *testing.T) {
+	authorID := uuid.New()
+	otherID := uuid.New()
+	draft := &models.Post{ID: uuid.New(), UserID: authorID, Title: "Draft", Status: models.PostStatusDraft}
+
+	tests := []struct {
+		name          string
+		ctx           context.Context
+		expectedError string
+	}{
+		{name: "author", ctx: models.ContextWithViewer(context.Background(), authorID)},
+		{name: "another user", ctx: models.ContextWithViewer(context.Background(), otherID), expectedError: "NOT_FOUND: Post not found"},
+		{name: "anonymous", ctx: context.Background(), expectedError: "NOT_FOUND: Post not found"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			postRepo := NewMockPostRepository()
+			postRepo.AddPost(draft)
+			service := NewPostService(postRepo, NewMockPostUserRepository())
+
+			_, err := service.GetPost(tt.ctx, draft.ID)
+			if tt.expectedError == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || err.Error() != tt.expectedError {
+				t.Errorf("expected error %q, got %v", tt.expectedError, err)
+			}
+		})
+	}
+
+	t.Run("other users cannot update drafts", func(t *testing.T) {
+		postRepo := NewMockPostRepository()
+		postRepo.AddPost(draft)
+		service := NewPostService(postRepo, NewMockPostUserRepository())
+
+		title := "Taken over"
+		_, err := service.UpdatePost(context.Background(), otherID, draft.ID, &models.UpdatePostRequest{Title: &title}, nil)
+		if err == nil || err.Error() != "NOT_FOUND: Post not found" {
+			t.Errorf("expected the draft to be reported missing, got %v", err)
+		}
+	})
+}
+
+func TestPostService_Revisions(t *testing.T) {
+	authorID := uuid.New()
+	otherID := uuid.New()
+
+	newService := func() (PostService, *MockPostRepository, *models.Post) {
+		postRepo := NewMockPostRepository()
+		post := &models.Post{ID: uuid.New(), UserID: authorID, Title: "Title", Content: "first\nsecond", Status: models.PostStatusPublished}
+		postRepo.Create(context.Background(), post)
+		return NewPostService(postRepo, NewMockPostUserRepository()), postRepo, post
+	}
+	content := func(s string) *string { return &s }
+
+	t.Run("updates record revisions", func(t *testing.T) {
+		service, _, post := newService()
+
+		updated, err := service.UpdatePost(context.Background(), authorID, post.ID, &models.UpdatePostRequest{Content: content("first\nchanged")}, nil)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if updated.Version != 2 {
+			t.Errorf("expected version 2, got %d", updated.Version)
+		}
+
+		result, err := service.ListRevisions(context.Background(), authorID, post.ID, models.NewPaginationParams(1, 10))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		revisions := result.Data.([]*models.PostRevision)
+		if len(revisions) != 2 || revisions[0].Version != 2 || revisions[1].Content != "first\nsecond" {
+			t.Errorf("unexpected revisions: %v", revisions)
+		}
+	})
+
+	t.Run("stale version is rejected", func(t *testing.T) {
+		service, _, post := newService()
+
+		stale := 0
+		_, err := service.UpdatePost(context.Background(), authorID, post.ID, &models.UpdatePostRequest{Content: content("x"), Version: &stale}, nil)
+		expected := "CONFLICT: Post has been changed since version 0"
+		if err == nil || err.Error() != expected {
+			t.Errorf("expected error %q, got %v", expected, err)
+		}
+	})
+
+	t.Run("diff defaults to the latest change", func(t *testing.T) {
+		service, _, post := newService()
+		service.UpdatePost(context.Background(), authorID, post.ID, &models.UpdatePostRequest{Content: content("first\nchanged")}, nil)
+
+		diff, err := service.DiffRevisions(context.Background(), authorID, post.ID, 0, 0)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		expected := "--- v1/content\n+++ v2/content\n@@ -1,2 +1,2 @@\n first\n-second\n+changed\n"
+		if diff.From != 1 || diff.To != 2 || diff.Diff != expected {
+			t.Errorf("unexpected diff from %d to %d:\n%s", diff.From, diff.To, diff.Diff)
+		}
+
+		if _, err := service.DiffRevisions(context.Background(), authorID, post.ID, 1, 5); err == nil || err.Error() != "NOT_FOUND: Revision not found" {
+			t.Errorf("expected a missing revision to be reported, got %v", err)
+		}
+	})
+
+	t.Run("diff of the first version", func(t *testing.T) {
+		service, _, post := newService()
+
+		_, err := service.DiffRevisions(context.Background(), authorID, post.ID, 0, 0)
+		expected := "VALIDATION_ERROR: Validation failed for field 'from' - Revisions start at version 1"
+		if err == nil || err.Error() != expected {
+			t.Errorf("expected error %q, got %v", expected, err)
+		}
+	})
+
+	t.Run("restore creates a new version", func(t *testing.T) {
+		service, _, post := newService()
+		service.UpdatePost(context.Background(), authorID, post.ID, &models.UpdatePostRequest{Content: content("clobbered")}, nil)
+
+		restored, err := service.RestoreRevision(context.Background(), authorID, post.ID, 1)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if restored.Content != "first\nsecond" || restored.Version != 3 {
+			t.Errorf("expected version 3 with the original content, got version %d: %q", restored.Version, restored.Content)
+		}
+	})
+
+	t.Run("only the author sees the history", func(t *testing.T) {
+		service, _, post := newService()
+
+		_, err := service.ListRevisions(context.Background(), otherID, post.ID, models.NewPaginationParams(1, 10))
+		if err == nil || errors.AsAppError(err).Code != errors.ErrCodeForbidden {
+			t.Errorf("expected forbidden, got %v", err)
+		}
+		_, err = service.RestoreRevision(context.Background(), otherID, post.ID, 1)
+		if err == nil || errors.AsAppError(err).Code != errors.ErrCodeForbidden {
+			t.Errorf("expected forbidden, got %v", err)
+		}
+	})
+}
+
+func TestPostService_IfMatch(t *testing.T) {
+	authorID := uuid.New()
+	title := "Changed"
+
+	tests := []struct {
+		name        string
+		ifMatch     *models.Precondition
+		expectedErr errors.ErrorCode
+	}{
+		{name: "no precondition", ifMatch: nil},
+		{name: "any version", ifMatch: &models.Precondition{Any: true}},
+		{name: "current version", ifMatch: &models.Precondition{Versions: []int{1}}},
+		{name: "one of several versions", ifMatch: &models.Precondition{Versions: []int{4, 1}}},
+		{name: "stale version", ifMatch: &models.Precondition{Versions: []int{2}}, expectedErr: errors.ErrCodePrecondition},
+		{name: "no matching tag", ifMatch: &models.Precondition{}, expectedErr: errors.ErrCodePrecondition},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, op := range []string{"update", "delete"} {
+				postRepo := NewMockPostRepository()
+				post := &models.Post{ID: uuid.New(), UserID: authorID, Title: "Title", Content: "Content", Status: models.PostStatusPublished}
+				postRepo.Create(context.Background(), post)
+				service := NewPostService(postRepo, NewMockPostUserRepository())
+
+				var err error
+				if op == "update" {
+					_, err = service.UpdatePost(context.Background(), authorID, post.ID, &models.UpdatePostRequest{Title: &title}, tt.ifMatch)
+				} else {
+					err = service.DeletePost(context.Background(), authorID, post.ID, tt.ifMatch)
+				}
+
+				if tt.expectedErr == "" {
+					if err != nil {
+						t.Errorf("%s: unexpected error: %v", op, err)
+					}
+					continue
+				}
+				if err == nil || errors.AsAppError(err).Code != tt.expectedErr {
+					t.Errorf("%s: expected %s, got %v", op, tt.expectedErr, err)
+				}
+				if _, exists := postRepo.posts[post.ID]; !exists || postRepo.posts[post.ID].Title != "Title" {
+					t.Errorf("%s: expected the post to be left alone", op)
+				}
+			}
+		})
+	}
+
+	t.Run("post changed after the check", func(t *testing.T) {
+		postRepo := NewMockPostRepository()
+		postRepo.deleteError = errors.StaleVersion("Post", 1)
+		post := &models.Post{ID: uuid.New(), UserID: authorID, Title: "Title", Content: "Content", Status: models.PostStatusPublished}
+		postRepo.Create(context.Background(), post)
+		service := NewPostService(postRepo, NewMockPostUserRepository())
+
+		err := service.DeletePost(context.Background(), authorID, post.ID, &models.Precondition{Versions: []int{1}})
+		if err == nil || errors.AsAppError(err).Code != errors.ErrCodePrecondition {
+			t.Errorf("expected a failed precondition, got %v", err)
+		}
+	})
+}
+
+func TestPostService_ReplacePost(t *testing.T) {
+	authorID := uuid.New()
+	publishedAt := time.Now().Add(-time.Hour).UTC()
+	scheduledAt := time.Now().Add(time.Hour).UTC()
+	version := 3
+
+	tests := []struct {
+		name        string
+		req         models.ReplacePostRequest
+		expectedErr errors.ErrorCode
+		check       func(*testing.T, *models.Post)
+	}{
+		{
+			name: "replaces every field",
+			req:  models.ReplacePostRequest{Title: "New", Content: "New content"},
+			check: func(t *testing.T, post *models.Post) {
+				if post.Title != "New" || post.Content != "New content" || len(post.Tags) != 0 {
+					t.Errorf("expected the fields to be replaced and the tags removed, got %+v", post)
+				}
+				if post.Status != models.PostStatusPublished || !post.PublishedAt.Equal(publishedAt) {
+					t.Errorf("expected the post to stay published at %v, got %s at %v", publishedAt, post.Status, post.PublishedAt)
+				}
+			},
+		},
+		{
+			name: "written back unchanged",
+			req:  models.ReplacePostRequest{Title: "Title", Content: "Content", Tags: []string{"go"}, Status: models.PostStatusPublished, PublishedAt: &publishedAt},
+			check: func(t *testing.T, post *models.Post) {
+				if post.Status != models.PostStatusPublished || !post.PublishedAt.Equal(publishedAt) || len(post.Tags) != 1 {
+					t.Errorf("expected the post to stay as it was, got %+v", post)
+				}
+			},
+		},
+		{
+			name: "moved back to draft",
+			req:  models.ReplacePostRequest{Title: "Title", Content: "Content", Status: models.PostStatusDraft},
+			check: func(t *testing.T, post *models.Post) {
+				if post.Status != models.PostStatusDraft || post.PublishedAt != nil {
+					t.Errorf("expected an unscheduled draft, got %s at %v", post.Status, post.PublishedAt)
+				}
+			},
+		},
+		{
+			name: "scheduled",
+			req:  models.ReplacePostRequest{Title: "Title", Content: "Content", PublishedAt: &scheduledAt},
+			check: func(t *testing.T, post *models.Post) {
+				if !post.IsScheduled() || !post.PublishedAt.Equal(scheduledAt) {
+					t.Errorf("expected a draft scheduled for %v, got %s at %v", scheduledAt, post.Status, post.PublishedAt)
+				}
+			},
+		},
+		{
+			name:        "missing title",
+			req:         models.ReplacePostRequest{Content: "Content"},
+			expectedErr: errors.ErrCodeValidation,
+		},
+		{
+			name:        "missing content",
+			req:         models.ReplacePostRequest{Title: "Title"},
+			expectedErr: errors.ErrCodeValidation,
+		},
+		{
+			name:        "stale version",
+			req:         models.ReplacePostRequest{Title: "Title", Content: "Content", Version: &version},
+			expectedErr: errors.ErrCodeConflict,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			postRepo := NewMockPostRepository()
+			post := &models.Post{
+				ID: uuid.New(), UserID: authorID, Title: "Title", Content: "Content", Tags: []string{"go"},
+				Status: models.PostStatusPublished, PublishedAt: &publishedAt,
+			}
+			postRepo.Create(context.Background(), post)
+			service := NewPostService(postRepo, NewMockPostUserRepository())
+
+			updated, err := service.ReplacePost(context.Background(), authorID, post.ID, &tt.req, nil)
+			if tt.expectedErr != "" {
+				if err == nil || errors.AsAppError(err).Code != tt.expectedErr {
+					t.Errorf("expected %s, got %v", tt.expectedErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if updated.Version != 2 {
+				t.Errorf("expected version 2, got %d", updated.Version)
+			}
+			tt.check(t, updated)
+		})
+	}
+}
+
+func TestPostService_PatchPost(t *testing.T) {
+	authorID := uuid.New()
+	otherID := uuid.New()
+	publishedAt := time.Now().Add(-time.Hour).UTC()
+
+	tests := []struct {
+		name        string
+		userID      uuid.UUID
+		format      models.PatchFormat
+		document    string
+		expectedErr errors.ErrorCode
+		check       func(*testing.T, *models.Post)
+	}{
+		{
+			name:     "merge patch",
+			format:   models.PatchFormatMergePatch,
+			document: `{"title":"Patched","tags":["b","a"]}`,
+			check: func(t *testing.T, post *models.Post) {
+				if post.Title != "Patched" || post.Content != "Content" || strings.Join(post.Tags, ",") != "a,b" {
+					t.Errorf("expected only the title and tags to change, got %+v", post)
+				}
+				if post.Status != models.PostStatusPublished || !post.PublishedAt.Equal(publishedAt) {
+					t.Errorf("expected the post to stay published at %v, got %s at %v", publishedAt, post.Status, post.PublishedAt)
+				}
+			},
+		},
+		{
+			name:     "merge patch removing the tags",
+			format:   models.PatchFormatMergePatch,
+			document: `{"tags":null}`,
+			check: func(t *testing.T, post *models.Post) {
+				if len(post.Tags) != 0 {
+					t.Errorf("expected no tags, got %v", post.Tags)
+				}
+			},
+		},
+		{
+			name:     "merge patch unpublishing",
+			format:   models.PatchFormatMergePatch,
+			document: `{"status":"draft"}`,
+			check: func(t *testing.T, post *models.Post) {
+				if post.Status != models.PostStatusDraft || post.PublishedAt != nil {
+					t.Errorf("expected an unscheduled draft, got %s at %v", post.Status, post.PublishedAt)
+				}
+			},
+		},
+		{
+			name:     "json patch",
+			format:   models.PatchFormatJSONPatch,
+			document: `[{"op":"test","path":"/version","value":1},{"op":"replace","path":"/content","value":"Patched"},{"op":"add","path":"/tags/-","value":"rust"}]`,
+			check: func(t *testing.T, post *models.Post) {
+				if post.Title != "Title" || post.Content != "Patched" || strings.Join(post.Tags, ",") != "go,rust" {
+					t.Errorf("expected the content and tags to change, got %+v", post)
+				}
+			},
+		},
+		{
+			name:        "removed title",
+			format:      models.PatchFormatMergePatch,
+			document:    `{"title":null}`,
+			expectedErr: errors.ErrCodeValidation,
+		},
+		{
+			name:        "stale version",
+			format:      models.PatchFormatMergePatch,
+			document:    `{"version":5,"title":"Patched"}`,
+			expectedErr: errors.ErrCodeConflict,
+		},
+		{
+			name:        "failed json patch test",
+			format:      models.PatchFormatJSONPatch,
+			document:    `[{"op":"test","path":"/title","value":"Other"},{"op":"replace","path":"/title","value":"Patched"}]`,
+			expectedErr: errors.ErrCodeConflict,
+		},
+		{
+			name:        "unknown field",
+			format:      models.PatchFormatJSONPatch,
+			document:    `[{"op":"add","path":"/user_id","value":"someone"}]`,
+			expectedErr: errors.ErrCodeBadRequest,
+		},
+		{
+			name:        "wrong type",
+			format:      models.PatchFormatMergePatch,
+			document:    `{"title":5}`,
+			expectedErr: errors.ErrCodeBadRequest,
+		},
+		{
+			name:        "malformed document",
+			format:      models.PatchFormatJSONPatch,
+			document:    `{"op":"remove"}`,
+			expectedErr: errors.ErrCodeBadRequest,
+		},
+		{
+			name:        "unsupported format",
+			format:      "application/xml",
+			document:    `<post/>`,
+			expectedErr: errors.ErrCodeUnsupportedMediaType,
+		},
+		{
+			name:        "another user's post",
+			userID:      otherID,
+			format:      models.PatchFormatMergePatch,
+			document:    `{"title":"Patched"}`,
+			expectedErr: errors.ErrCodeForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			postRepo := NewMockPostRepository()
+			post := &models.Post{
+				ID: uuid.New(), UserID: authorID, Title: "Title", Content: "Content", Tags: []string{"go"},
+				Status: models.PostStatusPublished, PublishedAt: &publishedAt,
+			}
+			postRepo.Create(context.Background(), post)
+			service := NewPostService(postRepo, NewMockPostUserRepository())
+
+			userID := tt.userID
+			if userID == uuid.Nil {
+				userID = authorID
+			}
+			patch := &models.PostPatch{Format: tt.format, Document: []byte(tt.document)}
+
+			updated, err := service.PatchPost(context.Background(), userID, post.ID, patch, nil)
+			if tt.expectedErr != "" {
+				if err == nil || errors.AsAppError(err).Code != tt.expectedErr {
+					t.Errorf("expected %s, got %v", tt.expectedErr, err)
+				}
+				if postRepo.posts[post.ID].Version != 1 || postRepo.posts[post.ID].Title != "Title" {
+					t.Errorf("expected the post to be left alone")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if updated.Version != 2 {
+				t.Errorf("expected version 2, got %d", updated.Version)
+			}
+			tt.check(t, updated)
+		})
+	}
+}
+
+func TestPostService_Trash(t *testing.T) {
+	authorID := uuid.New()
+	otherID := uuid.New()
+
+	postRepo := NewMockPostRepository()
+	post := &models.Post{ID: uuid.New(), UserID: authorID, Title: "Title", Content: "Content", Status: models.PostStatusPublished}
+	postRepo.Create(context.Background(), post)
+	service := NewPostService(postRepo, NewMockPostUserRepository())
+
+	if err := service.DeletePost(context.Background(), authorID, post.ID, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := service.GetPost(context.Background(), post.ID); err == nil || err.Error() != "NOT_FOUND: Post not found" {
+		t.Errorf("expected a deleted post to be hidden, got %v", err)
+	}
+
+	result, err := service.ListDeletedPosts(context.Background(), authorID, models.NewPaginationParams(1, 10))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if posts := result.Data.([]*models.Post); len(posts) != 1 || posts[0].ID != post.ID || posts[0].DeletedAt == nil {
+		t.Errorf("expected the post in the trash, got %v", posts)
+	}
+
+	_, err = service.RestorePost(context.Background(), otherID, post.ID)
+	if err == nil || errors.AsAppError(err).Code != errors.ErrCodeForbidden {
+		t.Errorf("expected forbidden for another user, got %v", err)
+	}
+
+	restored, err := service.RestorePost(context.Background(), authorID, post.ID)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if restored.ID != post.ID || restored.DeletedAt != nil {
+		t.Errorf("expected the post restored, got %+v", restored)
+	}
+
+	if _, err := service.RestoreAnyPost(context.Background(), post.ID); err == nil || err.Error() != "NOT_FOUND: Post not found" {
+		t.Errorf("expected restoring a post outside the trash to fail, got %v", err)
+	}
+	if err := service.DeleteAnyPost(context.Background(), post.ID); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := service.RestoreAnyPost(context.Background(), post.ID); err != nil {
+		t.Errorf("expected an admin to restore any post, got %v", err)
+	}
+}
@@ -0,0 +1,79 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/alinoer/go-std-api/internal/logger"
+	"github.com/alinoer/go-std-api/internal/models"
+	"github.com/alinoer/go-std-api/internal/repository"
+)
+
+const trashPurgeInterval = time.Hour
+
+// TrashPurger permanently deletes the users and posts that have been in the
+// trash for longer than the retention period
+type TrashPurger struct {
+	postRepo   repository.PostRepository
+	userRepo   repository.UserRepository
+	retention  time.Duration
+	postPolicy models.UserPostPolicy
+	interval   time.Duration
+	now        func() time.Time
+}
+
+// NewTrashPurger creates a purger that checks the trash every hour. Purged
+// users' posts are handled according to postPolicy.
+func NewTrashPurger(postRepo repository.PostRepository, userRepo repository.UserRepository, retention time.Duration, postPolicy models.UserPostPolicy) *TrashPurger {
+	return &TrashPurger{
+		postRepo:   postRepo,
+		userRepo:   userRepo,
+		retention:  retention,
+		postPolicy: postPolicy,
+		interval:   trashPurgeInterval,
+		now:        time.Now,
+	}
+}
+
+// Run purges the trash right away and then every interval until ctx is
+// cancelled. It only returns once no run is in progress, so callers can wait
+// for it to finish during shutdown.
+func (p *TrashPurger) Run(ctx context.Context) {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		p.Purge(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Purge permanently deletes what has been in the trash for longer than the
+// retention period and returns how many users and posts it deleted. Users go
+// first, since purging a user may delete the user's posts.
+func (p *TrashPurger) Purge(ctx context.Context) (users, posts int64, err error) {
+	before := p.now().Add(-p.retention)
+
+	users, err = p.userRepo.Purge(ctx, before, p.postPolicy)
+	if err == nil {
+		posts, err = p.postRepo.Purge(ctx, before)
+	}
+	if err != nil {
+		// Failures caused by shutting down are expected
+		if ctx.Err() == nil {
+			logger.GetLogger().WithContext(ctx).Error("Failed to purge the trash", err)
+		}
+		return users, posts, err
+	}
+
+	if users > 0 || posts > 0 {
+		logger.GetLogger().WithContext(ctx).Info("Purged the trash", "users", users, "posts", posts)
+	}
+
+	return users, posts, nil
+}
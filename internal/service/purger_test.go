@@ -0,0 +1,70 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/alinoer/go-std-api/internal/models"
+	"github.com/google/uuid"
+)
+
+func TestTrashPurger_Purge(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	expired := now.Add(-31 * 24 * time.Hour)
+	recent := now.Add(-24 * time.Hour)
+
+	tests := []struct {
+		name          string
+		deletedAt     []time.Time
+		deleteError   error
+		expectedPosts int64
+		expectedError bool
+	}{
+		{
+			name:          "purges expired posts only",
+			deletedAt:     []time.Time{expired, recent, expired},
+			expectedPosts: 2,
+		},
+		{
+			name:          "repository error",
+			deletedAt:     []time.Time{expired},
+			deleteError:   fmt.Errorf("connection refused"),
+			expectedError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			postRepo := NewMockPostRepository()
+			for _, deletedAt := range tt.deletedAt {
+				deletedAt := deletedAt
+				post := &models.Post{ID: uuid.New(), DeletedAt: &deletedAt}
+				postRepo.deletedPosts[post.ID] = post
+			}
+			postRepo.deleteError = tt.deleteError
+			userRepo := NewMockUserRepository()
+			user := &models.User{ID: uuid.New(), Username: "gone"}
+			userRepo.deletedUsers[user.ID] = user
+
+			purger := NewTrashPurger(postRepo, userRepo, 30*24*time.Hour, models.UserPostPolicyAnonymize)
+			purger.now = func() time.Time { return now }
+
+			users, posts, err := purger.Purge(context.Background())
+
+			if (err != nil) != tt.expectedError {
+				t.Fatalf("expected error: %v, got %v", tt.expectedError, err)
+			}
+			if tt.expectedError {
+				return
+			}
+			if users != 1 || posts != tt.expectedPosts {
+				t.Errorf("expected 1 user and %d posts purged, got %d and %d", tt.expectedPosts, users, posts)
+			}
+			if len(postRepo.deletedPosts) != len(tt.deletedAt)-int(tt.expectedPosts) {
+				t.Errorf("expected the recent posts to stay in the trash, got %d", len(postRepo.deletedPosts))
+			}
+		})
+	}
+}
@@ -0,0 +1,69 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/alinoer/go-std-api/internal/logger"
+	"github.com/alinoer/go-std-api/internal/repository"
+)
+
+const defaultPostSchedulerInterval = time.Minute
+
+// PostScheduler publishes scheduled drafts once their publication time has
+// passed
+type PostScheduler struct {
+	postRepo repository.PostRepository
+	interval time.Duration
+	now      func() time.Time
+}
+
+// NewPostScheduler creates a scheduler that checks for due posts every
+// interval, or every minute if interval is not positive
+func NewPostScheduler(postRepo repository.PostRepository, interval time.Duration) *PostScheduler {
+	if interval <= 0 {
+		interval = defaultPostSchedulerInterval
+	}
+	return &PostScheduler{
+		postRepo: postRepo,
+		interval: interval,
+		now:      time.Now,
+	}
+}
+
+// Run publishes due posts right away and then every interval until ctx is
+// cancelled. It only returns once no run is in progress, so callers can wait
+// for it to finish during shutdown.
+func (s *PostScheduler) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		s.PublishDue(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// PublishDue publishes the posts that are due and returns how many it
+// published
+func (s *PostScheduler) PublishDue(ctx context.Context) (int64, error) {
+	published, err := s.postRepo.PublishDue(ctx, s.now())
+	if err != nil {
+		// Failures caused by shutting down are expected
+		if ctx.Err() == nil {
+			logger.GetLogger().WithContext(ctx).Error("Failed to publish scheduled posts", err)
+		}
+		return 0, err
+	}
+
+	if published > 0 {
+		logger.GetLogger().WithContext(ctx).Info("Published scheduled posts", "count", published)
+	}
+
+	return published, nil
+}
@@ -0,0 +1,93 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/alinoer/go-std-api/internal/models"
+	"github.com/google/uuid"
+)
+
+func TestPostScheduler_PublishDue(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	due := now.Add(-time.Minute)
+	later := now.Add(time.Hour)
+
+	tests := []struct {
+		name              string
+		posts             []*models.Post
+		updateError       error
+		expectedPublished int64
+		expectedError     bool
+	}{
+		{
+			name: "publishes due drafts only",
+			posts: []*models.Post{
+				{ID: uuid.New(), Status: models.PostStatusDraft, PublishedAt: &due},
+				{ID: uuid.New(), Status: models.PostStatusDraft, PublishedAt: &later},
+				{ID: uuid.New(), Status: models.PostStatusDraft},
+			},
+			expectedPublished: 1,
+		},
+		{
+			name:          "repository error",
+			updateError:   fmt.Errorf("connection refused"),
+			expectedError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			postRepo := NewMockPostRepository()
+			for _, post := range tt.posts {
+				postRepo.AddPost(post)
+			}
+			postRepo.SetUpdateError(tt.updateError)
+			scheduler := NewPostScheduler(postRepo, time.Minute)
+			scheduler.now = func() time.Time { return now }
+
+			published, err := scheduler.PublishDue(context.Background())
+
+			if (err != nil) != tt.expectedError {
+				t.Fatalf("expected error: %v, got %v", tt.expectedError, err)
+			}
+			if published != tt.expectedPublished {
+				t.Errorf("expected %d published posts, got %d", tt.expectedPublished, published)
+			}
+			for _, post := range tt.posts {
+				if post.PublishedAt == &due && post.Status != models.PostStatusPublished {
+					t.Errorf("expected the due post to be published")
+				}
+			}
+		})
+	}
+}
+
+func TestPostScheduler_RunStopsOnCancel(t *testing.T) {
+	postRepo := NewMockPostRepository()
+	due := time.Now().Add(-time.Minute)
+	post := &models.Post{ID: uuid.New(), Status: models.PostStatusDraft, PublishedAt: &due}
+	postRepo.AddPost(post)
+
+	scheduler := NewPostScheduler(postRepo, time.Hour)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		scheduler.Run(ctx)
+	}()
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("expected Run to return after cancellation")
+	}
+
+	// The first run happens before Run waits for the ticker
+	if post.Status != models.PostStatusPublished {
+		t.Errorf("expected the due post to be published on start, got %s", post.Status)
+	}
+}
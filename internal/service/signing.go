@@ -0,0 +1,239 @@
+package service
+
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"os"
+	"sort"
+
+	"github.com/alinoer/go-std-api/internal/models"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const minRSAKeyBits = 2048
+
+// SigningKey is a key used to sign or verify access tokens. Asymmetric keys
+// are identified by their RFC 7638 thumbprint, which is sent as the kid
+// header; the HMAC key has no ID.
+type SigningKey struct {
+	ID     string
+	Method jwt.SigningMethod
+
+	signKey   interface{}
+	verifyKey interface{}
+}
+
+// CanSign reports whether the key holds private key material
+func (k *SigningKey) CanSign() bool {
+	return k.signKey != nil
+}
+
+// KeySet holds the key that signs new tokens and every key whose tokens are
+// still accepted. During a rotation the previous key stays in the set as a
+// verification key until the tokens it signed have expired.
+type KeySet struct {
+	signing      *SigningKey
+	verification map[string]*SigningKey
+}
+
+// NewHMACKeySet returns a key set that signs and verifies with HS256
+func NewHMACKeySet(secretKey string) *KeySet {
+	key := &SigningKey{
+		Method:    jwt.SigningMethodHS256,
+		signKey:   []byte(secretKey),
+		verifyKey: []byte(secretKey),
+	}
+	return &KeySet{
+		signing:      key,
+		verification: map[string]*SigningKey{key.ID: key},
+	}
+}
+
+// NewKeySet returns a key set that signs with signing and also accepts tokens
+// signed by any of the verification keys
+func NewKeySet(signing *SigningKey, verification ...*SigningKey) (*KeySet, error) {
+	if signing == nil || !signing.CanSign() {
+		return nil, fmt.Errorf("signing key must include a private key")
+	}
+
+	keys := &KeySet{
+		signing:      signing,
+		verification: map[string]*SigningKey{signing.ID: signing},
+	}
+	for _, key := range verification {
+		if _, ok := key.verifyKey.([]byte); ok {
+			return nil, fmt.Errorf("verification keys must be asymmetric")
+		}
+		keys.verification[key.ID] = key
+	}
+
+	return keys, nil
+}
+
+// LoadKeySetFromFiles builds a key set from a PEM encoded private signing key
+// and PEM encoded verification keys, which may be public or private keys
+func LoadKeySetFromFiles(signingKeyFile string, verificationKeyFiles []string) (*KeySet, error) {
+	data, err := os.ReadFile(signingKeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read signing key: %w", err)
+	}
+	signing, err := ParseSigningKeyPEM(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid signing key %s: %w", signingKeyFile, err)
+	}
+
+	var verification []*SigningKey
+	for _, file := range verificationKeyFiles {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read verification key: %w", err)
+		}
+		key, err := ParseSigningKeyPEM(data)
+		if err != nil {
+			return nil, fmt.Errorf("invalid verification key %s: %w", file, err)
+		}
+		verification = append(verification, key)
+	}
+
+	return NewKeySet(signing, verification...)
+}
+
+// ParseSigningKeyPEM parses an RSA or Ed25519 key. Private keys produce a key
+// that can sign; public keys produce a verification-only key.
+func ParseSigningKeyPEM(data []byte) (*SigningKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("no PEM block found")
+	}
+
+	var parsed interface{}
+	var err error
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PRIVATE KEY":
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PUBLIC KEY":
+		parsed, err = x509.ParsePKCS1PublicKey(block.Bytes)
+	case "PUBLIC KEY":
+		parsed, err = x509.ParsePKIXPublicKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("unsupported PEM block type %q", block.Type)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse key: %w", err)
+	}
+
+	var key *SigningKey
+	switch k := parsed.(type) {
+	case *rsa.PrivateKey:
+		key = &SigningKey{Method: jwt.SigningMethodRS256, signKey: k, verifyKey: &k.PublicKey}
+	case *rsa.PublicKey:
+		key = &SigningKey{Method: jwt.SigningMethodRS256, verifyKey: k}
+	case ed25519.PrivateKey:
+		key = &SigningKey{Method: jwt.SigningMethodEdDSA, signKey: k, verifyKey: k.Public()}
+	case ed25519.PublicKey:
+		key = &SigningKey{Method: jwt.SigningMethodEdDSA, verifyKey: k}
+	default:
+		return nil, fmt.Errorf("unsupported key type %T; use RSA or Ed25519", parsed)
+	}
+
+	if pub, ok := key.verifyKey.(*rsa.PublicKey); ok && pub.N.BitLen() < minRSAKeyBits {
+		return nil, fmt.Errorf("RSA keys must be at least %d bits", minRSAKeyBits)
+	}
+
+	key.ID = thumbprint(key.JWK())
+	return key, nil
+}
+
+// JWK returns the public part of the key as a JSON Web Key
+func (k *SigningKey) JWK() models.JWK {
+	switch pub := k.verifyKey.(type) {
+	case *rsa.PublicKey:
+		return models.JWK{
+			Kty: "RSA",
+			Use: "sig",
+			Alg: k.Method.Alg(),
+			Kid: k.ID,
+			N:   base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+			E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+		}
+	case ed25519.PublicKey:
+		return models.JWK{
+			Kty: "OKP",
+			Use: "sig",
+			Alg: k.Method.Alg(),
+			Kid: k.ID,
+			Crv: "Ed25519",
+			X:   base64.RawURLEncoding.EncodeToString(pub),
+		}
+	}
+	return models.JWK{}
+}
+
+// JWKS returns the public verification keys. HMAC keys are never published.
+func (ks *KeySet) JWKS() models.JWKS {
+	jwks := models.JWKS{Keys: []models.JWK{}}
+	for _, key := range ks.verification {
+		if _, ok := key.verifyKey.([]byte); ok {
+			continue
+		}
+		jwks.Keys = append(jwks.Keys, key.JWK())
+	}
+	sort.Slice(jwks.Keys, func(i, j int) bool {
+		return jwks.Keys[i].Kid < jwks.Keys[j].Kid
+	})
+	return jwks
+}
+
+func (ks *KeySet) sign(claims jwt.Claims) (string, error) {
+	token := jwt.NewWithClaims(ks.signing.Method, claims)
+	if ks.signing.ID != "" {
+		token.Header["kid"] = ks.signing.ID
+	}
+	return token.SignedString(ks.signing.signKey)
+}
+
+// keyFunc selects the verification key by kid and rejects tokens whose alg
+// does not match that key
+func (ks *KeySet) keyFunc(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	key, exists := ks.verification[kid]
+	if !exists {
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+	if token.Method.Alg() != key.Method.Alg() {
+		return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+	}
+	return key.verifyKey, nil
+}
+
+// thumbprint computes the RFC 7638 JWK thumbprint
+func thumbprint(jwk models.JWK) string {
+	var members interface{}
+	switch jwk.Kty {
+	case "RSA":
+		members = struct {
+			E   string `json:"e"`
+			Kty string `json:"kty"`
+			N   string `json:"n"`
+		}{jwk.E, jwk.Kty, jwk.N}
+	case "OKP":
+		members = struct {
+			Crv string `json:"crv"`
+			Kty string `json:"kty"`
+			X   string `json:"x"`
+		}{jwk.Crv, jwk.Kty, jwk.X}
+	}
+
+	data, _ := json.Marshal(members)
+	sum := sha256.Sum256(data)
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
@@ -0,0 +1,297 @@
+package service
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alinoer/go-std-api/internal/models"
+	"github.com/alinoer/go-std-api/internal/repository"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+func generateRSAKeyPEM(t *testing.T, bits int) []byte {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, bits)
+	if err != nil {
+		t.Fatalf("failed to generate RSA key: %v", err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+}
+
+func generateEd25519KeyPEM(t *testing.T) (private []byte, public []byte) {
+	t.Helper()
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate Ed25519 key: %v", err)
+	}
+	privDER, err := x509.MarshalPKCS8PrivateKey(priv)
+	if err != nil {
+		t.Fatalf("failed to marshal private key: %v", err)
+	}
+	pubDER, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		t.Fatalf("failed to marshal public key: %v", err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privDER}),
+		pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pubDER})
+}
+
+func newKeySetAuthService(keys *KeySet) *AuthService {
+	return NewAuthServiceWithConfig(DefaultTokenConfig(""), keys, nil, nil, repository.NewMemoryTokenRevocationStore())
+}
+
+func TestParseSigningKeyPEM(t *testing.T) {
+	edPrivate, edPublic := generateEd25519KeyPEM(t)
+
+	tests := []struct {
+		name          string
+		pem           []byte
+		expectedAlg   string
+		expectCanSign bool
+		expectedError string
+	}{
+		{
+			name:          "RSA private key",
+			pem:           generateRSAKeyPEM(t, 2048),
+			expectedAlg:   "RS256",
+			expectCanSign: true,
+		},
+		{
+			name:          "Ed25519 private key",
+			pem:           edPrivate,
+			expectedAlg:   "EdDSA",
+			expectCanSign: true,
+		},
+		{
+			name:        "Ed25519 public key",
+			pem:         edPublic,
+			expectedAlg: "EdDSA",
+		},
+		{
+			name:          "RSA key too small",
+			pem:           generateRSAKeyPEM(t, 1024),
+			expectedError: "RSA keys must be at least 2048 bits",
+		},
+		{
+			name:          "not PEM",
+			pem:           []byte("not a key"),
+			expectedError: "no PEM block found",
+		},
+		{
+			name:          "unsupported block",
+			pem:           pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: []byte{1}}),
+			expectedError: "unsupported PEM block type",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key, err := ParseSigningKeyPEM(tt.pem)
+
+			if tt.expectedError != "" {
+				if err == nil {
+					t.Fatalf("expected error containing %q, got nil", tt.expectedError)
+				}
+				if !strings.Contains(err.Error(), tt.expectedError) {
+					t.Errorf("expected error containing %q, got %q", tt.expectedError, err.Error())
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if key.Method.Alg() != tt.expectedAlg {
+				t.Errorf("expected alg %s, got %s", tt.expectedAlg, key.Method.Alg())
+			}
+			if key.CanSign() != tt.expectCanSign {
+				t.Errorf("expected CanSign=%t, got %t", tt.expectCanSign, key.CanSign())
+			}
+			if key.ID == "" {
+				t.Error("expected key ID to be set")
+			}
+		})
+	}
+
+	// The public and private halves of a key share a kid
+	privateKey, _ := ParseSigningKeyPEM(edPrivate)
+	publicKey, _ := ParseSigningKeyPEM(edPublic)
+	if privateKey.ID != publicKey.ID {
+		t.Errorf("expected matching key IDs, got %s and %s", privateKey.ID, publicKey.ID)
+	}
+}
+
+func TestAuthService_AsymmetricSigning(t *testing.T) {
+	ctx := context.Background()
+	userID := uuid.New()
+
+	rsaKey, err := ParseSigningKeyPEM(generateRSAKeyPEM(t, 2048))
+	if err != nil {
+		t.Fatalf("failed to parse RSA key: %v", err)
+	}
+	edPrivate, _ := generateEd25519KeyPEM(t)
+	edKey, err := ParseSigningKeyPEM(edPrivate)
+	if err != nil {
+		t.Fatalf("failed to parse Ed25519 key: %v", err)
+	}
+
+	for _, key := range []*SigningKey{rsaKey, edKey} {
+		t.Run(key.Method.Alg(), func(t *testing.T) {
+			keys, err := NewKeySet(key)
+			if err != nil {
+				t.Fatalf("failed to create key set: %v", err)
+			}
+			authService := newKeySetAuthService(keys)
+
+			token, _, err := authService.GenerateToken(ctx, &models.User{ID: userID, Username: "testuser"})
+			if err != nil {
+				t.Fatalf("failed to generate token: %v", err)
+			}
+
+			parsed, _, err := jwt.NewParser().ParseUnverified(token, &models.JWTClaims{})
+			if err != nil {
+				t.Fatalf("failed to parse token: %v", err)
+			}
+			if parsed.Header["kid"] != key.ID {
+				t.Errorf("expected kid %s, got %v", key.ID, parsed.Header["kid"])
+			}
+			if parsed.Header["alg"] != key.Method.Alg() {
+				t.Errorf("expected alg %s, got %v", key.Method.Alg(), parsed.Header["alg"])
+			}
+
+			claims, err := authService.ValidateToken(token)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if claims.UserID != userID {
+				t.Errorf("expected user ID %s, got %s", userID, claims.UserID)
+			}
+		})
+	}
+}
+
+func TestAuthService_KeyRotation(t *testing.T) {
+	ctx := context.Background()
+	userID := uuid.New()
+
+	oldKey, _ := ParseSigningKeyPEM(generateRSAKeyPEM(t, 2048))
+	edPrivate, _ := generateEd25519KeyPEM(t)
+	newKey, _ := ParseSigningKeyPEM(edPrivate)
+
+	oldKeys, _ := NewKeySet(oldKey)
+	oldService := newKeySetAuthService(oldKeys)
+	oldToken, _, err := oldService.GenerateToken(ctx, &models.User{ID: userID, Username: "testuser"})
+	if err != nil {
+		t.Fatalf("failed to generate token: %v", err)
+	}
+
+	// Rotate: sign with the new key while still accepting the old one
+	rotatedKeys, err := NewKeySet(newKey, oldKey)
+	if err != nil {
+		t.Fatalf("failed to create key set: %v", err)
+	}
+	rotatedService := newKeySetAuthService(rotatedKeys)
+
+	if _, err := rotatedService.ValidateToken(oldToken); err != nil {
+		t.Errorf("expected token signed by previous key to be accepted, got %v", err)
+	}
+
+	newToken, _, _ := rotatedService.GenerateToken(ctx, &models.User{ID: userID, Username: "testuser"})
+	if _, err := oldService.ValidateToken(newToken); err == nil {
+		t.Error("expected token signed by unknown key to be rejected")
+	}
+
+	jwks := rotatedService.JWKS()
+	if len(jwks.Keys) != 2 {
+		t.Fatalf("expected 2 keys in JWKS, got %d", len(jwks.Keys))
+	}
+	kids := map[string]string{}
+	for _, jwk := range jwks.Keys {
+		kids[jwk.Kid] = jwk.Kty
+	}
+	if kids[oldKey.ID] != "RSA" || kids[newKey.ID] != "OKP" {
+		t.Errorf("expected JWKS to contain both keys, got %v", kids)
+	}
+}
+
+func TestAuthService_RejectsAlgorithmConfusion(t *testing.T) {
+	rsaKey, _ := ParseSigningKeyPEM(generateRSAKeyPEM(t, 2048))
+	keys, _ := NewKeySet(rsaKey)
+	authService := newKeySetAuthService(keys)
+
+	// An HS256 token that names the RSA key and uses its public JWK as secret
+	claims := models.JWTClaims{
+		UserID: uuid.New(),
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+		},
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	token.Header["kid"] = rsaKey.ID
+	forged, err := token.SignedString([]byte(rsaKey.JWK().N))
+	if err != nil {
+		t.Fatalf("failed to sign token: %v", err)
+	}
+
+	if _, err := authService.ValidateToken(forged); err == nil {
+		t.Error("expected token with mismatched algorithm to be rejected")
+	}
+
+	// HMAC tokens without a kid are not accepted by an asymmetric key set
+	hmacToken, _, _ := NewAuthService("secret", nil, nil, repository.NewMemoryTokenRevocationStore()).GenerateToken(context.Background(), &models.User{ID: uuid.New(), Username: "testuser"})
+	if _, err := authService.ValidateToken(hmacToken); err == nil {
+		t.Error("expected HS256 token to be rejected")
+	}
+}
+
+func TestKeySet_JWKS(t *testing.T) {
+	if keys := NewHMACKeySet("secret").JWKS().Keys; len(keys) != 0 {
+		t.Errorf("expected HMAC secret not to be published, got %d keys", len(keys))
+	}
+
+	_, edPublic := generateEd25519KeyPEM(t)
+	publicOnly, _ := ParseSigningKeyPEM(edPublic)
+	if _, err := NewKeySet(publicOnly); err == nil {
+		t.Error("expected key set without a private signing key to be rejected")
+	}
+}
+
+func TestLoadKeySetFromFiles(t *testing.T) {
+	dir := t.TempDir()
+	edPrivate, _ := generateEd25519KeyPEM(t)
+	_, oldPublic := generateEd25519KeyPEM(t)
+
+	signingFile := filepath.Join(dir, "signing.pem")
+	oldFile := filepath.Join(dir, "old.pem")
+	if err := os.WriteFile(signingFile, edPrivate, 0600); err != nil {
+		t.Fatalf("failed to write key: %v", err)
+	}
+	if err := os.WriteFile(oldFile, oldPublic, 0600); err != nil {
+		t.Fatalf("failed to write key: %v", err)
+	}
+
+	keys, err := LoadKeySetFromFiles(signingFile, []string{oldFile})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(keys.JWKS().Keys) != 2 {
+		t.Errorf("expected 2 published keys, got %d", len(keys.JWKS().Keys))
+	}
+
+	if _, err := LoadKeySetFromFiles(filepath.Join(dir, "missing.pem"), nil); err == nil {
+		t.Error("expected missing signing key file to be rejected")
+	}
+	if _, err := LoadKeySetFromFiles(oldFile, nil); err == nil {
+		t.Error("expected public key as signing key to be rejected")
+	}
+}
@@ -0,0 +1,74 @@
+package service
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/alinoer/go-std-api/internal/errors"
+	"github.com/alinoer/go-std-api/internal/models"
+)
+
+const (
+	maxTagLength   = 32
+	maxTagsPerPost = 10
+)
+
+// normalizeTag turns a user supplied tag into its stored form: trimmed,
+// lowercased, without a leading '#' and with inner whitespace collapsed to
+// single dashes, so "#Go Lang" and "go-lang" name the same tag.
+func normalizeTag(tag string) (string, bool) {
+	tag = strings.TrimPrefix(strings.TrimSpace(tag), "#")
+	tag = strings.ToLower(strings.Join(strings.Fields(tag), "-"))
+	if tag == "" || len(tag) > maxTagLength {
+		return "", false
+	}
+
+	for _, r := range tag {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && !strings.ContainsRune("-+#.", r) {
+			return "", false
+		}
+	}
+
+	return tag, true
+}
+
+// normalizeTags normalizes, dedupes and sorts tags, reporting invalid ones
+// against field
+func normalizeTags(field string, tags []string) ([]string, error) {
+	seen := make(map[string]bool, len(tags))
+	normalized := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		name, ok := normalizeTag(tag)
+		if !ok {
+			return nil, errors.ValidationError(field, fmt.Sprintf(
+				"Invalid tag %q: tags must be 1-%d letters, digits or -+#. characters", tag, maxTagLength))
+		}
+		if !seen[name] {
+			seen[name] = true
+			normalized = append(normalized, name)
+		}
+	}
+
+	if len(normalized) > maxTagsPerPost {
+		return nil, errors.ValidationError(field, fmt.Sprintf("At most %d tags are allowed", maxTagsPerPost))
+	}
+
+	sort.Strings(normalized)
+	return normalized, nil
+}
+
+// normalizeTagFilter normalizes the tag filter of opts in place
+func normalizeTagFilter(opts *models.ListOptions) error {
+	if opts == nil || len(opts.Tags) == 0 {
+		return nil
+	}
+
+	tags, err := normalizeTags("tag", opts.Tags)
+	if err != nil {
+		return err
+	}
+	opts.Tags = tags
+	return nil
+}
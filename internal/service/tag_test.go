@@ -0,0 +1,153 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/alinoer/go-std-api/internal/models"
+	"github.com/google/uuid"
+)
+
+func TestNormalizeTags(t *testing.T) {
+	tests := []struct {
+		name          string
+		tags          []string
+		expected      []string
+		expectedError string
+	}{
+		{
+			name:     "trims, lowercases and sorts",
+			tags:     []string{"  Go ", "API"},
+			expected: []string{"api", "go"},
+		},
+		{
+			name:     "collapses whitespace and strips hash",
+			tags:     []string{"#Machine   Learning", "c#", "C++", "node.js"},
+			expected: []string{"c#", "c++", "machine-learning", "node.js"},
+		},
+		{
+			name:     "dedupes after normalizing",
+			tags:     []string{"go", "#go", " GO "},
+			expected: []string{"go"},
+		},
+		{
+			name:     "no tags",
+			tags:     nil,
+			expected: []string{},
+		},
+		{
+			name:          "empty tag",
+			tags:          []string{"go", "  "},
+			expectedError: `VALIDATION_ERROR: Validation failed for field 'tags' - Invalid tag "  ": tags must be 1-32 letters, digits or -+#. characters`,
+		},
+		{
+			name:          "invalid characters",
+			tags:          []string{"go/api"},
+			expectedError: `VALIDATION_ERROR: Validation failed for field 'tags' - Invalid tag "go/api": tags must be 1-32 letters, digits or -+#. characters`,
+		},
+		{
+			name:          "too long",
+			tags:          []string{strings.Repeat("a", 33)},
+			expectedError: `VALIDATION_ERROR: Validation failed for field 'tags' - Invalid tag "` + strings.Repeat("a", 33) + `": tags must be 1-32 letters, digits or -+#. characters`,
+		},
+		{
+			name:          "too many",
+			tags:          []string{"a", "b", "c", "d", "e", "f", "g", "h", "i", "j", "k"},
+			expectedError: "VALIDATION_ERROR: Validation failed for field 'tags' - At most 10 tags are allowed",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tags, err := normalizeTags("tags", tt.tags)
+
+			if tt.expectedError != "" {
+				if err == nil || err.Error() != tt.expectedError {
+					t.Errorf("expected error %q, got %v", tt.expectedError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tags == nil || strings.Join(tags, ",") != strings.Join(tt.expected, ",") {
+				t.Errorf("expected tags %v, got %v", tt.expected, tags)
+			}
+		})
+	}
+}
+
+func TestPostService_Tags(t *testing.T) {
+	userID := uuid.New()
+
+	t.Run("create normalizes tags", func(t *testing.T) {
+		postRepo := NewMockPostRepository()
+		userRepo := NewMockPostUserRepository()
+		userRepo.AddUser(&models.User{ID: userID, Username: "testuser"})
+		service := NewPostService(postRepo, userRepo)
+
+		post, err := service.CreatePost(context.Background(), userID, &models.CreatePostRequest{
+			Title: "Post", Content: "Content", Tags: []string{"Go", "#api", "go"},
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if strings.Join(post.Tags, ",") != "api,go" {
+			t.Errorf("expected tags [api go], got %v", post.Tags)
+		}
+	})
+
+	t.Run("update replaces tags only when given", func(t *testing.T) {
+		postRepo := NewMockPostRepository()
+		post := &models.Post{ID: uuid.New(), UserID: userID, Title: "Post", Content: "Content", Tags: []string{"go"}}
+		postRepo.AddPost(post)
+		service := NewPostService(postRepo, NewMockPostUserRepository())
+
+		title := "New title"
+		updated, err := service.UpdatePost(context.Background(), userID, post.ID, &models.UpdatePostRequest{Title: &title}, nil)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if strings.Join(updated.Tags, ",") != "go" {
+			t.Errorf("expected tags to be kept, got %v", updated.Tags)
+		}
+
+		tags := []string{"Knowledge Base"}
+		updated, err = service.UpdatePost(context.Background(), userID, post.ID, &models.UpdatePostRequest{Tags: &tags}, nil)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if strings.Join(updated.Tags, ",") != "knowledge-base" {
+			t.Errorf("expected tags [knowledge-base], got %v", updated.Tags)
+		}
+
+		invalid := []string{"a/b"}
+		if _, err := service.UpdatePost(context.Background(), userID, post.ID, &models.UpdatePostRequest{Tags: &invalid}, nil); err == nil {
+			t.Error("expected an invalid tag to be rejected")
+		}
+	})
+
+	t.Run("list filters are normalized", func(t *testing.T) {
+		postRepo := NewMockPostRepository()
+		service := NewPostService(postRepo, NewMockPostUserRepository())
+
+		opts := &models.ListOptions{Tags: []string{"API", "#go"}}
+		if _, err := service.ListPostsPaginated(context.Background(), models.NewPaginationParams(1, 10), opts); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if strings.Join(postRepo.listOptions.Tags, ",") != "api,go" {
+			t.Errorf("expected tags [api go], got %v", postRepo.listOptions.Tags)
+		}
+
+		_, err := service.ListPosts(context.Background(), &models.ListOptions{Tags: []string{"a b/c"}})
+		expected := `VALIDATION_ERROR: Validation failed for field 'tag' - Invalid tag "a b/c": tags must be 1-32 letters, digits or -+#. characters`
+		if err == nil || err.Error() != expected {
+			t.Errorf("expected error %q, got %v", expected, err)
+		}
+
+		if _, err := service.ListPosts(context.Background(), nil); err != nil {
+			t.Errorf("unexpected error without options: %v", err)
+		}
+	})
+}
@@ -2,10 +2,11 @@ package service
 
 import (
 	"context"
-	"crypto/sha256"
-	"fmt"
+	"sync"
 	"time"
 
+	"github.com/alinoer/go-std-api/internal/errors"
+	"github.com/alinoer/go-std-api/internal/logger"
 	"github.com/alinoer/go-std-api/internal/models"
 	"github.com/alinoer/go-std-api/internal/repository"
 
@@ -16,49 +17,109 @@ type UserService interface {
 	CreateUser(ctx context.Context, req *models.CreateUserRequest) (*models.User, error)
 	GetUser(ctx context.Context, id uuid.UUID) (*models.User, error)
 	GetUserByUsername(ctx context.Context, username string) (*models.User, error)
-	ListUsers(ctx context.Context) ([]*models.User, error)
-	ListUsersPaginated(ctx context.Context, pagination *models.PaginationParams) (*models.PaginatedResponse, error)
+	ListUsers(ctx context.Context, opts *models.ListOptions) ([]*models.User, error)
+	ListUsersPaginated(ctx context.Context, pagination *models.PaginationParams, opts *models.ListOptions) (*models.PaginatedResponse, error)
+	ListUsersByCursor(ctx context.Context, params *models.CursorParams, opts *models.ListOptions) (*models.PaginatedResponse, error)
 	ValidateCredentials(ctx context.Context, username, password string) (*models.User, error)
+	ListPrivateUsers(ctx context.Context, pagination *models.PaginationParams) (*models.PaginatedResponse, error)
+	UpdateUserRole(ctx context.Context, id uuid.UUID, role models.Role) (*models.User, error)
+	UpdateUser(ctx context.Context, userID, id uuid.UUID, req *models.UpdateUserRequest) (*models.User, error)
+	DeleteUser(ctx context.Context, userID, id uuid.UUID) error
+	// RestoreUser takes a deleted user out of the trash
+	RestoreUser(ctx context.Context, id uuid.UUID) (*models.User, error)
+}
+
+// TokenRevoker signs a user out of every session. AuthService implements it.
+type TokenRevoker interface {
+	RevokeAllTokens(ctx context.Context, userID uuid.UUID) error
+}
+
+// UserServiceConfig holds the account settings that vary between deployments
+type UserServiceConfig struct {
+	// PostPolicy decides what happens to a user's posts when the user
+	// deletes their account
+	PostPolicy models.UserPostPolicy
+	// PasswordPolicy is checked whenever a password is set; nil accepts any
+	// non-empty password the hasher can hash
+	PasswordPolicy *PasswordPolicy
+	// Tokens revokes a user's access and refresh tokens when their password
+	// changes or their account is deleted; nil leaves them valid until they
+	// expire
+	Tokens TokenRevoker
+}
+
+// DefaultUserServiceConfig returns the configuration used by NewUserService
+func DefaultUserServiceConfig() UserServiceConfig {
+	return UserServiceConfig{
+		PostPolicy:     models.UserPostPolicyAnonymize,
+		PasswordPolicy: NewPasswordPolicy(DefaultPasswordPolicyConfig(), nil),
+	}
 }
 
 type userService struct {
 	userRepo repository.UserRepository
+	hasher   PasswordHasher
+	config   UserServiceConfig
+
+	// dummyHash is verified against when a username does not exist, so that
+	// failed logins take as long whether or not it does
+	dummyHash     string
+	dummyHashOnce sync.Once
+}
+
+func NewUserService(userRepo repository.UserRepository, hasher PasswordHasher) UserService {
+	return NewUserServiceWithConfig(userRepo, hasher, DefaultUserServiceConfig())
 }
 
-func NewUserService(userRepo repository.UserRepository) UserService {
+func NewUserServiceWithConfig(userRepo repository.UserRepository, hasher PasswordHasher, config UserServiceConfig) UserService {
+	// Passwords the hasher cannot hash are a validation error rather than a
+	// failure to hash them
+	if maxBytes := hasher.MaxPasswordBytes(); maxBytes > 0 {
+		if config.PasswordPolicy == nil {
+			config.PasswordPolicy = NewPasswordPolicy(PasswordPolicyConfig{}, nil)
+		}
+		config.PasswordPolicy = config.PasswordPolicy.LimitBytes(maxBytes)
+	}
+
 	return &userService{
 		userRepo: userRepo,
+		hasher:   hasher,
+		config:   config,
 	}
 }
 
 func (s *userService) CreateUser(ctx context.Context, req *models.CreateUserRequest) (*models.User, error) {
 	if req.Username == "" {
-		return nil, fmt.Errorf("username is required")
+		return nil, errors.ValidationError("username", "Username is required")
 	}
 	if req.Password == "" {
-		return nil, fmt.Errorf("password is required")
+		return nil, errors.ValidationError("password", "Password is required")
+	}
+	if err := s.validatePassword(req.Username, req.Password); err != nil {
+		return nil, err
 	}
 
 	// Check if username already exists
 	existingUser, err := s.userRepo.GetByUsername(ctx, req.Username)
 	if err == nil && existingUser != nil {
-		return nil, fmt.Errorf("username already exists")
+		return nil, errors.Conflict("Username", "choose a different username")
 	}
 
-	// Hash the password (simple SHA256 for demo - use bcrypt in production)
-	hasher := sha256.New()
-	hasher.Write([]byte(req.Password))
-	passwordHash := fmt.Sprintf("%x", hasher.Sum(nil))
+	passwordHash, err := s.hasher.Hash(req.Password)
+	if err != nil {
+		return nil, errors.InternalError("Failed to hash password").WithInternal(err)
+	}
 
 	user := &models.User{
 		ID:           uuid.New(),
 		Username:     req.Username,
 		PasswordHash: passwordHash,
+		Role:         models.RoleUser,
 		CreatedAt:    time.Now(),
 	}
 
 	if err := s.userRepo.Create(ctx, user); err != nil {
-		return nil, fmt.Errorf("failed to create user: %w", err)
+		return nil, err
 	}
 
 	return user, nil
@@ -72,30 +133,68 @@ func (s *userService) GetUserByUsername(ctx context.Context, username string) (*
 	return s.userRepo.GetByUsername(ctx, username)
 }
 
-func (s *userService) ListUsers(ctx context.Context) ([]*models.User, error) {
-	return s.userRepo.List(ctx)
+func (s *userService) ListUsers(ctx context.Context, opts *models.ListOptions) ([]*models.User, error) {
+	return s.userRepo.List(ctx, opts)
 }
 
 func (s *userService) ValidateCredentials(ctx context.Context, username, password string) (*models.User, error) {
 	user, err := s.userRepo.GetByUsername(ctx, username)
 	if err != nil {
-		return nil, fmt.Errorf("invalid credentials")
+		if errors.AsAppError(err).Code == errors.ErrCodeNotFound {
+			// Spend the time checking a password would take so that
+			// usernames cannot be probed by timing the response
+			s.hasher.Verify(password, s.getDummyHash(ctx))
+			return nil, errors.Unauthorized("Invalid username or password")
+		}
+		return nil, err
 	}
 
-	// Hash the provided password and compare
-	hasher := sha256.New()
-	hasher.Write([]byte(password))
-	passwordHash := fmt.Sprintf("%x", hasher.Sum(nil))
+	ok, err := s.hasher.Verify(password, user.PasswordHash)
+	if err != nil || !ok {
+		return nil, errors.Unauthorized("Invalid username or password")
+	}
 
-	if user.PasswordHash != passwordHash {
-		return nil, fmt.Errorf("invalid credentials")
+	// Upgrade legacy or outdated hashes now that we know the plaintext
+	if s.hasher.NeedsRehash(user.PasswordHash) {
+		s.rehashPassword(ctx, user, password)
 	}
 
 	return user, nil
 }
 
-func (s *userService) ListUsersPaginated(ctx context.Context, pagination *models.PaginationParams) (*models.PaginatedResponse, error) {
-	users, total, err := s.userRepo.ListPaginated(ctx, pagination)
+// getDummyHash returns a hash of a random password made with the current
+// hasher settings
+func (s *userService) getDummyHash(ctx context.Context) string {
+	s.dummyHashOnce.Do(func() {
+		hash, err := s.hasher.Hash(uuid.NewString())
+		if err != nil {
+			logger.GetLogger().WithContext(ctx).Error("Failed to create dummy password hash", err)
+			return
+		}
+		s.dummyHash = hash
+	})
+	return s.dummyHash
+}
+
+// rehashPassword stores a fresh hash for the user. Failures are logged rather
+// than returned so that a successful login is never rejected because of them.
+func (s *userService) rehashPassword(ctx context.Context, user *models.User, password string) {
+	newHash, err := s.hasher.Hash(password)
+	if err != nil {
+		logger.GetLogger().WithContext(ctx).Error("Failed to rehash password", err, "user_id", user.ID)
+		return
+	}
+
+	if err := s.userRepo.UpdatePasswordHash(ctx, user.ID, newHash); err != nil {
+		logger.GetLogger().WithContext(ctx).Error("Failed to store rehashed password", err, "user_id", user.ID)
+		return
+	}
+
+	user.PasswordHash = newHash
+}
+
+func (s *userService) ListUsersPaginated(ctx context.Context, pagination *models.PaginationParams, opts *models.ListOptions) (*models.PaginatedResponse, error) {
+	users, total, err := s.userRepo.ListPaginated(ctx, pagination, opts)
 	if err != nil {
 		return nil, err
 	}
@@ -106,4 +205,191 @@ func (s *userService) ListUsersPaginated(ctx context.Context, pagination *models
 		Data:       users,
 		Pagination: meta,
 	}, nil
-}
\ No newline at end of file
+}
+
+// ListUsersByCursor returns a keyset paginated page of users. The total is
+// only counted when requested.
+func (s *userService) ListUsersByCursor(ctx context.Context, params *models.CursorParams, opts *models.ListOptions) (*models.PaginatedResponse, error) {
+	if opts.HasSort() {
+		return nil, errors.ValidationError("sort", "Sorting is not supported with cursor pagination")
+	}
+
+	users, hasMore, err := s.userRepo.ListByCursor(ctx, params, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var total *int64
+	if params.IncludeTotal {
+		count, err := s.userRepo.Count(ctx, opts)
+		if err != nil {
+			return nil, err
+		}
+		total = &count
+	}
+
+	var first, last *models.Cursor
+	if len(users) > 0 {
+		first = &models.Cursor{CreatedAt: users[0].CreatedAt, ID: users[0].ID}
+		last = &models.Cursor{CreatedAt: users[len(users)-1].CreatedAt, ID: users[len(users)-1].ID}
+	}
+
+	return &models.PaginatedResponse{
+		Data:       users,
+		Pagination: models.NewCursorPaginationMeta(params, first, last, hasMore, total),
+	}, nil
+}
+
+// ListPrivateUsers lists users including the fields only admins may see
+func (s *userService) ListPrivateUsers(ctx context.Context, pagination *models.PaginationParams) (*models.PaginatedResponse, error) {
+	users, total, err := s.userRepo.ListPaginated(ctx, pagination, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	privateUsers := make([]*models.PrivateUser, 0, len(users))
+	for _, user := range users {
+		privateUsers = append(privateUsers, models.NewPrivateUser(user))
+	}
+
+	return &models.PaginatedResponse{
+		Data:       privateUsers,
+		Pagination: models.NewPaginationMeta(pagination.Page, pagination.PageSize, total),
+	}, nil
+}
+
+func (s *userService) UpdateUserRole(ctx context.Context, id uuid.UUID, role models.Role) (*models.User, error) {
+	if !role.IsValid() {
+		return nil, errors.ValidationError("role", "Role must be one of user, moderator or admin")
+	}
+
+	user, err := s.userRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.userRepo.UpdateRole(ctx, id, role); err != nil {
+		return nil, err
+	}
+
+	user.Role = role
+	return user, nil
+}
+
+// UpdateUser changes the username and/or password of the authenticated user.
+// Either change requires the current password and signs the user out
+// everywhere, as tokens carry the username.
+func (s *userService) UpdateUser(ctx context.Context, userID, id uuid.UUID, req *models.UpdateUserRequest) (*models.User, error) {
+	if id != userID {
+		return nil, errors.Forbidden("You can only update your own account")
+	}
+
+	user, err := s.userRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	renamed := req.Username != nil && *req.Username != user.Username
+	if renamed {
+		existingUser, err := s.userRepo.GetByUsername(ctx, *req.Username)
+		if err == nil && existingUser != nil {
+			return nil, errors.Conflict("Username", "choose a different username")
+		}
+		user.Username = *req.Username
+	}
+
+	if req.Password == nil && !renamed {
+		if err := s.userRepo.Update(ctx, user); err != nil {
+			return nil, err
+		}
+		return user, nil
+	}
+
+	if req.CurrentPassword == nil || *req.CurrentPassword == "" {
+		return nil, errors.ValidationError("current_password", "Current password is required to change the username or password")
+	}
+	if req.Password != nil {
+		if err := s.validatePassword(user.Username, *req.Password); err != nil {
+			return nil, err
+		}
+	}
+
+	ok, err := s.hasher.Verify(*req.CurrentPassword, user.PasswordHash)
+	if err != nil || !ok {
+		return nil, errors.Unauthorized("Current password is incorrect")
+	}
+
+	if req.Password != nil {
+		passwordHash, err := s.hasher.Hash(*req.Password)
+		if err != nil {
+			return nil, errors.InternalError("Failed to hash password").WithInternal(err)
+		}
+		user.PasswordHash = passwordHash
+	} else if s.config.PasswordPolicy != nil {
+		// The password stays, so it must not be based on the new username
+		if validationErrors := s.config.PasswordPolicy.ValidateUsername(user.Username, *req.CurrentPassword); validationErrors != nil {
+			return nil, validationErrors.ToAppError()
+		}
+	}
+
+	if err := s.userRepo.Update(ctx, user); err != nil {
+		return nil, err
+	}
+
+	// Sign out every session, so that whoever knew the old password loses
+	// access along with it, and no token carries the old username
+	if s.config.Tokens != nil {
+		if err := s.config.Tokens.RevokeAllTokens(ctx, user.ID); err != nil {
+			return nil, err
+		}
+	}
+
+	return user, nil
+}
+
+// validatePassword checks a new password for username against the password
+// policy
+func (s *userService) validatePassword(username, password string) error {
+	if s.config.PasswordPolicy == nil {
+		return nil
+	}
+	if validationErrors := s.config.PasswordPolicy.Validate(username, password); validationErrors != nil {
+		return validationErrors.ToAppError()
+	}
+	return nil
+}
+
+// DeleteUser moves the authenticated user's account to the trash and signs it
+// out everywhere. The user's posts go with it, stay up or block the deletion
+// according to the configured policy, which is applied for good when the
+// account is purged.
+func (s *userService) DeleteUser(ctx context.Context, userID, id uuid.UUID) error {
+	if id != userID {
+		return errors.Forbidden("You can only delete your own account")
+	}
+
+	if _, err := s.userRepo.GetByID(ctx, id); err != nil {
+		return err
+	}
+
+	if err := s.userRepo.Delete(ctx, id, s.config.PostPolicy); err != nil {
+		return err
+	}
+
+	// A deleted account must not keep acting through the tokens it holds
+	if s.config.Tokens != nil {
+		if err := s.config.Tokens.RevokeAllTokens(ctx, id); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *userService) RestoreUser(ctx context.Context, id uuid.UUID) (*models.User, error) {
+	if err := s.userRepo.Restore(ctx, id); err != nil {
+		return nil, err
+	}
+
+	return s.userRepo.GetByID(ctx, id)
+}
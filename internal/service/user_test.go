@@ -4,8 +4,12 @@ import (
 	"context"
 	"crypto/sha256"
 	"fmt"
+	"sort"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/alinoer/go-std-api/internal/errors"
 	"github.com/alinoer/go-std-api/internal/models"
 	"github.com/google/uuid"
 )
@@ -14,12 +18,16 @@ import (
 type MockUserRepository struct {
 	users               map[uuid.UUID]*models.User
 	usersByUsername     map[string]*models.User
+	deletedUsers        map[uuid.UUID]*models.User
 	createError         error
 	getByIDError        error
 	getByUsernameError  error
 	listError           error
 	listPaginatedError  error
 	listPaginatedTotal  int64
+	updatePasswordHashError error
+	updateError         error
+	deleteError         error
 	
 	// Call tracking for verification
 	createCalls         []CreateUserCall
@@ -27,6 +35,8 @@ type MockUserRepository struct {
 	getByUsernameCalls  []GetByUsernameCall
 	listCalls           int
 	listPaginatedCalls  []ListPaginatedCall
+	updatePasswordHashCalls []UpdatePasswordHashCall
+	deleteCalls         []DeleteUserCall
 }
 
 // Call structures for tracking method calls
@@ -46,10 +56,21 @@ type ListPaginatedCall struct {
 	Pagination *models.PaginationParams
 }
 
+type UpdatePasswordHashCall struct {
+	ID           uuid.UUID
+	PasswordHash string
+}
+
+type DeleteUserCall struct {
+	ID     uuid.UUID
+	Policy models.UserPostPolicy
+}
+
 func NewMockUserRepository() *MockUserRepository {
 	return &MockUserRepository{
 		users:           make(map[uuid.UUID]*models.User),
 		usersByUsername: make(map[string]*models.User),
+		deletedUsers:    make(map[uuid.UUID]*models.User),
 	}
 }
 
@@ -70,7 +91,7 @@ func (m *MockUserRepository) GetByID(ctx context.Context, id uuid.UUID) (*models
 	}
 	user, exists := m.users[id]
 	if !exists {
-		return nil, fmt.Errorf("user not found")
+		return nil, errors.NotFound("User")
 	}
 	return user, nil
 }
@@ -82,12 +103,12 @@ func (m *MockUserRepository) GetByUsername(ctx context.Context, username string)
 	}
 	user, exists := m.usersByUsername[username]
 	if !exists {
-		return nil, fmt.Errorf("user not found")
+		return nil, errors.NotFound("User")
 	}
 	return user, nil
 }
 
-func (m *MockUserRepository) List(ctx context.Context) ([]*models.User, error) {
+func (m *MockUserRepository) List(ctx context.Context, opts *models.ListOptions) ([]*models.User, error) {
 	m.listCalls++
 	if m.listError != nil {
 		return nil, m.listError
@@ -99,7 +120,7 @@ func (m *MockUserRepository) List(ctx context.Context) ([]*models.User, error) {
 	return users, nil
 }
 
-func (m *MockUserRepository) ListPaginated(ctx context.Context, pagination *models.PaginationParams) ([]*models.User, int64, error) {
+func (m *MockUserRepository) ListPaginated(ctx context.Context, pagination *models.PaginationParams, opts *models.ListOptions) ([]*models.User, int64, error) {
 	m.listPaginatedCalls = append(m.listPaginatedCalls, ListPaginatedCall{Pagination: pagination})
 	if m.listPaginatedError != nil {
 		return nil, 0, m.listPaginatedError
@@ -117,6 +138,77 @@ func (m *MockUserRepository) ListPaginated(ctx context.Context, pagination *mode
 	return users, m.listPaginatedTotal, nil
 }
 
+func (m *MockUserRepository) UpdatePasswordHash(ctx context.Context, id uuid.UUID, passwordHash string) error {
+	m.updatePasswordHashCalls = append(m.updatePasswordHashCalls, UpdatePasswordHashCall{ID: id, PasswordHash: passwordHash})
+	if m.updatePasswordHashError != nil {
+		return m.updatePasswordHashError
+	}
+	user, exists := m.users[id]
+	if !exists {
+		return errors.NotFound("User")
+	}
+	user.PasswordHash = passwordHash
+	return nil
+}
+
+func (m *MockUserRepository) UpdateRole(ctx context.Context, id uuid.UUID, role models.Role) error {
+	user, exists := m.users[id]
+	if !exists {
+		return errors.NotFound("User")
+	}
+	user.Role = role
+	return nil
+}
+
+func (m *MockUserRepository) Update(ctx context.Context, user *models.User) error {
+	if m.updateError != nil {
+		return m.updateError
+	}
+	existing, exists := m.users[user.ID]
+	if !exists {
+		return errors.NotFound("User")
+	}
+	delete(m.usersByUsername, existing.Username)
+	m.users[user.ID] = user
+	m.usersByUsername[user.Username] = user
+	return nil
+}
+
+func (m *MockUserRepository) Delete(ctx context.Context, id uuid.UUID, policy models.UserPostPolicy) error {
+	m.deleteCalls = append(m.deleteCalls, DeleteUserCall{ID: id, Policy: policy})
+	if m.deleteError != nil {
+		return m.deleteError
+	}
+	user, exists := m.users[id]
+	if !exists {
+		return errors.NotFound("User")
+	}
+	delete(m.users, id)
+	delete(m.usersByUsername, user.Username)
+	m.deletedUsers[id] = user
+	return nil
+}
+
+func (m *MockUserRepository) Restore(ctx context.Context, id uuid.UUID) error {
+	user, exists := m.deletedUsers[id]
+	if !exists {
+		return errors.NotFound("User")
+	}
+	delete(m.deletedUsers, id)
+	m.users[id] = user
+	m.usersByUsername[user.Username] = user
+	return nil
+}
+
+func (m *MockUserRepository) Purge(ctx context.Context, before time.Time, policy models.UserPostPolicy) (int64, error) {
+	if m.deleteError != nil {
+		return 0, m.deleteError
+	}
+	purged := int64(len(m.deletedUsers))
+	m.deletedUsers = make(map[uuid.UUID]*models.User)
+	return purged, nil
+}
+
 // Helper methods for setting up mock behavior
 func (m *MockUserRepository) SetCreateError(err error) {
 	m.createError = err
@@ -126,6 +218,28 @@ func (m *MockUserRepository) SetGetByIDError(err error) {
 	m.getByIDError = err
 }
 
+func (m *MockUserRepository) ListByCursor(ctx context.Context, params *models.CursorParams, opts *models.ListOptions) ([]*models.User, bool, error) {
+	if m.listPaginatedError != nil {
+		return nil, false, m.listPaginatedError
+	}
+	var users []*models.User
+	for _, user := range m.users {
+		users = append(users, user)
+	}
+	sort.Slice(users, func(i, j int) bool {
+		return users[i].CreatedAt.After(users[j].CreatedAt)
+	})
+	hasMore := len(users) > params.Limit
+	if hasMore {
+		users = users[:params.Limit]
+	}
+	return users, hasMore, nil
+}
+
+func (m *MockUserRepository) Count(ctx context.Context, opts *models.ListOptions) (int64, error) {
+	return int64(len(m.users)), nil
+}
+
 func (m *MockUserRepository) SetGetByUsernameError(err error) {
 	m.getByUsernameError = err
 }
@@ -142,6 +256,10 @@ func (m *MockUserRepository) SetListPaginatedTotal(total int64) {
 	m.listPaginatedTotal = total
 }
 
+func (m *MockUserRepository) SetUpdatePasswordHashError(err error) {
+	m.updatePasswordHashError = err
+}
+
 func (m *MockUserRepository) AddUser(user *models.User) {
 	m.users[user.ID] = user
 	m.usersByUsername[user.Username] = user
@@ -168,6 +286,10 @@ func (m *MockUserRepository) GetListPaginatedCallCount() int {
 	return len(m.listPaginatedCalls)
 }
 
+func (m *MockUserRepository) GetUpdatePasswordHashCallCount() int {
+	return len(m.updatePasswordHashCalls)
+}
+
 func (m *MockUserRepository) WasCalledWithUsername(username string) bool {
 	for _, call := range m.getByUsernameCalls {
 		if call.Username == username {
@@ -192,17 +314,75 @@ func (m *MockUserRepository) Reset() {
 	m.getByUsernameCalls = nil
 	m.listCalls = 0
 	m.listPaginatedCalls = nil
+	m.updatePasswordHashCalls = nil
+}
+
+// MockTokenRevoker records the users whose tokens were revoked
+type MockTokenRevoker struct {
+	revoked []uuid.UUID
+	err     error
+}
+
+func (m *MockTokenRevoker) RevokeAllTokens(ctx context.Context, userID uuid.UUID) error {
+	if m.err != nil {
+		return m.err
+	}
+	m.revoked = append(m.revoked, userID)
+	return nil
+}
+
+// newTestPasswordHasher returns an argon2id hasher with cheap parameters so
+// tests stay fast
+func newTestPasswordHasher(t testing.TB) PasswordHasher {
+	hasher, err := NewPasswordHasher(PasswordHashConfig{
+		Algorithm:         PasswordAlgorithmArgon2id,
+		Argon2Memory:      1024,
+		Argon2Iterations:  1,
+		Argon2Parallelism: 1,
+		BcryptCost:        4,
+	})
+	if err != nil {
+		t.Fatalf("failed to create password hasher: %v", err)
+	}
+	return hasher
 }
 
 func TestNewUserService(t *testing.T) {
 	mockRepo := NewMockUserRepository()
-	service := NewUserService(mockRepo)
+	service := NewUserService(mockRepo, newTestPasswordHasher(t))
 
 	if service == nil {
 		t.Error("expected non-nil service")
 	}
 }
 
+func TestUserService_CreateUser_HasherLimit(t *testing.T) {
+	hasher, err := NewPasswordHasher(PasswordHashConfig{
+		Algorithm:         PasswordAlgorithmBcrypt,
+		Argon2Memory:      1024,
+		Argon2Iterations:  1,
+		Argon2Parallelism: 1,
+		BcryptCost:        4,
+	})
+	if err != nil {
+		t.Fatalf("failed to create password hasher: %v", err)
+	}
+
+	for _, config := range []UserServiceConfig{DefaultUserServiceConfig(), {}} {
+		service := NewUserServiceWithConfig(NewMockUserRepository(), hasher, config)
+
+		// bcrypt only hashes 72 bytes, which are 36 characters of Cyrillic
+		_, err := service.CreateUser(context.Background(), &models.CreateUserRequest{Username: "alice", Password: strings.Repeat("ж", 37)})
+		if appErr := errors.AsAppError(err); appErr == nil || appErr.Code != errors.ErrCodeValidation {
+			t.Errorf("expected a validation error for a password bcrypt cannot hash, got %v", err)
+		}
+
+		if _, err := service.CreateUser(context.Background(), &models.CreateUserRequest{Username: "alice", Password: strings.Repeat("ж", 36)}); err != nil {
+			t.Errorf("expected 72 bytes to be hashed, got %v", err)
+		}
+	}
+}
+
 func TestUserService_CreateUser(t *testing.T) {
 	tests := []struct {
 		name          string
@@ -228,7 +408,7 @@ func TestUserService_CreateUser(t *testing.T) {
 				Password: "password123",
 			},
 			setupMock:     func(mock *MockUserRepository) {},
-			expectedError: "username is required",
+			expectedError: "VALIDATION_ERROR: Validation failed for field 'username' - Username is required",
 		},
 		{
 			name: "empty password",
@@ -237,7 +417,16 @@ func TestUserService_CreateUser(t *testing.T) {
 				Password: "",
 			},
 			setupMock:     func(mock *MockUserRepository) {},
-			expectedError: "password is required",
+			expectedError: "VALIDATION_ERROR: Validation failed for field 'password' - Password is required",
+		},
+		{
+			name: "password below the policy",
+			request: &models.CreateUserRequest{
+				Username: "testuser",
+				Password: "short",
+			},
+			setupMock:     func(mock *MockUserRepository) {},
+			expectedError: "VALIDATION_ERROR: Multiple validation errors",
 		},
 		{
 			name: "username already exists",
@@ -252,7 +441,7 @@ func TestUserService_CreateUser(t *testing.T) {
 				}
 				mock.AddUser(existingUser)
 			},
-			expectedError: "username already exists",
+			expectedError: "CONFLICT: Username already exists - choose a different username",
 		},
 		{
 			name: "repository create error",
@@ -263,7 +452,7 @@ func TestUserService_CreateUser(t *testing.T) {
 			setupMock: func(mock *MockUserRepository) {
 				mock.SetCreateError(fmt.Errorf("database error"))
 			},
-			expectedError: "failed to create user: database error",
+			expectedError: "database error",
 		},
 	}
 
@@ -271,7 +460,7 @@ func TestUserService_CreateUser(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			mockRepo := NewMockUserRepository()
 			tt.setupMock(mockRepo)
-			service := NewUserService(mockRepo)
+			service := NewUserService(mockRepo, newTestPasswordHasher(t))
 
 			user, err := service.CreateUser(context.Background(), tt.request)
 
@@ -301,11 +490,12 @@ func TestUserService_CreateUser(t *testing.T) {
 			}
 
 			// Verify password hash
-			hasher := sha256.New()
-			hasher.Write([]byte(tt.request.Password))
-			expectedHash := fmt.Sprintf("%x", hasher.Sum(nil))
+			if !strings.HasPrefix(user.PasswordHash, "$argon2id$") {
+				t.Errorf("expected argon2id password hash, got %q", user.PasswordHash)
+			}
 
-			if user.PasswordHash != expectedHash {
+			ok, err := newTestPasswordHasher(t).Verify(tt.request.Password, user.PasswordHash)
+			if err != nil || !ok {
 				t.Errorf("password hash mismatch")
 			}
 
@@ -346,7 +536,7 @@ func TestUserService_GetUser(t *testing.T) {
 			setupMock: func(mock *MockUserRepository) {
 				// No user added
 			},
-			expectedError: "user not found",
+			expectedError: "NOT_FOUND: User not found",
 		},
 		{
 			name:   "repository error",
@@ -362,7 +552,7 @@ func TestUserService_GetUser(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			mockRepo := NewMockUserRepository()
 			tt.setupMock(mockRepo)
-			service := NewUserService(mockRepo)
+			service := NewUserService(mockRepo, newTestPasswordHasher(t))
 
 			// For the successful case, we need to use the actual user ID
 			var testID uuid.UUID
@@ -401,9 +591,10 @@ func TestUserService_GetUser(t *testing.T) {
 }
 
 func TestUserService_ValidateCredentials(t *testing.T) {
-	hasher := sha256.New()
-	hasher.Write([]byte("correctpassword"))
-	correctHash := fmt.Sprintf("%x", hasher.Sum(nil))
+	correctHash, err := newTestPasswordHasher(t).Hash("correctpassword")
+	if err != nil {
+		t.Fatalf("failed to hash password: %v", err)
+	}
 
 	tests := []struct {
 		name          string
@@ -433,7 +624,7 @@ func TestUserService_ValidateCredentials(t *testing.T) {
 			setupMock: func(mock *MockUserRepository) {
 				// No user added
 			},
-			expectedError: "invalid credentials",
+			expectedError: "UNAUTHORIZED: Invalid username or password",
 		},
 		{
 			name:     "wrong password",
@@ -447,16 +638,16 @@ func TestUserService_ValidateCredentials(t *testing.T) {
 				}
 				mock.AddUser(user)
 			},
-			expectedError: "invalid credentials",
+			expectedError: "UNAUTHORIZED: Invalid username or password",
 		},
 		{
 			name:     "repository error",
 			username: "testuser",
 			password: "password",
 			setupMock: func(mock *MockUserRepository) {
-				mock.SetGetByUsernameError(fmt.Errorf("database error"))
+				mock.SetGetByUsernameError(errors.DatabaseError("get user", fmt.Errorf("connection refused")))
 			},
-			expectedError: "invalid credentials",
+			expectedError: "DATABASE_ERROR: Database operation failed: get user",
 		},
 	}
 
@@ -464,7 +655,7 @@ func TestUserService_ValidateCredentials(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			mockRepo := NewMockUserRepository()
 			tt.setupMock(mockRepo)
-			service := NewUserService(mockRepo)
+			service := NewUserService(mockRepo, newTestPasswordHasher(t))
 
 			user, err := service.ValidateCredentials(context.Background(), tt.username, tt.password)
 
@@ -496,6 +687,100 @@ func TestUserService_ValidateCredentials(t *testing.T) {
 	}
 }
 
+// countingPasswordHasher counts the passwords it verifies
+type countingPasswordHasher struct {
+	PasswordHasher
+	verifyCalls int
+}
+
+func (h *countingPasswordHasher) Verify(password, encodedHash string) (bool, error) {
+	h.verifyCalls++
+	return h.PasswordHasher.Verify(password, encodedHash)
+}
+
+func TestUserService_ValidateCredentials_UnknownUser(t *testing.T) {
+	hasher := &countingPasswordHasher{PasswordHasher: newTestPasswordHasher(t)}
+	service := NewUserService(NewMockUserRepository(), hasher)
+
+	for i := 1; i <= 2; i++ {
+		_, err := service.ValidateCredentials(context.Background(), "nonexistent", "password")
+		if err == nil || err.Error() != "UNAUTHORIZED: Invalid username or password" {
+			t.Fatalf("expected invalid credentials, got %v", err)
+		}
+		// A password is verified as it would be for an existing user
+		if hasher.verifyCalls != i {
+			t.Errorf("expected %d password verifications, got %d", i, hasher.verifyCalls)
+		}
+	}
+}
+
+func TestUserService_ValidateCredentials_Rehash(t *testing.T) {
+	legacySum := sha256.Sum256([]byte("password123"))
+	legacyHash := fmt.Sprintf("%x", legacySum)
+
+	tests := []struct {
+		name              string
+		passwordHash      func(t *testing.T) string
+		updateError       error
+		expectUpdateCalls int
+		expectUpgraded    bool
+	}{
+		{
+			name:              "legacy sha256 hash is upgraded",
+			passwordHash:      func(t *testing.T) string { return legacyHash },
+			expectUpdateCalls: 1,
+			expectUpgraded:    true,
+		},
+		{
+			name: "current hash is left alone",
+			passwordHash: func(t *testing.T) string {
+				hash, err := newTestPasswordHasher(t).Hash("password123")
+				if err != nil {
+					t.Fatalf("failed to hash password: %v", err)
+				}
+				return hash
+			},
+			expectUpdateCalls: 0,
+		},
+		{
+			name:              "failed upgrade does not block login",
+			passwordHash:      func(t *testing.T) string { return legacyHash },
+			updateError:       fmt.Errorf("database error"),
+			expectUpdateCalls: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := NewMockUserRepository()
+			mockRepo.SetUpdatePasswordHashError(tt.updateError)
+			mockRepo.AddUser(&models.User{
+				ID:           uuid.New(),
+				Username:     "testuser",
+				PasswordHash: tt.passwordHash(t),
+			})
+			service := NewUserService(mockRepo, newTestPasswordHasher(t))
+
+			user, err := service.ValidateCredentials(context.Background(), "testuser", "password123")
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if got := mockRepo.GetUpdatePasswordHashCallCount(); got != tt.expectUpdateCalls {
+				t.Errorf("expected %d UpdatePasswordHash calls, got %d", tt.expectUpdateCalls, got)
+			}
+
+			upgraded := strings.HasPrefix(user.PasswordHash, "$argon2id$")
+			if tt.expectUpgraded && !upgraded {
+				t.Errorf("expected password hash to be upgraded, got %q", user.PasswordHash)
+			}
+			if !tt.expectUpgraded && tt.updateError != nil && upgraded {
+				t.Error("expected password hash to stay unchanged after failed update")
+			}
+		})
+	}
+}
+
 func TestUserService_ListUsersPaginated(t *testing.T) {
 	tests := []struct {
 		name          string
@@ -535,9 +820,9 @@ func TestUserService_ListUsersPaginated(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			mockRepo := NewMockUserRepository()
 			tt.setupMock(mockRepo)
-			service := NewUserService(mockRepo)
+			service := NewUserService(mockRepo, newTestPasswordHasher(t))
 
-			response, err := service.ListUsersPaginated(context.Background(), tt.pagination)
+			response, err := service.ListUsersPaginated(context.Background(), tt.pagination, nil)
 
 			if tt.expectedError != "" {
 				if err == nil {
@@ -565,16 +850,385 @@ func TestUserService_ListUsersPaginated(t *testing.T) {
 				return
 			}
 
-			if response.Pagination.Total != tt.expectedTotal {
-				t.Errorf("expected total %d, got %d", tt.expectedTotal, response.Pagination.Total)
+			if response.Pagination.Total == nil || *response.Pagination.Total != tt.expectedTotal {
+				t.Errorf("expected total %d, got %v", tt.expectedTotal, response.Pagination.Total)
+			}
+		})
+	}
+}
+
+func TestUserService_ListPrivateUsers(t *testing.T) {
+	mockRepo := NewMockUserRepository()
+	admin := &models.User{ID: uuid.New(), Username: "admin", Role: models.RoleAdmin}
+	mockRepo.AddUser(admin)
+	mockRepo.SetListPaginatedTotal(1)
+	service := NewUserService(mockRepo, newTestPasswordHasher(t))
+
+	response, err := service.ListPrivateUsers(context.Background(), models.NewPaginationParams(1, 10))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	users, ok := response.Data.([]*models.PrivateUser)
+	if !ok {
+		t.Fatalf("expected []*models.PrivateUser, got %T", response.Data)
+	}
+	if len(users) != 1 || users[0].Role != models.RoleAdmin {
+		t.Errorf("expected admin user with role, got %+v", users)
+	}
+
+	mockRepo.SetListPaginatedError(fmt.Errorf("database error"))
+	if _, err := service.ListPrivateUsers(context.Background(), models.NewPaginationParams(1, 10)); err == nil {
+		t.Error("expected repository error to be returned")
+	}
+}
+
+func TestUserService_UpdateUserRole(t *testing.T) {
+	userID := uuid.New()
+
+	tests := []struct {
+		name          string
+		userID        uuid.UUID
+		role          models.Role
+		expectedError string
+	}{
+		{
+			name:   "promote to moderator",
+			userID: userID,
+			role:   models.RoleModerator,
+		},
+		{
+			name:   "promote to admin",
+			userID: userID,
+			role:   models.RoleAdmin,
+		},
+		{
+			name:          "invalid role",
+			userID:        userID,
+			role:          models.Role("superuser"),
+			expectedError: "VALIDATION_ERROR: Validation failed for field 'role' - Role must be one of user, moderator or admin",
+		},
+		{
+			name:          "user not found",
+			userID:        uuid.New(),
+			role:          models.RoleAdmin,
+			expectedError: "NOT_FOUND: User not found",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := NewMockUserRepository()
+			mockRepo.AddUser(&models.User{ID: userID, Username: "testuser", Role: models.RoleUser})
+			service := NewUserService(mockRepo, newTestPasswordHasher(t))
+
+			user, err := service.UpdateUserRole(context.Background(), tt.userID, tt.role)
+
+			if tt.expectedError != "" {
+				if err == nil {
+					t.Fatalf("expected error %q, got nil", tt.expectedError)
+				}
+				if err.Error() != tt.expectedError {
+					t.Errorf("expected error %q, got %q", tt.expectedError, err.Error())
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if user.Role != tt.role {
+				t.Errorf("expected role %q, got %q", tt.role, user.Role)
+			}
+			stored, _ := mockRepo.GetByID(context.Background(), tt.userID)
+			if stored.Role != tt.role {
+				t.Errorf("expected stored role %q, got %q", tt.role, stored.Role)
 			}
 		})
 	}
 }
 
+func TestUserService_UpdateUser(t *testing.T) {
+	userID := uuid.New()
+	otherID := uuid.New()
+	strPtr := func(s string) *string { return &s }
+
+	tests := []struct {
+		name             string
+		actorID          uuid.UUID
+		request          *models.UpdateUserRequest
+		updateError      error
+		expectedError    string
+		expectedUsername string
+		expectedPassword string
+		expectedRevoked  bool
+	}{
+		{
+			name:             "change username",
+			actorID:          userID,
+			request:          &models.UpdateUserRequest{Username: strPtr("renamed"), CurrentPassword: strPtr("oldpassword")},
+			expectedUsername: "renamed",
+			expectedPassword: "oldpassword",
+			expectedRevoked:  true,
+		},
+		{
+			name:          "change username without current password",
+			actorID:       userID,
+			request:       &models.UpdateUserRequest{Username: strPtr("renamed")},
+			expectedError: "VALIDATION_ERROR: Validation failed for field 'current_password' - Current password is required to change the username or password",
+		},
+		{
+			name:          "change username with wrong current password",
+			actorID:       userID,
+			request:       &models.UpdateUserRequest{Username: strPtr("renamed"), CurrentPassword: strPtr("wrongpassword")},
+			expectedError: "UNAUTHORIZED: Current password is incorrect",
+		},
+		{
+			name:          "change username the password is based on",
+			actorID:       userID,
+			request:       &models.UpdateUserRequest{Username: strPtr("oldpass"), CurrentPassword: strPtr("oldpassword")},
+			expectedError: "VALIDATION_ERROR: Multiple validation errors",
+		},
+		{
+			name:             "change username and password it was based on",
+			actorID:          userID,
+			request:          &models.UpdateUserRequest{Username: strPtr("oldpass"), Password: strPtr("newpassword"), CurrentPassword: strPtr("oldpassword")},
+			expectedUsername: "oldpass",
+			expectedPassword: "newpassword",
+			expectedRevoked:  true,
+		},
+		{
+			name:             "keep own username",
+			actorID:          userID,
+			request:          &models.UpdateUserRequest{Username: strPtr("testuser")},
+			expectedUsername: "testuser",
+			expectedPassword: "oldpassword",
+		},
+		{
+			name:             "change password with current password",
+			actorID:          userID,
+			request:          &models.UpdateUserRequest{Password: strPtr("newpassword"), CurrentPassword: strPtr("oldpassword")},
+			expectedUsername: "testuser",
+			expectedPassword: "newpassword",
+			expectedRevoked:  true,
+		},
+		{
+			name:          "username taken",
+			actorID:       userID,
+			request:       &models.UpdateUserRequest{Username: strPtr("otheruser"), CurrentPassword: strPtr("oldpassword")},
+			expectedError: "CONFLICT: Username already exists - choose a different username",
+		},
+		{
+			name:          "username taken concurrently",
+			actorID:       userID,
+			request:       &models.UpdateUserRequest{Username: strPtr("renamed"), CurrentPassword: strPtr("oldpassword")},
+			updateError:   errors.Conflict("Username", "choose a different username"),
+			expectedError: "CONFLICT: Username already exists - choose a different username",
+		},
+		{
+			name:          "password change without current password",
+			actorID:       userID,
+			request:       &models.UpdateUserRequest{Password: strPtr("newpassword")},
+			expectedError: "VALIDATION_ERROR: Validation failed for field 'current_password' - Current password is required to change the username or password",
+		},
+		{
+			name:          "new password based on the username",
+			actorID:       userID,
+			request:       &models.UpdateUserRequest{Password: strPtr("testuser123"), CurrentPassword: strPtr("oldpassword")},
+			expectedError: "VALIDATION_ERROR: Multiple validation errors",
+		},
+		{
+			name:          "new password based on the new username",
+			actorID:       userID,
+			request:       &models.UpdateUserRequest{Username: strPtr("renamed"), Password: strPtr("renamed!!"), CurrentPassword: strPtr("oldpassword")},
+			expectedError: "VALIDATION_ERROR: Multiple validation errors",
+		},
+		{
+			name:          "password change with wrong current password",
+			actorID:       userID,
+			request:       &models.UpdateUserRequest{Password: strPtr("newpassword"), CurrentPassword: strPtr("wrongpassword")},
+			expectedError: "UNAUTHORIZED: Current password is incorrect",
+		},
+		{
+			name:          "update another user",
+			actorID:       otherID,
+			request:       &models.UpdateUserRequest{Username: strPtr("renamed")},
+			expectedError: "FORBIDDEN: You can only update your own account",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hasher := newTestPasswordHasher(t)
+			passwordHash, err := hasher.Hash("oldpassword")
+			if err != nil {
+				t.Fatalf("failed to hash password: %v", err)
+			}
+
+			mockRepo := NewMockUserRepository()
+			mockRepo.AddUser(&models.User{ID: userID, Username: "testuser", PasswordHash: passwordHash})
+			mockRepo.AddUser(&models.User{ID: otherID, Username: "otheruser", PasswordHash: passwordHash})
+			mockRepo.updateError = tt.updateError
+			tokens := &MockTokenRevoker{}
+			config := DefaultUserServiceConfig()
+			config.Tokens = tokens
+			service := NewUserServiceWithConfig(mockRepo, hasher, config)
+
+			user, err := service.UpdateUser(context.Background(), tt.actorID, userID, tt.request)
+
+			if tt.expectedError != "" {
+				if err == nil {
+					t.Fatalf("expected error %q, got nil", tt.expectedError)
+				}
+				if err.Error() != tt.expectedError {
+					t.Errorf("expected error %q, got %q", tt.expectedError, err.Error())
+				}
+				if len(tokens.revoked) != 0 {
+					t.Errorf("expected no tokens to be revoked, got %v", tokens.revoked)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if user.Username != tt.expectedUsername {
+				t.Errorf("expected username %q, got %q", tt.expectedUsername, user.Username)
+			}
+			if _, err := mockRepo.GetByUsername(context.Background(), tt.expectedUsername); err != nil {
+				t.Errorf("expected user to be stored under username %q", tt.expectedUsername)
+			}
+			ok, err := hasher.Verify(tt.expectedPassword, user.PasswordHash)
+			if err != nil || !ok {
+				t.Errorf("expected password %q to verify", tt.expectedPassword)
+			}
+			if revoked := len(tokens.revoked) == 1 && tokens.revoked[0] == userID; revoked != tt.expectedRevoked || len(tokens.revoked) > 1 {
+				t.Errorf("expected tokens revoked to be %v, got %v", tt.expectedRevoked, tokens.revoked)
+			}
+		})
+	}
+}
+
+func TestUserService_DeleteUser(t *testing.T) {
+	userID := uuid.New()
+
+	tests := []struct {
+		name           string
+		actorID        uuid.UUID
+		targetID       uuid.UUID
+		policy         models.UserPostPolicy
+		deleteError    error
+		expectedError  string
+		expectedPolicy models.UserPostPolicy
+	}{
+		{
+			name:           "default policy anonymizes posts",
+			actorID:        userID,
+			targetID:       userID,
+			expectedPolicy: models.UserPostPolicyAnonymize,
+		},
+		{
+			name:           "configured cascade policy",
+			actorID:        userID,
+			targetID:       userID,
+			policy:         models.UserPostPolicyCascade,
+			expectedPolicy: models.UserPostPolicyCascade,
+		},
+		{
+			name:          "block policy with posts",
+			actorID:       userID,
+			targetID:      userID,
+			policy:        models.UserPostPolicyBlock,
+			deleteError:   errors.NewAppError(errors.ErrCodeConflict, "User still has posts").WithDetails("Delete your posts before deleting your account"),
+			expectedError: "CONFLICT: User still has posts - Delete your posts before deleting your account",
+		},
+		{
+			name:          "delete another user",
+			actorID:       uuid.New(),
+			targetID:      userID,
+			expectedError: "FORBIDDEN: You can only delete your own account",
+		},
+		{
+			name:          "user not found",
+			actorID:       uuid.Nil,
+			targetID:      uuid.Nil,
+			expectedError: "NOT_FOUND: User not found",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := NewMockUserRepository()
+			mockRepo.AddUser(&models.User{ID: userID, Username: "testuser"})
+			mockRepo.deleteError = tt.deleteError
+
+			config := DefaultUserServiceConfig()
+			if tt.policy != "" {
+				config.PostPolicy = tt.policy
+			}
+			tokens := &MockTokenRevoker{}
+			config.Tokens = tokens
+			service := NewUserServiceWithConfig(mockRepo, newTestPasswordHasher(t), config)
+
+			err := service.DeleteUser(context.Background(), tt.actorID, tt.targetID)
+
+			if tt.expectedError != "" {
+				if err == nil {
+					t.Fatalf("expected error %q, got nil", tt.expectedError)
+				}
+				if err.Error() != tt.expectedError {
+					t.Errorf("expected error %q, got %q", tt.expectedError, err.Error())
+				}
+				if len(tokens.revoked) != 0 {
+					t.Errorf("expected no tokens to be revoked, got %v", tokens.revoked)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(mockRepo.deleteCalls) != 1 || mockRepo.deleteCalls[0].Policy != tt.expectedPolicy {
+				t.Errorf("expected one delete with policy %q, got %+v", tt.expectedPolicy, mockRepo.deleteCalls)
+			}
+			if _, err := mockRepo.GetByID(context.Background(), userID); err == nil {
+				t.Error("expected user to be deleted")
+			}
+			if len(tokens.revoked) != 1 || tokens.revoked[0] != userID {
+				t.Errorf("expected the user's tokens to be revoked, got %v", tokens.revoked)
+			}
+		})
+	}
+}
+
+func TestUserService_RestoreUser(t *testing.T) {
+	userID := uuid.New()
+	mockRepo := NewMockUserRepository()
+	mockRepo.AddUser(&models.User{ID: userID, Username: "testuser"})
+	service := NewUserService(mockRepo, newTestPasswordHasher(t))
+
+	if _, err := service.RestoreUser(context.Background(), userID); err == nil || err.Error() != "NOT_FOUND: User not found" {
+		t.Errorf("expected restoring a user outside the trash to fail, got %v", err)
+	}
+
+	if err := service.DeleteUser(context.Background(), userID, userID); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	user, err := service.RestoreUser(context.Background(), userID)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if user.ID != userID {
+		t.Errorf("expected user %s, got %s", userID, user.ID)
+	}
+	if _, err := service.GetUserByUsername(context.Background(), "testuser"); err != nil {
+		t.Errorf("expected the restored user to be found again, got %v", err)
+	}
+}
+
 func TestUserService_CreateUser_MockVerification(t *testing.T) {
 	mockRepo := NewMockUserRepository()
-	service := NewUserService(mockRepo)
+	service := NewUserService(mockRepo, newTestPasswordHasher(t))
 
 	// Test successful user creation
 	request := &models.CreateUserRequest{
@@ -635,7 +1289,7 @@ func TestUserService_CreateUser_MockVerification(t *testing.T) {
 // Benchmark tests for service layer performance
 func BenchmarkUserService_CreateUser(b *testing.B) {
 	mockRepo := NewMockUserRepository()
-	service := NewUserService(mockRepo)
+	service := NewUserService(mockRepo, newTestPasswordHasher(b))
 
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
@@ -649,7 +1303,7 @@ func BenchmarkUserService_CreateUser(b *testing.B) {
 
 func BenchmarkUserService_GetUser(b *testing.B) {
 	mockRepo := NewMockUserRepository()
-	service := NewUserService(mockRepo)
+	service := NewUserService(mockRepo, newTestPasswordHasher(b))
 
 	// Setup test data
 	testUser := &models.User{
@@ -666,12 +1320,13 @@ func BenchmarkUserService_GetUser(b *testing.B) {
 
 func BenchmarkUserService_ValidateCredentials(b *testing.B) {
 	mockRepo := NewMockUserRepository()
-	service := NewUserService(mockRepo)
+	service := NewUserService(mockRepo, newTestPasswordHasher(b))
 
 	// Setup test user with hashed password
-	hasher := sha256.New()
-	hasher.Write([]byte("password123"))
-	passwordHash := fmt.Sprintf("%x", hasher.Sum(nil))
+	passwordHash, err := newTestPasswordHasher(b).Hash("password123")
+	if err != nil {
+		b.Fatalf("failed to hash password: %v", err)
+	}
 
 	testUser := &models.User{
 		ID:           uuid.New(),
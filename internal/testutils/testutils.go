@@ -132,14 +132,26 @@ func createTables(t TestingInterface, db *pgxpool.Pool) {
 			id UUID PRIMARY KEY,
 			username VARCHAR(255) UNIQUE NOT NULL,
 			password_hash VARCHAR(255) NOT NULL,
+			role VARCHAR(20) NOT NULL DEFAULT 'user',
 			created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
-			updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+			updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+			deleted_at TIMESTAMP WITH TIME ZONE
 		)
 	`)
 	if err != nil {
 		t.Fatalf("Failed to create users table: %v", err)
 	}
 
+	// Placeholder author for the posts of deleted users
+	_, err = db.Exec(context.Background(), `
+		INSERT INTO users (id, username, password_hash)
+		VALUES ('00000000-0000-0000-0000-000000000000', '[deleted]', '!')
+		ON CONFLICT (id) DO NOTHING
+	`)
+	if err != nil {
+		t.Fatalf("Failed to create deleted user placeholder: %v", err)
+	}
+
 	// Create posts table
 	_, err = db.Exec(context.Background(), `
 		CREATE TABLE IF NOT EXISTS posts (
@@ -148,10 +160,185 @@ func createTables(t TestingInterface, db *pgxpool.Pool) {
 			title VARCHAR(255) NOT NULL,
 			content TEXT NOT NULL,
 			created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
-			updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+			updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+			reaction_count BIGINT NOT NULL DEFAULT 0,
+			activity_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			status TEXT NOT NULL DEFAULT 'published',
+			published_at TIMESTAMP WITH TIME ZONE,
+			version INTEGER NOT NULL DEFAULT 1,
+			deleted_at TIMESTAMP WITH TIME ZONE,
+			search_vector tsvector GENERATED ALWAYS AS (
+				setweight(to_tsvector('english', coalesce(title, '')), 'A') ||
+				setweight(to_tsvector('english', coalesce(content, '')), 'B')
+			) STORED
 		)
 	`)
 	if err != nil {
 		t.Fatalf("Failed to create posts table: %v", err)
 	}
+
+	// Create comments table
+	_, err = db.Exec(context.Background(), `
+		CREATE TABLE IF NOT EXISTS comments (
+			id UUID PRIMARY KEY,
+			post_id UUID NOT NULL REFERENCES posts(id) ON DELETE CASCADE,
+			user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+			parent_id UUID REFERENCES comments(id) ON DELETE CASCADE,
+			content TEXT NOT NULL,
+			created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+			updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+		)
+	`)
+	if err != nil {
+		t.Fatalf("Failed to create comments table: %v", err)
+	}
+
+	// Create post reactions table
+	_, err = db.Exec(context.Background(), `
+		CREATE TABLE IF NOT EXISTS post_reactions (
+			post_id UUID NOT NULL REFERENCES posts(id) ON DELETE CASCADE,
+			user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+			kind TEXT NOT NULL,
+			created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (post_id, user_id, kind)
+		)
+	`)
+	if err != nil {
+		t.Fatalf("Failed to create post_reactions table: %v", err)
+	}
+
+	// Create tags tables
+	_, err = db.Exec(context.Background(), `
+		CREATE TABLE IF NOT EXISTS tags (
+			id BIGSERIAL PRIMARY KEY,
+			name TEXT NOT NULL UNIQUE,
+			created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+		)
+	`)
+	if err != nil {
+		t.Fatalf("Failed to create tags table: %v", err)
+	}
+
+	_, err = db.Exec(context.Background(), `
+		CREATE TABLE IF NOT EXISTS post_tags (
+			post_id UUID NOT NULL REFERENCES posts(id) ON DELETE CASCADE,
+			tag_id BIGINT NOT NULL REFERENCES tags(id) ON DELETE CASCADE,
+			PRIMARY KEY (post_id, tag_id)
+		)
+	`)
+	if err != nil {
+		t.Fatalf("Failed to create post_tags table: %v", err)
+	}
+
+	// Create post revisions table
+	_, err = db.Exec(context.Background(), `
+		CREATE TABLE IF NOT EXISTS post_revisions (
+			post_id UUID NOT NULL REFERENCES posts(id) ON DELETE CASCADE,
+			version INTEGER NOT NULL,
+			editor_id UUID REFERENCES users(id) ON DELETE SET NULL,
+			title TEXT NOT NULL,
+			content TEXT NOT NULL,
+			created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (post_id, version)
+		)
+	`)
+	if err != nil {
+		t.Fatalf("Failed to create post_revisions table: %v", err)
+	}
+
+	// Create refresh tokens table
+	_, err = db.Exec(context.Background(), `
+		CREATE TABLE IF NOT EXISTS refresh_tokens (
+			id UUID PRIMARY KEY,
+			user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+			family_id UUID NOT NULL,
+			token_hash VARCHAR(64) UNIQUE NOT NULL,
+			expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+			created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+			revoked_at TIMESTAMP WITH TIME ZONE,
+			replaced_by UUID REFERENCES refresh_tokens(id) ON DELETE SET NULL
+		)
+	`)
+	if err != nil {
+		t.Fatalf("Failed to create refresh_tokens table: %v", err)
+	}
+
+	// Create token revocation tables
+	_, err = db.Exec(context.Background(), `
+		CREATE TABLE IF NOT EXISTS revoked_tokens (
+			jti TEXT PRIMARY KEY,
+			expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+			revoked_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+		)
+	`)
+	if err != nil {
+		t.Fatalf("Failed to create revoked_tokens table: %v", err)
+	}
+
+	_, err = db.Exec(context.Background(), `
+		CREATE TABLE IF NOT EXISTS user_token_generations (
+			user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+			generation BIGINT NOT NULL DEFAULT 0,
+			updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+		)
+	`)
+	if err != nil {
+		t.Fatalf("Failed to create user_token_generations table: %v", err)
+	}
+
+	// Create idempotency keys table
+	_, err = db.Exec(context.Background(), `
+		CREATE TABLE IF NOT EXISTS idempotency_keys (
+			scope TEXT NOT NULL,
+			key TEXT NOT NULL,
+			fingerprint TEXT NOT NULL,
+			status_code INTEGER,
+			headers JSONB,
+			body BYTEA,
+			created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+			expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+			PRIMARY KEY (scope, key)
+		)
+	`)
+	if err != nil {
+		t.Fatalf("Failed to create idempotency_keys table: %v", err)
+	}
+
+	_, err = db.Exec(context.Background(), `
+		CREATE TABLE IF NOT EXISTS rate_limit_buckets (
+			key TEXT PRIMARY KEY,
+			tokens DOUBLE PRECISION NOT NULL,
+			updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+			expires_at TIMESTAMP WITH TIME ZONE NOT NULL
+		)
+	`)
+	if err != nil {
+		t.Fatalf("Failed to create rate_limit_buckets table: %v", err)
+	}
+
+	_, err = db.Exec(context.Background(), `
+		CREATE TABLE IF NOT EXISTS login_failures (
+			key TEXT PRIMARY KEY,
+			failures INTEGER NOT NULL,
+			last_failed_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+			locked_until TIMESTAMP WITH TIME ZONE
+		)
+	`)
+	if err != nil {
+		t.Fatalf("Failed to create login_failures table: %v", err)
+	}
+
+	_, err = db.Exec(context.Background(), `
+		CREATE TABLE IF NOT EXISTS login_events (
+			id BIGSERIAL PRIMARY KEY,
+			user_id UUID REFERENCES users(id) ON DELETE SET NULL,
+			username TEXT NOT NULL,
+			ip_address TEXT NOT NULL,
+			outcome TEXT NOT NULL,
+			created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+		)
+	`)
+	if err != nil {
+		t.Fatalf("Failed to create login_events table: %v", err)
+	}
 }
\ No newline at end of file
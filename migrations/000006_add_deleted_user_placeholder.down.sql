@@ -0,0 +1 @@
+DELETE FROM users WHERE id = '00000000-0000-0000-0000-000000000000';
@@ -0,0 +1,5 @@
+-- Placeholder author for the posts of deleted users under the anonymize
+-- policy. The password hash matches no algorithm, so it can never log in.
+INSERT INTO users (id, username, password_hash, created_at)
+VALUES ('00000000-0000-0000-0000-000000000000', '[deleted]', '!', NOW())
+ON CONFLICT (id) DO NOTHING;
@@ -0,0 +1,3 @@
+CREATE INDEX idx_posts_created_at_id ON posts(created_at DESC, id DESC);
+CREATE INDEX idx_posts_user_id_created_at_id ON posts(user_id, created_at DESC, id DESC);
+CREATE INDEX idx_users_created_at_id ON users(created_at DESC, id DESC);
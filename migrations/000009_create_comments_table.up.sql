@@ -0,0 +1,14 @@
+CREATE TABLE comments (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    post_id UUID NOT NULL REFERENCES posts(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    -- Replies point at a top-level comment; replies to replies are rejected
+    parent_id UUID REFERENCES comments(id) ON DELETE CASCADE,
+    content TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_comments_post_id_created_at ON comments(post_id, created_at, id);
+CREATE INDEX idx_comments_parent_id_created_at ON comments(parent_id, created_at, id) WHERE parent_id IS NOT NULL;
+CREATE INDEX idx_comments_user_id ON comments(user_id);
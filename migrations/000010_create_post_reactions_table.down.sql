@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_posts_reaction_count_id;
+
+ALTER TABLE posts DROP COLUMN IF EXISTS reaction_count;
+
+DROP TABLE IF EXISTS post_reactions;
@@ -0,0 +1,15 @@
+CREATE TABLE post_reactions (
+    post_id UUID NOT NULL REFERENCES posts(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    kind TEXT NOT NULL CHECK (kind IN ('like', 'love', 'laugh', 'wow', 'sad')),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (post_id, user_id, kind)
+);
+
+CREATE INDEX idx_post_reactions_user_id ON post_reactions(user_id);
+
+-- Denormalized total kept in step with post_reactions by the repositories,
+-- so that sorting by popularity does not count every post's reactions
+ALTER TABLE posts ADD COLUMN reaction_count BIGINT NOT NULL DEFAULT 0;
+
+CREATE INDEX idx_posts_reaction_count_id ON posts(reaction_count DESC, id DESC);
@@ -0,0 +1,10 @@
+-- Existing posts were public as soon as they were created
+ALTER TABLE posts
+    ADD COLUMN status TEXT NOT NULL DEFAULT 'published' CHECK (status IN ('draft', 'published', 'archived')),
+    ADD COLUMN published_at TIMESTAMPTZ;
+
+UPDATE posts SET published_at = created_at;
+
+-- Drafts with a publication time are scheduled; the scheduler looks them up
+-- by time
+CREATE INDEX idx_posts_scheduled ON posts(published_at) WHERE status = 'draft';
@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS post_revisions;
+
+ALTER TABLE posts
+    DROP COLUMN IF EXISTS version,
+    DROP COLUMN IF EXISTS updated_at;
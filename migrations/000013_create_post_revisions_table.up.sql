@@ -0,0 +1,22 @@
+ALTER TABLE posts
+    ADD COLUMN updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
+
+UPDATE posts SET updated_at = created_at;
+
+-- A snapshot of a post's title and content for every version, including the
+-- first. Editors are kept as NULL once their account is deleted.
+CREATE TABLE post_revisions (
+    post_id UUID NOT NULL REFERENCES posts(id) ON DELETE CASCADE,
+    version INTEGER NOT NULL,
+    editor_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    title TEXT NOT NULL,
+    content TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (post_id, version)
+);
+
+CREATE INDEX idx_post_revisions_editor_id ON post_revisions(editor_id);
+
+INSERT INTO post_revisions (post_id, version, editor_id, title, content, created_at)
+SELECT id, 1, user_id, title, content, created_at FROM posts;
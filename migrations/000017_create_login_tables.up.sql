@@ -0,0 +1,23 @@
+-- Recent failed logins per username and per IP address. Keys are
+-- "username:<name>" or "ip:<address>" and need not belong to a user.
+CREATE TABLE login_failures (
+    key TEXT PRIMARY KEY,
+    failures INTEGER NOT NULL,
+    last_failed_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    locked_until TIMESTAMPTZ
+);
+
+CREATE INDEX idx_login_failures_last_failed_at ON login_failures(last_failed_at);
+
+-- Audit log of login attempts and unlocks
+CREATE TABLE login_events (
+    id BIGSERIAL PRIMARY KEY,
+    user_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    username TEXT NOT NULL,
+    ip_address TEXT NOT NULL,
+    outcome TEXT NOT NULL CHECK (outcome IN ('success', 'failure', 'locked', 'unlocked')),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_login_events_username_created_at ON login_events(username, created_at DESC);
+CREATE INDEX idx_login_events_user_id ON login_events(user_id);
@@ -0,0 +1,3 @@
+-- Reactions and comments do not change a post's version. activity_at records
+-- when they last changed, so that reads can report it in Last-Modified.
+ALTER TABLE posts ADD COLUMN activity_at TIMESTAMPTZ NOT NULL DEFAULT NOW();